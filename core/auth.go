@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"golang.org/x/oauth2"
 	"google.golang.org/api/idtoken"
@@ -44,20 +45,10 @@ var (
 //	A string in the format "Bearer <token>" on success, or an error if
 //	the token could not be fetched.
 func GetGoogleIDToken(ctx context.Context, audience string) (string, error) {
-	cacheMutex.Lock()
-	ts, ok := tokenSourceCache[audience]
-	if !ok {
-		// If not found in cache, create a new token source.
-		var err error
-		ts, err = newTokenSource(ctx, audience)
-		if err != nil {
-			cacheMutex.Unlock() // Unlock before returning the error.
-			return "", fmt.Errorf("failed to create new token source: %w", err)
-		}
-		// Store the new source in the cache.
-		tokenSourceCache[audience] = ts
+	ts, err := cachedIDTokenSource(ctx, audience)
+	if err != nil {
+		return "", fmt.Errorf("failed to create new token source: %w", err)
 	}
-	cacheMutex.Unlock()
 
 	// Use the token source to get a valid token.
 	token, err := ts.Token()
@@ -68,3 +59,100 @@ func GetGoogleIDToken(ctx context.Context, audience string) (string, error) {
 	// Return the token with the "Bearer " prefix.
 	return "Bearer " + token.AccessToken, nil
 }
+
+// NewGoogleIDTokenSource returns a TokenSource that mints Google ID tokens for
+// the given audience using Application Default Credentials. Tokens are cached
+// and refreshed shortly before they expire.
+//
+// The raw ID token is returned as the token's AccessToken, so the source can be
+// passed directly to WithAuthTokenSource. To authenticate to a Cloud Run
+// service with WithClientHeaderTokenSource, the "Authorization" header also
+// needs the "Bearer " prefix; use GetGoogleIDToken for that.
+//
+// Inputs:
+//
+//   - ctx: The context used to discover and load the default credentials.
+//   - audience: The recipient of the token, typically the URL of the secured service
+//
+// Returns:
+//
+//	A TokenSource for the audience, or an error if the default credentials
+//	could not be found or do not support ID tokens.
+func NewGoogleIDTokenSource(ctx context.Context, audience string) (oauth2.TokenSource, error) {
+	if audience == "" {
+		return nil, fmt.Errorf("audience cannot be empty")
+	}
+	ts, err := cachedIDTokenSource(ctx, audience)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create ID token source for audience '%s': %w", audience, err)
+	}
+	return ts, nil
+}
+
+// cachedIDTokenSource returns the ID token source for the audience, creating
+// and caching it on first use.
+func cachedIDTokenSource(ctx context.Context, audience string) (oauth2.TokenSource, error) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	if ts, ok := tokenSourceCache[audience]; ok {
+		return ts, nil
+	}
+	ts, err := newTokenSource(ctx, audience)
+	if err != nil {
+		return nil, err
+	}
+	tokenSourceCache[audience] = ts
+	return ts, nil
+}
+
+// cachingTokenSource serves a cached token until it is about to expire.
+type cachingTokenSource struct {
+	src  oauth2.TokenSource
+	skew time.Duration
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// NewCachingTokenSource wraps a TokenSource so that a fetched token is reused
+// until skew before its Expiry, after which the next call refreshes it from
+// src. Concurrent callers share a single fetch, and failed fetches are not
+// cached. A token with a zero Expiry never expires and is fetched only once.
+//
+// This avoids a network round trip per invocation when the wrapped source
+// calls a remote identity provider, and works with both WithAuthTokenSource
+// and WithClientHeaderTokenSource.
+//
+// Inputs:
+//
+//   - src: The underlying TokenSource to fetch tokens from.
+//   - skew: How long before expiry a cached token is considered stale.
+//     Negative values are treated as zero.
+//
+// Returns:
+//
+//	An oauth2.TokenSource that caches the tokens returned by src.
+func NewCachingTokenSource(src oauth2.TokenSource, skew time.Duration) oauth2.TokenSource {
+	return &cachingTokenSource{
+		src:  src,
+		skew: max(skew, 0),
+	}
+}
+
+// Token returns the cached token if it is still fresh, otherwise it fetches
+// and caches a new one.
+func (s *cachingTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != nil && (s.token.Expiry.IsZero() || time.Now().Add(s.skew).Before(s.token.Expiry)) {
+		return s.token, nil
+	}
+	token, err := s.src.Token()
+	if err != nil {
+		return nil, err
+	}
+	s.token = token
+	return token, nil
+}
@@ -19,7 +19,10 @@ package core
 import (
 	"context"
 	"errors"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -142,3 +145,221 @@ func TestGetGoogleIDToken_TokenFetchError(t *testing.T) {
 		t.Errorf("Expected error message to contain '%s', but got: %v", expectedErr.Error(), err)
 	}
 }
+
+func TestNewGoogleIDTokenSource(t *testing.T) {
+	t.Run("returns raw ID token", func(t *testing.T) {
+		setup(t)
+		var gotAudience string
+		newTokenSource = func(ctx context.Context, aud string, opts ...option.ClientOption) (oauth2.TokenSource, error) {
+			gotAudience = aud
+			return &mockAuthTokenSource{
+				tokenToReturn: &oauth2.Token{AccessToken: "raw-id-token"},
+			}, nil
+		}
+
+		ts, err := NewGoogleIDTokenSource(context.Background(), "https://my-service.run.app")
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if gotAudience != "https://my-service.run.app" {
+			t.Errorf("Expected audience 'https://my-service.run.app', but got '%s'", gotAudience)
+		}
+
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatalf("Expected no error from Token, but got: %v", err)
+		}
+		if token.AccessToken != "raw-id-token" {
+			t.Errorf("Expected token 'raw-id-token', but got '%s'", token.AccessToken)
+		}
+	})
+
+	t.Run("shares the cache with GetGoogleIDToken", func(t *testing.T) {
+		setup(t)
+		callCount := 0
+		newTokenSource = func(ctx context.Context, aud string, opts ...option.ClientOption) (oauth2.TokenSource, error) {
+			callCount++
+			return &mockAuthTokenSource{
+				tokenToReturn: &oauth2.Token{AccessToken: "some-token"},
+			}, nil
+		}
+
+		_, _ = NewGoogleIDTokenSource(context.Background(), "https://some-audience.com")
+		_, _ = GetGoogleIDToken(context.Background(), "https://some-audience.com")
+
+		if callCount != 1 {
+			t.Errorf("Expected newTokenSource to be called 1 time, but was called %d times", callCount)
+		}
+	})
+
+	t.Run("wraps credential errors", func(t *testing.T) {
+		setup(t)
+		expectedErr := errors.New("could not find default credentials")
+		newTokenSource = func(ctx context.Context, aud string, opts ...option.ClientOption) (oauth2.TokenSource, error) {
+			return nil, expectedErr
+		}
+
+		_, err := NewGoogleIDTokenSource(context.Background(), "https://some-audience.com")
+		if !errors.Is(err, expectedErr) {
+			t.Fatalf("Expected error to wrap '%v', but got: %v", expectedErr, err)
+		}
+		if !strings.Contains(err.Error(), "audience 'https://some-audience.com'") {
+			t.Errorf("Expected error to name the audience, but got: %v", err)
+		}
+
+		// A failed attempt must not be cached.
+		cacheMutex.Lock()
+		_, cached := tokenSourceCache["https://some-audience.com"]
+		cacheMutex.Unlock()
+		if cached {
+			t.Error("Expected failed token source not to be cached")
+		}
+	})
+
+	t.Run("rejects empty audience", func(t *testing.T) {
+		setup(t)
+		newTokenSource = func(ctx context.Context, aud string, opts ...option.ClientOption) (oauth2.TokenSource, error) {
+			t.Fatal("newTokenSource should not be called")
+			return nil, nil
+		}
+
+		if _, err := NewGoogleIDTokenSource(context.Background(), ""); err == nil {
+			t.Fatal("Expected an error for an empty audience, but got nil")
+		}
+	})
+
+	t.Run("token fetch errors surface from the source", func(t *testing.T) {
+		setup(t)
+		expectedErr := errors.New("metadata server unavailable")
+		newTokenSource = func(ctx context.Context, aud string, opts ...option.ClientOption) (oauth2.TokenSource, error) {
+			return &mockAuthTokenSource{errorToReturn: expectedErr}, nil
+		}
+
+		ts, err := NewGoogleIDTokenSource(context.Background(), "https://some-audience.com")
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if _, err := ts.Token(); !errors.Is(err, expectedErr) {
+			t.Errorf("Expected Token error '%v', but got: %v", expectedErr, err)
+		}
+	})
+}
+
+// countingTokenSource counts fetches and returns tokens that expire after ttl.
+type countingTokenSource struct {
+	calls atomic.Int32
+	ttl   time.Duration
+	err   error
+}
+
+func (c *countingTokenSource) Token() (*oauth2.Token, error) {
+	n := c.calls.Add(1)
+	if c.err != nil {
+		return nil, c.err
+	}
+	// Give concurrent callers a chance to pile up behind the fetch.
+	time.Sleep(10 * time.Millisecond)
+	token := &oauth2.Token{AccessToken: "token-" + strconv.Itoa(int(n))}
+	if c.ttl != 0 {
+		token.Expiry = time.Now().Add(c.ttl)
+	}
+	return token, nil
+}
+
+func TestNewCachingTokenSource(t *testing.T) {
+	t.Run("single fetch across concurrent calls", func(t *testing.T) {
+		src := &countingTokenSource{ttl: time.Hour}
+		ts := NewCachingTokenSource(src, time.Minute)
+
+		var wg sync.WaitGroup
+		tokens := make([]string, 50)
+		for i := range tokens {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				token, err := ts.Token()
+				if err != nil {
+					t.Errorf("Token returned an unexpected error: %v", err)
+					return
+				}
+				tokens[i] = token.AccessToken
+			}(i)
+		}
+		wg.Wait()
+
+		if got := src.calls.Load(); got != 1 {
+			t.Errorf("Expected 1 fetch, but got %d", got)
+		}
+		for i, token := range tokens {
+			if token != "token-1" {
+				t.Errorf("Expected call %d to get 'token-1', but got '%s'", i, token)
+			}
+		}
+	})
+
+	t.Run("refreshes within skew of expiry", func(t *testing.T) {
+		// Every token expires well within the skew, so each call refreshes.
+		src := &countingTokenSource{ttl: time.Minute}
+		ts := NewCachingTokenSource(src, time.Hour)
+
+		first, _ := ts.Token()
+		second, _ := ts.Token()
+
+		if got := src.calls.Load(); got != 2 {
+			t.Errorf("Expected 2 fetches, but got %d", got)
+		}
+		if first.AccessToken == second.AccessToken {
+			t.Errorf("Expected a refreshed token, but got '%s' twice", second.AccessToken)
+		}
+	})
+
+	t.Run("refreshes after expiry", func(t *testing.T) {
+		src := &countingTokenSource{ttl: 50 * time.Millisecond}
+		ts := NewCachingTokenSource(src, 0)
+
+		_, _ = ts.Token()
+		_, _ = ts.Token()
+		if got := src.calls.Load(); got != 1 {
+			t.Fatalf("Expected 1 fetch before expiry, but got %d", got)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatalf("Token returned an unexpected error: %v", err)
+		}
+		if got := src.calls.Load(); got != 2 {
+			t.Errorf("Expected 2 fetches after expiry, but got %d", got)
+		}
+		if token.AccessToken != "token-2" {
+			t.Errorf("Expected 'token-2', but got '%s'", token.AccessToken)
+		}
+	})
+
+	t.Run("token without expiry is fetched once", func(t *testing.T) {
+		src := &countingTokenSource{}
+		ts := NewCachingTokenSource(src, time.Minute)
+
+		for range 3 {
+			_, _ = ts.Token()
+		}
+		if got := src.calls.Load(); got != 1 {
+			t.Errorf("Expected 1 fetch, but got %d", got)
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		expectedErr := errors.New("identity provider unavailable")
+		src := &countingTokenSource{err: expectedErr}
+		ts := NewCachingTokenSource(src, time.Minute)
+
+		for range 2 {
+			if _, err := ts.Token(); !errors.Is(err, expectedErr) {
+				t.Errorf("Expected error '%v', but got: %v", expectedErr, err)
+			}
+		}
+		if got := src.calls.Load(); got != 2 {
+			t.Errorf("Expected 2 fetches, but got %d", got)
+		}
+	})
+}
@@ -0,0 +1,138 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// defaultBatchConcurrency bounds the calls InvokeBatch runs at once unless
+// WithBatchConcurrency sets another limit.
+const defaultBatchConcurrency = 8
+
+// BatchCall is a single tool invocation in a batch.
+type BatchCall struct {
+	// Tool is the loaded tool to invoke.
+	Tool *ToolboxTool
+	// Input holds the tool's arguments, as for ToolboxTool.Invoke.
+	Input map[string]any
+}
+
+// BatchResult is the outcome of a single call in a batch.
+type BatchResult struct {
+	// Result is the tool's output, as returned by ToolboxTool.Invoke.
+	Result any
+	// Err is the error of the call, if it failed or was not started.
+	Err error
+}
+
+// InvokeBatch invokes several tools concurrently, running at most the number
+// of calls set with WithBatchConcurrency at a time. A failing call does not
+// affect the others: its error is reported in its BatchResult. If ctx is
+// cancelled, calls that have not started yet are skipped and report the
+// context's error.
+//
+// Inputs:
+//   - ctx: The context for the batch and each of its calls.
+//   - calls: The calls to make.
+//
+// Returns:
+//
+//	One BatchResult per call, in the order of calls, and a nil error; or nil
+//	and an error if ctx is already done.
+func (tc *ToolboxClient) InvokeBatch(ctx context.Context, calls []BatchCall) ([]BatchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to invoke batch: %w", err)
+	}
+
+	concurrency := tc.batchConcurrency
+	if concurrency == 0 {
+		concurrency = defaultBatchConcurrency
+	}
+
+	return InvokeAll(ctx, calls, concurrency), nil
+}
+
+// InvokeRequest is a single invocation run by InvokeAll.
+type InvokeRequest = BatchCall
+
+// InvokeOutcome is the outcome of a single invocation run by InvokeAll.
+type InvokeOutcome = BatchResult
+
+// InvokeAll runs independent tool invocations on a pool of at most
+// concurrency goroutines, starting them in order; values below 1 run them one
+// at a time. A failing invocation does not affect the others. Once ctx is
+// done, no more invocations are started, and those left report the
+// context's error.
+//
+// Inputs:
+//   - ctx: The context for every invocation.
+//   - reqs: The invocations to run.
+//   - concurrency: The maximum number of invocations to run at once.
+//
+// Returns:
+//
+//	One InvokeOutcome per request, in the order of reqs.
+func InvokeAll(ctx context.Context, reqs []InvokeRequest, concurrency int) []InvokeOutcome {
+	outcomes := make([]InvokeOutcome, len(reqs))
+	runBounded(ctx, len(reqs), concurrency, func(i int) {
+		req := reqs[i]
+		if req.Tool == nil {
+			outcomes[i].Err = fmt.Errorf("request %d has no tool", i)
+			return
+		}
+		outcomes[i].Result, outcomes[i].Err = req.Tool.Invoke(ctx, req.Input)
+	}, func(i int) {
+		outcomes[i].Err = ctx.Err()
+	})
+	return outcomes
+}
+
+// runBounded calls run for each index in [0, n) on at most concurrency
+// goroutines at a time, starting indexes in order. Once ctx is done, the
+// indexes that have not started are passed to skip instead.
+func runBounded(ctx context.Context, n, concurrency int, run, skip func(i int)) {
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(max(concurrency, 1), n) {
+		wg.Go(func() {
+			for i := range next {
+				if ctx.Err() != nil {
+					skip(i)
+					continue
+				}
+				run(i)
+			}
+		})
+	}
+
+	i := 0
+send:
+	for ; i < n && ctx.Err() == nil; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break send
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	for ; i < n; i++ {
+		skip(i)
+	}
+}
@@ -0,0 +1,236 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingInvokeTransport records how many invocations run at once.
+type countingInvokeTransport struct {
+	dummyTransport
+	delay    time.Duration
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	calls    atomic.Int32
+}
+
+func (c *countingInvokeTransport) InvokeTool(ctx context.Context, name string, p map[string]any, h map[string]string) (any, error) {
+	c.calls.Add(1)
+	n := c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	return p["id"], nil
+}
+
+// newBatchTool returns a tool with an optional integer 'id' parameter.
+func newBatchTool(name string, tr transport.Transport) *ToolboxTool {
+	return &ToolboxTool{
+		name:       name,
+		transport:  tr,
+		parameters: []ParameterSchema{{Name: "id", Type: "integer"}},
+	}
+}
+
+func TestInvokeBatch(t *testing.T) {
+	t.Run("Preserves order and isolates failures", func(t *testing.T) {
+		client, err := NewToolboxClient("https://example.com")
+		require.NoError(t, err)
+
+		// The first call is the slowest, so it finishes last.
+		slow := newBatchTool("slow", &slowTransport{delay: 50 * time.Millisecond, output: "slow result"})
+		fast := newBatchTool("fast", &slowTransport{output: "fast result"})
+		failing := newBatchTool("failing", &slowTransport{err: errors.New("tool exploded")})
+
+		results, err := client.InvokeBatch(context.Background(), []BatchCall{
+			{Tool: slow, Input: map[string]any{}},
+			{Tool: failing, Input: map[string]any{}},
+			{Tool: fast, Input: map[string]any{}},
+			{Tool: fast, Input: map[string]any{"unknown": true}},
+			{Tool: nil},
+		})
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+
+		assert.Equal(t, "slow result", results[0].Result)
+		assert.NoError(t, results[0].Err)
+		assert.ErrorContains(t, results[1].Err, "tool exploded")
+		assert.Equal(t, "fast result", results[2].Result)
+		assert.NoError(t, results[2].Err)
+		assert.ErrorIs(t, results[3].Err, ErrUnexpectedParam)
+		assert.ErrorContains(t, results[4].Err, "request 4 has no tool")
+	})
+
+	t.Run("Bounds concurrency", func(t *testing.T) {
+		client, err := NewToolboxClient("https://example.com", WithBatchConcurrency(3))
+		require.NoError(t, err)
+
+		tr := &countingInvokeTransport{delay: 10 * time.Millisecond}
+		tool := newBatchTool("count", tr)
+		calls := make([]BatchCall, 12)
+		for i := range calls {
+			calls[i] = BatchCall{Tool: tool, Input: map[string]any{"id": i}}
+		}
+
+		results, err := client.InvokeBatch(context.Background(), calls)
+		require.NoError(t, err)
+		for i, r := range results {
+			require.NoError(t, r.Err)
+			assert.Equal(t, i, r.Result, "result %d is out of order", i)
+		}
+		assert.LessOrEqual(t, tr.peak.Load(), int32(3))
+		assert.Equal(t, int32(12), tr.calls.Load())
+	})
+
+	t.Run("Skips calls after cancellation", func(t *testing.T) {
+		client, err := NewToolboxClient("https://example.com", WithBatchConcurrency(1))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		tr := &countingInvokeTransport{delay: 20 * time.Millisecond}
+		tool := newBatchTool("count", tr)
+		calls := make([]BatchCall, 5)
+		for i := range calls {
+			calls[i] = BatchCall{Tool: tool, Input: map[string]any{"id": i}}
+		}
+
+		time.AfterFunc(30*time.Millisecond, cancel)
+		results, err := client.InvokeBatch(ctx, calls)
+		require.NoError(t, err)
+		require.Len(t, results, 5)
+
+		started := int(tr.calls.Load())
+		assert.Less(t, started, 5, "calls after cancellation should not start")
+		for _, r := range results[started:] {
+			assert.ErrorIs(t, r.Err, context.Canceled)
+		}
+	})
+
+	t.Run("Fails when the context is already done", func(t *testing.T) {
+		client, err := NewToolboxClient("https://example.com")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = client.InvokeBatch(ctx, []BatchCall{{Tool: newBatchTool("fast", &slowTransport{})}})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Failure on invalid concurrency", func(t *testing.T) {
+		_, err := NewToolboxClient("https://example.com", WithBatchConcurrency(0))
+		assert.Error(t, err)
+		_, err = NewToolboxClient("https://example.com", WithBatchConcurrency(2), WithBatchConcurrency(3))
+		assert.Error(t, err)
+	})
+}
+
+func TestInvokeAll(t *testing.T) {
+	t.Run("Preserves order and isolates errors", func(t *testing.T) {
+		tr := &countingInvokeTransport{delay: 5 * time.Millisecond}
+		tool := newBatchTool("count", tr)
+		failing := newBatchTool("failing", &slowTransport{err: errors.New("tool exploded")})
+
+		reqs := make([]InvokeRequest, 10)
+		for i := range reqs {
+			reqs[i] = InvokeRequest{Tool: tool, Input: map[string]any{"id": i}}
+		}
+		reqs[4] = InvokeRequest{Tool: failing}
+
+		outcomes := InvokeAll(context.Background(), reqs, 4)
+		require.Len(t, outcomes, 10)
+		for i, o := range outcomes {
+			if i == 4 {
+				assert.ErrorContains(t, o.Err, "tool exploded")
+				continue
+			}
+			require.NoError(t, o.Err)
+			assert.Equal(t, i, o.Result)
+		}
+		assert.LessOrEqual(t, tr.peak.Load(), int32(4))
+	})
+
+	t.Run("Runs sequentially below 1", func(t *testing.T) {
+		tr := &countingInvokeTransport{}
+		tool := newBatchTool("count", tr)
+		reqs := []InvokeRequest{{Tool: tool, Input: map[string]any{}}, {Tool: tool, Input: map[string]any{}}}
+
+		for _, o := range InvokeAll(context.Background(), reqs, 0) {
+			assert.NoError(t, o.Err)
+		}
+		assert.Equal(t, int32(1), tr.peak.Load())
+	})
+
+	t.Run("Cancellation leaves remaining requests unstarted", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var started []int
+		tool := newBatchTool("cancelling", &hookInvokeTransport{invoke: func(p map[string]any) {
+			id := p["id"].(int)
+			started = append(started, id)
+			if id == 1 {
+				cancel()
+			}
+		}})
+		reqs := make([]InvokeRequest, 5)
+		for i := range reqs {
+			reqs[i] = InvokeRequest{Tool: tool, Input: map[string]any{"id": i}}
+		}
+
+		outcomes := InvokeAll(ctx, reqs, 1)
+		assert.Equal(t, []int{0, 1}, started)
+		assert.NoError(t, outcomes[0].Err)
+		for _, o := range outcomes[2:] {
+			assert.ErrorIs(t, o.Err, context.Canceled)
+		}
+	})
+
+	t.Run("Already cancelled context starts nothing", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		tr := &countingInvokeTransport{}
+		outcomes := InvokeAll(ctx, []InvokeRequest{{Tool: newBatchTool("count", tr)}}, 2)
+		assert.ErrorIs(t, outcomes[0].Err, context.Canceled)
+		assert.Zero(t, tr.calls.Load())
+	})
+}
+
+// hookInvokeTransport calls invoke with the payload of every invocation.
+type hookInvokeTransport struct {
+	dummyTransport
+	invoke func(p map[string]any)
+}
+
+func (h *hookInvokeTransport) InvokeTool(ctx context.Context, name string, p map[string]any, hd map[string]string) (any, error) {
+	h.invoke(p)
+	return nil, nil
+}
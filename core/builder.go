@@ -0,0 +1,128 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
+
+// ToolBuilder constructs a ToolboxTool without a Toolbox server, for tests of
+// framework adapters and for local or offline use. Its methods can be
+// chained, and any error they detect is reported by Build.
+type ToolBuilder struct {
+	name        string
+	description string
+	parameters  []ParameterSchema
+	boundParams map[string]any
+	transport   transport.Transport
+	err         error
+}
+
+// NewToolBuilder starts building a tool with the given name and description.
+//
+// Inputs:
+//   - name: The name of the tool.
+//   - description: The description of the tool.
+//
+// Returns:
+//
+//	A *ToolBuilder to configure the tool with.
+func NewToolBuilder(name, description string) *ToolBuilder {
+	return &ToolBuilder{
+		name:        name,
+		description: description,
+		boundParams: make(map[string]any),
+	}
+}
+
+// AddParameter declares a parameter of the tool, as a server would in its
+// schema. Parameters with AuthSources are filled from authentication tokens.
+func (b *ToolBuilder) AddParameter(p ParameterSchema) *ToolBuilder {
+	for _, existing := range b.parameters {
+		if existing.Name == p.Name {
+			b.setErr(fmt.Errorf("parameter '%s' is already declared", p.Name))
+			return b
+		}
+	}
+	b.parameters = append(b.parameters, p)
+	return b
+}
+
+// WithBoundParam binds a parameter of the tool to a value, as
+// WithBindParamString and similar options do. The value may be a function
+// computing it at invocation time.
+func (b *ToolBuilder) WithBoundParam(name string, value any) *ToolBuilder {
+	if _, exists := b.boundParams[name]; exists {
+		b.setErr(fmt.Errorf("bound parameter '%s' is already set and cannot be overridden", name))
+		return b
+	}
+	b.boundParams[name] = value
+	return b
+}
+
+// WithTransport sets the transport the tool is invoked through, such as a
+// mock transport in tests.
+func (b *ToolBuilder) WithTransport(tr transport.Transport) *ToolBuilder {
+	if tr == nil {
+		b.setErr(fmt.Errorf("WithTransport: transport cannot be nil"))
+		return b
+	}
+	b.transport = tr
+	return b
+}
+
+// Build validates the configuration and constructs the tool, applying the
+// same checks as loading a tool from a server in strict mode.
+//
+// Returns:
+//
+//	The constructed *ToolboxTool, or nil and the first error found in the
+//	configuration.
+func (b *ToolBuilder) Build() (*ToolboxTool, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.name == "" {
+		return nil, fmt.Errorf("tool name cannot be empty")
+	}
+	if b.transport == nil {
+		return nil, fmt.Errorf("a transport is required to build tool '%s'", b.name)
+	}
+
+	config := newToolConfig()
+	maps.Copy(config.BoundParams, b.boundParams)
+	schema := ToolSchema{
+		Description: b.description,
+		Parameters:  slices.Clone(b.parameters),
+	}
+
+	tc := &ToolboxClient{}
+	tool, _, _, err := tc.newToolboxTool(b.name, schema, config, true, b.transport)
+	if err != nil {
+		return nil, err
+	}
+	return tool, nil
+}
+
+// setErr records the first error detected while configuring the builder.
+func (b *ToolBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,131 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolBuilder(t *testing.T) {
+	t.Run("Built tool can be invoked against a mock transport", func(t *testing.T) {
+		tr := &recordingTransport{output: "3 orders"}
+		tool, err := NewToolBuilder("list_orders", "Lists orders").
+			AddParameter(ParameterSchema{Name: "status", Type: "string", Required: true}).
+			AddParameter(ParameterSchema{Name: "region", Type: "string"}).
+			WithBoundParam("region", "emea").
+			WithTransport(tr).
+			Build()
+		require.NoError(t, err)
+
+		assert.Equal(t, "list_orders", tool.Name())
+		assert.Equal(t, "Lists orders", tool.Description())
+		require.Len(t, tool.Parameters(), 1)
+		assert.Equal(t, "status", tool.Parameters()[0].Name)
+		assert.Equal(t, map[string]ParamSource{"status": UserSupplied, "region": Bound}, tool.ParameterSources())
+
+		result, err := tool.Invoke(context.Background(), map[string]any{"status": "open"})
+		require.NoError(t, err)
+		assert.Equal(t, "3 orders", result)
+		assert.Equal(t, map[string]any{"status": "open", "region": "emea"}, tr.payload)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		assert.ErrorIs(t, err, ErrMissingRequiredParam)
+	})
+
+	t.Run("Function bindings are resolved at invocation", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool, err := NewToolBuilder("t", "").
+			AddParameter(ParameterSchema{Name: "user", Type: "string"}).
+			WithBoundParam("user", func() (string, error) { return "alice", nil }).
+			WithTransport(tr).
+			Build()
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"user": "alice"}, tr.payload)
+	})
+
+	t.Run("Auth parameters require a token source", func(t *testing.T) {
+		tool, err := NewToolBuilder("t", "").
+			AddParameter(ParameterSchema{Name: "user_id", Type: "string", AuthSources: []string{"google"}}).
+			WithTransport(&recordingTransport{}).
+			Build()
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]string{"user_id": {"google"}}, tool.RequiredAuthn())
+		assert.Empty(t, tool.Parameters())
+	})
+
+	t.Run("Invalid configurations are rejected", func(t *testing.T) {
+		tr := &recordingTransport{}
+		testCases := []struct {
+			name    string
+			builder *ToolBuilder
+			wantErr string
+		}{
+			{"empty name", NewToolBuilder("", "").WithTransport(tr), "tool name cannot be empty"},
+			{"missing transport", NewToolBuilder("t", ""), "a transport is required"},
+			{"nil transport", NewToolBuilder("t", "").WithTransport(nil), "transport cannot be nil"},
+			{
+				"duplicate parameter",
+				NewToolBuilder("t", "").
+					AddParameter(ParameterSchema{Name: "a", Type: "string"}).
+					AddParameter(ParameterSchema{Name: "a", Type: "integer"}).
+					WithTransport(tr),
+				"parameter 'a' is already declared",
+			},
+			{
+				"invalid parameter schema",
+				NewToolBuilder("t", "").AddParameter(ParameterSchema{Name: "a", Type: "bogus"}).WithTransport(tr),
+				"invalid schema for tool 't'",
+			},
+			{
+				"binding for an unknown parameter",
+				NewToolBuilder("t", "").WithBoundParam("missing", "x").WithTransport(tr),
+				"no parameter named 'missing'",
+			},
+			{
+				"binding of the wrong type",
+				NewToolBuilder("t", "").
+					AddParameter(ParameterSchema{Name: "n", Type: "integer"}).
+					WithBoundParam("n", "ten").
+					WithTransport(tr),
+				"invalid bound parameter for tool 't'",
+			},
+			{
+				"repeated binding",
+				NewToolBuilder("t", "").
+					AddParameter(ParameterSchema{Name: "a", Type: "string"}).
+					WithBoundParam("a", "x").
+					WithBoundParam("a", "y").
+					WithTransport(tr),
+				"already set and cannot be overridden",
+			},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				tool, err := tc.builder.Build()
+				assert.Nil(t, tool)
+				assert.ErrorContains(t, err, tc.wantErr)
+			})
+		}
+	})
+}
@@ -0,0 +1,76 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
+
+// manifestCache holds manifests fetched from the server for a fixed time, so
+// that repeated loads of the same tool or toolset do not re-fetch them. It is
+// safe for concurrent use.
+type manifestCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]manifestCacheEntry
+}
+
+// manifestCacheEntry is a cached manifest and the time it expires.
+type manifestCacheEntry struct {
+	manifest *transport.ManifestSchema
+	expires  time.Time
+}
+
+func newManifestCache(ttl time.Duration) *manifestCache {
+	return &manifestCache{ttl: ttl, entries: make(map[string]manifestCacheEntry)}
+}
+
+// manifestCacheKey identifies the manifest of a tool or toolset.
+func manifestCacheKey(kind, name string) string {
+	return kind + "/" + name
+}
+
+// get returns the cached manifest for key, if it has not expired.
+func (c *manifestCache) get(key string) (*transport.ManifestSchema, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.manifest, true
+}
+
+// put caches the manifest for key for the cache's TTL.
+func (c *manifestCache) put(key string, manifest *transport.ManifestSchema) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = manifestCacheEntry{manifest: manifest, expires: time.Now().Add(c.ttl)}
+}
+
+// clear removes every cached manifest.
+func (c *manifestCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
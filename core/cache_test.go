@@ -0,0 +1,148 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newCountingMCPServer wraps the mock MCP server and counts the tools/list
+// requests it receives.
+func newCountingMCPServer(t *testing.T, tools []mcpTool) (*httptest.Server, *atomic.Int32) {
+	mock := newMockMCPServer(t, tools)
+	handler := mock.Config.Handler
+	mock.Close()
+
+	var lists atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		if json.Unmarshal(body, &req) == nil && req.Method == "tools/list" {
+			lists.Add(1)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		handler.ServeHTTP(w, r)
+	}))
+	return server, &lists
+}
+
+func TestWithManifestCache(t *testing.T) {
+	tools := []mcpTool{
+		{Name: "search", Description: "s", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+	}
+
+	newClient := func(t *testing.T, server *httptest.Server, ttl time.Duration) *ToolboxClient {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618), WithManifestCache(ttl))
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("Serves repeated loads from the cache", func(t *testing.T) {
+		server, lists := newCountingMCPServer(t, tools)
+		defer server.Close()
+		client := newClient(t, server, time.Hour)
+
+		for range 3 {
+			_, err := client.LoadTool("search", context.Background())
+			require.NoError(t, err)
+			_, err = client.LoadToolset("", context.Background())
+			require.NoError(t, err)
+		}
+		assert.EqualValues(t, 2, lists.Load(), "one fetch for the tool and one for the toolset")
+	})
+
+	t.Run("Re-fetches after the TTL", func(t *testing.T) {
+		server, lists := newCountingMCPServer(t, tools)
+		defer server.Close()
+		client := newClient(t, server, 50*time.Millisecond)
+
+		_, err := client.LoadTool("search", context.Background())
+		require.NoError(t, err)
+		_, err = client.LoadTool("search", context.Background())
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, lists.Load())
+
+		time.Sleep(100 * time.Millisecond)
+		_, err = client.LoadTool("search", context.Background())
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, lists.Load())
+	})
+
+	t.Run("InvalidateManifestCache forces a fetch", func(t *testing.T) {
+		server, lists := newCountingMCPServer(t, tools)
+		defer server.Close()
+		client := newClient(t, server, time.Hour)
+
+		_, err := client.LoadToolset("", context.Background())
+		require.NoError(t, err)
+		client.InvalidateManifestCache()
+		_, err = client.LoadToolset("", context.Background())
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, lists.Load())
+	})
+
+	t.Run("Failed fetches are not cached", func(t *testing.T) {
+		server, lists := newCountingMCPServer(t, tools)
+		defer server.Close()
+		client := newClient(t, server, time.Hour)
+
+		_, err := client.LoadTool("missing", context.Background())
+		require.Error(t, err)
+		_, err = client.LoadTool("missing", context.Background())
+		require.Error(t, err)
+		assert.EqualValues(t, 2, lists.Load())
+	})
+
+	t.Run("Is safe for concurrent loads", func(t *testing.T) {
+		server, _ := newCountingMCPServer(t, tools)
+		defer server.Close()
+		client := newClient(t, server, time.Hour)
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Go(func() {
+				_, err := client.LoadTool("search", context.Background())
+				assert.NoError(t, err)
+				client.InvalidateManifestCache()
+			})
+		}
+		wg.Wait()
+	})
+
+	t.Run("Rejects invalid and duplicate settings", func(t *testing.T) {
+		_, err := NewToolboxClient("http://example.com", WithManifestCache(0))
+		assert.Error(t, err)
+		_, err = NewToolboxClient("http://example.com", WithManifestCache(time.Minute), WithManifestCache(time.Hour))
+		assert.ErrorContains(t, err, "manifest cache is already set")
+
+		client, err := NewToolboxClient("http://example.com")
+		require.NoError(t, err)
+		client.InvalidateManifestCache()
+	})
+}
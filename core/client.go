@@ -16,14 +16,22 @@ package core
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"slices"
 
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp/stdio"
 	mcp20241105 "github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp/v20241105"
 	mcp20250326 "github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp/v20250326"
 	mcp20250618 "github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp/v20250618"
@@ -35,18 +43,50 @@ import (
 type ToolboxClient struct {
 	baseURL             string
 	httpClient          *http.Client
+	httpClientSet       bool
+	transportConfig     *TransportConfig
 	protocol            Protocol
 	protocolSet         bool
 	transport           transport.Transport
+	customTransport     transport.Transport
 	clientHeaderSources map[string]oauth2.TokenSource
+	headersMu           sync.RWMutex
+	toolsetHeaders      map[string]map[string]string
 	defaultToolOptions  []ToolOption
 	defaultOptionsSet   bool
 	clientName          string
 	clientVersion       string
+	minServerVersion    string
+	forceHTTP1          bool
+	maxManifestSize     int64
+	methodOverrides     map[string]string
+	requestIDGenerator  func() any
+	middleware          []RoundTripMiddleware
+	manifestCache       *manifestCache
+	// toolsetBuildConcurrency bounds the goroutines constructing the tools
+	// of a toolset; values below 2 build them sequentially.
+	toolsetBuildConcurrency int
+	// batchConcurrency bounds the calls InvokeBatch runs at once; zero
+	// selects defaultBatchConcurrency.
+	batchConcurrency int
+	// command and commandArgs launch a local server for the Stdio protocol.
+	command     string
+	commandArgs []string
+	invokeOpts  invokeOptions
+	// autoProtocol is set for AutoProtocol. Until negotiated is set, the
+	// transport and protocol may be replaced under transportMu.
+	autoProtocol bool
+	negotiated   bool
+	transportMu  sync.Mutex
+	// serverVersion is the version the server last reported, recorded under
+	// serverVersionMu.
+	serverVersion   string
+	serverVersionMu sync.Mutex
 }
 
 // NewToolboxClient creates and configures a new, immutable client for interacting with a
-// Toolbox server.
+// Toolbox server. It performs no I/O; the session with the server is
+// established lazily on first use.
 //
 // Inputs:
 //   - url: The base URL of the Toolbox server.
@@ -58,6 +98,102 @@ type ToolboxClient struct {
 //	A configured *ToolboxClient and a nil error on success, or a nil client
 //	and an error if configuration fails.
 func NewToolboxClient(url string, opts ...ClientOption) (*ToolboxClient, error) {
+	tc, err := newConfiguredClient("NewToolboxClient", url, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if tc.customTransport != nil {
+		if tc.protocolSet {
+			return nil, fmt.Errorf("WithTransport cannot be combined with WithProtocol")
+		}
+		if tc.command != "" {
+			return nil, fmt.Errorf("WithTransport cannot be combined with WithCommand")
+		}
+		tc.protocol = ""
+		tc.transport = tc.customTransport
+		return tc, nil
+	}
+
+	if tc.command != "" {
+		if tc.protocolSet && tc.protocol != Stdio {
+			return nil, fmt.Errorf("WithCommand requires the %s protocol, but %s was set", Stdio, tc.protocol)
+		}
+		tc.protocol = Stdio
+	}
+
+	if tc.protocol != Stdio {
+		checkSecureHeaders(tc.baseURL, len(tc.clientHeaderSources) > 0 || len(tc.toolsetHeaders) > 0)
+	}
+
+	if slices.Contains(GetSupportedMcpVersions(), string(tc.protocol)) && tc.protocol != MCPLatest {
+		log.Printf("A newer version of MCP: v%s is available. Please use MCPLatest to use the latest features.", MCPLatest)
+	}
+
+	protocol := tc.protocol
+	if protocol == AutoProtocol {
+		if tc.command != "" {
+			return nil, fmt.Errorf("WithCommand requires the %s protocol, but %s was set", Stdio, AutoProtocol)
+		}
+		// Start with the newest version; the server may offer an older one
+		// during the handshake.
+		tc.autoProtocol = true
+		protocol = MCPLatest
+	}
+	if protocol == Stdio && tc.command == "" {
+		return nil, fmt.Errorf("the %s protocol requires a command set with WithCommand", Stdio)
+	}
+	// Initialize the Transport based on the selected Protocol.
+	tr, err := tc.newTransport(protocol)
+	if err != nil {
+		return nil, err
+	}
+	tc.transport = tr
+
+	return tc, nil
+}
+
+// newTransport creates the transport that speaks the given protocol.
+func (tc *ToolboxClient) newTransport(protocol Protocol) (transport.Transport, error) {
+	transportOpts := tc.mcpOptions()
+	switch protocol {
+	case MCPv20251125:
+		return mcp20251125.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion, transportOpts...)
+	case MCPv20250618:
+		return mcp20250618.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion, transportOpts...)
+	case MCPv20250326:
+		return mcp20250326.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion, transportOpts...)
+	case MCPv20241105:
+		return mcp20241105.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion, transportOpts...)
+	case Stdio:
+		return stdio.New(tc.command, tc.commandArgs, tc.clientName, tc.clientVersion, transportOpts...)
+	default:
+		return nil, fmt.Errorf("unsupported protocol version: %s", protocol)
+	}
+}
+
+// mcpOptions translates the client configuration into options for the MCP
+// transports.
+func (tc *ToolboxClient) mcpOptions() []mcp.Option {
+	var opts []mcp.Option
+	if tc.maxManifestSize > 0 {
+		opts = append(opts, mcp.WithMaxManifestSize(tc.maxManifestSize))
+	}
+	if len(tc.methodOverrides) > 0 {
+		opts = append(opts, mcp.WithMethodOverrides(tc.methodOverrides))
+	}
+	if tc.requestIDGenerator != nil {
+		opts = append(opts, mcp.WithRequestIDGenerator(tc.requestIDGenerator))
+	}
+	if tc.invokeOpts.logger != nil {
+		opts = append(opts, mcp.WithLogger(tc.invokeOpts.logger))
+	}
+	return opts
+}
+
+// newConfiguredClient creates a client with default values and applies the
+// given options to it, leaving the transport for the caller to set.
+func newConfiguredClient(caller string, url string, opts []ClientOption) (*ToolboxClient, error) {
 	// Initialize the client with default values.
 	// We default to MCP Protocol (the newest version alias) if not overridden.
 	tc := &ToolboxClient{
@@ -72,36 +208,266 @@ func NewToolboxClient(url string, opts ...ClientOption) (*ToolboxClient, error)
 	// Apply each functional option to customize the client configuration.
 	for _, opt := range opts {
 		if opt == nil {
-			return nil, fmt.Errorf("NewToolboxClient: received a nil ClientOption")
+			return nil, fmt.Errorf("%s: received a nil ClientOption", caller)
 		}
 		if err := opt(tc); err != nil {
 			return nil, err
 		}
 	}
 
-	checkSecureHeaders(tc.baseURL, len(tc.clientHeaderSources) > 0)
+	if err := tc.configureHTTPClient(); err != nil {
+		return nil, err
+	}
 
-	// Initialize the Transport based on the selected Protocol.
-	var transportErr error
+	return tc, nil
+}
 
-	if slices.Contains(GetSupportedMcpVersions(), string(tc.protocol)) && tc.protocol != MCPLatest {
-		log.Printf("A newer version of MCP: v%s is available. Please use MCPLatest to use the latest features.", MCPLatest)
+// NewToolboxClientContext creates a client like NewToolboxClient, but
+// eagerly establishes the session with the server under the given context,
+// so that construction can be cancelled or bounded by a deadline.
+//
+// Inputs:
+//   - ctx: The context that governs the initial handshake.
+//   - url: The base URL of the Toolbox server.
+//   - opts: A variadic list of ClientOption functions to configure the client.
+//
+// Returns:
+//
+//	A ready *ToolboxClient and a nil error on success, or a nil client and an
+//	error if configuration or the handshake fails.
+func NewToolboxClientContext(ctx context.Context, url string, opts ...ClientOption) (*ToolboxClient, error) {
+	tc, err := NewToolboxClient(url, opts...)
+	if err != nil {
+		return nil, err
 	}
 
-	switch tc.protocol {
-	case MCPv20251125:
-		tc.transport, transportErr = mcp20251125.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion)
-	case MCPv20250618:
-		tc.transport, transportErr = mcp20250618.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion)
-	case MCPv20250326:
-		tc.transport, transportErr = mcp20250326.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion)
-	case MCPv20241105:
-		tc.transport, transportErr = mcp20241105.New(tc.baseURL, tc.httpClient, tc.clientName, tc.clientVersion)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to initialize client: %w", err)
+	}
+	// With AutoProtocol, resolving the headers negotiates the protocol and may
+	// replace the transport, so the transport is read afterwards.
+	headers, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		// The caller never gets the client, so release what the handshake
+		// may have started, such as a stdio server process.
+		_ = tc.Close()
+		return nil, err
+	}
+	initializer, ok := tc.currentTransport().(transport.Initializer)
+	if !ok {
+		return tc, nil
+	}
+	if err := initializer.EnsureInitialized(ctx, headers); err != nil {
+		_ = tc.Close()
+		return nil, fmt.Errorf("failed to initialize client: %w", err)
+	}
+	return tc, nil
+}
+
+// Close releases the resources held by the client's transport, such as the
+// server process launched for the Stdio protocol. Tools loaded from the
+// client cannot be invoked once it is closed.
+//
+// Returns:
+//
+//	A nil error on success, or an error if the resources could not be
+//	released.
+func (tc *ToolboxClient) Close() error {
+	tc.transportMu.Lock()
+	defer tc.transportMu.Unlock()
+	if closer, ok := tc.transport.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// ServerInfo describes the server a client is connected to.
+type ServerInfo struct {
+	// Protocol is the protocol the client speaks to the server, or empty for
+	// a transport given with WithTransport.
+	Protocol Protocol
+	// Version is the version the server reported, if any.
+	Version string
+}
+
+// Ping checks that the server is reachable and speaks a compatible protocol,
+// so that an application can fail fast at startup instead of on its first
+// tool call. For MCP protocols it performs the handshake, if it has not
+// already happened, and then pings the server.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the check.
+//
+// Returns:
+//
+//	A nil error if the server is healthy, or an error describing why it
+//	cannot be used, such as a protocol version mismatch.
+func (tc *ToolboxClient) Ping(ctx context.Context) error {
+	_, err := tc.ServerInfo(ctx)
+	return err
+}
+
+// ServerInfo checks the server as Ping does and describes it.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the check.
+//
+// Returns:
+//
+//	The server's description and a nil error on success, or nil and an error
+//	if the server is unreachable, incompatible, or older than the version
+//	required with WithMinServerVersion.
+func (tc *ToolboxClient) ServerInfo(ctx context.Context) (*ServerInfo, error) {
+	headers, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var version string
+	tr := tc.currentTransport()
+	if pinger, ok := tr.(transport.Pinger); ok {
+		version, err = pinger.Ping(ctx, headers)
+	} else {
+		// Without a dedicated health check, fetching the default toolset
+		// proves that the server answers.
+		var manifest *transport.ManifestSchema
+		if manifest, err = tr.ListTools(ctx, "", headers); err == nil {
+			version = manifest.ServerVersion
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to ping server: %w", err)
+	}
+	tc.recordServerVersion(version)
+	if err := tc.checkServerVersion(version); err != nil {
+		return nil, err
+	}
+	return &ServerInfo{Protocol: tc.NegotiatedProtocol(), Version: version}, nil
+}
+
+// ServerVersion returns the version the server reported, for compatibility
+// gating and telemetry. For MCP protocols this is the version from the
+// server's initialize response; for other transports it is the version in
+// the manifest. The version seen by the last manifest fetch is returned if
+// there was one; otherwise the server is checked as ServerInfo does.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the check, if one is made.
+//
+// Returns:
+//
+//	The server version, which is empty if the server does not report one,
+//	and a nil error on success, or an empty string and an error if the
+//	server had to be checked and the check failed.
+func (tc *ToolboxClient) ServerVersion(ctx context.Context) (string, error) {
+	tc.serverVersionMu.Lock()
+	version := tc.serverVersion
+	tc.serverVersionMu.Unlock()
+	if version != "" {
+		return version, nil
+	}
+
+	info, err := tc.ServerInfo(ctx)
+	if err != nil {
+		return "", err
+	}
+	return info.Version, nil
+}
+
+// recordServerVersion remembers the version a server reported, if any, for
+// ServerVersion.
+func (tc *ToolboxClient) recordServerVersion(version string) {
+	if version == "" {
+		return
+	}
+	tc.serverVersionMu.Lock()
+	tc.serverVersion = version
+	tc.serverVersionMu.Unlock()
+}
+
+// configureHTTPClient applies the transport-level client options once all
+// options have been processed, so that they compose with WithHTTPClient
+// regardless of the order in which they were given. The user's http.Client
+// and RoundTripper are copied rather than modified.
+func (tc *ToolboxClient) configureHTTPClient() error {
+	if tc.transportConfig != nil {
+		if tc.httpClientSet {
+			return fmt.Errorf("WithTransportConfig cannot be combined with WithHTTPClient")
+		}
+		tc.applyTransportConfig()
+	}
+	if tc.forceHTTP1 {
+		if err := tc.disableHTTP2(); err != nil {
+			return err
+		}
+	}
+	if len(tc.middleware) > 0 {
+		tc.applyMiddleware()
+	}
+	return nil
+}
+
+// applyTransportConfig replaces the default client with one whose transport
+// is a copy of http.DefaultTransport tuned by the transport config.
+func (tc *ToolboxClient) applyTransportConfig() {
+	cfg := tc.transportConfig
+	httpTransport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.MaxIdleConns > 0 {
+		httpTransport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	if cfg.MaxIdleConnsPerHost > 0 {
+		httpTransport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout > 0 {
+		httpTransport.IdleConnTimeout = cfg.IdleConnTimeout
+	}
+	httpTransport.DisableKeepAlives = cfg.DisableKeepAlives
+
+	client := *tc.httpClient
+	client.Transport = httpTransport
+	tc.httpClient = &client
+}
+
+// disableHTTP2 replaces the client's transport with a copy restricted to
+// HTTP/1.1.
+func (tc *ToolboxClient) disableHTTP2() error {
+	var base *http.Transport
+	switch rt := tc.httpClient.Transport.(type) {
+	case nil:
+		base = http.DefaultTransport.(*http.Transport)
+	case *http.Transport:
+		base = rt
 	default:
-		return nil, fmt.Errorf("unsupported protocol version: %s", tc.protocol)
+		return fmt.Errorf("WithForceHTTP1: cannot disable HTTP/2 on transport of type %T; provide an *http.Transport", rt)
+	}
+
+	httpTransport := base.Clone()
+	httpTransport.ForceAttemptHTTP2 = false
+	httpTransport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	httpTransport.Protocols = protocols
+
+	client := *tc.httpClient
+	client.Transport = httpTransport
+	tc.httpClient = &client
+	return nil
+}
+
+// applyMiddleware wraps the client's transport in the middleware chain, with
+// the first middleware outermost.
+func (tc *ToolboxClient) applyMiddleware() {
+	rt := tc.httpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	for i := len(tc.middleware) - 1; i >= 0; i-- {
+		rt = tc.middleware[i](rt)
 	}
 
-	return tc, transportErr
+	client := *tc.httpClient
+	client.Transport = rt
+	tc.httpClient = &client
 }
 
 // newToolboxTool is an internal factory method that constructs a
@@ -126,6 +492,9 @@ func (tc *ToolboxClient) newToolboxTool(
 	isStrict bool,
 	tr transport.Transport,
 ) (*ToolboxTool, []string, []string, error) {
+	if len(finalConfig.UnbindParams) > 0 {
+		return nil, nil, nil, fmt.Errorf("WithUnbindParam option is only applicable to ToolFrom")
+	}
 
 	// These will be the parameters that the end-user must provide at invocation time.
 	finalParameters := make([]ParameterSchema, 0)
@@ -137,6 +506,10 @@ func (tc *ToolboxClient) newToolboxTool(
 	localBoundParams := make(map[string]any)
 	// This map stores the schemas of the bound parameters for validation during invocation.
 	localBoundSchemas := make(map[string]ParameterSchema)
+	// This list keeps every validated parameter in declaration order.
+	var schemaParameters []ParameterSchema
+	// This list tracks struct-bound parameters that were deliberately left unbound.
+	var skippedBoundKeys []string
 
 	// Iterate over the tool's parameters from the schema to categorize them.
 	for _, p := range schema.Parameters {
@@ -154,14 +527,25 @@ func (tc *ToolboxClient) newToolboxTool(
 			return nil, nil, nil, fmt.Errorf("invalid schema for tool '%s': %w", name, err)
 		}
 		paramSchema[p.Name] = struct{}{}
+		schemaParameters = append(schemaParameters, p)
 
 		if len(p.AuthSources) > 0 {
 			// The parameter is satisfied by an authentication source.
 			authnParams[p.Name] = p.AuthSources
 		} else if val, isBound := finalConfig.BoundParams[p.Name]; isBound {
-			// The parameter is satisfied by a pre-configured bound value.
-			localBoundParams[p.Name] = val
-			localBoundSchemas[p.Name] = p
+			if value, apply := unwrapStructBinding(val, p); apply {
+				if err := validateStaticBinding(p, value); err != nil {
+					return nil, nil, nil, fmt.Errorf("invalid bound parameter for tool '%s': %w", name, err)
+				}
+				// The parameter is satisfied by a pre-configured bound value.
+				localBoundParams[p.Name] = value
+				localBoundSchemas[p.Name] = p
+			} else {
+				// A zero-valued struct field leaves the optional parameter
+				// to the caller, but still counts as used.
+				skippedBoundKeys = append(skippedBoundKeys, p.Name)
+				finalParameters = append(finalParameters, p)
+			}
 		} else {
 			// The parameter is not satisfied by auth or bindings, so it must
 			// be provided by the user at invocation.
@@ -169,6 +553,22 @@ func (tc *ToolboxClient) newToolboxTool(
 		}
 	}
 
+	// Validate the output schema, if the server declared one.
+	var outputSchema []ParameterSchema
+	for _, p := range schema.OutputSchema {
+		if ap, ok := p.AdditionalProperties.(map[string]any); ok {
+			apParam, err := mapToSchema(ap)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			p.AdditionalProperties = apParam
+		}
+		if err := p.ValidateDefinition(); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid output schema for tool '%s': %w", name, err)
+		}
+		outputSchema = append(outputSchema, p)
+	}
+
 	// In strict mode, ensure that all provided bound parameters actually exist
 	// on the tool's schema.
 	if isStrict {
@@ -179,8 +579,43 @@ func (tc *ToolboxClient) newToolboxTool(
 		}
 	}
 
+	// Keep the transforms for parameters that exist on this tool. In strict
+	// mode, a transform for an unknown parameter is an error.
+	var localTransforms map[string]func(v any) (any, error)
+	for paramName, fn := range finalConfig.ParamTransforms {
+		if _, exists := paramSchema[paramName]; !exists {
+			if isStrict {
+				return nil, nil, nil, fmt.Errorf("unable to transform parameter: no parameter named '%s' found on tool '%s'", paramName, name)
+			}
+			continue
+		}
+		if localTransforms == nil {
+			localTransforms = make(map[string]func(v any) (any, error))
+		}
+		localTransforms[paramName] = fn
+	}
+
+	// Keep the aliases of parameters that exist on this tool. In strict mode,
+	// an alias for an unknown parameter is an error.
+	var localAliases map[string]string
+	for alias, paramName := range finalConfig.ParamAliases {
+		if _, exists := paramSchema[paramName]; !exists {
+			if isStrict {
+				return nil, nil, nil, fmt.Errorf("unable to alias parameter: no parameter named '%s' found on tool '%s'", paramName, name)
+			}
+			continue
+		}
+		if _, exists := paramSchema[alias]; exists {
+			return nil, nil, nil, fmt.Errorf("unable to alias parameter '%s': '%s' is already a parameter of tool '%s'", paramName, alias, name)
+		}
+		if localAliases == nil {
+			localAliases = make(map[string]string)
+		}
+		localAliases[alias] = paramName
+	}
+
 	// Collect the keys of the bound parameters that were actually used.
-	var usedBoundKeys []string
+	usedBoundKeys := skippedBoundKeys
 	for k := range localBoundParams {
 		usedBoundKeys = append(usedBoundKeys, k)
 	}
@@ -203,25 +638,30 @@ func (tc *ToolboxClient) newToolboxTool(
 		boundParamSchemas:   localBoundSchemas,
 		requiredAuthnParams: remainingAuthnParams,
 		requiredAuthzTokens: remainingAuthzTokens,
-		clientHeaderSources: tc.clientHeaderSources,
+		clientHeaderSources: tc.headerSources(),
+		examples:            schema.Examples,
+		invokeOpts:          &tc.invokeOpts,
+		paramTransforms:     localTransforms,
+		outputSchema:        outputSchema,
+		schemaParameters:    schemaParameters,
+		maxAttempts:         finalConfig.MaxAttempts,
+		backoff:             finalConfig.Backoff,
+		invokeTimeout:       finalConfig.InvokeTimeout,
+		idempotencyKey:      finalConfig.IdempotencyKey,
+
+		paramAliases:          localAliases,
+		caseInsensitiveParams: finalConfig.CaseInsensitiveParams,
+		autoIdempotencyKey:    finalConfig.AutoIdempotencyKey,
+		aggregateValidation:   finalConfig.AggregateValidation,
 	}
 
 	return tt, usedAuthKeys, usedBoundKeys, nil
 }
 
-// LoadTool fetches a manifest for a single tool
-//
-// Inputs:
-//   - name: The specific name of the tool to load.
-//   - ctx: The context to control the lifecycle of the request.
-//   - opts: A variadic list of ToolOption functions to configure auth tokens
-//     or bind parameters for this tool.
-//
-// Returns:
-//
-//	A configured *ToolboxTool and a nil error on success, or a nil tool and
-//	an error if loading or validation fails.
-func (tc *ToolboxClient) LoadTool(name string, ctx context.Context, opts ...ToolOption) (*ToolboxTool, error) {
+// buildToolConfig applies the client-wide default tool options followed by
+// the call-specific options to a fresh ToolConfig. The caller name is used to
+// attribute nil-option errors.
+func (tc *ToolboxClient) buildToolConfig(caller string, opts []ToolOption) (*ToolConfig, error) {
 	finalConfig := newToolConfig()
 
 	// Apply client-wide default options first.
@@ -231,123 +671,434 @@ func (tc *ToolboxClient) LoadTool(name string, ctx context.Context, opts ...Tool
 		}
 	}
 
-	// Then, apply the tool-specific options provided in this call.
+	// Then, apply the options provided in this call.
 	for _, opt := range opts {
 		if opt == nil {
-			return nil, fmt.Errorf("LoadTool: received a nil ToolOption in options list")
+			return nil, fmt.Errorf("%s: received a nil ToolOption in options list", caller)
 		}
 		if err := opt(finalConfig); err != nil {
 			return nil, err
 		}
 	}
+	return finalConfig, nil
+}
 
-	checkSecureHeaders(tc.baseURL, len(finalConfig.AuthTokenSources) > 0)
+// toolBuild is the outcome of constructing one tool of a toolset.
+type toolBuild struct {
+	tool          *ToolboxTool
+	usedAuthKeys  []string
+	usedBoundKeys []string
+	err           error
+}
 
-	resolvedHeaders, err := resolveClientHeaders(tc.clientHeaderSources)
-	if err != nil {
-		return nil, err
+// buildTools constructs the named tools from their schemas, using up to the
+// number of goroutines set with WithToolsetBuildConcurrency. The shared
+// configuration is only read. The results are in the order of names.
+func (tc *ToolboxClient) buildTools(names []string, schemas map[string]ToolSchema, finalConfig *ToolConfig, isStrict bool) []toolBuild {
+	builds := make([]toolBuild, len(names))
+	tr := tc.currentTransport()
+	build := func(i int) {
+		b := &builds[i]
+		b.tool, b.usedAuthKeys, b.usedBoundKeys, b.err = tc.newToolboxTool(names[i], schemas[names[i]], finalConfig, isStrict, tr)
 	}
 
-	// Fetch the manifest for the specified tool.
-	manifest, err := tc.transport.GetTool(ctx, name, resolvedHeaders)
+	workers := min(max(tc.toolsetBuildConcurrency, 1), len(names))
+	if workers <= 1 {
+		for i := range names {
+			build(i)
+		}
+		return builds
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Go(func() {
+			for i := range next {
+				build(i)
+			}
+		})
+	}
+	for i := range names {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return builds
+}
 
+// resolveClientHeaders resolves the client-wide headers, logging failures.
+// With AutoProtocol, it also negotiates the protocol version on first use,
+// since the handshake needs the resolved headers.
+func (tc *ToolboxClient) resolveClientHeaders(ctx context.Context) (map[string]string, error) {
+	headers, err := resolveClientHeaders(ctx, tc.headerSources())
 	if err != nil {
-		return nil, fmt.Errorf("failed to load tool manifest for '%s': %w", name, err)
+		tc.invokeOpts.log().ErrorContext(ctx, "failed to resolve client headers", "error", err)
+		return nil, err
 	}
-	if manifest.Tools == nil {
-		return nil, fmt.Errorf("tool '%s' not found (manifest contains no tools)", name)
+	if tc.autoProtocol {
+		if err := tc.negotiateProtocol(ctx, headers); err != nil {
+			return nil, err
+		}
 	}
-	schema, ok := manifest.Tools[name]
-	if !ok {
-		return nil, fmt.Errorf("tool '%s' not found", name)
+	return headers, nil
+}
+
+// negotiateProtocol performs the handshake for AutoProtocol. If the server
+// answers the newest version with another supported one, the transport is
+// replaced by one for that version and the handshake is retried once.
+func (tc *ToolboxClient) negotiateProtocol(ctx context.Context, headers map[string]string) error {
+	tc.transportMu.Lock()
+	defer tc.transportMu.Unlock()
+	if tc.negotiated {
+		return nil
 	}
 
-	// Construct the tool from its schema and the final configuration.
-	tool, usedAuthKeys, usedBoundKeys, err := tc.newToolboxTool(name, schema, finalConfig, true, tc.transport)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create toolbox tool from schema for '%s': %w", name, err)
+	protocol := MCPLatest
+	initializer, ok := tc.transport.(transport.Initializer)
+	if ok {
+		err := initializer.EnsureInitialized(ctx, headers)
+		var mismatch *transport.VersionMismatchError
+		if errors.As(err, &mismatch) && slices.Contains(GetSupportedMcpVersions(), mismatch.ServerVersion) {
+			protocol = Protocol(mismatch.ServerVersion)
+			tc.invokeOpts.log().DebugContext(ctx, "server offered another MCP version", "requested", mismatch.ClientVersion, "offered", mismatch.ServerVersion)
+			tr, trErr := tc.newTransport(protocol)
+			if trErr != nil {
+				return fmt.Errorf("failed to negotiate protocol version: %w", trErr)
+			}
+			if closer, ok := tc.transport.(io.Closer); ok {
+				_ = closer.Close()
+			}
+			tc.transport = tr
+			if initializer, ok := tr.(transport.Initializer); ok {
+				err = initializer.EnsureInitialized(ctx, headers)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to negotiate protocol version: %w", err)
+		}
 	}
 
-	// Create sets of provided and used keys for efficient lookup.
-	providedAuthKeys := make(map[string]struct{})
-	for k := range finalConfig.AuthTokenSources {
-		providedAuthKeys[k] = struct{}{}
+	tc.protocol = protocol
+	tc.negotiated = true
+	return nil
+}
+
+// currentTransport returns the client's transport. With AutoProtocol, it may
+// be replaced during negotiation, so it is read under transportMu and only
+// after the headers, and with them the protocol, have been resolved.
+func (tc *ToolboxClient) currentTransport() transport.Transport {
+	tc.transportMu.Lock()
+	defer tc.transportMu.Unlock()
+	return tc.transport
+}
+
+// NegotiatedProtocol returns the protocol the client speaks to the server.
+// With AutoProtocol, it is the MCP version agreed with the server, or an
+// empty Protocol if the client has not contacted the server yet.
+func (tc *ToolboxClient) NegotiatedProtocol() Protocol {
+	tc.transportMu.Lock()
+	defer tc.transportMu.Unlock()
+	if tc.autoProtocol && !tc.negotiated {
+		return ""
 	}
-	providedBoundKeys := make(map[string]struct{})
-	for k := range finalConfig.BoundParams {
-		providedBoundKeys[k] = struct{}{}
+	return tc.protocol
+}
+
+// headerSources returns the current client-wide header sources. Once the
+// client has been constructed the map is replaced rather than modified, so
+// the returned map must not be modified either.
+func (tc *ToolboxClient) headerSources() map[string]oauth2.TokenSource {
+	tc.headersMu.RLock()
+	defer tc.headersMu.RUnlock()
+	return tc.clientHeaderSources
+}
+
+// SetClientHeader sets a client-wide HTTP header after the client has been
+// created, replacing any existing source for the header. Unlike
+// WithClientHeaderTokenSource, overriding a header is allowed, which makes it
+// possible, for example, to rotate an API key.
+//
+// This makes the client mutable. Requests started after SetClientHeader
+// returns use the new header, but requests that are already in flight may
+// use either value, so callers that need a header to change at a precise
+// point must coordinate with their own requests. Tools keep the client
+// headers that were set when they were loaded.
+func (tc *ToolboxClient) SetClientHeader(name string, src oauth2.TokenSource) error {
+	if err := validateHeaderName(name); err != nil {
+		return err
 	}
-	usedAuthSet := make(map[string]struct{})
-	for _, k := range usedAuthKeys {
-		usedAuthSet[k] = struct{}{}
+	if src == nil {
+		return fmt.Errorf("SetClientHeader: provided oauth2.TokenSource for header '%s' cannot be nil", name)
 	}
-	usedBoundSet := make(map[string]struct{})
-	for _, k := range usedBoundKeys {
-		usedBoundSet[k] = struct{}{}
+	tc.headersMu.Lock()
+	defer tc.headersMu.Unlock()
+	sources := maps.Clone(tc.clientHeaderSources)
+	sources[name] = src
+	tc.clientHeaderSources = sources
+	return nil
+}
+
+// RemoveClientHeader removes a client-wide HTTP header after the client has
+// been created. It does nothing if the header is not set. The same
+// concurrency caveats as for SetClientHeader apply.
+func (tc *ToolboxClient) RemoveClientHeader(name string) {
+	tc.headersMu.Lock()
+	defer tc.headersMu.Unlock()
+	if _, ok := tc.clientHeaderSources[name]; !ok {
+		return
 	}
+	sources := maps.Clone(tc.clientHeaderSources)
+	delete(sources, name)
+	tc.clientHeaderSources = sources
+}
 
-	// Find any provided options that were not consumed during tool creation.
-	var errorMessages []string
-	unusedAuth := findUnusedKeys(providedAuthKeys, usedAuthSet)
-	unusedBound := findUnusedKeys(providedBoundKeys, usedBoundSet)
+// fetchManifest requests a manifest with fetch and logs the request. kind
+// and name identify what is fetched, such as a tool or a toolset. When
+// manifest caching is enabled, a cached manifest is returned instead of
+// fetching, and fetched manifests are cached.
+func (tc *ToolboxClient) fetchManifest(ctx context.Context, kind, name string, headers map[string]string, fetch func() (*transport.ManifestSchema, error)) (*transport.ManifestSchema, error) {
+	logger := tc.invokeOpts.log()
+	cacheKey := manifestCacheKey(kind, name)
+	if tc.manifestCache != nil {
+		if manifest, ok := tc.manifestCache.get(cacheKey); ok {
+			logger.DebugContext(ctx, "using cached manifest", "kind", kind, "name", name)
+			return manifest, nil
+		}
+	}
 
-	if len(unusedAuth) > 0 {
-		errorMessages = append(errorMessages, fmt.Sprintf("unused auth tokens: %s", strings.Join(unusedAuth, ", ")))
+	logger.DebugContext(ctx, "fetching manifest", "kind", kind, "name", name, "url", tc.baseURL, "headers", redactHeaders(headers))
+	start := time.Now()
+	manifest, err := fetch()
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to fetch manifest", "kind", kind, "name", name, "duration", time.Since(start), "error", err)
+		return nil, err
 	}
-	if len(unusedBound) > 0 {
-		errorMessages = append(errorMessages, fmt.Sprintf("unused bound parameters: %s", strings.Join(unusedBound, ", ")))
+	logger.DebugContext(ctx, "fetched manifest", "kind", kind, "name", name, "duration", time.Since(start), "tools", len(manifest.Tools))
+	tc.recordServerVersion(manifest.ServerVersion)
+
+	if tc.manifestCache != nil {
+		tc.manifestCache.put(cacheKey, manifest)
 	}
-	if len(errorMessages) > 0 {
-		return nil, fmt.Errorf("validation failed for tool '%s': %s", name, strings.Join(errorMessages, "; "))
+	return manifest, nil
+}
+
+// InvalidateManifestCache discards every manifest cached with
+// WithManifestCache, so that the next loads fetch fresh manifests from the
+// server. It does nothing when caching is disabled.
+func (tc *ToolboxClient) InvalidateManifestCache() {
+	if tc.manifestCache != nil {
+		tc.manifestCache.clear()
+	}
+}
+
+// resolveToolsetHeaders resolves the client-wide headers and overlays the
+// headers configured for the given toolset with WithToolsetHeaders.
+func (tc *ToolboxClient) resolveToolsetHeaders(ctx context.Context, toolset string) (map[string]string, error) {
+	resolved, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
 	}
+	maps.Copy(resolved, tc.toolsetHeaders[toolset])
+	return resolved, nil
+}
 
-	return tool, nil
+// checkServerVersion verifies that the version reported by the server satisfies
+// the minimum configured with WithMinServerVersion, if any.
+func (tc *ToolboxClient) checkServerVersion(serverVersion string) error {
+	if tc.minServerVersion == "" {
+		return nil
+	}
+	cmp, err := compareSemver(serverVersion, tc.minServerVersion)
+	if err != nil {
+		return fmt.Errorf("cannot compare server version %q against required %q: %w", serverVersion, tc.minServerVersion, err)
+	}
+	if cmp < 0 {
+		return fmt.Errorf("server version %s is below required %s", serverVersion, tc.minServerVersion)
+	}
+	return nil
 }
 
-// LoadToolset fetches a manifest for a collection of tools.
+// LoadTool fetches a manifest for a single tool
 //
 // Inputs:
-//   - name: Name of the toolset to be loaded.Set this arg to "" to load the default toolset
+//   - name: The specific name of the tool to load.
 //   - ctx: The context to control the lifecycle of the request.
-//   - opts: A variadic list of ToolOption functions. These can include WithStrict
-//     and options for auth or bound params that may apply to tools in the set.
+//   - opts: A variadic list of ToolOption functions to configure auth tokens
+//     or bind parameters for this tool. Loading is strict unless WithStrict(false)
+//     is given, in which case unused auth tokens and bound parameters are
+//     ignored instead of causing an error.
+//
+// Returns:
+//
+//	A configured *ToolboxTool and a nil error on success, or a nil tool and
+//	an error if loading or validation fails.
+func (tc *ToolboxClient) LoadTool(name string, ctx context.Context, opts ...ToolOption) (_ *ToolboxTool, err error) {
+	defer func(start time.Time) {
+		tc.invokeOpts.metrics().ObserveLoad(LoadKindTool, time.Since(start), err)
+	}(time.Now())
+
+	tool, _, err := tc.loadTool(ctx, "LoadTool", name, opts)
+	return tool, err
+}
+
+// loadTool implements LoadTool, attributing option errors to caller. It also
+// returns the auth services whose tokens the tool uses.
+func (tc *ToolboxClient) loadTool(ctx context.Context, caller, name string, opts []ToolOption) (*ToolboxTool, []string, error) {
+	finalConfig, err := tc.buildToolConfig(caller, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	checkSecureHeaders(tc.baseURL, len(finalConfig.AuthTokenSources) > 0)
+
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Fetch the manifest for the specified tool.
+	manifest, err := tc.fetchManifest(ctx, LoadKindTool, name, resolvedHeaders, func() (*transport.ManifestSchema, error) {
+		return tc.currentTransport().GetTool(ctx, name, resolvedHeaders)
+	})
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load tool manifest for '%s': %w", name, err)
+	}
+	if err := tc.checkServerVersion(manifest.ServerVersion); err != nil {
+		return nil, nil, err
+	}
+	if manifest.Tools == nil {
+		return nil, nil, fmt.Errorf("tool '%s' not found (manifest contains no tools)", name)
+	}
+	schema, ok := manifest.Tools[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("tool '%s' not found", name)
+	}
+
+	// Unlike LoadToolset, LoadTool is strict unless told otherwise.
+	strict := finalConfig.Strict || !finalConfig.strictSet
+
+	// Construct the tool from its schema and the final configuration.
+	tool, usedAuthKeys, usedBoundKeys, err := tc.newToolboxTool(name, schema, finalConfig, strict, tc.currentTransport())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create toolbox tool from schema for '%s': %w", name, err)
+	}
+	if !strict {
+		return tool, usedAuthKeys, nil
+	}
+
+	// Create sets of provided and used keys for efficient lookup.
+	providedAuthKeys := make(map[string]struct{})
+	for k := range finalConfig.AuthTokenSources {
+		providedAuthKeys[k] = struct{}{}
+	}
+	providedBoundKeys := make(map[string]struct{})
+	for k := range finalConfig.BoundParams {
+		providedBoundKeys[k] = struct{}{}
+	}
+	usedAuthSet := make(map[string]struct{})
+	for _, k := range usedAuthKeys {
+		usedAuthSet[k] = struct{}{}
+	}
+	usedBoundSet := make(map[string]struct{})
+	for _, k := range usedBoundKeys {
+		usedBoundSet[k] = struct{}{}
+	}
+
+	// Find any provided options that were not consumed during tool creation.
+	var errorMessages []string
+	unusedAuth := findUnusedKeys(providedAuthKeys, usedAuthSet)
+	unusedBound := findUnusedKeys(providedBoundKeys, usedBoundSet)
+
+	if len(unusedAuth) > 0 {
+		errorMessages = append(errorMessages, fmt.Sprintf("unused auth tokens: %s", strings.Join(unusedAuth, ", ")))
+	}
+	if len(unusedBound) > 0 {
+		errorMessages = append(errorMessages, fmt.Sprintf("unused bound parameters: %s", strings.Join(unusedBound, ", ")))
+	}
+	if len(errorMessages) > 0 {
+		return nil, nil, fmt.Errorf("validation failed for tool '%s': %s", name, strings.Join(errorMessages, "; "))
+	}
+
+	return tool, usedAuthKeys, nil
+}
+
+// LoadAuthorizedTool loads a tool and attaches a static token for one of
+// its authentication services, so the returned tool is ready to invoke.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//   - name: The specific name of the tool to load.
+//   - service: The name of the authentication service the token is for.
+//   - token: The token to send for that service.
+//   - opts: A variadic list of additional ToolOption functions for this tool.
+//
+// Returns:
+//
+//	A configured *ToolboxTool and a nil error on success, or a nil tool and
+//	an error if loading fails or the tool does not use the service.
+func (tc *ToolboxClient) LoadAuthorizedTool(ctx context.Context, name, service string, token string, opts ...ToolOption) (_ *ToolboxTool, err error) {
+	if service == "" {
+		return nil, fmt.Errorf("LoadAuthorizedTool: service name cannot be empty")
+	}
+	defer func(start time.Time) {
+		tc.invokeOpts.metrics().ObserveLoad(LoadKindTool, time.Since(start), err)
+	}(time.Now())
+
+	allOpts := append(slices.Clone(opts), WithAuthTokenString(service, token))
+	tool, usedAuthKeys, err := tc.loadTool(ctx, "LoadAuthorizedTool", name, allOpts)
+	if err != nil {
+		return nil, err
+	}
+	// Loading with WithStrict(false) does not reject unused tokens, so check
+	// the service explicitly.
+	if !slices.Contains(usedAuthKeys, service) {
+		return nil, fmt.Errorf("tool '%s' does not use auth service '%s'", name, service)
+	}
+	return tool, nil
+}
+
+// LoadToolset fetches a manifest for a collection of tools.
+//
+// Inputs:
+//   - name: Name of the toolset to be loaded.Set this arg to "" to load the default toolset
+//   - ctx: The context to control the lifecycle of the request.
+//   - opts: A variadic list of ToolOption functions. These can include WithStrict
+//     and options for auth or bound params that may apply to tools in the set.
 //
 // Returns:
 //
 //	A slice of configured *ToolboxTool and a nil error on success, or a nil
 //	slice and an error if loading or validation fails.
-func (tc *ToolboxClient) LoadToolset(name string, ctx context.Context, opts ...ToolOption) ([]*ToolboxTool, error) {
-	finalConfig := newToolConfig()
-	// Apply client-wide default options first.
-	for _, opt := range tc.defaultToolOptions {
-		if err := opt(finalConfig); err != nil {
-			return nil, err
-		}
-	}
+func (tc *ToolboxClient) LoadToolset(name string, ctx context.Context, opts ...ToolOption) (_ []*ToolboxTool, err error) {
+	defer func(start time.Time) {
+		tc.invokeOpts.metrics().ObserveLoad(LoadKindToolset, time.Since(start), err)
+	}(time.Now())
 
-	// Then, apply the toolset-specific options provided in this call.
-	for _, opt := range opts {
-		if opt == nil {
-			return nil, fmt.Errorf("LoadToolset: received a nil ToolOption in options list")
-		}
-		if err := opt(finalConfig); err != nil {
-			return nil, err
-		}
+	finalConfig, err := tc.buildToolConfig("LoadToolset", opts)
+	if err != nil {
+		return nil, err
 	}
 
 	checkSecureHeaders(tc.baseURL, len(finalConfig.AuthTokenSources) > 0)
 
 	// Fetch the manifest for the toolset.
-	resolvedHeaders, err := resolveClientHeaders(tc.clientHeaderSources)
+	resolvedHeaders, err := tc.resolveToolsetHeaders(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 
 	// Fetch Manifest via Transport
-	manifest, err := tc.transport.ListTools(ctx, name, resolvedHeaders)
+	manifest, err := tc.fetchManifest(ctx, LoadKindToolset, name, resolvedHeaders, func() (*transport.ManifestSchema, error) {
+		return tc.currentTransport().ListTools(ctx, name, resolvedHeaders)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to load toolset manifest for '%s': %w", name, err)
 	}
+	if err := tc.checkServerVersion(manifest.ServerVersion); err != nil {
+		return nil, err
+	}
 	if manifest.Tools == nil {
 		return nil, fmt.Errorf("toolset '%s' not found (manifest contains no tools)", name)
 	}
@@ -365,23 +1116,33 @@ func (tc *ToolboxClient) LoadToolset(name string, ctx context.Context, opts ...T
 		providedBoundKeys[k] = struct{}{}
 	}
 
-	for toolName, schema := range manifest.Tools {
-		// Construct each tool from its schema and the shared configuration.
-		tool, usedAuthKeys, usedBoundKeys, err := tc.newToolboxTool(toolName, schema, finalConfig, finalConfig.Strict, tc.transport)
-		if err != nil {
-			return nil, fmt.Errorf("failed to create tool '%s': %w", toolName, err)
+	var toolNames []string
+	for _, toolName := range slices.Sorted(maps.Keys(manifest.Tools)) {
+		if finalConfig.ToolFilter == nil || finalConfig.ToolFilter(toolName) {
+			toolNames = append(toolNames, toolName)
 		}
-		tools = append(tools, tool)
+	}
+
+	// Construct the tools from their schemas and the shared configuration,
+	// then check them in name order so that the first error is deterministic.
+	builds := tc.buildTools(toolNames, manifest.Tools, finalConfig, finalConfig.Strict)
+	for i, toolName := range toolNames {
+		b := builds[i]
+		if b.err != nil {
+			return nil, fmt.Errorf("failed to create tool '%s': %w", toolName, b.err)
+		}
+		b.tool.toolsetHeaders = tc.toolsetHeaders[name]
+		tools = append(tools, b.tool)
 
 		// Validation behavior depends on whether strict mode is enabled.
 		if finalConfig.Strict {
 			// In strict mode, validate each tool individually for unused options.
 			usedAuthSet := make(map[string]struct{})
-			for _, k := range usedAuthKeys {
+			for _, k := range b.usedAuthKeys {
 				usedAuthSet[k] = struct{}{}
 			}
 			usedBoundSet := make(map[string]struct{})
-			for _, k := range usedBoundKeys {
+			for _, k := range b.usedBoundKeys {
 				usedBoundSet[k] = struct{}{}
 			}
 
@@ -401,10 +1162,10 @@ func (tc *ToolboxClient) LoadToolset(name string, ctx context.Context, opts ...T
 		} else {
 			// In non-strict mode, aggregate all used keys across all tools.
 			// Validation will happen once at the end.
-			for _, k := range usedAuthKeys {
+			for _, k := range b.usedAuthKeys {
 				overallUsedAuthKeys[k] = struct{}{}
 			}
-			for _, k := range usedBoundKeys {
+			for _, k := range b.usedBoundKeys {
 				overallUsedBoundParams[k] = struct{}{}
 			}
 		}
@@ -433,3 +1194,438 @@ func (tc *ToolboxClient) LoadToolset(name string, ctx context.Context, opts ...T
 
 	return tools, nil
 }
+
+// LoadToolsetMap loads a toolset as LoadToolset does and returns its tools
+// keyed by name, as agent registries usually need them.
+//
+// Inputs:
+//   - name: Name of the toolset to be loaded. Set this arg to "" to load the
+//     default toolset.
+//   - ctx: The context to control the lifecycle of the request.
+//   - opts: A variadic list of ToolOption functions, as for LoadToolset.
+//
+// Returns:
+//
+//	A map of tool names to configured *ToolboxTool and a nil error on success,
+//	or a nil map and an error if loading or validation fails, or if two tools
+//	share a name.
+func (tc *ToolboxClient) LoadToolsetMap(name string, ctx context.Context, opts ...ToolOption) (map[string]*ToolboxTool, error) {
+	tools, err := tc.LoadToolset(name, ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	toolMap := make(map[string]*ToolboxTool, len(tools))
+	for _, tool := range tools {
+		if _, ok := toolMap[tool.Name()]; ok {
+			return nil, fmt.Errorf("toolset '%s' contains more than one tool named '%s'", name, tool.Name())
+		}
+		toolMap[tool.Name()] = tool
+	}
+	return toolMap, nil
+}
+
+// SearchTools asks the server for the tools matching a query and loads them,
+// which avoids listing every tool on large servers. It requires a transport
+// and server that support tool search, and otherwise returns an error
+// wrapping transport.ErrToolSearchNotSupported.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//   - query: The search query, interpreted by the server.
+//   - opts: A variadic list of ToolOption functions, such as options for auth
+//     or bound params. Options that do not apply to any matching tool are
+//     ignored, since the result set is not known in advance.
+//
+// Returns:
+//
+//	A slice of configured *ToolboxTool, sorted by name, and a nil error on
+//	success, or a nil slice and an error if the search fails.
+func (tc *ToolboxClient) SearchTools(ctx context.Context, query string, opts ...ToolOption) ([]*ToolboxTool, error) {
+	finalConfig, err := tc.buildToolConfig("SearchTools", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	checkSecureHeaders(tc.baseURL, len(finalConfig.AuthTokenSources) > 0)
+
+	// Resolving the headers may negotiate the protocol and replace the
+	// transport, so the transport is read afterwards.
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tr := tc.currentTransport()
+	searcher, ok := tr.(transport.ToolSearcher)
+	if !ok {
+		return nil, fmt.Errorf("failed to search tools: %w", transport.ErrToolSearchNotSupported)
+	}
+
+	manifest, err := searcher.SearchTools(ctx, query, resolvedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tools for query '%s': %w", query, err)
+	}
+	if err := tc.checkServerVersion(manifest.ServerVersion); err != nil {
+		return nil, err
+	}
+
+	toolNames := make([]string, 0, len(manifest.Tools))
+	for toolName := range manifest.Tools {
+		toolNames = append(toolNames, toolName)
+	}
+	slices.Sort(toolNames)
+
+	tools := make([]*ToolboxTool, 0, len(toolNames))
+	for _, toolName := range toolNames {
+		tool, _, _, err := tc.newToolboxTool(toolName, manifest.Tools[toolName], finalConfig, false, tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tool '%s': %w", toolName, err)
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// ListToolsets asks the server for the names of the toolsets it exposes. It
+// requires a transport and server that support enumerating toolsets, and
+// otherwise returns an error wrapping transport.ErrToolsetListingNotSupported.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//
+// Returns:
+//
+//	The toolset names, sorted, and a nil error on success, or a nil slice and
+//	an error if the listing fails.
+func (tc *ToolboxClient) ListToolsets(ctx context.Context) ([]string, error) {
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lister, ok := tc.currentTransport().(transport.ToolsetLister)
+	if !ok {
+		return nil, fmt.Errorf("failed to list toolsets: %w", transport.ErrToolsetListingNotSupported)
+	}
+
+	names, err := lister.ListToolsets(ctx, resolvedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list toolsets: %w", err)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// ListToolNames fetches the manifest of a toolset and returns the names of
+// its tools, without constructing the tools. This is cheaper than
+// LoadToolset for discovery, and does not validate auth token sources or
+// bound parameters.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//   - toolset: The name of the toolset to list. An empty string lists the
+//     default toolset.
+//
+// Returns:
+//
+//	The tool names, sorted, and a nil error on success, or a nil slice and an
+//	error if the manifest cannot be fetched.
+func (tc *ToolboxClient) ListToolNames(ctx context.Context, toolset string) ([]string, error) {
+	resolvedHeaders, err := tc.resolveToolsetHeaders(ctx, toolset)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := tc.fetchManifest(ctx, LoadKindToolset, toolset, resolvedHeaders, func() (*transport.ManifestSchema, error) {
+		return tc.currentTransport().ListTools(ctx, toolset, resolvedHeaders)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load toolset manifest for '%s': %w", toolset, err)
+	}
+	if err := tc.checkServerVersion(manifest.ServerVersion); err != nil {
+		return nil, err
+	}
+	if manifest.Tools == nil {
+		return nil, fmt.Errorf("toolset '%s' not found (manifest contains no tools)", toolset)
+	}
+	return slices.Sorted(maps.Keys(manifest.Tools)), nil
+}
+
+// ListResources asks the server for the resources, such as files or blobs,
+// that it exposes. It requires a transport and server that support
+// resources, and otherwise returns an error wrapping
+// transport.ErrResourcesNotSupported.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//
+// Returns:
+//
+//	The resources and a nil error on success, or a nil slice and an error if
+//	the listing fails.
+func (tc *ToolboxClient) ListResources(ctx context.Context) ([]Resource, error) {
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, ok := tc.currentTransport().(transport.ResourceReader)
+	if !ok {
+		return nil, fmt.Errorf("failed to list resources: %w", transport.ErrResourcesNotSupported)
+	}
+
+	resources, err := reader.ListResources(ctx, resolvedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list resources: %w", err)
+	}
+	return resources, nil
+}
+
+// ReadResource asks the server for the contents of a resource. It requires a
+// transport and server that support resources, and otherwise returns an
+// error wrapping transport.ErrResourcesNotSupported.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//   - uri: The URI of the resource, as reported by ListResources.
+//
+// Returns:
+//
+//	The resource contents, which may hold several parts, and a nil error on
+//	success, or a nil slice and an error if the read fails.
+func (tc *ToolboxClient) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, ok := tc.currentTransport().(transport.ResourceReader)
+	if !ok {
+		return nil, fmt.Errorf("failed to read resource '%s': %w", uri, transport.ErrResourcesNotSupported)
+	}
+
+	contents, err := reader.ReadResource(ctx, uri, resolvedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read resource '%s': %w", uri, err)
+	}
+	return contents, nil
+}
+
+// ListPrompts asks the server for the prompt templates it exposes. It
+// requires a transport and server that support prompts, and otherwise returns
+// an error wrapping transport.ErrPromptsNotSupported.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//
+// Returns:
+//
+//	The prompts, with their arguments, and a nil error on success, or a nil
+//	slice and an error if the listing fails.
+func (tc *ToolboxClient) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	getter, ok := tc.currentTransport().(transport.PromptGetter)
+	if !ok {
+		return nil, fmt.Errorf("failed to list prompts: %w", transport.ErrPromptsNotSupported)
+	}
+
+	prompts, err := getter.ListPrompts(ctx, resolvedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list prompts: %w", err)
+	}
+	return prompts, nil
+}
+
+// GetPrompt asks the server to render a prompt template. It requires a
+// transport and server that support prompts, and otherwise returns an error
+// wrapping transport.ErrPromptsNotSupported.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request.
+//   - name: The name of the prompt, as reported by ListPrompts.
+//   - args: The values of the prompt's arguments, by name.
+//
+// Returns:
+//
+//	The rendered prompt messages and a nil error on success, or a nil result
+//	and an error if rendering fails.
+func (tc *ToolboxClient) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	resolvedHeaders, err := tc.resolveClientHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	getter, ok := tc.currentTransport().(transport.PromptGetter)
+	if !ok {
+		return nil, fmt.Errorf("failed to get prompt '%s': %w", name, transport.ErrPromptsNotSupported)
+	}
+
+	result, err := getter.GetPrompt(ctx, name, args, resolvedHeaders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get prompt '%s': %w", name, err)
+	}
+	return result, nil
+}
+
+// ToolOrError is a single item emitted by StreamToolset: either a fully
+// constructed tool or the error encountered while building it.
+type ToolOrError struct {
+	Tool *ToolboxTool
+	Err  error
+}
+
+// StreamToolset fetches a manifest for a collection of tools and emits each
+// tool on the returned channel as soon as it has been constructed, instead of
+// materializing the whole toolset first.
+//
+// Tools are emitted in name order. Errors for individual tools are emitted
+// as items with a non-nil Err and do not stop the stream. In non-strict mode,
+// options that could not be applied to any tool are reported as a final item
+// with a non-nil Err. The channel is closed once all tools have been emitted
+// or when ctx is cancelled.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request and the stream.
+//   - name: Name of the toolset to be loaded. Set this arg to "" to load the default toolset.
+//   - opts: A variadic list of ToolOption functions, as accepted by LoadToolset.
+//
+// Returns:
+//
+//	A receive-only channel of ToolOrError and a nil error on success, or a
+//	nil channel and an error if the manifest could not be fetched.
+func (tc *ToolboxClient) StreamToolset(ctx context.Context, name string, opts ...ToolOption) (<-chan ToolOrError, error) {
+	finalConfig, err := tc.buildToolConfig("StreamToolset", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	checkSecureHeaders(tc.baseURL, len(finalConfig.AuthTokenSources) > 0)
+
+	resolvedHeaders, err := tc.resolveToolsetHeaders(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := tc.fetchManifest(ctx, LoadKindToolset, name, resolvedHeaders, func() (*transport.ManifestSchema, error) {
+		return tc.currentTransport().ListTools(ctx, name, resolvedHeaders)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load toolset manifest for '%s': %w", name, err)
+	}
+	if err := tc.checkServerVersion(manifest.ServerVersion); err != nil {
+		return nil, err
+	}
+	if manifest.Tools == nil {
+		return nil, fmt.Errorf("toolset '%s' not found (manifest contains no tools)", name)
+	}
+
+	toolNames := make([]string, 0, len(manifest.Tools))
+	for toolName := range manifest.Tools {
+		if finalConfig.ToolFilter != nil && !finalConfig.ToolFilter(toolName) {
+			continue
+		}
+		toolNames = append(toolNames, toolName)
+	}
+	slices.Sort(toolNames)
+
+	providedAuthKeys := make(map[string]struct{})
+	for k := range finalConfig.AuthTokenSources {
+		providedAuthKeys[k] = struct{}{}
+	}
+	providedBoundKeys := make(map[string]struct{})
+	for k := range finalConfig.BoundParams {
+		providedBoundKeys[k] = struct{}{}
+	}
+
+	out := make(chan ToolOrError)
+	go func() {
+		defer close(out)
+
+		emit := func(item ToolOrError) bool {
+			select {
+			case out <- item:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		overallUsedAuthKeys := make(map[string]struct{})
+		overallUsedBoundParams := make(map[string]struct{})
+
+		for _, toolName := range toolNames {
+			if ctx.Err() != nil {
+				return
+			}
+
+			tool, usedAuthKeys, usedBoundKeys, err := tc.newToolboxTool(toolName, manifest.Tools[toolName], finalConfig, finalConfig.Strict, tc.currentTransport())
+			if err != nil {
+				if !emit(ToolOrError{Err: fmt.Errorf("failed to create tool '%s': %w", toolName, err)}) {
+					return
+				}
+				continue
+			}
+
+			if finalConfig.Strict {
+				usedAuthSet := make(map[string]struct{})
+				for _, k := range usedAuthKeys {
+					usedAuthSet[k] = struct{}{}
+				}
+				usedBoundSet := make(map[string]struct{})
+				for _, k := range usedBoundKeys {
+					usedBoundSet[k] = struct{}{}
+				}
+
+				var errorMessages []string
+				if unusedAuth := findUnusedKeys(providedAuthKeys, usedAuthSet); len(unusedAuth) > 0 {
+					errorMessages = append(errorMessages, fmt.Sprintf("unused auth tokens: %s", strings.Join(unusedAuth, ", ")))
+				}
+				if unusedBound := findUnusedKeys(providedBoundKeys, usedBoundSet); len(unusedBound) > 0 {
+					errorMessages = append(errorMessages, fmt.Sprintf("unused bound parameters: %s", strings.Join(unusedBound, ", ")))
+				}
+				if len(errorMessages) > 0 {
+					if !emit(ToolOrError{Err: fmt.Errorf("validation failed for tool '%s': %s", toolName, strings.Join(errorMessages, "; "))}) {
+						return
+					}
+					continue
+				}
+			} else {
+				for _, k := range usedAuthKeys {
+					overallUsedAuthKeys[k] = struct{}{}
+				}
+				for _, k := range usedBoundKeys {
+					overallUsedBoundParams[k] = struct{}{}
+				}
+			}
+
+			tool.toolsetHeaders = tc.toolsetHeaders[name]
+			if !emit(ToolOrError{Tool: tool}) {
+				return
+			}
+		}
+
+		if !finalConfig.Strict {
+			var errorMessages []string
+			if unusedAuth := findUnusedKeys(providedAuthKeys, overallUsedAuthKeys); len(unusedAuth) > 0 {
+				errorMessages = append(errorMessages, fmt.Sprintf("unused auth tokens could not be applied to any tool: %s", strings.Join(unusedAuth, ", ")))
+			}
+			if unusedBound := findUnusedKeys(providedBoundKeys, overallUsedBoundParams); len(unusedBound) > 0 {
+				errorMessages = append(errorMessages, fmt.Sprintf("unused bound parameters could not be applied to any tool: %s", strings.Join(unusedBound, ", ")))
+			}
+			if len(errorMessages) > 0 {
+				toolsetName := name
+				if toolsetName == "" {
+					toolsetName = "default"
+				}
+				emit(ToolOrError{Err: fmt.Errorf("validation failed for toolset '%s': %s", toolsetName, strings.Join(errorMessages, "; "))})
+			}
+		}
+	}()
+
+	return out, nil
+}
@@ -21,15 +21,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/oauth2"
@@ -160,6 +169,86 @@ func TestNewToolboxClient(t *testing.T) {
 
 }
 
+func TestNewToolboxClientContext(t *testing.T) {
+	mcpTools := []mcpTool{
+		{Name: "tool1", Description: "d1", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+	}
+
+	t.Run("Performs the handshake eagerly", func(t *testing.T) {
+		var mu sync.Mutex
+		var methods []string
+		mock := newMockMCPServer(t, mcpTools)
+		defer mock.Close()
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			_ = json.Unmarshal(body, &req)
+			mu.Lock()
+			methods = append(methods, req.Method)
+			mu.Unlock()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			mock.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+
+		client, err := NewToolboxClientContext(context.Background(), server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+		require.NotNil(t, client)
+
+		mu.Lock()
+		assert.Equal(t, []string{"initialize", "notifications/initialized"}, methods)
+		mu.Unlock()
+
+		tool, err := client.LoadTool("tool1", context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "tool1", tool.Name())
+	})
+
+	t.Run("Cancelled context aborts the handshake", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		client, err := NewToolboxClientContext(ctx, server.URL, WithHTTPClient(server.Client()))
+		require.Error(t, err)
+		assert.Nil(t, client)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Zero(t, requests, "no request should be sent with a cancelled context")
+	})
+
+	t.Run("Deadline bounds a stalled handshake", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		client, err := NewToolboxClientContext(ctx, server.URL, WithHTTPClient(server.Client()))
+		require.Error(t, err)
+		assert.Nil(t, client)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Propagates option errors", func(t *testing.T) {
+		_, err := NewToolboxClientContext(context.Background(), "url", nil)
+		require.Error(t, err)
+	})
+}
+
 func TestNewToolboxClient_ProtocolWarnings(t *testing.T) {
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
@@ -434,15 +523,68 @@ func TestLoadToolAndLoadToolset(t *testing.T) {
 		}
 	})
 
-	t.Run("LoadTool - Delayed Validation for Bound Parameters", func(t *testing.T) {
+	t.Run("LoadTool - Non-strict mode ignores unused options", func(t *testing.T) {
 		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
-		// param1 expects a string, but we bind an int. LoadTool should not error.
 		tool, err := client.LoadTool("toolA",
+			context.Background(),
+			WithStrict(false),
+			WithBindParamString("param1", "value1"),
+			WithBindParamString("extra", "ignored"),
+			WithAuthTokenString("google", "token-google"),
+			WithAuthTokenString("github", "token-github"),
+		)
+		require.NoError(t, err, "Non-strict LoadTool should tolerate unused options")
+		assert.Equal(t, "value1", tool.boundParams["param1"])
+		assert.NotContains(t, tool.boundParams, "extra")
+	})
+
+	t.Run("LoadTool - Strict mode rejects an unused binding", func(t *testing.T) {
+		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		for _, opts := range [][]ToolOption{
+			{WithBindParamString("extra", "value")},
+			{WithStrict(true), WithBindParamString("extra", "value")},
+		} {
+			opts = append(opts, WithAuthTokenString("google", "token-google"))
+			_, err := client.LoadTool("toolA", context.Background(), opts...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "no parameter named 'extra' found on tool 'toolA'")
+		}
+	})
+
+	t.Run("LoadTool - Validates Static Bound Parameters", func(t *testing.T) {
+		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		// param1 expects a string, but we bind an int.
+		_, err := client.LoadTool("toolA",
 			context.Background(),
 			WithBindParamInt("param1", 123),
 			WithAuthTokenString("google", "token-google"),
 		)
-		require.NoError(t, err, "LoadTool should delay type validation")
+		require.Error(t, err, "LoadTool should reject a wrong-typed bound value")
+		assert.Contains(t, err.Error(), "bound parameter 'param1' failed validation")
+		assert.Contains(t, err.Error(), "expects a string, but got int")
+	})
+
+	t.Run("LoadTool - Accepts Correctly Typed Bound Parameters", func(t *testing.T) {
+		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		tool, err := client.LoadTool("toolA",
+			context.Background(),
+			WithBindParamString("param1", "value"),
+			WithAuthTokenString("google", "token-google"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "value", tool.boundParams["param1"])
+	})
+
+	t.Run("LoadTool - Delayed Validation for Bound Functions", func(t *testing.T) {
+		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		// param1 expects a string, but the function returns an int. It is
+		// only validated once resolved at invocation.
+		tool, err := client.LoadTool("toolA",
+			context.Background(),
+			WithBindParamIntFunc("param1", func() (int, error) { return 123, nil }),
+			WithAuthTokenString("google", "token-google"),
+		)
+		require.NoError(t, err, "LoadTool should delay validation of bound functions")
 
 		// Confirm the schema was captured for Invoke
 		assert.NotNil(t, tool.boundParamSchemas["param1"])
@@ -579,245 +721,2780 @@ func TestLoadToolAndLoadToolset(t *testing.T) {
 	})
 }
 
-func TestLoadTool_HTTPWarning(t *testing.T) {
-	// Setup a mock HTTP server (not HTTPS) using MCP
+func TestLoadTool_MinServerVersion(t *testing.T) {
 	mcpTools := []mcpTool{
-		{
-			Name:        "test-tool",
-			Description: "A test tool",
-			InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
-		},
+		{Name: "tool1", Description: "d1", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
 	}
+	// The mock server reports version 1.0.0.
 	server := newMockMCPServer(t, mcpTools)
 	defer server.Close()
 
-	client, err := NewToolboxClient(server.URL)
-	require.NoError(t, err)
+	t.Run("Refuses a server below the minimum version", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithMinServerVersion("1.2.0"))
+		require.NoError(t, err)
 
-	t.Run("Warning logged when auth tokens are provided over HTTP", func(t *testing.T) {
-		output := captureLogOutput(func() {
-			_, err := client.LoadTool("test-tool", context.Background(), WithAuthTokenString("service", "token"))
-			// We expect no error, or at least we don't care about the error for the warning test
-			// ignoring error check as we only care about the log
-			_ = err
-		})
-		assert.Contains(t, output, "WARNING: This connection is using HTTP")
-	})
+		_, err = client.LoadTool("tool1", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server version 1.0.0 is below required 1.2.0")
 
-	t.Run("No warning when no auth tokens provided", func(t *testing.T) {
-		output := captureLogOutput(func() {
-			_, _ = client.LoadTool("test-tool", context.Background())
-		})
-		assert.NotContains(t, output, "WARNING: This connection is using HTTP")
+		_, err = client.LoadToolset("", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server version 1.0.0 is below required 1.2.0")
 	})
-}
-
-func TestLoadToolset_HTTPWarning(t *testing.T) {
-	// Setup a mock HTTP server with MCP
-	mcpTools := []mcpTool{
-		{Name: "tool1", Description: "d1", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
-		{Name: "tool2", Description: "d2", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
-	}
-	server := newMockMCPServer(t, mcpTools)
-	defer server.Close()
 
-	client, err := NewToolboxClient(server.URL)
-	require.NoError(t, err)
+	t.Run("Accepts a server satisfying the minimum version", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithMinServerVersion("v1.0.0"))
+		require.NoError(t, err)
 
-	t.Run("Warning logged when auth tokens are provided over HTTP", func(t *testing.T) {
-		output := captureLogOutput(func() {
-			_, _ = client.LoadToolset("test-toolset", context.Background(), WithAuthTokenString("service", "token"))
-		})
-		assert.Contains(t, output, "WARNING: This connection is using HTTP")
+		tool, err := client.LoadTool("tool1", context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "tool1", tool.Name())
 	})
 
-	t.Run("No warning when no auth tokens provided", func(t *testing.T) {
-		output := captureLogOutput(func() {
-			_, _ = client.LoadToolset("test-toolset", context.Background())
-		})
-		assert.NotContains(t, output, "WARNING: This connection is using HTTP")
+	t.Run("Rejects an invalid minimum version", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL, WithMinServerVersion("latest"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid semantic version")
 	})
 }
 
-func TestDefaultOptionOverwriting(t *testing.T) {
-	// Setup a mock server using MCP
+func TestStreamToolset(t *testing.T) {
 	mcpTools := []mcpTool{
-		{
-			Name:        "toolWithParams",
-			Description: "A tool that uses the parameters being tested",
-			InputSchema: map[string]any{
-				"type": "object",
-				"properties": map[string]any{
-					"user_id": map[string]any{"type": "string"},
-				},
-			},
-			Meta: map[string]any{
-				"toolbox/authInvoke": []string{"google"},
-			},
-		},
+		{Name: "toolC", Description: "c", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+		{Name: "toolA", Description: "a", InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"param1": map[string]any{"type": "string"},
+		}}},
+		{Name: "toolB", Description: "b", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
 	}
 	server := newMockMCPServer(t, mcpTools)
 	defer server.Close()
 
-	t.Run("LoadTool - Fails when overriding a default bound parameter", func(t *testing.T) {
-		client, err := NewToolboxClient(server.URL,
-			WithHTTPClient(server.Client()),
-			WithDefaultToolOptions(
-				WithBindParamString("user_id", "default_user"),
-			),
-		)
-		if err != nil {
-			t.Fatalf("Client creation with default options failed unexpectedly: %v", err)
-		}
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
 
-		_, err = client.LoadTool("toolWithParams", context.Background(),
-			WithBindParamString("user_id", "override_user"),
-		)
+	t.Run("Emits all tools in name order and closes the channel", func(t *testing.T) {
+		ch, err := client.StreamToolset(context.Background(), "")
+		require.NoError(t, err)
 
-		if err == nil {
-			t.Fatal("Expected an error when overriding a default bound parameter, but got nil")
+		var names []string
+		for item := range ch {
+			require.NoError(t, item.Err)
+			names = append(names, item.Tool.Name())
 		}
+		assert.Equal(t, []string{"toolA", "toolB", "toolC"}, names)
+	})
 
-		expectedErrorMsg := "duplicate parameter binding: parameter 'user_id' is already set"
-		if !strings.Contains(err.Error(), expectedErrorMsg) {
-			t.Errorf("Expected error message to contain %q, but got: %v", expectedErrorMsg, err)
+	t.Run("Emits per-tool errors in strict mode", func(t *testing.T) {
+		ch, err := client.StreamToolset(context.Background(), "", WithStrict(true), WithBindParamString("param1", "v"))
+		require.NoError(t, err)
+
+		var tools, errs int
+		for item := range ch {
+			if item.Err != nil {
+				errs++
+				continue
+			}
+			tools++
 		}
+		assert.Equal(t, 1, tools, "only toolA accepts param1")
+		assert.Equal(t, 2, errs)
 	})
 
-	t.Run("LoadTool - Fails when overriding a default auth token", func(t *testing.T) {
+	t.Run("Reports unused options as a final error", func(t *testing.T) {
+		ch, err := client.StreamToolset(context.Background(), "", WithBindParamString("missing", "v"))
+		require.NoError(t, err)
 
-		client, err := NewToolboxClient(server.URL,
-			WithHTTPClient(server.Client()),
-			WithDefaultToolOptions(
-				WithAuthTokenString("google", "default_google_token"),
-			),
-		)
-		if err != nil {
-			t.Fatalf("Client creation with default options failed unexpectedly: %v", err)
+		var last ToolOrError
+		count := 0
+		for item := range ch {
+			last = item
+			count++
 		}
+		assert.Equal(t, 4, count)
+		require.Error(t, last.Err)
+		assert.Contains(t, last.Err.Error(), "unused bound parameters could not be applied to any tool: missing")
+	})
 
-		_, err = client.LoadTool("toolWithParams", context.Background(),
-			WithAuthTokenString("google", "override_google_token"),
-		)
+	t.Run("Closes the channel on context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		ch, err := client.StreamToolset(ctx, "")
+		require.NoError(t, err)
 
-		if err == nil {
-			t.Fatal("Expected an error when overriding a default auth token, but got nil")
-		}
+		first := <-ch
+		require.NoError(t, first.Err)
+		cancel()
 
-		expectedErrorMsg := "authentication source 'google' is already set"
-		if !strings.Contains(err.Error(), expectedErrorMsg) {
-			t.Errorf("Expected error message to contain %q, but got: %v", expectedErrorMsg, err)
+		for range ch {
 		}
 	})
+
+	t.Run("Fails when the manifest cannot be fetched", func(t *testing.T) {
+		badClient, err := NewToolboxClient("http://127.0.0.1:0")
+		require.NoError(t, err)
+
+		_, err = badClient.StreamToolset(context.Background(), "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to load toolset manifest")
+	})
 }
 
-func TestNegativeAndEdgeCases(t *testing.T) {
-	// MCP server returning empty tool list
-	server := newMockMCPServer(t, []mcpTool{})
-	defer server.Close()
+func TestLoadToolsetMap(t *testing.T) {
+	emptySchema := map[string]any{"type": "object", "properties": map[string]any{}}
 
-	t.Run("LoadTool fails when a nil ToolOption is provided", func(t *testing.T) {
+	t.Run("Keys the tools by name", func(t *testing.T) {
+		server := newMockMCPServer(t, []mcpTool{
+			{Name: "get-user", Description: "u", InputSchema: emptySchema},
+			{Name: "get-order", Description: "o", InputSchema: emptySchema},
+		})
+		defer server.Close()
 
-		client, _ := NewToolboxClient(server.URL)
-		_, err := client.LoadTool("any-tool", context.Background(), nil)
-		if err == nil {
-			t.Fatal("Expected an error when a nil option is passed to LoadTool, but got nil")
-		}
-		if !strings.Contains(err.Error(), "received a nil ToolOption ") {
-			t.Errorf("Expected nil option error, got: %v", err)
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+
+		tools, err := client.LoadToolsetMap("", context.Background())
+		require.NoError(t, err)
+		require.Len(t, tools, 2)
+		for name, tool := range tools {
+			assert.Equal(t, name, tool.Name())
 		}
+		assert.Equal(t, "o", tools["get-order"].Description())
 	})
 
-	t.Run("Client options fail fast with nil arguments", func(t *testing.T) {
+	t.Run("Rejects duplicate tool names", func(t *testing.T) {
+		server := newMockMCPServer(t, []mcpTool{
+			{Name: "get-user", Description: "first", InputSchema: emptySchema},
+			{Name: "get-user", Description: "second", InputSchema: emptySchema},
+		})
+		defer server.Close()
 
-		// Test WithHTTPClient(nil)
-		_, err := NewToolboxClient(server.URL, WithHTTPClient(nil))
-		if err == nil {
-			t.Error("Expected error from WithHTTPClient(nil), but got nil")
-		} else if !strings.Contains(err.Error(), "http.Client cannot be nil") {
-			t.Errorf("Incorrect error message for nil http client. Got: %v", err)
-		}
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
 
-		// Test WithClientHeaderTokenSource(name, nil)
-		_, err = NewToolboxClient(server.URL, WithClientHeaderTokenSource("any", nil))
-		if err == nil {
-			t.Error("Expected error from WithClientHeaderTokenSource(name, nil), but got nil")
-		} else if !strings.Contains(err.Error(), "oauth2.TokenSource for header 'any' cannot be nil") {
-			t.Errorf("Incorrect error message for nil token source. Got: %v", err)
-		}
+		tools, err := client.LoadToolsetMap("", context.Background())
+		assert.Nil(t, tools)
+		assert.ErrorContains(t, err, "received duplicate tool definition at index 1: tool 'get-user' is already defined")
 	})
 
-	t.Run("LoadTool fails gracefully if manifest has no tools", func(t *testing.T) {
-		// Mock server above returns empty list of tools
-		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	t.Run("Propagates load errors", func(t *testing.T) {
+		server := newMockMCPServer(t, []mcpTool{{Name: "get-user", Description: "u", InputSchema: emptySchema}})
+		defer server.Close()
 
-		// This call would panic if the code doesn't check for a nil map.
-		_, err := client.LoadTool("any-tool", context.Background())
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
 
-		if err == nil {
-			t.Fatal("Expected an error when manifest has no tools, but got nil")
-		}
-		if !strings.Contains(err.Error(), "tool 'any-tool' not found") {
-			t.Errorf("Expected 'tool not found' error, got: %v", err)
-		}
+		_, err = client.LoadToolsetMap("", context.Background(), WithBindParamString("region", "emea"), WithStrict(true))
+		assert.Error(t, err)
 	})
 }
 
-// TestOptionDuplicateAndEdgeCases covers scenarios where options are used incorrectly.
-func TestOptionDuplicateAndEdgeCases(t *testing.T) {
-	t.Run("Fails when trying to add default tool options twice", func(t *testing.T) {
-		// Action: Try to configure a client with the same option type twice.
-		_, err := NewToolboxClient("url",
-			WithDefaultToolOptions(WithStrict(true)), // First call
-			WithDefaultToolOptions(WithStrict(true)), // Second call should fail
-		)
+func TestLoadToolset_ToolNameGlob(t *testing.T) {
+	emptySchema := map[string]any{"type": "object", "properties": map[string]any{}}
+	mcpTools := []mcpTool{
+		{Name: "get-user", Description: "u", InputSchema: emptySchema},
+		{Name: "get-order", Description: "o", InputSchema: emptySchema},
+		{Name: "delete-user", Description: "d", InputSchema: emptySchema, Meta: map[string]any{
+			"toolbox/authInvoke": []string{"admin"},
+		}},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
 
-		// Assert
-		if err == nil {
-			t.Fatal("Expected an error when setting default tool options twice, but got nil")
-		}
-		if !strings.Contains(err.Error(), "default tool options have already been set") {
-			t.Errorf("Incorrect error message for duplicate default options. Got: %v", err)
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+
+	t.Run("Includes only matching tools", func(t *testing.T) {
+		tools, err := client.LoadToolset("", context.Background(), WithToolNameGlob("get-*"))
+		require.NoError(t, err)
+
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name())
 		}
+		assert.ElementsMatch(t, []string{"get-user", "get-order"}, names)
 	})
 
-	t.Run("Fails when ClientHeaderTokenSource tries to overwrite", func(t *testing.T) {
-		_, err := NewToolboxClient("url",
-			WithClientHeaderString("Authorization", "token-a"),
-			WithClientHeaderTokenSource("Authorization", &mockTokenSource{}), // Overwrite attempt
-		)
+	t.Run("StreamToolset applies the same filter", func(t *testing.T) {
+		ch, err := client.StreamToolset(context.Background(), "", WithToolNameGlob("*-user"))
+		require.NoError(t, err)
 
-		if err == nil {
-			t.Fatal("Expected an error when overwriting a client header, but got nil")
-		}
-		if !strings.Contains(err.Error(), "client header 'Authorization' is already set") {
-			t.Errorf("Incorrect error message for duplicate client header. Got: %v", err)
+		var names []string
+		for item := range ch {
+			require.NoError(t, item.Err)
+			names = append(names, item.Tool.Name())
 		}
+		assert.Equal(t, []string{"delete-user", "get-user"}, names)
 	})
 
-	t.Run("Fails when WithAuthTokenSource tries to overwrite", func(t *testing.T) {
-		// Note: This check happens at application time, not client creation time.
-		config := newToolConfig()
-		_ = WithAuthTokenString("google", "token-a")(config)             // Set it once
-		err := WithAuthTokenSource("google", &mockTokenSource{})(config) // Try to overwrite
+	t.Run("Auth used only by excluded tools is reported as unused", func(t *testing.T) {
+		_, err := client.LoadToolset("", context.Background(),
+			WithToolNameGlob("get-*"),
+			WithAuthTokenString("admin", "token"),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unused auth tokens could not be applied to any tool: admin")
+	})
 
-		if err == nil {
-			t.Fatal("Expected an error when overwriting an auth token source, but got nil")
-		}
-		if !strings.Contains(err.Error(), "authentication source 'google' is already set") {
-			t.Errorf("Incorrect error message for duplicate auth token. Got: %v", err)
-		}
+	t.Run("Auth used by an included tool is accepted", func(t *testing.T) {
+		tools, err := client.LoadToolset("", context.Background(),
+			WithToolNameGlob("delete-*"),
+			WithAuthTokenString("admin", "token"),
+		)
+		require.NoError(t, err)
+		require.Len(t, tools, 1)
+		assert.Equal(t, "delete-user", tools[0].Name())
+	})
+
+	t.Run("Invalid pattern fails before any request", func(t *testing.T) {
+		_, err := client.LoadToolset("", context.Background(), WithToolNameGlob(`get-\`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid glob pattern")
 	})
 }
 
-// TestLoadToolAndLoadToolset_ErrorPaths covers various failure scenarios for the main functions.
-func TestLoadToolAndLoadToolset_ErrorPaths(t *testing.T) {
-	// Setup a mock server and manifest
-	mcpTools := []mcpTool{
-		{
-			Name:        "toolA",
-			Description: "Tool A",
-			InputSchema: map[string]any{
+func TestSearchTools(t *testing.T) {
+	newSearchServer := func(t *testing.T, supportsSearch bool) (*httptest.Server, *[]string) {
+		var queries []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				toolsCapability := map[string]any{}
+				if supportsSearch {
+					toolsCapability["search"] = true
+				}
+				result = map[string]any{
+					"protocolVersion": "2025-06-18",
+					"capabilities":    map[string]any{"tools": toolsCapability},
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "tools/search":
+				params, _ := req.Params.(map[string]any)
+				query, _ := params["query"].(string)
+				queries = append(queries, query)
+				result = map[string]any{"tools": []mcpTool{
+					{Name: "get-weather", Description: "w", InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+						"city": map[string]any{"type": "string"},
+					}}},
+					{Name: "get-forecast", Description: "f", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+				}}
+			default:
+				http.Error(w, "method not found", http.StatusNotFound)
+				return
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+		return server, &queries
+	}
+
+	t.Run("Loads the matching tools from a server supporting search", func(t *testing.T) {
+		server, queries := newSearchServer(t, true)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		tools, err := client.SearchTools(context.Background(), "weather", WithBindParamString("city", "Paris"))
+		require.NoError(t, err)
+		require.Len(t, tools, 2)
+		assert.Equal(t, "get-forecast", tools[0].Name())
+		assert.Equal(t, "get-weather", tools[1].Name())
+		assert.Empty(t, tools[1].Parameters(), "bound parameter should be removed from the schema")
+		assert.Equal(t, []string{"weather"}, *queries)
+	})
+
+	t.Run("Errors when the server does not advertise search", func(t *testing.T) {
+		server, queries := newSearchServer(t, false)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		_, err = client.SearchTools(context.Background(), "weather")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, transport.ErrToolSearchNotSupported)
+		assert.Empty(t, *queries, "no search request should be sent")
+	})
+
+	t.Run("Errors when the transport cannot search", func(t *testing.T) {
+		client, err := NewToolboxClientFromManifest([]byte(`{"tools": {"t": {"description": "d", "parameters": []}}}`))
+		require.NoError(t, err)
+
+		_, err = client.SearchTools(context.Background(), "weather")
+		require.Error(t, err)
+		assert.ErrorIs(t, err, transport.ErrToolSearchNotSupported)
+	})
+}
+
+func TestListToolsets(t *testing.T) {
+	newToolsetServer := func(t *testing.T, supportsListing bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				toolsCapability := map[string]any{}
+				if supportsListing {
+					toolsCapability["toolsets"] = true
+				}
+				result = map[string]any{
+					"protocolVersion": "2025-06-18",
+					"capabilities":    map[string]any{"tools": toolsCapability},
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "toolsets/list":
+				result = map[string]any{"toolsets": []map[string]any{
+					{"name": "travel"},
+					{"name": "billing"},
+				}}
+			default:
+				http.Error(w, "method not found", http.StatusNotFound)
+				return
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+	}
+
+	t.Run("Returns the toolset names from a supporting server", func(t *testing.T) {
+		server := newToolsetServer(t, true)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		names, err := client.ListToolsets(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"billing", "travel"}, names)
+	})
+
+	t.Run("Errors when the server does not advertise toolsets", func(t *testing.T) {
+		server := newToolsetServer(t, false)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		_, err = client.ListToolsets(context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, transport.ErrToolsetListingNotSupported)
+		assert.Contains(t, err.Error(), "listing toolsets is not supported")
+	})
+
+	t.Run("Errors when the transport cannot list toolsets", func(t *testing.T) {
+		client, err := NewToolboxClientFromManifest([]byte(`{"tools": {"t": {"description": "d", "parameters": []}}}`))
+		require.NoError(t, err)
+
+		_, err = client.ListToolsets(context.Background())
+		assert.ErrorIs(t, err, transport.ErrToolsetListingNotSupported)
+	})
+}
+
+func TestListToolNames(t *testing.T) {
+	emptySchema := map[string]any{"type": "object", "properties": map[string]any{}}
+	toolsets := map[string][]mcpTool{
+		"/mcp/": {
+			{Name: "zeta", Description: "z", InputSchema: emptySchema},
+			{Name: "alpha", Description: "a", InputSchema: emptySchema, Meta: map[string]any{
+				"toolbox/authInvoke": []string{"admin"},
+			}},
+			{Name: "mid", Description: "m", InputSchema: emptySchema},
+		},
+		"/mcp/billing": {
+			{Name: "refund", Description: "r", InputSchema: emptySchema},
+			{Name: "charge", Description: "c", InputSchema: emptySchema},
+		},
+	}
+
+	var listRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{"listChanged": true}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			listRequests++
+			tools, ok := toolsets[r.URL.Path]
+			if !ok {
+				http.Error(w, "toolset not found", http.StatusNotFound)
+				return
+			}
+			result = map[string]any{"tools": tools}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+	require.NoError(t, err)
+
+	t.Run("Lists the default toolset", func(t *testing.T) {
+		names, err := client.ListToolNames(context.Background(), "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alpha", "mid", "zeta"}, names)
+	})
+
+	t.Run("Lists a named toolset", func(t *testing.T) {
+		names, err := client.ListToolNames(context.Background(), "billing")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"charge", "refund"}, names)
+	})
+
+	t.Run("Does not validate bindings", func(t *testing.T) {
+		// Loading the toolset strictly fails because no tool uses the bound
+		// parameter, but discovering its tools does not.
+		_, err := client.LoadToolset("", context.Background(), WithBindParamString("region", "emea"), WithStrict(true))
+		require.Error(t, err)
+
+		names, err := client.ListToolNames(context.Background(), "")
+		require.NoError(t, err)
+		assert.Contains(t, names, "alpha")
+	})
+
+	t.Run("Reports an unknown toolset", func(t *testing.T) {
+		_, err := client.ListToolNames(context.Background(), "missing")
+		assert.ErrorContains(t, err, "failed to load toolset manifest for 'missing'")
+	})
+
+	t.Run("Uses the manifest cache", func(t *testing.T) {
+		cached, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618), WithManifestCache(time.Minute))
+		require.NoError(t, err)
+
+		before := listRequests
+		for range 2 {
+			_, err := cached.ListToolNames(context.Background(), "billing")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, before+1, listRequests)
+	})
+}
+
+func TestResources(t *testing.T) {
+	// newResourceServer serves two pages of resources and their contents,
+	// answering the handshake with the protocol version the client asked for.
+	newResourceServer := func(t *testing.T, supportsResources bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			params, _ := req.Params.(map[string]any)
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				capabilities := map[string]any{"tools": map[string]any{}}
+				if supportsResources {
+					capabilities["resources"] = map[string]any{}
+				}
+				w.Header().Set("Mcp-Session-Id", "session")
+				result = map[string]any{
+					"protocolVersion": params["protocolVersion"],
+					"capabilities":    capabilities,
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "resources/list":
+				if params["cursor"] == "page-2" {
+					result = map[string]any{"resources": []map[string]any{
+						{"uri": "file:///logo.png", "name": "logo.png", "mimeType": "image/png"},
+					}}
+				} else {
+					result = map[string]any{
+						"resources": []map[string]any{
+							{"uri": "file:///README.md", "name": "README.md", "mimeType": "text/markdown", "description": "Project readme."},
+						},
+						"nextCursor": "page-2",
+					}
+				}
+			case "resources/read":
+				switch params["uri"] {
+				case "file:///README.md":
+					result = map[string]any{"contents": []map[string]any{
+						{"uri": "file:///README.md", "mimeType": "text/markdown", "text": "# Hello"},
+					}}
+				case "file:///logo.png":
+					result = map[string]any{"contents": []map[string]any{
+						{"uri": "file:///logo.png", "mimeType": "image/png", "blob": "iVBORw=="},
+					}}
+				default:
+					resBytes, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": req.ID, "error": map[string]any{"code": -32002, "message": "Resource not found"}})
+					w.Header().Set("Content-Type", "application/json")
+					_, _ = w.Write(resBytes)
+					return
+				}
+			default:
+				http.Error(w, "method not found", http.StatusNotFound)
+				return
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+	}
+
+	for _, protocol := range []Protocol{MCPv20241105, MCPv20250326, MCPv20250618, MCPv20251125} {
+		t.Run("Lists and reads resources over "+string(protocol), func(t *testing.T) {
+			server := newResourceServer(t, true)
+			defer server.Close()
+
+			client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(protocol))
+			require.NoError(t, err)
+
+			resources, err := client.ListResources(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, []Resource{
+				{URI: "file:///README.md", Name: "README.md", MimeType: "text/markdown", Description: "Project readme."},
+				{URI: "file:///logo.png", Name: "logo.png", MimeType: "image/png"},
+			}, resources)
+
+			contents, err := client.ReadResource(context.Background(), "file:///README.md")
+			require.NoError(t, err)
+			assert.Equal(t, []ResourceContents{{URI: "file:///README.md", MimeType: "text/markdown", Text: "# Hello"}}, contents)
+
+			contents, err = client.ReadResource(context.Background(), "file:///logo.png")
+			require.NoError(t, err)
+			require.Len(t, contents, 1)
+			assert.Equal(t, []byte{0x89, 'P', 'N', 'G'}, contents[0].Blob)
+
+			_, err = client.ReadResource(context.Background(), "file:///missing")
+			assert.ErrorContains(t, err, "Resource not found")
+		})
+	}
+
+	t.Run("Errors when the server does not advertise resources", func(t *testing.T) {
+		server := newResourceServer(t, false)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		_, err = client.ListResources(context.Background())
+		assert.ErrorIs(t, err, transport.ErrResourcesNotSupported)
+		assert.Contains(t, err.Error(), "server does not support resources")
+
+		_, err = client.ReadResource(context.Background(), "file:///README.md")
+		assert.ErrorIs(t, err, transport.ErrResourcesNotSupported)
+	})
+
+	t.Run("Errors when the transport cannot read resources", func(t *testing.T) {
+		client, err := NewToolboxClientFromManifest([]byte(`{"tools": {"t": {"description": "d", "parameters": []}}}`))
+		require.NoError(t, err)
+
+		_, err = client.ListResources(context.Background())
+		assert.ErrorIs(t, err, transport.ErrResourcesNotSupported)
+	})
+}
+
+func TestPing(t *testing.T) {
+	// newPingServer answers the handshake with the given protocol version, or
+	// the one the client asked for when it is empty, and counts pings.
+	newPingServer := func(t *testing.T, protocolVersion string, capabilities map[string]any, pings *int) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			params, _ := req.Params.(map[string]any)
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				version := protocolVersion
+				if version == "" {
+					version, _ = params["protocolVersion"].(string)
+				}
+				w.Header().Set("Mcp-Session-Id", "session")
+				result = map[string]any{
+					"protocolVersion": version,
+					"capabilities":    capabilities,
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.2.3"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "ping":
+				*pings++
+				result = map[string]any{}
+			default:
+				http.Error(w, "method not found", http.StatusNotFound)
+				return
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+	}
+	toolsCapability := map[string]any{"tools": map[string]any{"listChanged": true}}
+
+	for _, protocol := range []Protocol{MCPv20241105, MCPv20250326, MCPv20250618, MCPv20251125} {
+		t.Run("Reports the server over "+string(protocol), func(t *testing.T) {
+			pings := 0
+			server := newPingServer(t, "", toolsCapability, &pings)
+			defer server.Close()
+
+			client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(protocol))
+			require.NoError(t, err)
+
+			require.NoError(t, client.Ping(context.Background()))
+			info, err := client.ServerInfo(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, &ServerInfo{Protocol: protocol, Version: "1.2.3"}, info)
+			assert.Equal(t, 2, pings, "every check must reach the server")
+		})
+	}
+
+	t.Run("Reports a protocol version mismatch", func(t *testing.T) {
+		pings := 0
+		server := newPingServer(t, "1999-01-01", toolsCapability, &pings)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		err = client.Ping(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to ping server")
+		assert.Contains(t, err.Error(), "MCP version mismatch: client (2025-06-18) != server (1999-01-01)")
+		assert.Zero(t, pings)
+	})
+
+	t.Run("Reports a missing tools capability", func(t *testing.T) {
+		pings := 0
+		server := newPingServer(t, "", map[string]any{}, &pings)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+
+		err = client.Ping(context.Background())
+		assert.ErrorContains(t, err, "server does not support the 'tools' capability")
+	})
+
+	t.Run("Reports an unreachable server", func(t *testing.T) {
+		pings := 0
+		server := newPingServer(t, "", toolsCapability, &pings)
+		url := server.URL
+		server.Close()
+
+		client, err := NewToolboxClient(url)
+		require.NoError(t, err)
+
+		err = client.Ping(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to ping server")
+		assert.Contains(t, err.Error(), "connection refused")
+	})
+
+	t.Run("Enforces the minimum server version", func(t *testing.T) {
+		pings := 0
+		server := newPingServer(t, "", toolsCapability, &pings)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithMinServerVersion("2.0.0"))
+		require.NoError(t, err)
+
+		err = client.Ping(context.Background())
+		assert.ErrorContains(t, err, "server version 1.2.3 is below required 2.0.0")
+	})
+
+	t.Run("Falls back to listing tools", func(t *testing.T) {
+		client, err := NewToolboxClientFromManifest([]byte(`{"serverVersion": "0.9.0", "tools": {"t": {"description": "d", "parameters": []}}}`))
+		require.NoError(t, err)
+
+		info, err := client.ServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "0.9.0", info.Version)
+	})
+}
+
+func TestSessionReuse(t *testing.T) {
+	// newSessionServer counts the requests for each method and records any
+	// request that is sent without the session established by the handshake.
+	newSessionServer := func(t *testing.T) (*httptest.Server, func(method string) int, *[]string) {
+		var mu sync.Mutex
+		counts := make(map[string]int)
+		var missingSession []string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			params, _ := req.Params.(map[string]any)
+
+			mu.Lock()
+			counts[req.Method]++
+			if req.Method != "initialize" && r.Header.Get("Mcp-Session-Id") != "session-1" {
+				missingSession = append(missingSession, req.Method)
+			}
+			mu.Unlock()
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				// Slow the handshake down so that concurrent callers overlap.
+				time.Sleep(20 * time.Millisecond)
+				w.Header().Set("Mcp-Session-Id", "session-1")
+				result = map[string]any{
+					"protocolVersion": params["protocolVersion"],
+					"capabilities":    map[string]any{"tools": map[string]any{"listChanged": true}},
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "tools/list":
+				result = map[string]any{"tools": []map[string]any{
+					{"name": "echo", "description": "Echoes a message.", "inputSchema": map[string]any{"type": "object"}},
+				}}
+			case "tools/call":
+				result = map[string]any{"content": []map[string]any{{"type": "text", "text": "ok"}}}
+			default:
+				http.Error(w, "method not found", http.StatusNotFound)
+				return
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+
+		count := func(method string) int {
+			mu.Lock()
+			defer mu.Unlock()
+			return counts[method]
+		}
+		return server, count, &missingSession
+	}
+
+	for _, protocol := range []Protocol{MCPv20241105, MCPv20250326, MCPv20250618, MCPv20251125} {
+		t.Run("Parallel calls share one handshake over "+string(protocol), func(t *testing.T) {
+			server, count, missingSession := newSessionServer(t)
+			defer server.Close()
+
+			client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(protocol))
+			require.NoError(t, err)
+
+			const callers = 25
+			var wg sync.WaitGroup
+			errs := make([]error, callers)
+			for i := range callers {
+				wg.Go(func() {
+					tool, err := client.LoadTool("echo", context.Background())
+					if err == nil {
+						_, err = tool.Invoke(context.Background(), map[string]any{})
+					}
+					errs[i] = err
+				})
+			}
+			wg.Wait()
+
+			for _, err := range errs {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, 1, count("initialize"), "the handshake must run exactly once")
+			assert.Equal(t, 1, count("notifications/initialized"))
+			assert.Equal(t, callers, count("tools/list"))
+			assert.Equal(t, callers, count("tools/call"))
+
+			if protocol == MCPv20250326 || protocol == MCPv20251125 {
+				assert.Empty(t, *missingSession, "every request after the handshake must carry the session")
+			}
+		})
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	// newPromptServer serves two pages of prompts and renders the 'review'
+	// prompt, answering the handshake with the protocol version the client
+	// asked for.
+	newPromptServer := func(t *testing.T, supportsPrompts bool) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			params, _ := req.Params.(map[string]any)
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				capabilities := map[string]any{"tools": map[string]any{}}
+				if supportsPrompts {
+					capabilities["prompts"] = map[string]any{}
+				}
+				w.Header().Set("Mcp-Session-Id", "session")
+				result = map[string]any{
+					"protocolVersion": params["protocolVersion"],
+					"capabilities":    capabilities,
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "prompts/list":
+				if params["cursor"] == "page-2" {
+					result = map[string]any{"prompts": []map[string]any{{"name": "greet"}}}
+				} else {
+					result = map[string]any{
+						"prompts": []map[string]any{{
+							"name":        "review",
+							"description": "Review code.",
+							"arguments":   []map[string]any{{"name": "code", "description": "The code to review.", "required": true}},
+						}},
+						"nextCursor": "page-2",
+					}
+				}
+			case "prompts/get":
+				args, _ := params["arguments"].(map[string]any)
+				result = map[string]any{
+					"description": "Code review",
+					"messages": []map[string]any{
+						{"role": "user", "content": map[string]any{"type": "text", "text": fmt.Sprintf("Review %v: %v", params["name"], args["code"])}},
+						{"role": "user", "content": map[string]any{"type": "image", "data": "iVBORw==", "mimeType": "image/png"}},
+					},
+				}
+			default:
+				http.Error(w, "method not found", http.StatusNotFound)
+				return
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+	}
+
+	for _, protocol := range []Protocol{MCPv20241105, MCPv20250326, MCPv20250618, MCPv20251125} {
+		t.Run("Lists and gets prompts over "+string(protocol), func(t *testing.T) {
+			server := newPromptServer(t, true)
+			defer server.Close()
+
+			client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(protocol))
+			require.NoError(t, err)
+
+			prompts, err := client.ListPrompts(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, []Prompt{
+				{Name: "review", Description: "Review code.", Arguments: []transport.PromptArgument{{Name: "code", Description: "The code to review.", Required: true}}},
+				{Name: "greet"},
+			}, prompts)
+
+			rendered, err := client.GetPrompt(context.Background(), "review", map[string]string{"code": "x := 1"})
+			require.NoError(t, err)
+			assert.Equal(t, &PromptResult{
+				Description: "Code review",
+				Messages: []transport.PromptMessage{
+					{Role: "user", Content: transport.PromptContent{Type: "text", Text: "Review review: x := 1"}},
+					{Role: "user", Content: transport.PromptContent{Type: "image", Data: []byte{0x89, 'P', 'N', 'G'}, MimeType: "image/png"}},
+				},
+			}, rendered)
+		})
+	}
+
+	t.Run("Errors when the server does not advertise prompts", func(t *testing.T) {
+		server := newPromptServer(t, false)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		_, err = client.ListPrompts(context.Background())
+		assert.ErrorIs(t, err, transport.ErrPromptsNotSupported)
+		assert.Contains(t, err.Error(), "server does not support prompts")
+
+		_, err = client.GetPrompt(context.Background(), "review", nil)
+		assert.ErrorIs(t, err, transport.ErrPromptsNotSupported)
+	})
+
+	t.Run("Errors when the transport cannot get prompts", func(t *testing.T) {
+		client, err := NewToolboxClientFromManifest([]byte(`{"tools": {"t": {"description": "d", "parameters": []}}}`))
+		require.NoError(t, err)
+
+		_, err = client.ListPrompts(context.Background())
+		assert.ErrorIs(t, err, transport.ErrPromptsNotSupported)
+	})
+}
+
+func TestWithMCPMethodOverrides(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		methods = append(methods, req.Method)
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tool/list":
+			result = map[string]any{"tools": []mcpTool{
+				{Name: "echo", Description: "e", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+			}}
+		case "tool/call":
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "pong"}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	t.Run("Overridden method names are sent", func(t *testing.T) {
+		methods = nil
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithMCPMethodOverrides(map[string]string{"tools/list": "tool/list", "tools/call": "tool/call"}),
+		)
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+		result, err := tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "pong", result)
+		assert.Equal(t, []string{"initialize", "notifications/initialized", "tool/list", "tool/call"}, methods)
+	})
+
+	t.Run("DryRun reports the overridden method", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithMCPMethodOverrides(map[string]string{"tools/list": "tool/list", "tools/call": "tool/call"}),
+		)
+		require.NoError(t, err)
+		tool, err := client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+
+		methods = nil
+		prepared, err := tool.DryRun(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "tool/call", prepared.Method)
+		assert.Equal(t, server.URL+"/mcp/", prepared.URL)
+		assert.Empty(t, methods, "DryRun must not contact the server")
+	})
+
+	t.Run("Spec names are used without overrides", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+
+		_, err = client.LoadTool("echo", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "404")
+	})
+
+	t.Run("Invalid overrides are rejected", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL, WithMCPMethodOverrides(nil))
+		assert.Error(t, err)
+		_, err = NewToolboxClient(server.URL, WithMCPMethodOverrides(map[string]string{"tools/list": ""}))
+		assert.Error(t, err)
+	})
+}
+
+// inMemoryTransport is a transport.Transport serving a canned manifest and
+// answering every invocation with a canned result, recording the calls.
+type inMemoryTransport struct {
+	manifest transport.ManifestSchema
+	output   any
+	invoked  []string
+	payloads []map[string]any
+}
+
+func (m *inMemoryTransport) BaseURL() string { return "memory://toolbox" }
+
+func (m *inMemoryTransport) GetTool(ctx context.Context, toolName string, headers map[string]string) (*transport.ManifestSchema, error) {
+	tool, ok := m.manifest.Tools[toolName]
+	if !ok {
+		return nil, fmt.Errorf("tool '%s' not found", toolName)
+	}
+	return &transport.ManifestSchema{ServerVersion: m.manifest.ServerVersion, Tools: map[string]transport.ToolSchema{toolName: tool}}, nil
+}
+
+func (m *inMemoryTransport) ListTools(ctx context.Context, toolsetName string, headers map[string]string) (*transport.ManifestSchema, error) {
+	return &m.manifest, nil
+}
+
+func (m *inMemoryTransport) InvokeTool(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (any, error) {
+	m.invoked = append(m.invoked, toolName)
+	m.payloads = append(m.payloads, payload)
+	return m.output, nil
+}
+
+func TestWithTransport(t *testing.T) {
+	newInMemoryTransport := func() *inMemoryTransport {
+		return &inMemoryTransport{
+			manifest: transport.ManifestSchema{
+				ServerVersion: "1.2.3",
+				Tools: map[string]transport.ToolSchema{
+					"greet": {Description: "Greets", Parameters: []ParameterSchema{{Name: "name", Type: "string", Required: true}}},
+					"ping":  {Description: "Pings"},
+				},
+			},
+			output: "hello",
+		}
+	}
+
+	t.Run("Loads and invokes through the injected transport", func(t *testing.T) {
+		tr := newInMemoryTransport()
+		client, err := NewToolboxClient("", WithTransport(tr))
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("greet", context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "Greets", tool.Description())
+		result, err := tool.Invoke(context.Background(), map[string]any{"name": "Ada"})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", result)
+
+		tools, err := client.LoadToolset("", context.Background())
+		require.NoError(t, err)
+		assert.Len(t, tools, 2)
+		for _, tool := range tools {
+			if tool.Name() == "ping" {
+				_, err := tool.Invoke(context.Background(), map[string]any{})
+				require.NoError(t, err)
+			}
+		}
+
+		assert.Equal(t, []string{"greet", "ping"}, tr.invoked)
+		assert.Equal(t, map[string]any{"name": "Ada"}, tr.payloads[0])
+
+		info, err := client.ServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &ServerInfo{Version: "1.2.3"}, info)
+	})
+
+	t.Run("Conflicting options are rejected", func(t *testing.T) {
+		tr := newInMemoryTransport()
+		_, err := NewToolboxClient("", WithTransport(tr), WithProtocol(MCPLatest))
+		assert.ErrorContains(t, err, "cannot be combined with WithProtocol")
+		_, err = NewToolboxClient("", WithCommand("server"), WithTransport(tr))
+		assert.ErrorContains(t, err, "cannot be combined with WithCommand")
+		_, err = NewToolboxClient("", WithTransport(nil))
+		assert.ErrorContains(t, err, "transport cannot be nil")
+		_, err = NewToolboxClient("", WithTransport(tr), WithTransport(tr))
+		assert.ErrorContains(t, err, "already set")
+	})
+}
+
+func TestServerVersion(t *testing.T) {
+	t.Run("MCP version is captured from the handshake", func(t *testing.T) {
+		// The mock server reports version 1.0.0 in its initialize response.
+		server := newMockMCPServer(t, []mcpTool{
+			{Name: "tool1", Description: "d1", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+		})
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+		_, err = client.LoadTool("tool1", context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", client.serverVersion)
+
+		version, err := client.ServerVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", version)
+	})
+
+	t.Run("Version is fetched when nothing was loaded", func(t *testing.T) {
+		tr := &inMemoryTransport{manifest: transport.ManifestSchema{ServerVersion: "1.2.3"}}
+		client, err := NewToolboxClient("", WithTransport(tr))
+		require.NoError(t, err)
+		version, err := client.ServerVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3", version)
+	})
+
+	t.Run("Manifest version is captured and follows the last fetch", func(t *testing.T) {
+		tr := &inMemoryTransport{manifest: transport.ManifestSchema{
+			ServerVersion: "1.2.3",
+			Tools:         map[string]transport.ToolSchema{"ping": {Description: "Pings"}},
+		}}
+		client, err := NewToolboxClient("", WithTransport(tr))
+		require.NoError(t, err)
+
+		_, err = client.LoadTool("ping", context.Background())
+		require.NoError(t, err)
+		version, err := client.ServerVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3", version)
+
+		tr.manifest.ServerVersion = "1.3.0"
+		_, err = client.LoadToolset("", context.Background())
+		require.NoError(t, err)
+		version, err = client.ServerVersion(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "1.3.0", version)
+	})
+
+	t.Run("Unreachable server is reported", func(t *testing.T) {
+		server := newMockMCPServer(t, nil)
+		server.Close()
+
+		client, err := NewToolboxClient(server.URL)
+		require.NoError(t, err)
+		_, err = client.ServerVersion(context.Background())
+		assert.ErrorContains(t, err, "failed to ping server")
+	})
+}
+
+func TestToolboxTool_ParameterSources(t *testing.T) {
+	mcpTools := []mcpTool{
+		{
+			Name:        "orders",
+			Description: "Lists orders",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"status":  map[string]any{"type": "string"},
+					"region":  map[string]any{"type": "string"},
+					"user_id": map[string]any{"type": "string"},
+				},
+			},
+			Meta: map[string]any{
+				"toolbox/authParam": map[string]any{"user_id": []string{"google"}},
+			},
+		},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+
+	want := map[string]ParamSource{"status": UserSupplied, "region": Bound, "user_id": AuthBacked}
+
+	t.Run("Reports every kind of parameter", func(t *testing.T) {
+		tool, err := client.LoadTool("orders", context.Background(),
+			WithBindParamString("region", "emea"),
+			WithAuthTokenString("google", "token"),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, want, tool.ParameterSources())
+		require.Len(t, tool.Parameters(), 1)
+		assert.Equal(t, "status", tool.Parameters()[0].Name)
+	})
+
+	t.Run("Reports auth parameters whose service is missing", func(t *testing.T) {
+		tool, err := client.LoadTool("orders", context.Background(), WithBindParamString("region", "emea"))
+		require.NoError(t, err)
+		assert.Equal(t, want, tool.ParameterSources())
+	})
+
+	t.Run("Follows bindings changed with ToolFrom", func(t *testing.T) {
+		tool, err := client.LoadTool("orders", context.Background(), WithBindParamString("region", "emea"))
+		require.NoError(t, err)
+		derived, err := tool.ToolFrom(WithUnbindParam("region"), WithBindParamString("status", "open"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]ParamSource{"status": Bound, "region": UserSupplied, "user_id": AuthBacked}, derived.ParameterSources())
+		assert.Equal(t, want, tool.ParameterSources(), "the parent tool must not change")
+	})
+
+	t.Run("Sources have readable names", func(t *testing.T) {
+		assert.Equal(t, "user", UserSupplied.String())
+		assert.Equal(t, "bound", Bound.String())
+		assert.Equal(t, "auth", AuthBacked.String())
+	})
+}
+
+func TestWithRequestIDGenerator(t *testing.T) {
+	type sentMessage struct {
+		method string
+		id     any
+	}
+	var sent []sentMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		sent = append(sent, sentMessage{req.Method, req.ID})
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{
+				{Name: "echo", Description: "e", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+			}}
+		case "tools/call":
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "pong"}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	t.Run("Generated IDs are sent in order", func(t *testing.T) {
+		sent = nil
+		next := 0
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithRequestIDGenerator(func() any {
+				next++
+				return fmt.Sprintf("req-%d", next)
+			}),
+		)
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+
+		assert.Equal(t, []sentMessage{
+			{"initialize", "req-1"},
+			{"notifications/initialized", nil},
+			{"tools/list", "req-2"},
+			{"tools/call", "req-3"},
+		}, sent)
+	})
+
+	t.Run("Numeric IDs are supported", func(t *testing.T) {
+		sent = nil
+		next := 0
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithRequestIDGenerator(func() any {
+				next++
+				return next
+			}),
+		)
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+		result, err := tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "pong", result)
+		require.Len(t, sent, 4)
+		assert.Equal(t, float64(3), sent[3].id)
+	})
+
+	t.Run("Invalid generators are rejected", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL, WithRequestIDGenerator(nil))
+		assert.ErrorContains(t, err, "cannot be nil")
+
+		gen := func() any { return 1 }
+		_, err = NewToolboxClient(server.URL, WithRequestIDGenerator(gen), WithRequestIDGenerator(gen))
+		assert.ErrorContains(t, err, "already set")
+	})
+}
+
+func TestLoadTool_ParameterTransform(t *testing.T) {
+	mcpTools := []mcpTool{
+		{Name: "invite", Description: "i", InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"email": map[string]any{"type": "string"},
+		}}},
+		{Name: "ping", Description: "p", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	trim := func(v any) (any, error) { return strings.TrimSpace(v.(string)), nil }
+
+	t.Run("Applies to a known parameter", func(t *testing.T) {
+		tool, err := client.LoadTool("invite", context.Background(), WithParameterTransform("email", trim))
+		require.NoError(t, err)
+		payload, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"email": " a@b.c "})
+		require.NoError(t, err)
+		assert.Equal(t, "a@b.c", payload["email"])
+	})
+
+	t.Run("Fails for an unknown parameter", func(t *testing.T) {
+		_, err := client.LoadTool("invite", context.Background(), WithParameterTransform("phone", trim))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no parameter named 'phone' found on tool 'invite'")
+	})
+
+	t.Run("Toolset applies transforms only where the parameter exists", func(t *testing.T) {
+		tools, err := client.LoadToolset("", context.Background(), WithParameterTransform("email", trim))
+		require.NoError(t, err)
+		assert.Len(t, tools, 2)
+	})
+}
+
+func TestLoadTool_ParamAliases(t *testing.T) {
+	mcpTools := []mcpTool{
+		{Name: "query", Description: "q", InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+			"num_rows": map[string]any{"type": "integer"},
+		}}},
+		{Name: "ping", Description: "p", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	aliases := WithParamAliases(map[string][]string{"num_rows": {"numRows"}})
+
+	t.Run("Applies to a known parameter", func(t *testing.T) {
+		tool, err := client.LoadTool("query", context.Background(), aliases, WithCaseInsensitiveParams(true))
+		require.NoError(t, err)
+		payload, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"NumRows": 10})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"num_rows": 10}, payload)
+	})
+
+	t.Run("Fails for an unknown parameter", func(t *testing.T) {
+		_, err := client.LoadTool("ping", context.Background(), aliases)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no parameter named 'num_rows' found on tool 'ping'")
+	})
+
+	t.Run("Toolset applies aliases only where the parameter exists", func(t *testing.T) {
+		tools, err := client.LoadToolset("", context.Background(), aliases)
+		require.NoError(t, err)
+		assert.Len(t, tools, 2)
+	})
+
+	t.Run("Rejects invalid options", func(t *testing.T) {
+		_, err := client.LoadTool("query", context.Background(), WithParamAliases(map[string][]string{"num_rows": {"n"}, "other": {"n"}}))
+		assert.ErrorContains(t, err, "alias 'n' is already set for parameter 'num_rows'")
+
+		_, err = client.LoadTool("query", context.Background(), WithCaseInsensitiveParams(true), WithCaseInsensitiveParams(false))
+		assert.ErrorContains(t, err, "case-insensitive parameter matching is already set")
+	})
+}
+
+func TestWithMaxManifestSize(t *testing.T) {
+	mcpTools := []mcpTool{
+		{
+			Name:        "bulky",
+			Description: strings.Repeat("A very long description. ", 200),
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	t.Run("Oversized manifest is rejected", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithMaxManifestSize(1024))
+		require.NoError(t, err)
+
+		_, err = client.LoadToolset("", context.Background())
+		require.Error(t, err)
+		assert.ErrorIs(t, err, transport.ErrManifestTooLarge)
+		assert.Contains(t, err.Error(), "manifest exceeds max size")
+
+		_, err = client.LoadTool("bulky", context.Background())
+		assert.ErrorIs(t, err, transport.ErrManifestTooLarge)
+	})
+
+	t.Run("Manifest within the limit loads", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithMaxManifestSize(64*1024))
+		require.NoError(t, err)
+
+		tools, err := client.LoadToolset("", context.Background())
+		require.NoError(t, err)
+		assert.Len(t, tools, 1)
+	})
+
+	t.Run("Invalid sizes are rejected", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL, WithMaxManifestSize(0))
+		assert.Error(t, err)
+		_, err = NewToolboxClient(server.URL, WithMaxManifestSize(1024), WithMaxManifestSize(2048))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadAuthorizedTool(t *testing.T) {
+	mcpTools := []mcpTool{
+		{
+			Name:        "repo-stats",
+			Description: "Repository statistics",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{
+				"repo": map[string]any{"type": "string"},
+			}},
+			Meta: map[string]any{"toolbox/authInvoke": []string{"github"}},
+		},
+		{Name: "ping", Description: "p", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+
+	t.Run("Attaches the token for the service", func(t *testing.T) {
+		tool, err := client.LoadAuthorizedTool(context.Background(), "repo-stats", "github", "gh-token",
+			WithBindParamString("repo", "toolbox"))
+		require.NoError(t, err)
+		assert.Equal(t, "repo-stats", tool.Name())
+
+		source, ok := tool.authTokenSources["github"]
+		require.True(t, ok, "expected a token source for 'github'")
+		token, err := source.Token()
+		require.NoError(t, err)
+		assert.Equal(t, "gh-token", token.AccessToken)
+		assert.Equal(t, "toolbox", tool.boundParams["repo"])
+	})
+
+	t.Run("Fails when the tool does not use the service", func(t *testing.T) {
+		_, err := client.LoadAuthorizedTool(context.Background(), "ping", "github", "gh-token")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unused auth tokens: github")
+	})
+
+	t.Run("Fails when the tool does not use the service without strict loading", func(t *testing.T) {
+		tool, err := client.LoadAuthorizedTool(context.Background(), "ping", "github", "gh-token", WithStrict(false))
+		require.Error(t, err)
+		assert.Nil(t, tool)
+		assert.Contains(t, err.Error(), "tool 'ping' does not use auth service 'github'")
+	})
+
+	t.Run("Fails on an empty service name", func(t *testing.T) {
+		_, err := client.LoadAuthorizedTool(context.Background(), "repo-stats", "", "gh-token")
+		require.Error(t, err)
+	})
+}
+
+func TestLoadTool_InvokeTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{
+				{Name: "slow", Description: "s", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+			}}
+		case "tools/call":
+			// Hang until the client gives up or the test ends.
+			select {
+			case <-r.Context().Done():
+			case <-release:
+			}
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// The client has no HTTP timeout, so only the invoke timeout can end the call.
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(&http.Client{}), WithProtocol(MCPv20250618))
+	require.NoError(t, err)
+
+	t.Run("Per-invoke timeout fires", func(t *testing.T) {
+		tool, err := client.LoadTool("slow", context.Background(), WithInvokeTimeout(50*time.Millisecond))
+		require.NoError(t, err)
+
+		start := time.Now()
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Earlier parent deadline wins", func(t *testing.T) {
+		tool, err := client.LoadTool("slow", context.Background(), WithInvokeTimeout(time.Hour))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = tool.Invoke(ctx, map[string]any{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("Invalid and duplicate timeouts are rejected", func(t *testing.T) {
+		_, err := client.LoadTool("slow", context.Background(), WithInvokeTimeout(0))
+		assert.Error(t, err)
+
+		tool, err := client.LoadTool("slow", context.Background(), WithInvokeTimeout(time.Second))
+		require.NoError(t, err)
+		_, err = tool.ToolFrom(WithInvokeTimeout(2 * time.Second))
+		assert.ErrorContains(t, err, "cannot override existing invoke timeout")
+	})
+}
+
+func TestInvokeRaw(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{
+				{Name: "search", Description: "s", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+				{Name: "broken", Description: "b", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+			}}
+		case "tools/call":
+			w.Header().Set("X-Request-Id", "req-42")
+			w.Header().Set("X-RateLimit-Remaining", "7")
+			if params, _ := req.Params.(map[string]any); params["name"] == "broken" {
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "found"}}}
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+	require.NoError(t, err)
+
+	t.Run("Returns the response metadata", func(t *testing.T) {
+		tool, err := client.LoadTool("search", context.Background())
+		require.NoError(t, err)
+
+		result, err := tool.InvokeRaw(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "found", result.Value)
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		assert.Equal(t, "req-42", result.Header.Get("X-Request-Id"))
+		assert.Equal(t, "7", result.Header.Get("X-RateLimit-Remaining"))
+		assert.Contains(t, string(result.RawBody), `"text":"found"`)
+
+		value, err := tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, result.Value, value, "Invoke must return the same value")
+	})
+
+	t.Run("Failed invocations carry the response headers", func(t *testing.T) {
+		tool, err := client.LoadTool("broken", context.Background())
+		require.NoError(t, err)
+
+		result, err := tool.InvokeRaw(context.Background(), map[string]any{})
+		assert.Nil(t, result)
+		var statusErr *transport.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+		assert.Equal(t, "req-42", statusErr.Header.Get("X-Request-Id"))
+	})
+
+	t.Run("Transports without metadata leave it empty", func(t *testing.T) {
+		tool := &ToolboxTool{name: "plain", transport: &dummyTransport{}, parameters: []ParameterSchema{}}
+		result, err := tool.InvokeRaw(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Zero(t, result.StatusCode)
+		assert.Nil(t, result.Header)
+		assert.Nil(t, result.RawBody)
+	})
+}
+
+func TestWithBindParamsFromStruct(t *testing.T) {
+	type guest struct {
+		Name string `json:"name"`
+		VIP  bool   `json:"vip"`
+	}
+	type booking struct {
+		City   string `json:"city"`
+		Nights int    `json:"nights"`
+		Guest  guest  `json:"guest"`
+		Note   string `json:"note" toolbox:"keepzero"`
+		Secret string `json:"-"`
+	}
+
+	var received map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{{
+				Name:        "book",
+				Description: "Book a hotel.",
+				InputSchema: map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"city":   map[string]any{"type": "string"},
+						"nights": map[string]any{"type": "integer"},
+						"guest":  map[string]any{"type": "object"},
+						"note":   map[string]any{"type": "string"},
+					},
+					"required": []string{"city", "guest"},
+				},
+			}}}
+		case "tools/call":
+			params, _ := req.Params.(map[string]any)
+			received, _ = params["arguments"].(map[string]any)
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "booked"}}}
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+	require.NoError(t, err)
+
+	t.Run("Binds fields and nested structs", func(t *testing.T) {
+		cfg := &booking{City: "Oslo", Guest: guest{Name: "Ada", VIP: true}, Secret: "hidden"}
+		tool, err := client.LoadTool("book", context.Background(), WithBindParamsFromStruct(cfg))
+		require.NoError(t, err)
+
+		params := tool.Parameters()
+		require.Len(t, params, 1, "the zero optional field must stay unbound")
+		assert.Equal(t, "nights", params[0].Name)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{"nights": 3})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{
+			"city":   "Oslo",
+			"nights": float64(3),
+			"guest":  map[string]any{"name": "Ada", "vip": true},
+			"note":   "",
+		}, received)
+	})
+
+	t.Run("Rejects fields without a matching parameter", func(t *testing.T) {
+		type extra struct {
+			City  string `json:"city"`
+			Guest guest  `json:"guest"`
+			Floor int    `json:"floor"`
+		}
+		_, err := client.LoadTool("book", context.Background(), WithBindParamsFromStruct(extra{City: "Oslo", Floor: 2}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no parameter named 'floor' found on tool 'book'")
+	})
+
+	t.Run("Respects existing bindings", func(t *testing.T) {
+		_, err := client.LoadTool("book", context.Background(),
+			WithBindParamString("city", "Paris"),
+			WithBindParamsFromStruct(booking{City: "Oslo"}),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parameter 'city' is already set")
+
+		tool, err := client.LoadTool("book", context.Background(), WithBindParamString("city", "Paris"))
+		require.NoError(t, err)
+		_, err = tool.ToolFrom(WithBindParamsFromStruct(struct {
+			City string `json:"city"`
+		}{City: "Oslo"}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot override existing bound parameter: 'city'")
+	})
+
+	t.Run("Rejects non-struct values", func(t *testing.T) {
+		assert.Error(t, WithBindParamsFromStruct(map[string]any{"city": "Oslo"})(newToolConfig()))
+		assert.Error(t, WithBindParamsFromStruct((*booking)(nil))(newToolConfig()))
+	})
+}
+
+// recordingHandler is a slog.Handler that keeps every record it handles.
+type recordingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *recordingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+// find returns the first record with the given message, and its attributes
+// rendered as text.
+func (h *recordingHandler) find(msg string) (slog.Record, string, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range h.records {
+		if r.Message == msg {
+			var attrs strings.Builder
+			r.Attrs(func(a slog.Attr) bool {
+				fmt.Fprintf(&attrs, "%s=%v ", a.Key, a.Value)
+				return true
+			})
+			return r, attrs.String(), true
+		}
+	}
+	return slog.Record{}, "", false
+}
+
+// dump renders every record as text, for asserting on secrets.
+func (h *recordingHandler) dump() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var out strings.Builder
+	for _, r := range h.records {
+		fmt.Fprintf(&out, "%s %s ", r.Level, r.Message)
+		r.Attrs(func(a slog.Attr) bool {
+			fmt.Fprintf(&out, "%s=%v ", a.Key, a.Value)
+			return true
+		})
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func TestWithLogger(t *testing.T) {
+	t.Run("Logs manifest fetches, the handshake and invocations", func(t *testing.T) {
+		server, _ := newFlakyServer(t, 1, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		handler := &recordingHandler{}
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithLogger(slog.New(handler)),
+			WithClientHeaderString("X-Api-Key", "client-secret"),
+		)
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("flaky", context.Background(),
+			WithStrict(false),
+			WithAuthTokenString("google", "auth-secret"),
+			WithRetry(2, ConstantBackoff(time.Millisecond)),
+		)
+		require.NoError(t, err)
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+
+		for msg, level := range map[string]slog.Level{
+			"fetching manifest":                slog.LevelDebug,
+			"fetched manifest":                 slog.LevelDebug,
+			"starting MCP handshake":           slog.LevelDebug,
+			"MCP handshake completed":          slog.LevelDebug,
+			"invoking tool":                    slog.LevelDebug,
+			"tool invocation failed, retrying": slog.LevelWarn,
+			"tool invocation succeeded":        slog.LevelDebug,
+		} {
+			record, _, ok := handler.find(msg)
+			if assert.True(t, ok, "missing record %q", msg) {
+				assert.Equal(t, level, record.Level, msg)
+			}
+		}
+
+		_, attrs, _ := handler.find("tool invocation succeeded")
+		assert.Contains(t, attrs, "status=200")
+		_, attrs, _ = handler.find("invoking tool")
+		assert.Contains(t, attrs, "google_token:REDACTED")
+		assert.Contains(t, attrs, "X-Api-Key:REDACTED")
+
+		logs := handler.dump()
+		assert.NotContains(t, logs, "client-secret")
+		assert.NotContains(t, logs, "auth-secret")
+	})
+
+	t.Run("Logs token resolution and terminal failures as errors", func(t *testing.T) {
+		server, _ := newFlakyServer(t, 5, http.StatusBadRequest)
+		defer server.Close()
+
+		handler := &recordingHandler{}
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithLogger(slog.New(handler)),
+		)
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("flaky", context.Background())
+		require.NoError(t, err)
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.Error(t, err)
+		record, _, ok := handler.find("tool invocation failed")
+		require.True(t, ok)
+		assert.Equal(t, slog.LevelError, record.Level)
+
+		failing, err := tool.ToolFrom(WithAuthTokenSource("google", &failingTokenSource{}))
+		require.NoError(t, err)
+		_, err = failing.Invoke(context.Background(), map[string]any{})
+		require.Error(t, err)
+		record, attrs, ok := handler.find("failed to resolve auth token")
+		require.True(t, ok)
+		assert.Equal(t, slog.LevelError, record.Level)
+		assert.Contains(t, attrs, "service=google")
+	})
+
+	t.Run("Rejects nil and duplicate loggers", func(t *testing.T) {
+		_, err := NewToolboxClient("http://example.com", WithLogger(nil))
+		assert.Error(t, err)
+		_, err = NewToolboxClient("http://example.com", WithLogger(slog.Default()), WithLogger(slog.Default()))
+		assert.ErrorContains(t, err, "logger is already set")
+	})
+}
+
+func TestWithToolsetHeaders(t *testing.T) {
+	var mu sync.Mutex
+	// Records the X-Toolset-Key header seen for each method and path.
+	seen := make(map[string]string)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			mu.Lock()
+			seen["list "+r.URL.Path] = r.Header.Get("X-Toolset-Key")
+			mu.Unlock()
+			toolName := strings.TrimPrefix(r.URL.Path, "/mcp/") + "-tool"
+			result = map[string]any{"tools": []mcpTool{{
+				Name:        toolName,
+				InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+			}}}
+		case "tools/call":
+			var params struct {
+				Name string `json:"name"`
+			}
+			raw, _ := json.Marshal(req.Params)
+			_ = json.Unmarshal(raw, &params)
+			mu.Lock()
+			seen["call "+params.Name] = r.Header.Get("X-Toolset-Key")
+			mu.Unlock()
+			result = map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}}
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL,
+		WithHTTPClient(server.Client()),
+		WithClientHeaderString("X-Toolset-Key", "global"),
+		WithToolsetHeaders("set-a", map[string]string{"X-Toolset-Key": "key-a"}),
+	)
+	require.NoError(t, err)
+
+	for _, toolset := range []string{"set-a", "set-b"} {
+		tools, err := client.LoadToolset(toolset, context.Background())
+		require.NoError(t, err)
+		require.Len(t, tools, 1)
+		_, err = tools[0].Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "key-a", seen["list /mcp/set-a"], "toolset header should override the global one when loading")
+	assert.Equal(t, "key-a", seen["call set-a-tool"], "toolset header should be sent when invoking the toolset's tools")
+	assert.Equal(t, "global", seen["list /mcp/set-b"])
+	assert.Equal(t, "global", seen["call set-b-tool"])
+
+	t.Run("Failure on setting a toolset twice", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL,
+			WithToolsetHeaders("set-a", map[string]string{"A": "1"}),
+			WithToolsetHeaders("set-a", map[string]string{"B": "2"}),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "headers for toolset 'set-a' are already set")
+	})
+}
+
+func TestWithClientHeaderFunc(t *testing.T) {
+	type requestIDKey struct{}
+
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		mu.Lock()
+		seen[req.Method] = r.Header.Get("X-Request-Id")
+		mu.Unlock()
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{{
+				Name:        "echo",
+				InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+			}}}
+		case "tools/call":
+			result = map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}}
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	requestID := func(ctx context.Context) (string, error) {
+		id, ok := ctx.Value(requestIDKey{}).(string)
+		if !ok {
+			return "", errors.New("no request ID in context")
+		}
+		return id, nil
+	}
+
+	t.Run("Sends the value computed from the request context", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithClientHeaderFunc("X-Request-Id", requestID),
+		)
+		require.NoError(t, err)
+
+		loadCtx := context.WithValue(context.Background(), requestIDKey{}, "load-1")
+		tool, err := client.LoadTool("echo", loadCtx)
+		require.NoError(t, err)
+
+		invokeCtx := context.WithValue(context.Background(), requestIDKey{}, "invoke-2")
+		_, err = tool.Invoke(invokeCtx, map[string]any{})
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, "load-1", seen["tools/list"])
+		assert.Equal(t, "invoke-2", seen["tools/call"])
+	})
+
+	t.Run("Propagates errors from the function", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithClientHeaderFunc("X-Request-Id", requestID),
+		)
+		require.NoError(t, err)
+
+		_, err = client.LoadTool("echo", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to resolve client header 'X-Request-Id'")
+		assert.Contains(t, err.Error(), "no request ID in context")
+
+		loadCtx := context.WithValue(context.Background(), requestIDKey{}, "load-3")
+		tool, err := client.LoadTool("echo", loadCtx)
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no request ID in context")
+	})
+
+	t.Run("Failure on duplicate or nil function", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL,
+			WithClientHeaderString("X-Request-Id", "static"),
+			WithClientHeaderFunc("X-Request-Id", requestID),
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "client header 'X-Request-Id' is already set")
+
+		_, err = NewToolboxClient(server.URL, WithClientHeaderFunc("X-Request-Id", nil))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cannot be nil")
+	})
+}
+
+// ctxTokenSource is a ContextTokenSource recording the value stored under
+// ctxValueKey in the contexts it is given. When block is set, it waits for the
+// context to be done instead.
+type ctxTokenSource struct {
+	mu    sync.Mutex
+	seen  []any
+	block bool
+}
+
+type ctxValueKey struct{}
+
+func (s *ctxTokenSource) Token(ctx context.Context) (*oauth2.Token, error) {
+	s.mu.Lock()
+	s.seen = append(s.seen, ctx.Value(ctxValueKey{}))
+	s.mu.Unlock()
+	if s.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return &oauth2.Token{AccessToken: "token"}, nil
+}
+
+func TestContextTokenSource(t *testing.T) {
+	newTransport := func() *inMemoryTransport {
+		return &inMemoryTransport{
+			manifest: transport.ManifestSchema{Tools: map[string]transport.ToolSchema{
+				"secure": {Parameters: []ParameterSchema{{Name: "user_id", Type: "string", AuthSources: []string{"google"}}}},
+			}},
+			output: "ok",
+		}
+	}
+
+	t.Run("Auth token source receives the invoke context", func(t *testing.T) {
+		src := &ctxTokenSource{}
+		client, err := NewToolboxClient("", WithTransport(newTransport()))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("secure", context.Background(), WithAuthTokenContextSource("google", src))
+		require.NoError(t, err)
+
+		ctx := context.WithValue(context.Background(), ctxValueKey{}, "invoke-1")
+		_, err = tool.Invoke(ctx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"invoke-1"}, src.seen)
+	})
+
+	t.Run("Client header source receives each request context", func(t *testing.T) {
+		src := &ctxTokenSource{}
+		client, err := NewToolboxClient("", WithTransport(newTransport()), WithClientHeaderContextSource("X-Tenant", src))
+		require.NoError(t, err)
+
+		loadCtx := context.WithValue(context.Background(), ctxValueKey{}, "load-1")
+		tool, err := client.LoadTool("secure", loadCtx, WithAuthTokenString("google", "token"))
+		require.NoError(t, err)
+		invokeCtx := context.WithValue(context.Background(), ctxValueKey{}, "invoke-2")
+		_, err = tool.Invoke(invokeCtx, map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []any{"load-1", "invoke-2"}, src.seen)
+	})
+
+	t.Run("Cancellation reaches the token source", func(t *testing.T) {
+		src := &ctxTokenSource{}
+		tr := newTransport()
+		client, err := NewToolboxClient("", WithTransport(tr))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("secure", context.Background(), WithAuthTokenContextSource("google", src))
+		require.NoError(t, err)
+
+		src.block = true
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = tool.Invoke(ctx, map[string]any{})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Contains(t, err.Error(), "failed to resolve auth token google")
+		assert.Len(t, src.seen, 1)
+		assert.Empty(t, tr.invoked)
+	})
+
+	t.Run("Failure on duplicate or nil source", func(t *testing.T) {
+		_, err := NewToolboxClient("",
+			WithClientHeaderString("X-Tenant", "static"),
+			WithClientHeaderContextSource("X-Tenant", &ctxTokenSource{}),
+		)
+		assert.ErrorContains(t, err, "client header 'X-Tenant' is already set")
+		_, err = NewToolboxClient("", WithClientHeaderContextSource("X-Tenant", nil))
+		assert.ErrorContains(t, err, "cannot be nil")
+
+		config := newToolConfig()
+		require.NoError(t, WithAuthTokenString("google", "token")(config))
+		assert.ErrorContains(t, WithAuthTokenContextSource("google", &ctxTokenSource{})(config), "already set")
+		assert.ErrorContains(t, WithAuthTokenContextSource("other", nil)(config), "cannot be nil")
+	})
+}
+
+func TestSetAndRemoveClientHeader(t *testing.T) {
+	var mu sync.Mutex
+	var listed []string
+	var called []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			mu.Lock()
+			listed = append(listed, r.Header.Get("X-Api-Key"))
+			mu.Unlock()
+			result = map[string]any{"tools": []mcpTool{{
+				Name:        "echo",
+				InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+			}}}
+		case "tools/call":
+			mu.Lock()
+			called = append(called, r.Header.Get("X-Api-Key"))
+			mu.Unlock()
+			result = map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}}
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer server.Close()
+
+	staticSource := func(v string) oauth2.TokenSource {
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: v})
+	}
+
+	client, err := NewToolboxClient(server.URL,
+		WithHTTPClient(server.Client()),
+		WithClientHeaderString("X-Api-Key", "key-1"),
+	)
+	require.NoError(t, err)
+
+	oldTool, err := client.LoadTool("echo", context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, client.SetClientHeader("X-Api-Key", staticSource("key-2")))
+	newTool, err := client.LoadTool("echo", context.Background())
+	require.NoError(t, err)
+
+	client.RemoveClientHeader("X-Api-Key")
+	_, err = client.LoadTool("echo", context.Background())
+	require.NoError(t, err)
+
+	// Removing a header that is not set is a no-op.
+	client.RemoveClientHeader("X-Missing")
+
+	_, err = oldTool.Invoke(context.Background(), map[string]any{})
+	require.NoError(t, err)
+	_, err = newTool.Invoke(context.Background(), map[string]any{})
+	require.NoError(t, err)
+
+	mu.Lock()
+	assert.Equal(t, []string{"key-1", "key-2", ""}, listed, "manifest loads should use the current headers")
+	assert.Equal(t, []string{"key-1", "key-2"}, called, "tools should keep the headers they were loaded with")
+	mu.Unlock()
+
+	t.Run("Failure on invalid input", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL)
+		require.NoError(t, err)
+		assert.Error(t, client.SetClientHeader("bad header", staticSource("v")))
+		assert.Error(t, client.SetClientHeader("X-Api-Key", nil))
+	})
+
+	t.Run("Safe to call concurrently with loads", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		for i := range 10 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_ = client.SetClientHeader("X-Api-Key", staticSource(fmt.Sprint(i)))
+			}()
+			go func() {
+				defer wg.Done()
+				_, _ = client.LoadTool("echo", context.Background())
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func TestAutoProtocol(t *testing.T) {
+	// newVersionServer returns a server that answers every initialize
+	// request with the given protocol version, and records the versions
+	// the client requested.
+	newVersionServer := func(serverVersion string) (*httptest.Server, func() []string) {
+		var mu sync.Mutex
+		var requested []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			_ = json.Unmarshal(body, &req)
+
+			var result any
+			switch req.Method {
+			case "initialize":
+				var params struct {
+					ProtocolVersion string `json:"protocolVersion"`
+				}
+				raw, _ := json.Marshal(req.Params)
+				_ = json.Unmarshal(raw, &params)
+				mu.Lock()
+				requested = append(requested, params.ProtocolVersion)
+				mu.Unlock()
+				w.Header().Set("Mcp-Session-Id", "session-1")
+				result = map[string]any{
+					"protocolVersion": serverVersion,
+					"capabilities":    map[string]any{"tools": map[string]any{}, "prompts": map[string]any{}},
+					"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+				}
+			case "notifications/initialized":
+				w.WriteHeader(http.StatusOK)
+				return
+			case "tools/list":
+				result = map[string]any{"tools": []mcpTool{{
+					Name:        "echo",
+					InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+				}}}
+			case "tools/call":
+				result = map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}}
+			case "prompts/list":
+				result = map[string]any{"prompts": []map[string]any{{"name": "greeting"}}}
+			}
+
+			resBytes, _ := json.Marshal(result)
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+		}))
+		return server, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(requested)
+		}
+	}
+
+	t.Run("Downgrades to the version offered by the server", func(t *testing.T) {
+		server, requested := newVersionServer("2025-03-26")
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(AutoProtocol))
+		require.NoError(t, err)
+		assert.Equal(t, Protocol(""), client.NegotiatedProtocol(), "no protocol should be negotiated before first use")
+
+		tool, err := client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+		result, err := tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", result)
+
+		_, err = client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, MCPv20250326, client.NegotiatedProtocol())
+		assert.Equal(t, []string{string(MCPLatest), "2025-03-26"}, requested(), "the client should retry the handshake once")
+
+		info, err := client.ServerInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, MCPv20250326, info.Protocol)
+	})
+
+	t.Run("NewToolboxClientContext negotiates before the handshake", func(t *testing.T) {
+		server, requested := newVersionServer("2025-03-26")
+		defer server.Close()
+
+		client, err := NewToolboxClientContext(context.Background(), server.URL, WithHTTPClient(server.Client()), WithProtocol(AutoProtocol))
+		require.NoError(t, err)
+		assert.Equal(t, MCPv20250326, client.NegotiatedProtocol())
+		assert.Equal(t, []string{string(MCPLatest), "2025-03-26"}, requested())
+	})
+
+	t.Run("First list call uses the negotiated transport", func(t *testing.T) {
+		server, _ := newVersionServer("2025-03-26")
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(AutoProtocol))
+		require.NoError(t, err)
+		prompts, err := client.ListPrompts(context.Background())
+		require.NoError(t, err)
+		require.Len(t, prompts, 1)
+		assert.Equal(t, "greeting", prompts[0].Name)
+	})
+
+	t.Run("Concurrent calls during negotiation", func(t *testing.T) {
+		server, requested := newVersionServer("2025-03-26")
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(AutoProtocol))
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if i%2 == 1 {
+					_, errs[i] = client.ListPrompts(context.Background())
+					return
+				}
+				tool, err := client.LoadTool("echo", context.Background())
+				if err == nil {
+					_, err = tool.Invoke(context.Background(), map[string]any{})
+				}
+				errs[i] = err
+			}()
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, []string{string(MCPLatest), "2025-03-26"}, requested())
+	})
+
+	t.Run("Keeps the newest version when the server supports it", func(t *testing.T) {
+		server, requested := newVersionServer(string(MCPLatest))
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(AutoProtocol))
+		require.NoError(t, err)
+		_, err = client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, MCPLatest, client.NegotiatedProtocol())
+		assert.Equal(t, []string{string(MCPLatest)}, requested())
+	})
+
+	t.Run("Fails when the server offers an unsupported version", func(t *testing.T) {
+		server, requested := newVersionServer("2000-01-01")
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(AutoProtocol))
+		require.NoError(t, err)
+		_, err = client.LoadTool("echo", context.Background())
+		require.Error(t, err)
+
+		var mismatch *transport.VersionMismatchError
+		require.ErrorAs(t, err, &mismatch)
+		assert.Equal(t, "2000-01-01", mismatch.ServerVersion)
+		assert.Equal(t, []string{string(MCPLatest)}, requested())
+		assert.Equal(t, Protocol(""), client.NegotiatedProtocol())
+	})
+
+	t.Run("Explicit protocol does not downgrade", func(t *testing.T) {
+		server, requested := newVersionServer("2025-03-26")
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPLatest))
+		require.NoError(t, err)
+		_, err = client.LoadTool("echo", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "MCP version mismatch")
+		assert.Equal(t, []string{string(MCPLatest)}, requested())
+		assert.Equal(t, MCPLatest, client.NegotiatedProtocol())
+	})
+
+	t.Run("Failure with WithCommand", func(t *testing.T) {
+		_, err := NewToolboxClient("", WithCommand("server"), WithProtocol(AutoProtocol))
+		require.Error(t, err)
+	})
+}
+
+func TestInvoke_MCPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		_ = json.Unmarshal(body, &req)
+
+		resp := map[string]any{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "initialize":
+			resp["result"] = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			resp["result"] = map[string]any{"tools": []mcpTool{{
+				Name:        "echo",
+				InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+			}}}
+		case "tools/call":
+			resp["error"] = map[string]any{
+				"code":    -32602,
+				"message": "Invalid params",
+				"data":    map[string]any{"param": "city", "reason": "unknown city"},
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+	require.NoError(t, err)
+	tool, err := client.LoadTool("echo", context.Background())
+	require.NoError(t, err)
+
+	_, err = tool.Invoke(context.Background(), map[string]any{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MCP request failed with code -32602: Invalid params")
+
+	var mcpErr *MCPError
+	require.ErrorAs(t, err, &mcpErr)
+	assert.Equal(t, -32602, mcpErr.Code)
+	assert.Equal(t, "Invalid params", mcpErr.Message)
+	assert.JSONEq(t, `{"param":"city","reason":"unknown city"}`, string(mcpErr.Data))
+}
+
+func TestLoadTool_HTTPWarning(t *testing.T) {
+	// Setup a mock HTTP server (not HTTPS) using MCP
+	mcpTools := []mcpTool{
+		{
+			Name:        "test-tool",
+			Description: "A test tool",
+			InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+		},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL)
+	require.NoError(t, err)
+
+	t.Run("Warning logged when auth tokens are provided over HTTP", func(t *testing.T) {
+		output := captureLogOutput(func() {
+			_, err := client.LoadTool("test-tool", context.Background(), WithAuthTokenString("service", "token"))
+			// We expect no error, or at least we don't care about the error for the warning test
+			// ignoring error check as we only care about the log
+			_ = err
+		})
+		assert.Contains(t, output, "WARNING: This connection is using HTTP")
+	})
+
+	t.Run("No warning when no auth tokens provided", func(t *testing.T) {
+		output := captureLogOutput(func() {
+			_, _ = client.LoadTool("test-tool", context.Background())
+		})
+		assert.NotContains(t, output, "WARNING: This connection is using HTTP")
+	})
+}
+
+func TestLoadToolset_HTTPWarning(t *testing.T) {
+	// Setup a mock HTTP server with MCP
+	mcpTools := []mcpTool{
+		{Name: "tool1", Description: "d1", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+		{Name: "tool2", Description: "d2", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	client, err := NewToolboxClient(server.URL)
+	require.NoError(t, err)
+
+	t.Run("Warning logged when auth tokens are provided over HTTP", func(t *testing.T) {
+		output := captureLogOutput(func() {
+			_, _ = client.LoadToolset("test-toolset", context.Background(), WithAuthTokenString("service", "token"))
+		})
+		assert.Contains(t, output, "WARNING: This connection is using HTTP")
+	})
+
+	t.Run("No warning when no auth tokens provided", func(t *testing.T) {
+		output := captureLogOutput(func() {
+			_, _ = client.LoadToolset("test-toolset", context.Background())
+		})
+		assert.NotContains(t, output, "WARNING: This connection is using HTTP")
+	})
+}
+
+func TestDefaultOptionOverwriting(t *testing.T) {
+	// Setup a mock server using MCP
+	mcpTools := []mcpTool{
+		{
+			Name:        "toolWithParams",
+			Description: "A tool that uses the parameters being tested",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"user_id": map[string]any{"type": "string"},
+				},
+			},
+			Meta: map[string]any{
+				"toolbox/authInvoke": []string{"google"},
+			},
+		},
+	}
+	server := newMockMCPServer(t, mcpTools)
+	defer server.Close()
+
+	t.Run("LoadTool - Fails when overriding a default bound parameter", func(t *testing.T) {
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithDefaultToolOptions(
+				WithBindParamString("user_id", "default_user"),
+			),
+		)
+		if err != nil {
+			t.Fatalf("Client creation with default options failed unexpectedly: %v", err)
+		}
+
+		_, err = client.LoadTool("toolWithParams", context.Background(),
+			WithBindParamString("user_id", "override_user"),
+		)
+
+		if err == nil {
+			t.Fatal("Expected an error when overriding a default bound parameter, but got nil")
+		}
+
+		expectedErrorMsg := "duplicate parameter binding: parameter 'user_id' is already set"
+		if !strings.Contains(err.Error(), expectedErrorMsg) {
+			t.Errorf("Expected error message to contain %q, but got: %v", expectedErrorMsg, err)
+		}
+	})
+
+	t.Run("LoadTool - Fails when overriding a default auth token", func(t *testing.T) {
+
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithDefaultToolOptions(
+				WithAuthTokenString("google", "default_google_token"),
+			),
+		)
+		if err != nil {
+			t.Fatalf("Client creation with default options failed unexpectedly: %v", err)
+		}
+
+		_, err = client.LoadTool("toolWithParams", context.Background(),
+			WithAuthTokenString("google", "override_google_token"),
+		)
+
+		if err == nil {
+			t.Fatal("Expected an error when overriding a default auth token, but got nil")
+		}
+
+		expectedErrorMsg := "authentication source 'google' is already set"
+		if !strings.Contains(err.Error(), expectedErrorMsg) {
+			t.Errorf("Expected error message to contain %q, but got: %v", expectedErrorMsg, err)
+		}
+	})
+}
+
+func TestNegativeAndEdgeCases(t *testing.T) {
+	// MCP server returning empty tool list
+	server := newMockMCPServer(t, []mcpTool{})
+	defer server.Close()
+
+	t.Run("LoadTool fails when a nil ToolOption is provided", func(t *testing.T) {
+
+		client, _ := NewToolboxClient(server.URL)
+		_, err := client.LoadTool("any-tool", context.Background(), nil)
+		if err == nil {
+			t.Fatal("Expected an error when a nil option is passed to LoadTool, but got nil")
+		}
+		if !strings.Contains(err.Error(), "received a nil ToolOption ") {
+			t.Errorf("Expected nil option error, got: %v", err)
+		}
+	})
+
+	t.Run("Client options fail fast with nil arguments", func(t *testing.T) {
+
+		// Test WithHTTPClient(nil)
+		_, err := NewToolboxClient(server.URL, WithHTTPClient(nil))
+		if err == nil {
+			t.Error("Expected error from WithHTTPClient(nil), but got nil")
+		} else if !strings.Contains(err.Error(), "http.Client cannot be nil") {
+			t.Errorf("Incorrect error message for nil http client. Got: %v", err)
+		}
+
+		// Test WithClientHeaderTokenSource(name, nil)
+		_, err = NewToolboxClient(server.URL, WithClientHeaderTokenSource("any", nil))
+		if err == nil {
+			t.Error("Expected error from WithClientHeaderTokenSource(name, nil), but got nil")
+		} else if !strings.Contains(err.Error(), "oauth2.TokenSource for header 'any' cannot be nil") {
+			t.Errorf("Incorrect error message for nil token source. Got: %v", err)
+		}
+	})
+
+	t.Run("LoadTool fails gracefully if manifest has no tools", func(t *testing.T) {
+		// Mock server above returns empty list of tools
+		client, _ := NewToolboxClient(server.URL, WithHTTPClient(server.Client()))
+
+		// This call would panic if the code doesn't check for a nil map.
+		_, err := client.LoadTool("any-tool", context.Background())
+
+		if err == nil {
+			t.Fatal("Expected an error when manifest has no tools, but got nil")
+		}
+		if !strings.Contains(err.Error(), "tool 'any-tool' not found") {
+			t.Errorf("Expected 'tool not found' error, got: %v", err)
+		}
+	})
+}
+
+// TestOptionDuplicateAndEdgeCases covers scenarios where options are used incorrectly.
+func TestOptionDuplicateAndEdgeCases(t *testing.T) {
+	t.Run("Fails when trying to add default tool options twice", func(t *testing.T) {
+		// Action: Try to configure a client with the same option type twice.
+		_, err := NewToolboxClient("url",
+			WithDefaultToolOptions(WithStrict(true)), // First call
+			WithDefaultToolOptions(WithStrict(true)), // Second call should fail
+		)
+
+		// Assert
+		if err == nil {
+			t.Fatal("Expected an error when setting default tool options twice, but got nil")
+		}
+		if !strings.Contains(err.Error(), "default tool options have already been set") {
+			t.Errorf("Incorrect error message for duplicate default options. Got: %v", err)
+		}
+	})
+
+	t.Run("Fails when ClientHeaderTokenSource tries to overwrite", func(t *testing.T) {
+		_, err := NewToolboxClient("url",
+			WithClientHeaderString("Authorization", "token-a"),
+			WithClientHeaderTokenSource("Authorization", &mockTokenSource{}), // Overwrite attempt
+		)
+
+		if err == nil {
+			t.Fatal("Expected an error when overwriting a client header, but got nil")
+		}
+		if !strings.Contains(err.Error(), "client header 'Authorization' is already set") {
+			t.Errorf("Incorrect error message for duplicate client header. Got: %v", err)
+		}
+	})
+
+	t.Run("Fails when WithAuthTokenSource tries to overwrite", func(t *testing.T) {
+		// Note: This check happens at application time, not client creation time.
+		config := newToolConfig()
+		_ = WithAuthTokenString("google", "token-a")(config)             // Set it once
+		err := WithAuthTokenSource("google", &mockTokenSource{})(config) // Try to overwrite
+
+		if err == nil {
+			t.Fatal("Expected an error when overwriting an auth token source, but got nil")
+		}
+		if !strings.Contains(err.Error(), "authentication source 'google' is already set") {
+			t.Errorf("Incorrect error message for duplicate auth token. Got: %v", err)
+		}
+	})
+}
+
+// TestLoadToolAndLoadToolset_ErrorPaths covers various failure scenarios for the main functions.
+func TestLoadToolAndLoadToolset_ErrorPaths(t *testing.T) {
+	// Setup a mock server and manifest
+	mcpTools := []mcpTool{
+		{
+			Name:        "toolA",
+			Description: "Tool A",
+			InputSchema: map[string]any{
 				"type": "object",
 				"properties": map[string]any{
 					"param1":     map[string]any{"type": "string"},
@@ -959,3 +3636,158 @@ func TestLoadToolAndLoadToolset_ErrorPaths(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadToolset_BuildConcurrency(t *testing.T) {
+	// syntheticManifest builds a manifest with n tools, whose parameters at
+	// the given indexes have an invalid type.
+	syntheticManifest := func(n int, invalid ...int) []byte {
+		tools := make(map[string]any, n)
+		for i := range n {
+			paramType := "string"
+			if slices.Contains(invalid, i) {
+				paramType = "bogus"
+			}
+			tools[fmt.Sprintf("tool-%03d", i)] = map[string]any{
+				"description": fmt.Sprintf("Tool number %d.", i),
+				"parameters": []map[string]any{
+					{"name": "query", "type": paramType, "required": true, "description": "Query."},
+					{"name": "limit", "type": "integer", "description": "Limit."},
+				},
+			}
+		}
+		data, err := json.Marshal(map[string]any{"serverVersion": "1.0.0", "tools": tools})
+		require.NoError(t, err)
+		return data
+	}
+
+	load := func(t *testing.T, manifest []byte, opts ...ClientOption) ([]*ToolboxTool, error) {
+		client, err := NewToolboxClientFromManifest(manifest, opts...)
+		require.NoError(t, err)
+		return client.LoadToolset("", context.Background(), WithBindParamInt("limit", 5), WithStrict(false))
+	}
+
+	t.Run("Matches the sequential result", func(t *testing.T) {
+		manifest := syntheticManifest(200)
+		sequential, err := load(t, manifest)
+		require.NoError(t, err)
+		require.Len(t, sequential, 200)
+
+		for range 5 {
+			concurrent, err := load(t, manifest, WithToolsetBuildConcurrency(8))
+			require.NoError(t, err)
+			require.Len(t, concurrent, len(sequential))
+			for i := range sequential {
+				assert.Equal(t, sequential[i].Name(), concurrent[i].Name())
+				assert.Equal(t, fmt.Sprintf("tool-%03d", i), concurrent[i].Name(), "tools must be ordered by name")
+				assert.Equal(t, sequential[i].Description(), concurrent[i].Description())
+				assert.Equal(t, sequential[i].Parameters(), concurrent[i].Parameters())
+				assert.Equal(t, sequential[i].boundParams, concurrent[i].boundParams)
+			}
+		}
+	})
+
+	t.Run("Reports the first failing tool", func(t *testing.T) {
+		manifest := syntheticManifest(200, 170, 42, 99)
+		_, seqErr := load(t, manifest)
+		require.Error(t, seqErr)
+		assert.Contains(t, seqErr.Error(), "failed to create tool 'tool-042'")
+
+		for range 5 {
+			_, err := load(t, manifest, WithToolsetBuildConcurrency(16))
+			require.Error(t, err)
+			assert.Equal(t, seqErr.Error(), err.Error())
+		}
+	})
+
+	t.Run("Rejects invalid and duplicate settings", func(t *testing.T) {
+		_, err := NewToolboxClient("http://example.com", WithToolsetBuildConcurrency(0))
+		assert.Error(t, err)
+		_, err = NewToolboxClient("http://example.com", WithToolsetBuildConcurrency(2), WithToolsetBuildConcurrency(4))
+		assert.ErrorContains(t, err, "already set")
+	})
+}
+
+func TestWithCommand(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "echoserver")
+	out, err := exec.Command("go", "build", "-o", bin, "./transport/mcp/stdio/testdata/echoserver").CombinedOutput()
+	require.NoError(t, err, "failed to build echo server: %s", out)
+
+	t.Run("Loads and invokes tools over stdio", func(t *testing.T) {
+		client, err := NewToolboxClient("", WithCommand(bin))
+		require.NoError(t, err)
+		assert.Equal(t, Stdio, client.protocol)
+
+		tool, err := client.LoadTool("echo", context.Background())
+		require.NoError(t, err)
+		result, err := tool.Invoke(context.Background(), map[string]any{"message": "hello"})
+		require.NoError(t, err)
+		assert.Equal(t, "hello", result)
+
+		require.NoError(t, client.Close())
+		_, err = tool.Invoke(context.Background(), map[string]any{"message": "hello"})
+		assert.Error(t, err, "tools must not be invocable once the client is closed")
+	})
+
+	t.Run("Rejects conflicting configurations", func(t *testing.T) {
+		_, err := NewToolboxClient("", WithCommand(bin), WithProtocol(MCPv20250618))
+		assert.ErrorContains(t, err, "WithCommand requires the stdio protocol")
+
+		_, err = NewToolboxClient("", WithProtocol(Stdio))
+		assert.ErrorContains(t, err, "requires a command set with WithCommand")
+
+		_, err = NewToolboxClient("", WithCommand(""))
+		assert.Error(t, err)
+
+		_, err = NewToolboxClient("", WithCommand(bin), WithCommand(bin))
+		assert.ErrorContains(t, err, "command is already set")
+	})
+
+	// assertReaped checks that the server process whose ID is in pidFile has
+	// exited and been waited for.
+	assertReaped := func(t *testing.T, pidFile string) {
+		data, err := os.ReadFile(pidFile)
+		require.NoError(t, err)
+		pid, err := strconv.Atoi(string(data))
+		require.NoError(t, err)
+		process, err := os.FindProcess(pid)
+		if err != nil {
+			return
+		}
+		assert.Error(t, process.Signal(syscall.Signal(0)), "server process %d is still running", pid)
+	}
+
+	t.Run("A failed eager handshake stops the server", func(t *testing.T) {
+		pidFile := filepath.Join(t.TempDir(), "pid")
+		client, err := NewToolboxClientContext(context.Background(), "", WithCommand(bin, "-pidfile", pidFile, "-initialize", "reject"))
+		require.ErrorContains(t, err, "server does not support the 'tools' capability")
+		assert.Nil(t, client)
+		assertReaped(t, pidFile)
+	})
+
+	t.Run("A cancelled eager handshake stops the server", func(t *testing.T) {
+		pidFile := filepath.Join(t.TempDir(), "pid")
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			// Cancel once the server has received the initialize request.
+			for {
+				if _, err := os.Stat(pidFile); err == nil {
+					cancel()
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}()
+
+		client, err := NewToolboxClientContext(ctx, "", WithCommand(bin, "-pidfile", pidFile, "-initialize", "hang"))
+		require.ErrorIs(t, err, context.Canceled)
+		assert.Nil(t, client)
+		assertReaped(t, pidFile)
+	})
+
+	t.Run("Close is a no-op for HTTP transports", func(t *testing.T) {
+		client, err := NewToolboxClient("http://localhost:5000")
+		require.NoError(t, err)
+		assert.NoError(t, client.Close())
+	})
+}
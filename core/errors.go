@@ -0,0 +1,46 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
+
+// Errors returned by the client, wrapped with the name of the parameter or
+// auth service involved. Use errors.Is to test for them.
+var (
+	// ErrMissingRequiredParam is returned when a required parameter is
+	// neither provided, bound nor defaulted.
+	ErrMissingRequiredParam = errors.New("missing required parameter")
+
+	// ErrUnexpectedParam is returned when the input contains a parameter that
+	// the tool does not accept, or one that is already bound.
+	ErrUnexpectedParam = errors.New("unexpected parameter")
+
+	// ErrPermission is returned when a tool requires an auth service for
+	// which no token source was provided.
+	ErrPermission = errors.New("permission error")
+
+	// ErrUnauthorized matches a request that the server rejected with a 401
+	// or 403 status. The *transport.StatusError in the chain holds the
+	// response details.
+	ErrUnauthorized = transport.ErrUnauthorized
+
+	// ErrBoundParamOverride is returned by ToolFrom when binding a parameter
+	// that is already bound.
+	ErrBoundParamOverride = errors.New("cannot override existing bound parameter")
+)
@@ -0,0 +1,130 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
+
+// NewToolboxClientFromManifest creates a client that serves LoadTool and
+// LoadToolset from a Toolbox manifest given as JSON, without contacting a
+// server. This is useful for offline testing and tooling that only needs
+// tool definitions. The manifest is served as the default toolset.
+//
+// Tools loaded from such a client have no server to talk to, so invoking them
+// returns an error.
+//
+// Inputs:
+//   - manifest: The JSON encoding of a transport.ManifestSchema.
+//   - opts: A variadic list of ClientOption functions to configure the client,
+//     such as default tool options.
+//
+// Returns:
+//
+//	A configured *ToolboxClient and a nil error on success, or a nil client
+//	and an error if the manifest cannot be parsed or configuration fails.
+func NewToolboxClientFromManifest(manifest []byte, opts ...ClientOption) (*ToolboxClient, error) {
+	var schema transport.ManifestSchema
+	if err := json.Unmarshal(manifest, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	if len(schema.Tools) == 0 {
+		return nil, fmt.Errorf("manifest contains no tools")
+	}
+
+	tc, err := newConfiguredClient("NewToolboxClientFromManifest", "", opts)
+	if err != nil {
+		return nil, err
+	}
+	tc.transport = &manifestTransport{manifest: schema}
+	return tc, nil
+}
+
+// ValidateManifest checks the definition of every input and output parameter
+// of every tool in a manifest, as loading the tools would, but reports all the
+// problems at once instead of stopping at the first. This helps diagnose a
+// malformed manifest before loading from it.
+//
+// Inputs:
+//   - m: The manifest to validate.
+//
+// Returns:
+//
+//	A slice of errors, ordered by tool name and tagged with the tool and
+//	parameter at fault, or nil if the manifest is well-formed.
+func ValidateManifest(m *ManifestSchema) []error {
+	if m == nil {
+		return []error{fmt.Errorf("manifest is nil")}
+	}
+
+	var errs []error
+	check := func(tool, kind string, params []ParameterSchema) {
+		for _, p := range params {
+			if ap, ok := p.AdditionalProperties.(map[string]any); ok {
+				apParam, err := mapToSchema(ap)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("tool '%s', %s '%s': %w", tool, kind, p.Name, err))
+					continue
+				}
+				p.AdditionalProperties = apParam
+			}
+			if err := p.ValidateDefinition(); err != nil {
+				errs = append(errs, fmt.Errorf("tool '%s', %s '%s': %w", tool, kind, p.Name, err))
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(m.Tools)) {
+		tool := m.Tools[name]
+		check(name, "parameter", tool.Parameters)
+		check(name, "output parameter", tool.OutputSchema)
+	}
+	return errs
+}
+
+// manifestTransport is a transport.Transport that serves tool definitions
+// from a static manifest and cannot invoke tools.
+type manifestTransport struct {
+	manifest transport.ManifestSchema
+}
+
+func (m *manifestTransport) BaseURL() string { return "" }
+
+func (m *manifestTransport) GetTool(ctx context.Context, toolName string, headers map[string]string) (*transport.ManifestSchema, error) {
+	tool, ok := m.manifest.Tools[toolName]
+	if !ok {
+		return nil, fmt.Errorf("tool '%s' not found", toolName)
+	}
+	return &transport.ManifestSchema{
+		ServerVersion: m.manifest.ServerVersion,
+		Tools:         map[string]transport.ToolSchema{toolName: tool},
+	}, nil
+}
+
+func (m *manifestTransport) ListTools(ctx context.Context, toolsetName string, headers map[string]string) (*transport.ManifestSchema, error) {
+	if toolsetName != "" {
+		return nil, fmt.Errorf("toolset '%s' not found: a static manifest only provides the default toolset", toolsetName)
+	}
+	return &m.manifest, nil
+}
+
+func (m *manifestTransport) InvokeTool(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (any, error) {
+	return nil, fmt.Errorf("cannot invoke tool '%s': the client was created from a static manifest and has no server transport", toolName)
+}
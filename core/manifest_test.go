@@ -0,0 +1,163 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const offlineManifest = `{
+	"serverVersion": "1.2.0",
+	"tools": {
+		"search-hotels": {
+			"description": "Search hotels by location.",
+			"parameters": [
+				{"name": "location", "type": "string", "required": true, "description": "City name."},
+				{"name": "max_results", "type": "integer", "description": "Result limit."}
+			]
+		},
+		"book-hotel": {
+			"description": "Book a hotel.",
+			"parameters": [
+				{"name": "hotel_id", "type": "string", "required": true, "description": "Hotel ID."}
+			],
+			"authRequired": ["google"]
+		}
+	}
+}`
+
+func TestNewToolboxClientFromManifest(t *testing.T) {
+	client, err := NewToolboxClientFromManifest([]byte(offlineManifest))
+	require.NoError(t, err)
+
+	t.Run("LoadTool builds the tool from the manifest", func(t *testing.T) {
+		tool, err := client.LoadTool("search-hotels", context.Background())
+		require.NoError(t, err)
+
+		assert.Equal(t, "search-hotels", tool.Name())
+		assert.Equal(t, "Search hotels by location.", tool.Description())
+		params := tool.Parameters()
+		require.Len(t, params, 2)
+		assert.Equal(t, "location", params[0].Name)
+		assert.Equal(t, "string", params[0].Type)
+		assert.True(t, params[0].Required)
+		assert.Equal(t, "max_results", params[1].Name)
+		assert.Equal(t, "integer", params[1].Type)
+	})
+
+	t.Run("LoadToolset serves the default toolset", func(t *testing.T) {
+		tools, err := client.LoadToolset("", context.Background(), WithAuthTokenString("google", "token"))
+		require.NoError(t, err)
+
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name())
+		}
+		assert.ElementsMatch(t, []string{"search-hotels", "book-hotel"}, names)
+	})
+
+	t.Run("Unknown tool and named toolset return errors", func(t *testing.T) {
+		_, err := client.LoadTool("missing", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tool 'missing' not found")
+
+		_, err = client.LoadToolset("travel", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "toolset 'travel' not found")
+	})
+
+	t.Run("Invoke errors without a server transport", func(t *testing.T) {
+		tool, err := client.LoadTool("search-hotels", context.Background())
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{"location": "Paris"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "has no server transport")
+	})
+
+	t.Run("Client options still apply", func(t *testing.T) {
+		c, err := NewToolboxClientFromManifest([]byte(offlineManifest), WithMinServerVersion("2.0.0"))
+		require.NoError(t, err)
+
+		_, err = c.LoadTool("search-hotels", context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server version 1.2.0 is below required 2.0.0")
+	})
+
+	t.Run("Invalid manifests are rejected", func(t *testing.T) {
+		_, err := NewToolboxClientFromManifest([]byte(`{"tools":`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to parse manifest")
+
+		_, err = NewToolboxClientFromManifest([]byte(`{"serverVersion": "1.0.0", "tools": {}}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "manifest contains no tools")
+
+		_, err = NewToolboxClientFromManifest([]byte(offlineManifest), nil)
+		require.Error(t, err)
+	})
+}
+
+func TestValidateManifest(t *testing.T) {
+	t.Run("Reports every problem in the manifest", func(t *testing.T) {
+		var manifest ManifestSchema
+		require.NoError(t, json.Unmarshal([]byte(`{
+			"tools": {
+				"search-hotels": {
+					"parameters": [{"name": "location", "type": "string"}]
+				},
+				"book-hotel": {
+					"parameters": [
+						{"name": "hotel_id"},
+						{"name": "nights", "type": "integer", "minimum": 10, "maximum": 1}
+					]
+				},
+				"cancel-booking": {
+					"parameters": [{"name": "code", "type": "string", "pattern": "("}],
+					"outputSchema": [
+						{"name": "details", "type": "object", "additionalProperties": {"type": "array"}}
+					]
+				}
+			}
+		}`), &manifest))
+
+		errs := ValidateManifest(&manifest)
+		require.Len(t, errs, 4)
+		assert.ErrorContains(t, errs[0], "tool 'book-hotel', parameter 'hotel_id': schema validation failed for 'hotel_id': type is missing")
+		assert.ErrorContains(t, errs[1], "tool 'book-hotel', parameter 'nights': schema validation failed for 'nights': minimum 10 is greater than maximum 1")
+		assert.ErrorContains(t, errs[2], "tool 'cancel-booking', parameter 'code': schema validation failed for 'code': invalid pattern")
+		assert.ErrorContains(t, errs[3], "tool 'cancel-booking', output parameter 'details'")
+		assert.ErrorContains(t, errs[3], "nested maps or arrays are not supported")
+	})
+
+	t.Run("Accepts a well-formed manifest", func(t *testing.T) {
+		var manifest ManifestSchema
+		require.NoError(t, json.Unmarshal([]byte(offlineManifest), &manifest))
+		assert.Empty(t, ValidateManifest(&manifest))
+	})
+
+	t.Run("Rejects a nil manifest", func(t *testing.T) {
+		errs := ValidateManifest(nil)
+		require.Len(t, errs, 1)
+		assert.ErrorContains(t, errs[0], "manifest is nil")
+	})
+}
@@ -0,0 +1,48 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import "time"
+
+// The kinds of load reported to MetricsObserver.ObserveLoad.
+const (
+	// LoadKindTool identifies a LoadTool call.
+	LoadKindTool = "tool"
+	// LoadKindToolset identifies a LoadToolset call.
+	LoadKindToolset = "toolset"
+)
+
+// MetricsObserver receives timing and outcome measurements from a client, so
+// that they can be exported to a metrics system such as Prometheus,
+// OpenTelemetry or statsd. Implementations must be safe for concurrent use.
+type MetricsObserver interface {
+	// ObserveInvoke is called once every tool invocation has finished, with
+	// its total duration and its error, or nil on success.
+	ObserveInvoke(tool string, duration time.Duration, err error)
+	// ObserveLoad is called once every load has finished, with the kind of
+	// load, one of the LoadKind constants, its duration and its error, or
+	// nil on success.
+	ObserveLoad(kind string, duration time.Duration, err error)
+}
+
+// NoopMetricsObserver is a MetricsObserver that discards every measurement.
+// It is used when no observer is registered with WithMetricsObserver.
+type NoopMetricsObserver struct{}
+
+// ObserveInvoke does nothing.
+func (NoopMetricsObserver) ObserveInvoke(string, time.Duration, error) {}
+
+// ObserveLoad does nothing.
+func (NoopMetricsObserver) ObserveLoad(string, time.Duration, error) {}
@@ -0,0 +1,109 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type observation struct {
+	name     string
+	duration time.Duration
+	failed   bool
+}
+
+// fakeMetricsObserver records every measurement it receives.
+type fakeMetricsObserver struct {
+	mu      sync.Mutex
+	invokes []observation
+	loads   []observation
+}
+
+func (f *fakeMetricsObserver) ObserveInvoke(tool string, duration time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.invokes = append(f.invokes, observation{tool, duration, err != nil})
+}
+
+func (f *fakeMetricsObserver) ObserveLoad(kind string, duration time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.loads = append(f.loads, observation{kind, duration, err != nil})
+}
+
+func TestWithMetricsObserver(t *testing.T) {
+	server, _ := newToolServer(t, []string{"search"}, "found", 0)
+	defer server.Close()
+
+	obs := &fakeMetricsObserver{}
+	client, err := NewToolboxClient(server.URL,
+		WithHTTPClient(server.Client()),
+		WithProtocol(MCPv20250618),
+		WithMetricsObserver(obs),
+		WithObserverSampleRate(0),
+	)
+	require.NoError(t, err)
+
+	t.Run("Loads are observed", func(t *testing.T) {
+		_, err := client.LoadTool("search", context.Background())
+		require.NoError(t, err)
+		_, err = client.LoadTool("missing", context.Background())
+		require.Error(t, err)
+		_, err = client.LoadToolset("", context.Background())
+		require.NoError(t, err)
+
+		require.Len(t, obs.loads, 3)
+		assert.Equal(t, LoadKindTool, obs.loads[0].name)
+		assert.False(t, obs.loads[0].failed)
+		assert.Equal(t, LoadKindTool, obs.loads[1].name)
+		assert.True(t, obs.loads[1].failed)
+		assert.Equal(t, LoadKindToolset, obs.loads[2].name)
+		assert.False(t, obs.loads[2].failed)
+		for _, o := range obs.loads {
+			assert.Positive(t, o.duration)
+		}
+	})
+
+	t.Run("Invocations are observed regardless of sampling", func(t *testing.T) {
+		tool, err := client.LoadTool("search", context.Background())
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		_, err = tool.Invoke(context.Background(), map[string]any{"unknown": 1})
+		require.Error(t, err)
+
+		require.Len(t, obs.invokes, 2)
+		assert.Equal(t, observation{"search", obs.invokes[0].duration, false}, obs.invokes[0])
+		assert.Positive(t, obs.invokes[0].duration)
+		assert.Equal(t, "search", obs.invokes[1].name)
+		assert.True(t, obs.invokes[1].failed, "validation failures must be observed")
+	})
+
+	t.Run("Rejects nil and duplicate observers", func(t *testing.T) {
+		_, err := NewToolboxClient(server.URL, WithMetricsObserver(nil))
+		assert.Error(t, err)
+		_, err = NewToolboxClient(server.URL, WithMetricsObserver(obs), WithMetricsObserver(NoopMetricsObserver{}))
+		assert.ErrorContains(t, err, "metrics observer is already set")
+	})
+}
@@ -0,0 +1,303 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
+
+// MultiClient combines an ordered list of clients, such as a primary and a
+// secondary Toolbox server, into a single logical client. Tools are merged
+// across the clients with the earlier client winning on name collisions, and
+// CallTool fails over to the next client when a server is unreachable or
+// answers with a server error.
+type MultiClient struct {
+	clients []*ToolboxClient
+	// mu guards collisions, the name collisions found by the last
+	// LoadToolset, and tools, the tools CallTool loaded from each client.
+	mu         sync.Mutex
+	collisions []ToolCollision
+	tools      []map[string]*ToolboxTool
+}
+
+// NewMultiClient creates a MultiClient over the given clients, in priority
+// order.
+//
+// Inputs:
+//   - clients: The clients to combine, highest priority first.
+//
+// Returns:
+//
+//	A *MultiClient and a nil error on success, or a nil client and an error
+//	if no clients are given or one of them is nil.
+func NewMultiClient(clients ...*ToolboxClient) (*MultiClient, error) {
+	if len(clients) == 0 {
+		return nil, fmt.Errorf("NewMultiClient: at least one client must be provided")
+	}
+	for i, c := range clients {
+		if c == nil {
+			return nil, fmt.Errorf("NewMultiClient: client at index %d is nil", i)
+		}
+	}
+	tools := make([]map[string]*ToolboxTool, len(clients))
+	for i := range tools {
+		tools[i] = make(map[string]*ToolboxTool)
+	}
+	return &MultiClient{clients: append([]*ToolboxClient(nil), clients...), tools: tools}, nil
+}
+
+// ClientError records the failure of one of the clients of a MultiClient.
+type ClientError struct {
+	// Index is the position of the client in the MultiClient.
+	Index int
+	// BaseURL is the base URL of the client's server.
+	BaseURL string
+	// Err is the error returned by the client.
+	Err error
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("client %d (%s): %v", e.Index, e.BaseURL, e.Err)
+}
+
+func (e *ClientError) Unwrap() error {
+	return e.Err
+}
+
+// ToolCollision records a tool name offered by more than one client of a
+// MultiClient. The tool from the first listed client is used. Collisions are
+// expected when the clients are replicas of each other, so they are reported
+// by Collisions rather than as errors.
+type ToolCollision struct {
+	// Name is the name of the tool.
+	Name string
+	// Clients holds the indexes of the clients offering the tool, in order.
+	Clients []int
+}
+
+// MultiClientError reports the per-client failures encountered by a
+// MultiClient operation.
+type MultiClientError struct {
+	ClientErrors []*ClientError
+}
+
+func (e *MultiClientError) Error() string {
+	msgs := make([]string, len(e.ClientErrors))
+	for i, ce := range e.ClientErrors {
+		msgs[i] = ce.Error()
+	}
+	return "multi-client: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the per-client errors, so that errors.Is and errors.As can
+// inspect them.
+func (e *MultiClientError) Unwrap() []error {
+	errs := make([]error, len(e.ClientErrors))
+	for i, ce := range e.ClientErrors {
+		errs[i] = ce
+	}
+	return errs
+}
+
+// addClientError records the failure of the client at index i.
+func (mc *MultiClient) addClientError(e *MultiClientError, i int, err error) {
+	e.ClientErrors = append(e.ClientErrors, &ClientError{Index: i, BaseURL: mc.clients[i].baseURL, Err: err})
+}
+
+// LoadTool loads a tool from the first client that provides it.
+//
+// Inputs:
+//   - name: The specific name of the tool to load.
+//   - ctx: The context to control the lifecycle of the requests.
+//   - opts: A variadic list of ToolOption functions, applied to each client's
+//     LoadTool call.
+//
+// Returns:
+//
+//	The tool from the highest-priority client that could load it and a nil
+//	error, or a nil tool and a *MultiClientError holding every client's
+//	failure.
+func (mc *MultiClient) LoadTool(name string, ctx context.Context, opts ...ToolOption) (*ToolboxTool, error) {
+	multiErr := &MultiClientError{}
+	for i, c := range mc.clients {
+		tool, err := c.LoadTool(name, ctx, opts...)
+		if err == nil {
+			return tool, nil
+		}
+		mc.addClientError(multiErr, i, err)
+	}
+	return nil, multiErr
+}
+
+// LoadToolset loads the named toolset from every client and merges the
+// tools, keeping the tool from the earliest client when several provide the
+// same name. The names provided by several clients are then reported by
+// Collisions.
+//
+// Unlike the other loaders, LoadToolset may return tools together with a
+// non-nil *MultiClientError: the merged tools are returned as long as one
+// client succeeded, and the error reports the clients that failed.
+//
+// Inputs:
+//   - name: Name of the toolset to be loaded. Set this arg to "" to load the default toolset.
+//   - ctx: The context to control the lifecycle of the requests.
+//   - opts: A variadic list of ToolOption functions, applied to each client's
+//     LoadToolset call.
+//
+// Returns:
+//
+//	The merged tools, in client order, and a nil error if every client
+//	succeeded; otherwise a *MultiClientError, with a nil slice if every
+//	client failed.
+func (mc *MultiClient) LoadToolset(name string, ctx context.Context, opts ...ToolOption) ([]*ToolboxTool, error) {
+	multiErr := &MultiClientError{}
+	var merged []*ToolboxTool
+	owners := make(map[string][]int)
+	succeeded := false
+
+	for i, c := range mc.clients {
+		tools, err := c.LoadToolset(name, ctx, opts...)
+		if err != nil {
+			mc.addClientError(multiErr, i, err)
+			continue
+		}
+		succeeded = true
+		for _, tool := range tools {
+			if len(owners[tool.Name()]) == 0 {
+				merged = append(merged, tool)
+			}
+			owners[tool.Name()] = append(owners[tool.Name()], i)
+		}
+	}
+
+	var collisions []ToolCollision
+	for _, tool := range merged {
+		if clients := owners[tool.Name()]; len(clients) > 1 {
+			collisions = append(collisions, ToolCollision{Name: tool.Name(), Clients: clients})
+		}
+	}
+	mc.mu.Lock()
+	mc.collisions = collisions
+	mc.mu.Unlock()
+
+	if !succeeded {
+		return nil, multiErr
+	}
+	if len(multiErr.ClientErrors) > 0 {
+		return merged, multiErr
+	}
+	return merged, nil
+}
+
+// Collisions returns the tool names that several clients offered in the last
+// LoadToolset call, with the clients offering each. They are informational:
+// the tool of the first listed client is used.
+//
+// Returns:
+//
+//	The collisions in the order of the merged tools, or nil if there were
+//	none or LoadToolset has not been called.
+func (mc *MultiClient) Collisions() []ToolCollision {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	return slices.Clone(mc.collisions)
+}
+
+// CallTool loads and invokes a tool, failing over to the next client when a
+// client cannot load the tool or its server is unreachable or answers with a
+// 5xx status. Other invocation errors, such as invalid input, are returned
+// without trying further clients.
+//
+// A tool loaded without options is kept for each client and reused by later
+// calls. Since options cannot be compared, calls with options load the tool
+// again every time.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the requests.
+//   - name: The name of the tool to call.
+//   - input: The input parameters for the tool.
+//   - opts: A variadic list of ToolOption functions used to load the tool.
+//
+// Returns:
+//
+//	The result from the first client that served the call and a nil error,
+//	or a nil result and an error. When every client failed over, the error
+//	is a *MultiClientError.
+func (mc *MultiClient) CallTool(ctx context.Context, name string, input map[string]any, opts ...ToolOption) (any, error) {
+	multiErr := &MultiClientError{}
+	for i := range mc.clients {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tool, err := mc.clientTool(ctx, i, name, opts)
+		if err != nil {
+			mc.addClientError(multiErr, i, err)
+			continue
+		}
+		result, err := tool.Invoke(ctx, input)
+		if err == nil {
+			return result, nil
+		}
+		if !isFailoverError(err) {
+			return nil, err
+		}
+		mc.addClientError(multiErr, i, err)
+	}
+	return nil, multiErr
+}
+
+// clientTool returns the named tool of the client at index i, reusing the
+// tool loaded by an earlier call when no options are given.
+func (mc *MultiClient) clientTool(ctx context.Context, i int, name string, opts []ToolOption) (*ToolboxTool, error) {
+	if len(opts) > 0 {
+		return mc.clients[i].LoadTool(name, ctx, opts...)
+	}
+
+	mc.mu.Lock()
+	tool, ok := mc.tools[i][name]
+	mc.mu.Unlock()
+	if ok {
+		return tool, nil
+	}
+
+	tool, err := mc.clients[i].LoadTool(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+	mc.mu.Lock()
+	mc.tools[i][name] = tool
+	mc.mu.Unlock()
+	return tool, nil
+}
+
+// isFailoverError reports whether an invocation error means the server is
+// unavailable, so that another server may be tried.
+func isFailoverError(err error) bool {
+	var statusErr *transport.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
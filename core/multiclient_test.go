@@ -0,0 +1,249 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newToolServer creates an MCP server offering the given tools, whose calls
+// answer with the reply text, or fail with callStatus when it is non-zero.
+func newToolServer(t *testing.T, tools []string, reply string, callStatus int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			list := make([]mcpTool, len(tools))
+			for i, name := range tools {
+				list[i] = mcpTool{Name: name, Description: reply, InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}}
+			}
+			result = map[string]any{"tools": list}
+		case "tools/call":
+			calls.Add(1)
+			if callStatus != 0 {
+				http.Error(w, "unavailable", callStatus)
+				return
+			}
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": reply}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	return server, &calls
+}
+
+func newMultiClient(t *testing.T, servers ...*httptest.Server) *MultiClient {
+	t.Helper()
+	clients := make([]*ToolboxClient, len(servers))
+	for i, server := range servers {
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		clients[i] = client
+	}
+	mc, err := NewMultiClient(clients...)
+	require.NoError(t, err)
+	return mc
+}
+
+func TestMultiClient_Load(t *testing.T) {
+	primary, _ := newToolServer(t, []string{"search", "book"}, "primary", 0)
+	defer primary.Close()
+	secondary, _ := newToolServer(t, []string{"book", "cancel"}, "secondary", 0)
+	defer secondary.Close()
+
+	t.Run("LoadToolset merges tools and reports collisions", func(t *testing.T) {
+		mc := newMultiClient(t, primary, secondary)
+
+		tools, err := mc.LoadToolset("", context.Background())
+		require.Len(t, tools, 3)
+
+		byName := make(map[string]string)
+		for _, tool := range tools {
+			byName[tool.Name()] = tool.Description()
+		}
+		assert.Equal(t, map[string]string{"search": "primary", "book": "primary", "cancel": "secondary"}, byName)
+
+		require.NoError(t, err, "collisions between healthy clients are not errors")
+		assert.Equal(t, []ToolCollision{{Name: "book", Clients: []int{0, 1}}}, mc.Collisions())
+	})
+
+	t.Run("Replicas offering the same tools load without error", func(t *testing.T) {
+		replica, _ := newToolServer(t, []string{"search", "book"}, "replica", 0)
+		defer replica.Close()
+		mc := newMultiClient(t, primary, replica)
+		assert.Nil(t, mc.Collisions())
+
+		tools, err := mc.LoadToolset("", context.Background())
+		require.NoError(t, err)
+		assert.Len(t, tools, 2)
+		assert.Len(t, mc.Collisions(), 2)
+	})
+
+	t.Run("LoadToolset returns the remaining tools when a client fails", func(t *testing.T) {
+		down, _ := newToolServer(t, nil, "down", 0)
+		down.Close()
+		mc := newMultiClient(t, down, secondary)
+
+		tools, err := mc.LoadToolset("", context.Background())
+		assert.Len(t, tools, 2)
+
+		var multiErr *MultiClientError
+		require.ErrorAs(t, err, &multiErr)
+		require.Len(t, multiErr.ClientErrors, 1)
+		assert.Equal(t, 0, multiErr.ClientErrors[0].Index)
+		assert.Equal(t, down.URL, multiErr.ClientErrors[0].BaseURL)
+	})
+
+	t.Run("LoadTool falls through to the client providing the tool", func(t *testing.T) {
+		mc := newMultiClient(t, primary, secondary)
+
+		tool, err := mc.LoadTool("cancel", context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", tool.Description())
+
+		_, err = mc.LoadTool("missing", context.Background())
+		var multiErr *MultiClientError
+		require.ErrorAs(t, err, &multiErr)
+		assert.Len(t, multiErr.ClientErrors, 2)
+	})
+
+	t.Run("Rejects invalid client lists", func(t *testing.T) {
+		_, err := NewMultiClient()
+		assert.Error(t, err)
+		_, err = NewMultiClient(nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestMultiClient_CallTool(t *testing.T) {
+	t.Run("Fails over when the primary is down", func(t *testing.T) {
+		primary, _ := newToolServer(t, []string{"search"}, "primary", 0)
+		primary.Close()
+		secondary, secondaryCalls := newToolServer(t, []string{"search"}, "secondary", 0)
+		defer secondary.Close()
+		mc := newMultiClient(t, primary, secondary)
+
+		result, err := mc.CallTool(context.Background(), "search", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", result)
+		assert.EqualValues(t, 1, secondaryCalls.Load())
+	})
+
+	t.Run("Fails over on a 5xx response", func(t *testing.T) {
+		primary, primaryCalls := newToolServer(t, []string{"search"}, "primary", http.StatusServiceUnavailable)
+		defer primary.Close()
+		secondary, _ := newToolServer(t, []string{"search"}, "secondary", 0)
+		defer secondary.Close()
+		mc := newMultiClient(t, primary, secondary)
+
+		result, err := mc.CallTool(context.Background(), "search", map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "secondary", result)
+		assert.EqualValues(t, 1, primaryCalls.Load())
+	})
+
+	t.Run("Does not fail over on a 4xx response", func(t *testing.T) {
+		primary, _ := newToolServer(t, []string{"search"}, "primary", http.StatusBadRequest)
+		defer primary.Close()
+		secondary, secondaryCalls := newToolServer(t, []string{"search"}, "secondary", 0)
+		defer secondary.Close()
+		mc := newMultiClient(t, primary, secondary)
+
+		_, err := mc.CallTool(context.Background(), "search", map[string]any{})
+		require.Error(t, err)
+		var multiErr *MultiClientError
+		assert.False(t, errors.As(err, &multiErr))
+		assert.EqualValues(t, 0, secondaryCalls.Load())
+	})
+
+	t.Run("Reuses the loaded tool across calls", func(t *testing.T) {
+		backend, calls := newToolServer(t, []string{"search"}, "primary", 0)
+		defer backend.Close()
+		var lists atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			var req mcpRPCRequest
+			_ = json.Unmarshal(body, &req)
+			if req.Method == "tools/list" {
+				lists.Add(1)
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			backend.Config.Handler.ServeHTTP(w, r)
+		}))
+		defer server.Close()
+		mc := newMultiClient(t, server)
+
+		for range 3 {
+			result, err := mc.CallTool(context.Background(), "search", map[string]any{})
+			require.NoError(t, err)
+			assert.Equal(t, "primary", result)
+		}
+		assert.EqualValues(t, 3, calls.Load())
+		assert.EqualValues(t, 1, lists.Load())
+
+		_, err := mc.CallTool(context.Background(), "search", map[string]any{}, WithRetry(1, ConstantBackoff(0)))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, lists.Load(), "calls with options load the tool again")
+	})
+
+	t.Run("Reports every client when all fail", func(t *testing.T) {
+		primary, _ := newToolServer(t, []string{"search"}, "primary", http.StatusInternalServerError)
+		defer primary.Close()
+		secondary, _ := newToolServer(t, []string{"search"}, "secondary", http.StatusBadGateway)
+		defer secondary.Close()
+		mc := newMultiClient(t, primary, secondary)
+
+		_, err := mc.CallTool(context.Background(), "search", map[string]any{})
+		var multiErr *MultiClientError
+		require.ErrorAs(t, err, &multiErr)
+		assert.Len(t, multiErr.ClientErrors, 2)
+		assert.Contains(t, err.Error(), "client 1")
+	})
+}
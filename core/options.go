@@ -15,9 +15,21 @@
 package core
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"net"
 	"net/http"
+	"reflect"
+	"slices"
+	"time"
 
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"golang.org/x/oauth2"
 )
 
@@ -26,6 +38,119 @@ import (
 // ClientOption configures a ToolboxClient at creation time.
 type ClientOption func(*ToolboxClient) error
 
+// invokeOptions holds the client-wide settings that tools consult at
+// invocation time. Tools share a pointer to their client's options, so they
+// must not be modified once the client has been constructed.
+type invokeOptions struct {
+	warningHandler func(toolName string, warnings []string)
+	canonicalInput bool
+	numberAsJSON   bool
+	beforeInvoke   func(ctx context.Context, toolName string, input map[string]any)
+	afterInvoke    func(ctx context.Context, toolName string, result any, err error, dur time.Duration)
+
+	callerIdentity       func(ctx context.Context) (string, error)
+	callerIdentityHeader string
+
+	authHeaderFormatter func(service string) (headerName string, valueFn func(token string) string)
+
+	retryableStatusCodes    map[int]struct{}
+	replaceRetryableDefault bool
+
+	httpTrace       func(toolName string, timing HTTPTiming)
+	attemptObserver func(toolName string, attempt int, err error, willRetry bool)
+
+	inputSanitizer func(input map[string]any) (map[string]any, error)
+	validateUTF8   bool
+
+	validateOutput bool
+
+	observerSampleRate    float64
+	observerSampleRateSet bool
+
+	metricsObserver MetricsObserver
+
+	logger *slog.Logger
+}
+
+// log returns the registered logger, or a logger that discards its records.
+func (o *invokeOptions) log() *slog.Logger {
+	if o.logger == nil {
+		return discardLogger
+	}
+	return o.logger
+}
+
+// discardLogger is used when no logger is registered with WithLogger.
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// metrics returns the registered metrics observer, or a no-op observer.
+func (o *invokeOptions) metrics() MetricsObserver {
+	if o.metricsObserver == nil {
+		return NoopMetricsObserver{}
+	}
+	return o.metricsObserver
+}
+
+// sampleObservation decides whether the observers of a single invocation
+// should fire on success, according to WithObserverSampleRate. Without a
+// sample rate every invocation is observed.
+func (o *invokeOptions) sampleObservation() bool {
+	if !o.observerSampleRateSet {
+		return true
+	}
+	return rand.Float64() < o.observerSampleRate
+}
+
+// retryableStatus reports whether a failed invocation with the given HTTP
+// status may be retried. By default 429 and all 5xx statuses are retryable;
+// WithRetryableStatusCodes extends that set and
+// WithOnlyRetryableStatusCodes replaces it.
+func (o *invokeOptions) retryableStatus(code int) bool {
+	if _, ok := o.retryableStatusCodes[code]; ok {
+		return true
+	}
+	if o.replaceRetryableDefault {
+		return false
+	}
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
+
+// retryable reports whether an invocation error is a transient failure that
+// the retry logic may attempt again: a retryable status or a network error.
+func (o *invokeOptions) retryable(err error) bool {
+	var statusErr *transport.StatusError
+	if errors.As(err, &statusErr) {
+		return o.retryableStatus(statusErr.StatusCode)
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// authHeader returns the HTTP header name and value that carry a token from
+// the given auth service. By default the token is sent as-is in the
+// "<service>_token" header expected by Toolbox; WithAuthHeaderFormatter
+// overrides this.
+func (o *invokeOptions) authHeader(service, token string) (string, string, error) {
+	if o.authHeaderFormatter == nil {
+		return service + "_token", token, nil
+	}
+	headerName, valueFn := o.authHeaderFormatter(service)
+	if err := validateHeaderName(headerName); err != nil {
+		return "", "", fmt.Errorf("auth header formatter returned an invalid header for service '%s': %w", service, err)
+	}
+	if valueFn == nil {
+		return headerName, token, nil
+	}
+	return headerName, valueFn(token), nil
+}
+
+// DefaultCallerIdentityHeader is the header used by WithCallerIdentity unless
+// WithCallerIdentityHeader selects another one.
+const DefaultCallerIdentityHeader = "X-Caller-Identity"
+
+// defaultInvokeOptions is used by tools that were not created by a client.
+var defaultInvokeOptions = invokeOptions{}
+
 // Constructor for a newToolConfig which initializes the maps for auth token sources and bound parameters
 func newToolConfig() *ToolConfig {
 	return &ToolConfig{
@@ -64,6 +189,46 @@ func WithProtocol(p Protocol) ClientOption {
 	}
 }
 
+// WithCommand configures the client to launch a local MCP server with the
+// given command and arguments, and to speak to it over the stdio transport.
+// The server is started on first use and stopped by ToolboxClient.Close. It
+// selects the Stdio protocol, and the client's URL is ignored.
+//
+// Requests are not sent over HTTP, so HTTP options and headers, including
+// auth tokens, do not apply to the server.
+func WithCommand(name string, args ...string) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if name == "" {
+			return fmt.Errorf("WithCommand: command name cannot be empty")
+		}
+		if tc.command != "" {
+			return fmt.Errorf("command is already set and cannot be overridden")
+		}
+		tc.command = name
+		tc.commandArgs = append([]string(nil), args...)
+		return nil
+	}
+}
+
+// WithTransport makes the client use the given transport for every request,
+// instead of one of the built-in protocols, for example an in-memory
+// transport in tests or a custom wire protocol. It cannot be combined with
+// WithProtocol or WithCommand, and HTTP options only apply if the transport
+// itself uses them. ToolboxClient.Close closes the transport if it implements
+// io.Closer.
+func WithTransport(t transport.Transport) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if t == nil {
+			return fmt.Errorf("WithTransport: transport cannot be nil")
+		}
+		if tc.customTransport != nil {
+			return fmt.Errorf("transport is already set and cannot be overridden")
+		}
+		tc.customTransport = t
+		return nil
+	}
+}
+
 // WithHTTPClient provides a custom http.Client to the ToolboxClient.
 func WithHTTPClient(client *http.Client) ClientOption {
 	return func(tc *ToolboxClient) error {
@@ -71,6 +236,180 @@ func WithHTTPClient(client *http.Client) ClientOption {
 			return fmt.Errorf("WithHTTPClient: provided http.Client cannot be nil")
 		}
 		tc.httpClient = client
+		tc.httpClientSet = true
+		return nil
+	}
+}
+
+// TransportConfig tunes the connection pool of the client's default HTTP
+// transport. Zero fields keep the defaults of http.DefaultTransport.
+type TransportConfig struct {
+	// MaxIdleConns limits the idle connections kept across all hosts.
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the idle connections kept per host.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept before it is
+	// closed.
+	IdleConnTimeout time.Duration
+	// DisableKeepAlives makes every request use a new connection.
+	DisableKeepAlives bool
+}
+
+// WithTransportConfig builds the client's HTTP transport with the given
+// connection pool and keep-alive settings, for high-throughput services. It
+// cannot be combined with WithHTTPClient; configure the transport of a custom
+// http.Client directly instead.
+func WithTransportConfig(cfg TransportConfig) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if cfg.MaxIdleConns < 0 || cfg.MaxIdleConnsPerHost < 0 || cfg.IdleConnTimeout < 0 {
+			return fmt.Errorf("WithTransportConfig: limits and timeouts cannot be negative")
+		}
+		if tc.transportConfig != nil {
+			return fmt.Errorf("transport config is already set and cannot be overridden")
+		}
+		tc.transportConfig = &cfg
+		return nil
+	}
+}
+
+// WithManifestCache caches the manifests fetched by LoadTool and LoadToolset
+// for the given time to live, keyed by tool or toolset name, so that
+// reloading the same tools within that window does not contact the server.
+// Use ToolboxClient.InvalidateManifestCache to discard cached manifests
+// early.
+func WithManifestCache(ttl time.Duration) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if ttl <= 0 {
+			return fmt.Errorf("WithManifestCache: ttl must be positive, got %v", ttl)
+		}
+		if tc.manifestCache != nil {
+			return fmt.Errorf("manifest cache is already set and cannot be overridden")
+		}
+		tc.manifestCache = newManifestCache(ttl)
+		return nil
+	}
+}
+
+// WithToolsetBuildConcurrency lets LoadToolset construct up to n tools of a
+// toolset concurrently, which speeds up loading toolsets with many tools. The
+// returned tools and any error are the same as when building sequentially,
+// which is the default.
+func WithToolsetBuildConcurrency(n int) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if n < 1 {
+			return fmt.Errorf("WithToolsetBuildConcurrency: concurrency must be at least 1, got %d", n)
+		}
+		if tc.toolsetBuildConcurrency != 0 {
+			return fmt.Errorf("toolset build concurrency is already set and cannot be overridden")
+		}
+		tc.toolsetBuildConcurrency = n
+		return nil
+	}
+}
+
+// WithBatchConcurrency sets how many calls InvokeBatch runs at once. The
+// default is 8.
+func WithBatchConcurrency(n int) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if n < 1 {
+			return fmt.Errorf("WithBatchConcurrency: concurrency must be at least 1, got %d", n)
+		}
+		if tc.batchConcurrency != 0 {
+			return fmt.Errorf("batch concurrency is already set and cannot be overridden")
+		}
+		tc.batchConcurrency = n
+		return nil
+	}
+}
+
+// RoundTripMiddleware wraps an http.RoundTripper to add behavior to every
+// request the client sends, such as extra headers, response rewriting or
+// audit logging. A middleware may also answer a request itself without
+// calling next.
+type RoundTripMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// WithMiddleware wraps the client's HTTP transport in the given middleware.
+// The first middleware is the outermost: it sees each request first and its
+// response last. The chain wraps the transport of a client given with
+// WithHTTPClient, or http.DefaultTransport, and is built on a copy, so the
+// caller's http.Client is not modified. The option may be given more than
+// once; later middleware is nested inside earlier middleware.
+func WithMiddleware(mw ...RoundTripMiddleware) ClientOption {
+	return func(tc *ToolboxClient) error {
+		for i, m := range mw {
+			if m == nil {
+				return fmt.Errorf("WithMiddleware: middleware at index %d cannot be nil", i)
+			}
+		}
+		tc.middleware = append(tc.middleware, mw...)
+		return nil
+	}
+}
+
+// WithForceHTTP1 disables HTTP/2 on the client's transport, for load
+// balancers and proxies that mishandle it. It applies to the default client
+// as well as to one given with WithHTTPClient, whose transport must then be an
+// *http.Transport.
+func WithForceHTTP1() ClientOption {
+	return func(tc *ToolboxClient) error {
+		tc.forceHTTP1 = true
+		return nil
+	}
+}
+
+// WithMaxManifestSize limits the size, in bytes, of the tool definitions the
+// client reads from the server, so that a huge manifest cannot exhaust
+// memory. Loading fails with an error wrapping transport.ErrManifestTooLarge
+// when the limit is exceeded. Defaults to 8 MiB.
+func WithMaxManifestSize(n int64) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if n <= 0 {
+			return fmt.Errorf("WithMaxManifestSize: size must be positive, got %d", n)
+		}
+		if tc.maxManifestSize != 0 {
+			return fmt.Errorf("max manifest size is already set and cannot be overridden")
+		}
+		tc.maxManifestSize = n
+		return nil
+	}
+}
+
+// WithMCPMethodOverrides maps canonical MCP method names, such as
+// "tools/list", "tools/call" or "initialize", to the names used by a
+// non-standard server, for example "tool/list". Methods without an entry
+// keep their spec name.
+func WithMCPMethodOverrides(overrides map[string]string) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if len(overrides) == 0 {
+			return fmt.Errorf("WithMCPMethodOverrides: at least one override must be provided")
+		}
+		if tc.methodOverrides != nil {
+			return fmt.Errorf("MCP method overrides are already set and cannot be overridden")
+		}
+		for method, name := range overrides {
+			if method == "" || name == "" {
+				return fmt.Errorf("WithMCPMethodOverrides: method names cannot be empty, got '%s' -> '%s'", method, name)
+			}
+		}
+		tc.methodOverrides = maps.Clone(overrides)
+		return nil
+	}
+}
+
+// WithRequestIDGenerator sets the function that produces the IDs of the
+// JSON-RPC requests the client sends, for example to embed a trace or span ID
+// that can be found in the server's logs, or to make IDs predictable in
+// tests. Each call must return a distinct string or number. Notifications
+// carry no ID and are unaffected. Defaults to random UUIDs.
+func WithRequestIDGenerator(fn func() any) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithRequestIDGenerator: generator function cannot be nil")
+		}
+		if tc.requestIDGenerator != nil {
+			return fmt.Errorf("request ID generator is already set and cannot be overridden")
+		}
+		tc.requestIDGenerator = fn
 		return nil
 	}
 }
@@ -78,6 +417,9 @@ func WithHTTPClient(client *http.Client) ClientOption {
 // WithClientHeaderString adds a static string value as a client-wide HTTP header.
 func WithClientHeaderString(headerName string, value string) ClientOption {
 	return func(tc *ToolboxClient) error {
+		if err := validateHeaderName(headerName); err != nil {
+			return err
+		}
 		if _, exists := tc.clientHeaderSources[headerName]; exists {
 			return fmt.Errorf("client header '%s' is already set and cannot be overridden", headerName)
 		}
@@ -90,6 +432,9 @@ func WithClientHeaderString(headerName string, value string) ClientOption {
 // WithClientHeaderTokenSource adds a dynamic client-wide HTTP header from a TokenSource.
 func WithClientHeaderTokenSource(headerName string, value oauth2.TokenSource) ClientOption {
 	return func(tc *ToolboxClient) error {
+		if err := validateHeaderName(headerName); err != nil {
+			return err
+		}
 		if _, exists := tc.clientHeaderSources[headerName]; exists {
 			return fmt.Errorf("client header '%s' is already set and cannot be overridden", headerName)
 		}
@@ -101,6 +446,383 @@ func WithClientHeaderTokenSource(headerName string, value oauth2.TokenSource) Cl
 	}
 }
 
+// WithClientHeaderFunc adds a client-wide HTTP header whose value is computed
+// by fn for every request, using that request's context. This suits values
+// that differ per request, such as an idempotency key or a value carried in
+// the context. If fn returns an error, the request fails.
+func WithClientHeaderFunc(headerName string, fn func(ctx context.Context) (string, error)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if err := validateHeaderName(headerName); err != nil {
+			return err
+		}
+		if _, exists := tc.clientHeaderSources[headerName]; exists {
+			return fmt.Errorf("client header '%s' is already set and cannot be overridden", headerName)
+		}
+		if fn == nil {
+			return fmt.Errorf("WithClientHeaderFunc: provided function for header '%s' cannot be nil", headerName)
+		}
+		tc.clientHeaderSources[headerName] = headerFuncSource(fn)
+		return nil
+	}
+}
+
+// WithClientHeaderContextSource adds a dynamic client-wide HTTP header from a
+// ContextTokenSource, which receives the context of each request so that
+// fetching the value can be cancelled or bounded by a deadline.
+func WithClientHeaderContextSource(headerName string, value ContextTokenSource) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if err := validateHeaderName(headerName); err != nil {
+			return err
+		}
+		if _, exists := tc.clientHeaderSources[headerName]; exists {
+			return fmt.Errorf("client header '%s' is already set and cannot be overridden", headerName)
+		}
+		if value == nil {
+			return fmt.Errorf("WithClientHeaderContextSource: provided ContextTokenSource for header '%s' cannot be nil", headerName)
+		}
+		tc.clientHeaderSources[headerName] = contextSourceAdapter{src: value}
+		return nil
+	}
+}
+
+// WithMinServerVersion makes the client refuse to load tools from a Toolbox
+// server whose reported version is below the given semantic version.
+func WithMinServerVersion(version string) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if tc.minServerVersion != "" {
+			return fmt.Errorf("minimum server version is already set and cannot be overridden")
+		}
+		if _, _, err := parseSemver(version); err != nil {
+			return fmt.Errorf("WithMinServerVersion: %w", err)
+		}
+		tc.minServerVersion = version
+		return nil
+	}
+}
+
+// WithCanonicalInput makes every tool invocation canonicalize its payload
+// before it is sent. The merged user input and bound parameters are
+// normalized through a JSON round trip, so that values which encode to the
+// same JSON (for example a struct and the equivalent map) produce
+// byte-identical request payloads with sorted object keys and numbers
+// preserved exactly as encoded. Array element order is part of a value's
+// meaning and is never changed.
+func WithCanonicalInput() ClientOption {
+	return func(tc *ToolboxClient) error {
+		tc.invokeOpts.canonicalInput = true
+		return nil
+	}
+}
+
+// WithNumberAsJSONNumber makes every tool invocation decode a JSON result
+// before returning it, keeping numbers as json.Number rather than float64 so
+// that large integers are preserved exactly. Results that are not valid JSON
+// are returned unchanged as strings.
+func WithNumberAsJSONNumber() ClientOption {
+	return func(tc *ToolboxClient) error {
+		tc.invokeOpts.numberAsJSON = true
+		return nil
+	}
+}
+
+// WithRetryableStatusCodes adds HTTP status codes that the retry logic treats
+// as transient, on top of the default set of 429 and 5xx. Statuses outside
+// the resulting set fail immediately.
+func WithRetryableStatusCodes(codes ...int) ClientOption {
+	return func(tc *ToolboxClient) error {
+		return addRetryableStatusCodes(tc, codes)
+	}
+}
+
+// WithOnlyRetryableStatusCodes makes the retry logic treat only the given HTTP
+// status codes as transient, replacing the default set of 429 and 5xx.
+func WithOnlyRetryableStatusCodes(codes ...int) ClientOption {
+	return func(tc *ToolboxClient) error {
+		tc.invokeOpts.replaceRetryableDefault = true
+		return addRetryableStatusCodes(tc, codes)
+	}
+}
+
+// addRetryableStatusCodes validates and records retryable status codes.
+func addRetryableStatusCodes(tc *ToolboxClient, codes []int) error {
+	if len(codes) == 0 {
+		return fmt.Errorf("at least one retryable status code must be provided")
+	}
+	if tc.invokeOpts.retryableStatusCodes == nil {
+		tc.invokeOpts.retryableStatusCodes = make(map[int]struct{}, len(codes))
+	}
+	for _, code := range codes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid HTTP status code %d", code)
+		}
+		tc.invokeOpts.retryableStatusCodes[code] = struct{}{}
+	}
+	return nil
+}
+
+// WithValidateUTF8 makes every tool invocation reject string parameter
+// values, including strings inside arrays and objects, that are not valid
+// UTF-8. The check is off by default to avoid its overhead.
+func WithValidateUTF8() ClientOption {
+	return func(tc *ToolboxClient) error {
+		tc.invokeOpts.validateUTF8 = true
+		return nil
+	}
+}
+
+// WithBeforeInvoke registers a function that is called at the start of every
+// tool invocation, before the input is validated. The hook receives a copy of
+// the caller's input map.
+func WithBeforeInvoke(fn func(ctx context.Context, toolName string, input map[string]any)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithBeforeInvoke: provided hook cannot be nil")
+		}
+		if tc.invokeOpts.beforeInvoke != nil {
+			return fmt.Errorf("before-invoke hook is already set and cannot be overridden")
+		}
+		tc.invokeOpts.beforeInvoke = fn
+		return nil
+	}
+}
+
+// WithAfterInvoke registers a function that is called once every tool
+// invocation has finished, with its result, error and total duration. It also
+// runs when the invocation fails before reaching the server, for example on a
+// validation error.
+func WithAfterInvoke(fn func(ctx context.Context, toolName string, result any, err error, dur time.Duration)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithAfterInvoke: provided hook cannot be nil")
+		}
+		if tc.invokeOpts.afterInvoke != nil {
+			return fmt.Errorf("after-invoke hook is already set and cannot be overridden")
+		}
+		tc.invokeOpts.afterInvoke = fn
+		return nil
+	}
+}
+
+// WithMetricsObserver registers an observer that is told the duration and
+// outcome of every tool invocation and of every LoadTool and LoadToolset
+// call, including those that fail. Unlike the invoke hooks, the observer is
+// not subject to WithObserverSampleRate.
+func WithMetricsObserver(obs MetricsObserver) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if obs == nil {
+			return fmt.Errorf("WithMetricsObserver: provided observer cannot be nil")
+		}
+		if tc.invokeOpts.metricsObserver != nil {
+			return fmt.Errorf("metrics observer is already set and cannot be overridden")
+		}
+		tc.invokeOpts.metricsObserver = obs
+		return nil
+	}
+}
+
+// WithLogger sets a logger that receives structured logs about manifest
+// fetches, the protocol handshake, tool invocations and token resolution
+// failures. Wire details are logged at Debug level, retryable failures at
+// Warn and terminal failures at Error. Header values, including auth tokens,
+// are always redacted. Without this option nothing is logged.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if logger == nil {
+			return fmt.Errorf("WithLogger: provided logger cannot be nil")
+		}
+		if tc.invokeOpts.logger != nil {
+			return fmt.Errorf("logger is already set and cannot be overridden")
+		}
+		tc.invokeOpts.logger = logger
+		return nil
+	}
+}
+
+// WithCallerIdentity registers a function that resolves the identity of the
+// end user on whose behalf a tool is invoked. It runs for every invocation,
+// using the invocation's context, and its result is sent in the
+// DefaultCallerIdentityHeader header (see WithCallerIdentityHeader). If the
+// function returns an error, the invocation is aborted.
+func WithCallerIdentity(fn func(ctx context.Context) (string, error)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithCallerIdentity: provided function cannot be nil")
+		}
+		if tc.invokeOpts.callerIdentity != nil {
+			return fmt.Errorf("caller identity is already set and cannot be overridden")
+		}
+		tc.invokeOpts.callerIdentity = fn
+		return nil
+	}
+}
+
+// WithCallerIdentityHeader sets the HTTP header that carries the identity
+// resolved by WithCallerIdentity.
+func WithCallerIdentityHeader(headerName string) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if headerName == "" {
+			return fmt.Errorf("WithCallerIdentityHeader: header name cannot be empty")
+		}
+		if err := validateHeaderName(headerName); err != nil {
+			return err
+		}
+		if tc.invokeOpts.callerIdentityHeader != "" {
+			return fmt.Errorf("WithCallerIdentityHeader is already set and cannot be overridden")
+		}
+		tc.invokeOpts.callerIdentityHeader = headerName
+		return nil
+	}
+}
+
+// WithAuthHeaderFormatter overrides how tokens from auth token sources (see
+// WithAuthTokenSource) are sent when a tool is invoked. For each auth service,
+// fn returns the name of the header carrying its token and an optional
+// function that formats the token into the header value; a nil valueFn sends
+// the token unchanged. By default a token for service "my_service" is sent in
+// the "my_service_token" header.
+//
+// For example, to send the token as a bearer token:
+//
+//	WithAuthHeaderFormatter(func(service string) (string, func(string) string) {
+//		return "Authorization", func(token string) string { return "Bearer " + token }
+//	})
+func WithAuthHeaderFormatter(fn func(service string) (headerName string, valueFn func(token string) string)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithAuthHeaderFormatter: provided function cannot be nil")
+		}
+		if tc.invokeOpts.authHeaderFormatter != nil {
+			return fmt.Errorf("auth header formatter is already set and cannot be overridden")
+		}
+		tc.invokeOpts.authHeaderFormatter = fn
+		return nil
+	}
+}
+
+// WithWarningHandler registers a function that is called with any non-fatal
+// warnings the server attaches to a tool invocation response. When unset,
+// warnings are ignored.
+func WithWarningHandler(fn func(toolName string, warnings []string)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithWarningHandler: provided handler cannot be nil")
+		}
+		if tc.invokeOpts.warningHandler != nil {
+			return fmt.Errorf("warning handler is already set and cannot be overridden")
+		}
+		tc.invokeOpts.warningHandler = fn
+		return nil
+	}
+}
+
+// WithHTTPTrace registers a function that is called after every tool
+// invocation with the low-level HTTP phase timings (DNS, connect, TLS and
+// time to first byte) of the requests it made, to help tell network latency
+// apart from server latency. It is called for failed invocations too.
+func WithHTTPTrace(fn func(toolName string, timing HTTPTiming)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithHTTPTrace: provided function cannot be nil")
+		}
+		if tc.invokeOpts.httpTrace != nil {
+			return fmt.Errorf("HTTP trace function is already set and cannot be overridden")
+		}
+		tc.invokeOpts.httpTrace = fn
+		return nil
+	}
+}
+
+// WithAttemptObserver registers a function that is called after every
+// attempt to call the server during a tool invocation, to help diagnose
+// flapping backends when retries are enabled. attempt counts from 1, err is
+// the attempt's error, or nil for a successful attempt, and willRetry reports
+// whether another attempt follows. The final attempt is always reported with
+// willRetry false.
+func WithAttemptObserver(fn func(toolName string, attempt int, err error, willRetry bool)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithAttemptObserver: provided function cannot be nil")
+		}
+		if tc.invokeOpts.attemptObserver != nil {
+			return fmt.Errorf("attempt observer is already set and cannot be overridden")
+		}
+		tc.invokeOpts.attemptObserver = fn
+		return nil
+	}
+}
+
+// WithValidateOutput makes every tool invocation check its result against the
+// output schema the server declared for the tool, if any, and return an error
+// naming the offending field when the result does not conform. This catches
+// server bugs early. The check is off by default.
+func WithValidateOutput() ClientOption {
+	return func(tc *ToolboxClient) error {
+		tc.invokeOpts.validateOutput = true
+		return nil
+	}
+}
+
+// WithObserverSampleRate limits the invocation observers registered with
+// WithBeforeInvoke, WithAfterInvoke and WithHTTPTrace to a random fraction
+// of successful invocations, given as rate between 0.0 and 1.0. For example,
+// a rate of 0.01 observes about 1% of them.
+//
+// Failed invocations are always reported to the after-invoke hook and the
+// HTTP trace function. The before-invoke hook runs before the outcome is
+// known, so it only fires for sampled invocations.
+func WithObserverSampleRate(rate float64) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if math.IsNaN(rate) || rate < 0 || rate > 1 {
+			return fmt.Errorf("WithObserverSampleRate: rate must be between 0.0 and 1.0, got %v", rate)
+		}
+		if tc.invokeOpts.observerSampleRateSet {
+			return fmt.Errorf("observer sample rate is already set and cannot be overridden")
+		}
+		tc.invokeOpts.observerSampleRate = rate
+		tc.invokeOpts.observerSampleRateSet = true
+		return nil
+	}
+}
+
+// WithInputSanitizer registers a function that cleans up the input of every
+// tool invocation before it is validated, for example to drop internal keys a
+// model may have invented. It receives a copy of the input and returns the
+// input to use, or an error to reject the invocation.
+func WithInputSanitizer(fn func(input map[string]any) (map[string]any, error)) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if fn == nil {
+			return fmt.Errorf("WithInputSanitizer: provided function cannot be nil")
+		}
+		if tc.invokeOpts.inputSanitizer != nil {
+			return fmt.Errorf("input sanitizer is already set and cannot be overridden")
+		}
+		tc.invokeOpts.inputSanitizer = fn
+		return nil
+	}
+}
+
+// WithToolsetHeaders adds static HTTP headers that are sent only when loading
+// the named toolset and when invoking tools loaded from it with LoadToolset.
+// Use "" for the default toolset. When a header is also set client-wide, the
+// toolset-specific value wins for that toolset's requests.
+func WithToolsetHeaders(toolsetName string, headers map[string]string) ClientOption {
+	return func(tc *ToolboxClient) error {
+		if _, exists := tc.toolsetHeaders[toolsetName]; exists {
+			return fmt.Errorf("headers for toolset '%s' are already set and cannot be overridden", toolsetName)
+		}
+		for name := range headers {
+			if err := validateHeaderName(name); err != nil {
+				return err
+			}
+		}
+		if tc.toolsetHeaders == nil {
+			tc.toolsetHeaders = make(map[string]map[string]string)
+		}
+		tc.toolsetHeaders[toolsetName] = maps.Clone(headers)
+		return nil
+	}
+}
+
 // WithDefaultToolOptions provides default Options that will be applied to every tool
 // loaded by this client.
 func WithDefaultToolOptions(opts ...ToolOption) ClientOption {
@@ -118,10 +840,23 @@ func WithDefaultToolOptions(opts ...ToolOption) ClientOption {
 
 // ToolConfig holds all configurable aspects for creating or deriving a tool.
 type ToolConfig struct {
-	AuthTokenSources map[string]oauth2.TokenSource
-	BoundParams      map[string]any
-	Strict           bool
-	strictSet        bool
+	AuthTokenSources       map[string]oauth2.TokenSource
+	BoundParams            map[string]any
+	Strict                 bool
+	strictSet              bool
+	ToolFilter             func(toolName string) bool
+	ParamTransforms        map[string]func(v any) (any, error)
+	MaxAttempts            int
+	Backoff                BackoffStrategy
+	InvokeTimeout          time.Duration
+	UnbindParams           []string
+	ParamAliases           map[string]string
+	CaseInsensitiveParams  bool
+	caseInsensitiveSet     bool
+	IdempotencyKey         string
+	AutoIdempotencyKey     bool
+	AggregateValidation    bool
+	aggregateValidationSet bool
 }
 
 // ToolOption defines a single, universal type for a functional option that configures a tool.
@@ -136,7 +871,8 @@ type Float interface {
 	~float32 | ~float64
 }
 
-// WithStrict provides an option to enable strict validation for LoadToolset.
+// WithStrict provides an option to enable strict validation for LoadToolset,
+// or to disable it for LoadTool, which is strict by default.
 func WithStrict(strict bool) ToolOption {
 	return func(c *ToolConfig) error {
 		if c.strictSet {
@@ -148,6 +884,186 @@ func WithStrict(strict bool) ToolOption {
 	}
 }
 
+// WithToolFilter provides an option for LoadToolset to include only the
+// tools for which the predicate returns true. Excluded tools are not
+// constructed, so options used only by them are reported as unused.
+func WithToolFilter(predicate func(toolName string) bool) ToolOption {
+	return func(c *ToolConfig) error {
+		if predicate == nil {
+			return fmt.Errorf("tool filter cannot be nil")
+		}
+		if c.ToolFilter != nil {
+			return fmt.Errorf("tool filter is already set and cannot be overridden")
+		}
+		c.ToolFilter = predicate
+		return nil
+	}
+}
+
+// WithToolNameGlob provides an option for LoadToolset to include only the
+// tools whose names match a glob pattern, where '*' matches any sequence of
+// characters and '?' matches a single character. A backslash escapes the
+// character that follows it.
+func WithToolNameGlob(pattern string) ToolOption {
+	re, err := compileGlob(pattern)
+	return func(c *ToolConfig) error {
+		if err != nil {
+			return err
+		}
+		return WithToolFilter(re.MatchString)(c)
+	}
+}
+
+// WithParameterTransform provides a function that normalizes the value given
+// for a parameter, such as trimming whitespace, before the value is
+// validated. An error from the function rejects the invocation. Loading a
+// tool fails if it has no parameter with the given name.
+func WithParameterTransform(paramName string, fn func(v any) (any, error)) ToolOption {
+	return func(c *ToolConfig) error {
+		if fn == nil {
+			return fmt.Errorf("transform for parameter '%s' cannot be nil", paramName)
+		}
+		if _, exists := c.ParamTransforms[paramName]; exists {
+			return fmt.Errorf("transform for parameter '%s' is already set and cannot be overridden", paramName)
+		}
+		if c.ParamTransforms == nil {
+			c.ParamTransforms = make(map[string]func(v any) (any, error))
+		}
+		c.ParamTransforms[paramName] = fn
+		return nil
+	}
+}
+
+// WithParamAliases maps parameter names to alternative names that Invoke
+// accepts in their place, such as the camel-case spelling a model may emit
+// for a snake-case parameter. Input keys are renamed to their parameter
+// before validation, and an invocation that gives a parameter under more than
+// one name is rejected. In strict mode, loading a tool fails if it has no
+// parameter with one of the given names.
+func WithParamAliases(aliases map[string][]string) ToolOption {
+	return func(c *ToolConfig) error {
+		for _, paramName := range slices.Sorted(maps.Keys(aliases)) {
+			for _, alias := range aliases[paramName] {
+				if alias == "" {
+					return fmt.Errorf("alias for parameter '%s' cannot be empty", paramName)
+				}
+				if existing, exists := c.ParamAliases[alias]; exists {
+					return fmt.Errorf("alias '%s' is already set for parameter '%s'", alias, existing)
+				}
+				if c.ParamAliases == nil {
+					c.ParamAliases = make(map[string]string)
+				}
+				c.ParamAliases[alias] = paramName
+			}
+		}
+		return nil
+	}
+}
+
+// WithCaseInsensitiveParams makes Invoke accept input keys that match a
+// parameter name, or an alias set with WithParamAliases, in a different case.
+// Exact matches take precedence, and an invocation that gives a parameter
+// under more than one spelling is rejected.
+func WithCaseInsensitiveParams(enabled bool) ToolOption {
+	return func(c *ToolConfig) error {
+		if c.caseInsensitiveSet {
+			return fmt.Errorf("case-insensitive parameter matching is already set and cannot be overridden")
+		}
+		c.CaseInsensitiveParams = enabled
+		c.caseInsensitiveSet = true
+		return nil
+	}
+}
+
+// WithAggregateValidation makes Invoke report every problem with the input
+// at once, such as missing required parameters, values of the wrong type and
+// unexpected keys, joined with errors.Join, instead of stopping at the first
+// one. Each reported error still matches the sentinel errors with errors.Is.
+func WithAggregateValidation(enabled bool) ToolOption {
+	return func(c *ToolConfig) error {
+		if c.aggregateValidationSet {
+			return fmt.Errorf("aggregate validation is already set and cannot be overridden")
+		}
+		c.AggregateValidation = enabled
+		c.aggregateValidationSet = true
+		return nil
+	}
+}
+
+// WithRetry makes Invoke retry the server call on transient failures, that
+// is network errors and the statuses configured with WithRetryableStatusCodes
+// (by default 429 and 5xx), up to maxAttempts attempts in total. Before each
+// retry it waits for the delay chosen by backoff, or, if backoff is nil, an
+// exponential backoff with jitter starting at 100ms. Other failures, such as
+// invalid input or permission errors, are returned immediately, and
+// cancelling the invocation's context aborts the wait.
+func WithRetry(maxAttempts int, backoff BackoffStrategy) ToolOption {
+	return func(c *ToolConfig) error {
+		if maxAttempts < 1 {
+			return fmt.Errorf("WithRetry: maxAttempts must be at least 1, got %d", maxAttempts)
+		}
+		if c.MaxAttempts != 0 {
+			return fmt.Errorf("retry policy is already set and cannot be overridden")
+		}
+		if backoff == nil {
+			backoff = defaultBackoff
+		}
+		c.MaxAttempts = maxAttempts
+		c.Backoff = backoff
+		return nil
+	}
+}
+
+// WithInvokeTimeout bounds every Invoke call of the tool, including any
+// retries, to the given duration, independently of the client's HTTP
+// timeout. It is combined with the caller's context, so the earlier of the
+// two deadlines applies, and an expired timeout is reported as
+// context.DeadlineExceeded.
+func WithInvokeTimeout(d time.Duration) ToolOption {
+	return func(c *ToolConfig) error {
+		if d <= 0 {
+			return fmt.Errorf("WithInvokeTimeout: timeout must be positive, got %v", d)
+		}
+		if c.InvokeTimeout != 0 {
+			return fmt.Errorf("invoke timeout is already set and cannot be overridden")
+		}
+		c.InvokeTimeout = d
+		return nil
+	}
+}
+
+// WithIdempotencyKey sends the given key as the Idempotency-Key header of
+// every Invoke call of the tool, and as the 'idempotencyKey' entry of the
+// request's '_meta' for MCP servers. The key is the same on every retry
+// attempt, so that servers can deduplicate repeated side effects. It is
+// intended for tools derived with ToolFrom for a single logical operation;
+// use WithAutoIdempotencyKey to give each call its own key.
+func WithIdempotencyKey(key string) ToolOption {
+	return func(c *ToolConfig) error {
+		if key == "" {
+			return fmt.Errorf("WithIdempotencyKey: key must not be empty")
+		}
+		if c.IdempotencyKey != "" || c.AutoIdempotencyKey {
+			return fmt.Errorf("idempotency key is already set and cannot be overridden")
+		}
+		c.IdempotencyKey = key
+		return nil
+	}
+}
+
+// WithAutoIdempotencyKey generates a fresh random idempotency key for each
+// Invoke call of the tool and sends it as WithIdempotencyKey does. All retry
+// attempts of a call share its key, while distinct calls get distinct keys.
+func WithAutoIdempotencyKey() ToolOption {
+	return func(c *ToolConfig) error {
+		if c.IdempotencyKey != "" || c.AutoIdempotencyKey {
+			return fmt.Errorf("idempotency key is already set and cannot be overridden")
+		}
+		c.AutoIdempotencyKey = true
+		return nil
+	}
+}
+
 // WithAuthTokenSource provides an authentication token from a standard TokenSource.
 func WithAuthTokenSource(authSourceName string, idToken oauth2.TokenSource) ToolOption {
 	return func(c *ToolConfig) error {
@@ -159,6 +1075,22 @@ func WithAuthTokenSource(authSourceName string, idToken oauth2.TokenSource) Tool
 	}
 }
 
+// WithAuthTokenContextSource provides an authentication token from a
+// ContextTokenSource, which receives the context of each invocation so that
+// fetching the token can be cancelled or bounded by a deadline.
+func WithAuthTokenContextSource(authSourceName string, idToken ContextTokenSource) ToolOption {
+	return func(c *ToolConfig) error {
+		if _, exists := c.AuthTokenSources[authSourceName]; exists {
+			return fmt.Errorf("authentication source '%s' is already set and cannot be overridden", authSourceName)
+		}
+		if idToken == nil {
+			return fmt.Errorf("WithAuthTokenContextSource: provided ContextTokenSource for '%s' cannot be nil", authSourceName)
+		}
+		c.AuthTokenSources[authSourceName] = contextSourceAdapter{src: idToken}
+		return nil
+	}
+}
+
 // WithAuthTokenString provides a static string authentication token.
 func WithAuthTokenString(authSourceName string, idToken string) ToolOption {
 	return func(c *ToolConfig) error {
@@ -182,6 +1114,19 @@ func createBoundParamToolOption(name string, value any) ToolOption {
 	}
 }
 
+// WithUnbindParam provides an option for ToolFrom to remove a parameter
+// binding inherited from the parent tool, so that the parameter must again be
+// provided at invocation. The parameter keeps its original schema.
+func WithUnbindParam(name string) ToolOption {
+	return func(c *ToolConfig) error {
+		if slices.Contains(c.UnbindParams, name) {
+			return fmt.Errorf("duplicate parameter unbinding: parameter '%s' is already unbound", name)
+		}
+		c.UnbindParams = append(c.UnbindParams, name)
+		return nil
+	}
+}
+
 // WithBindParamString binds a static string value to a parameter.
 func WithBindParamString(name string, value string) ToolOption {
 	return createBoundParamToolOption(name, value)
@@ -228,7 +1173,89 @@ func WithBindParamBoolFunc(name string, fn func() (bool, error)) ToolOption {
 	return createBoundParamToolOption(name, fn)
 }
 
+// WithBindParamBytes binds static binary data to a "bytes" parameter. The
+// data is sent base64-encoded in the invocation payload.
+func WithBindParamBytes(name string, data []byte) ToolOption {
+	return createBoundParamToolOption(name, base64.StdEncoding.EncodeToString(data))
+}
+
+// --- Context Bindings ---
+//
+// Parameters bound with the *FromContext variants are read at invocation time
+// from the context passed to Invoke, using the given key as for
+// context.Context.Value. The invocation fails if the context holds no value
+// for the key or a value of the wrong type.
+
+// contextParam resolves a bound parameter from the invocation context.
+type contextParam func(ctx context.Context) (any, error)
+
+// contextValue returns a contextParam reading the value stored under key and
+// converting it with convert, which reports false for unsupported types.
+func contextValue(key any, want string, convert func(v any) (any, bool)) contextParam {
+	return func(ctx context.Context) (any, error) {
+		raw := ctx.Value(key)
+		if raw == nil {
+			return nil, fmt.Errorf("context has no value for key '%v'", key)
+		}
+		value, ok := convert(raw)
+		if !ok {
+			return nil, fmt.Errorf("context value for key '%v' is a %T, not %s", key, raw, want)
+		}
+		return value, nil
+	}
+}
+
+// WithBindParamStringFromContext binds a parameter to the string stored in
+// the invocation context under key.
+func WithBindParamStringFromContext(name string, key any) ToolOption {
+	return createBoundParamToolOption(name, contextValue(key, "a string", func(v any) (any, bool) {
+		s, ok := v.(string)
+		return s, ok
+	}))
+}
+
+// WithBindParamIntFromContext binds a parameter to the integer stored in the
+// invocation context under key. Any Go integer type is accepted.
+func WithBindParamIntFromContext(name string, key any) ToolOption {
+	return createBoundParamToolOption(name, contextValue(key, "an integer", func(v any) (any, bool) {
+		rv := reflect.ValueOf(v)
+		switch {
+		case rv.CanInt():
+			return int(rv.Int()), true
+		case rv.CanUint():
+			return int(rv.Uint()), true
+		}
+		return nil, false
+	}))
+}
+
+// WithBindParamFloatFromContext binds a parameter to the float stored in the
+// invocation context under key. Both float32 and float64 are accepted.
+func WithBindParamFloatFromContext(name string, key any) ToolOption {
+	return createBoundParamToolOption(name, contextValue(key, "a float", func(v any) (any, bool) {
+		rv := reflect.ValueOf(v)
+		if !rv.CanFloat() {
+			return nil, false
+		}
+		return rv.Float(), true
+	}))
+}
+
+// WithBindParamBoolFromContext binds a parameter to the boolean stored in the
+// invocation context under key.
+func WithBindParamBoolFromContext(name string, key any) ToolOption {
+	return createBoundParamToolOption(name, contextValue(key, "a boolean", func(v any) (any, bool) {
+		b, ok := v.(bool)
+		return b, ok
+	}))
+}
+
 // --- Array Bindings ---
+//
+// Functions bound with the *Func variants of the array and map bindings are
+// resolved at invocation time. If such a function returns a nil slice or map,
+// the parameter is omitted from the payload when it is optional, and the
+// invocation fails when it is required.
 
 // WithBindParamStringArray binds a static slice of strings to a parameter.
 func WithBindParamStringArray(name string, value []string) ToolOption {
@@ -377,3 +1404,36 @@ func WithBindParamAnyMap(name string, value map[string]any) ToolOption {
 func WithBindParamAnyMapFunc(name string, fn func() (map[string]any, error)) ToolOption {
 	return createBoundParamToolOption(name, fn)
 }
+
+// --- Struct Bindings ---
+
+// WithBindParamsFromStruct binds every exported field of a struct to the
+// parameter of the same name. Parameter names follow the field's `json` tag,
+// or the field name when there is none, and fields tagged `json:"-"` are
+// skipped. Nested structs are bound as objects, keyed the same way.
+//
+// A field holding its zero value is not bound when it maps to an optional
+// parameter, so that the parameter stays available at invocation. Tag the
+// field with `toolbox:"keepzero"` to bind its zero value anyway.
+//
+// Inputs:
+//   - v: A struct, or a non-nil pointer to a struct.
+//
+// Returns:
+//
+//	A ToolOption that binds one parameter per field. Applying it fails if v is
+//	not a struct or if one of its parameters is already bound.
+func WithBindParamsFromStruct(v any) ToolOption {
+	return func(c *ToolConfig) error {
+		fields, err := structBindings(v)
+		if err != nil {
+			return fmt.Errorf("WithBindParamsFromStruct: %w", err)
+		}
+		for _, f := range fields {
+			if err := createBoundParamToolOption(f.name, f)(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -17,12 +17,17 @@
 package core
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"golang.org/x/oauth2"
 )
 
@@ -70,6 +75,235 @@ func TestWithHTTPClient(t *testing.T) {
 	})
 }
 
+// roundTripperFunc adapts a function to the http.RoundTripper interface.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestWithForceHTTP1(t *testing.T) {
+	assertHTTP1Only := func(t *testing.T, client *ToolboxClient) *http.Transport {
+		t.Helper()
+		tr, ok := client.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("Expected *http.Transport, got %T", client.httpClient.Transport)
+		}
+		if tr.ForceAttemptHTTP2 {
+			t.Error("Expected ForceAttemptHTTP2 to be false")
+		}
+		if tr.TLSNextProto == nil || len(tr.TLSNextProto) != 0 {
+			t.Errorf("Expected an empty, non-nil TLSNextProto map, got %v", tr.TLSNextProto)
+		}
+		if tr.Protocols == nil || tr.Protocols.HTTP2() || !tr.Protocols.HTTP1() {
+			t.Errorf("Expected only HTTP/1 to be enabled, got %v", tr.Protocols)
+		}
+		return tr
+	}
+
+	t.Run("Disables HTTP/2 on the default client", func(t *testing.T) {
+		client, err := NewToolboxClient("https://api.example.com", WithForceHTTP1())
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		tr := assertHTTP1Only(t, client)
+		if tr == http.DefaultTransport {
+			t.Error("http.DefaultTransport must not be modified")
+		}
+	})
+
+	t.Run("Composes with a custom client in any order", func(t *testing.T) {
+		userTransport := &http.Transport{ForceAttemptHTTP2: true, MaxIdleConns: 7}
+		userClient := &http.Client{Transport: userTransport}
+
+		client, err := NewToolboxClient("https://api.example.com", WithForceHTTP1(), WithHTTPClient(userClient))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		tr := assertHTTP1Only(t, client)
+		if tr.MaxIdleConns != 7 {
+			t.Errorf("Expected the user's transport settings to be kept, got MaxIdleConns=%d", tr.MaxIdleConns)
+		}
+		if userClient.Transport != userTransport || !userTransport.ForceAttemptHTTP2 {
+			t.Error("The user's http.Client and transport must not be modified")
+		}
+	})
+
+	t.Run("Failure on a transport that cannot be configured", func(t *testing.T) {
+		custom := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) { return nil, nil })}
+		_, err := NewToolboxClient("https://api.example.com", WithHTTPClient(custom), WithForceHTTP1())
+		if err == nil {
+			t.Fatal("Expected an error for a non-*http.Transport RoundTripper, but got none")
+		}
+		if !strings.Contains(err.Error(), "cannot disable HTTP/2") {
+			t.Errorf("Unexpected error message: %v", err)
+		}
+	})
+}
+
+func TestWithTransportConfig(t *testing.T) {
+	t.Run("Tunes the default transport", func(t *testing.T) {
+		client, err := NewToolboxClient("https://api.example.com", WithTransportConfig(TransportConfig{
+			MaxIdleConns:        200,
+			MaxIdleConnsPerHost: 50,
+			IdleConnTimeout:     30 * time.Second,
+			DisableKeepAlives:   true,
+		}))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		tr, ok := client.httpClient.Transport.(*http.Transport)
+		if !ok {
+			t.Fatalf("Expected *http.Transport, got %T", client.httpClient.Transport)
+		}
+		if tr == http.DefaultTransport {
+			t.Error("http.DefaultTransport must not be modified")
+		}
+		if tr.MaxIdleConns != 200 || tr.MaxIdleConnsPerHost != 50 || tr.IdleConnTimeout != 30*time.Second || !tr.DisableKeepAlives {
+			t.Errorf("Unexpected transport settings: MaxIdleConns=%d MaxIdleConnsPerHost=%d IdleConnTimeout=%v DisableKeepAlives=%t",
+				tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout, tr.DisableKeepAlives)
+		}
+	})
+
+	t.Run("Zero fields keep the defaults and compose with WithForceHTTP1", func(t *testing.T) {
+		client, err := NewToolboxClient("https://api.example.com", WithForceHTTP1(), WithTransportConfig(TransportConfig{MaxIdleConnsPerHost: 10}))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		tr := client.httpClient.Transport.(*http.Transport)
+		defaults := http.DefaultTransport.(*http.Transport)
+		if tr.MaxIdleConnsPerHost != 10 || tr.MaxIdleConns != defaults.MaxIdleConns || tr.IdleConnTimeout != defaults.IdleConnTimeout {
+			t.Errorf("Unexpected transport settings: MaxIdleConns=%d MaxIdleConnsPerHost=%d IdleConnTimeout=%v",
+				tr.MaxIdleConns, tr.MaxIdleConnsPerHost, tr.IdleConnTimeout)
+		}
+		if tr.Protocols == nil || tr.Protocols.HTTP2() {
+			t.Errorf("Expected HTTP/2 to stay disabled, got %v", tr.Protocols)
+		}
+	})
+
+	t.Run("Failure when combined with WithHTTPClient", func(t *testing.T) {
+		_, err := NewToolboxClient("https://api.example.com", WithTransportConfig(TransportConfig{MaxIdleConns: 5}), WithHTTPClient(&http.Client{}))
+		if err == nil || !strings.Contains(err.Error(), "cannot be combined with WithHTTPClient") {
+			t.Errorf("Expected a conflict error, got %v", err)
+		}
+	})
+
+	t.Run("Failure on invalid or repeated config", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithTransportConfig(TransportConfig{IdleConnTimeout: -time.Second})(client); err == nil {
+			t.Error("Expected an error for a negative timeout, but got none")
+		}
+		if err := WithTransportConfig(TransportConfig{})(client); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if err := WithTransportConfig(TransportConfig{})(client); err == nil {
+			t.Error("Expected an error when setting the transport config twice, but got none")
+		}
+	})
+}
+
+func TestWithMiddleware(t *testing.T) {
+	record := func(calls *[]string, name string) RoundTripMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				*calls = append(*calls, name+" request")
+				resp, err := next.RoundTrip(r)
+				*calls = append(*calls, name+" response")
+				return resp, err
+			})
+		}
+	}
+
+	t.Run("Runs in order around the user's transport", func(t *testing.T) {
+		var calls []string
+		userTransport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			calls = append(calls, "transport")
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: r}, nil
+		})
+		userClient := &http.Client{Transport: userTransport}
+
+		client, err := NewToolboxClient("https://api.example.com",
+			WithMiddleware(record(&calls, "first"), record(&calls, "second")),
+			WithHTTPClient(userClient),
+			WithMiddleware(record(&calls, "third")),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if reflect.ValueOf(userClient.Transport).Pointer() != reflect.ValueOf(userTransport).Pointer() {
+			t.Error("The user's http.Client must not be modified")
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, "https://api.example.com", nil)
+		resp, err := client.httpClient.Do(req)
+		if err != nil {
+			t.Fatalf("Request failed unexpectedly: %v", err)
+		}
+		resp.Body.Close()
+
+		want := []string{"first request", "second request", "third request", "transport", "third response", "second response", "first response"}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("Expected calls %v, got %v", want, calls)
+		}
+	})
+
+	t.Run("Can short-circuit a request", func(t *testing.T) {
+		reached := false
+		userClient := &http.Client{Transport: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			reached = true
+			return nil, fmt.Errorf("the server must not be reached")
+		})}
+		stub := func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusServiceUnavailable,
+					Body:       http.NoBody,
+					Header:     http.Header{},
+					Request:    r,
+				}, nil
+			})
+		}
+
+		client, err := NewToolboxClient("https://api.example.com", WithHTTPClient(userClient), WithMiddleware(stub), WithProtocol(MCPv20250618))
+		if err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		_, err = client.LoadTool("any", context.Background())
+		var statusErr *transport.StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Expected the stubbed 503 response, got %v", err)
+		}
+		if reached {
+			t.Error("The request must not reach the underlying transport")
+		}
+	})
+
+	t.Run("Wraps the default transport and composes with WithForceHTTP1", func(t *testing.T) {
+		var inner http.RoundTripper
+		capture := func(next http.RoundTripper) http.RoundTripper {
+			inner = next
+			return next
+		}
+		if _, err := NewToolboxClient("https://api.example.com", WithMiddleware(capture)); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if inner != http.DefaultTransport {
+			t.Errorf("Expected http.DefaultTransport to be wrapped, got %T", inner)
+		}
+
+		if _, err := NewToolboxClient("https://api.example.com", WithMiddleware(capture), WithForceHTTP1()); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if tr, ok := inner.(*http.Transport); !ok || tr == http.DefaultTransport || tr.ForceAttemptHTTP2 {
+			t.Errorf("Expected the middleware to wrap the HTTP/1 transport, got %T", inner)
+		}
+	})
+
+	t.Run("Failure on nil middleware", func(t *testing.T) {
+		if err := WithMiddleware(nil)(newTestClient()); err == nil {
+			t.Error("Expected an error for nil middleware, but got none")
+		}
+	})
+}
+
 func TestWithClientVersion(t *testing.T) {
 	t.Run("Success case", func(t *testing.T) {
 		client := newTestClient()
@@ -175,6 +409,42 @@ func TestWithClientHeaderString(t *testing.T) {
 			t.Error("Expected an error for duplicate header, but got none")
 		}
 	})
+
+	t.Run("Header name validation", func(t *testing.T) {
+		testCases := []struct {
+			name       string
+			headerName string
+			wantErr    bool
+		}{
+			{"Valid token name", "X-Request_ID.v2", false},
+			{"Name with a space", "Bad Header", true},
+			{"Name with a control character", "Bad-Header\n", true},
+			{"Empty name", "", true},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				err := WithClientHeaderString(tc.headerName, "value")(newTestClient())
+				if !tc.wantErr {
+					if err != nil {
+						t.Errorf("Expected no error, but got: %v", err)
+					}
+					return
+				}
+				want := fmt.Sprintf("invalid header name '%s'", tc.headerName)
+				if err == nil || err.Error() != want {
+					t.Errorf("Expected error %q, but got: %v", want, err)
+				}
+			})
+		}
+
+		t.Run("Token source header", func(t *testing.T) {
+			err := WithClientHeaderTokenSource("Bad\x7fHeader", oauth2.StaticTokenSource(&oauth2.Token{}))(newTestClient())
+			if err == nil || !strings.Contains(err.Error(), "invalid header name") {
+				t.Errorf("Expected an invalid header name error, but got: %v", err)
+			}
+		})
+	})
 }
 
 func TestWithClientHeaderTokenSource(t *testing.T) {
@@ -248,6 +518,341 @@ func TestWithDefaultToolOptions(t *testing.T) {
 	})
 }
 
+func TestWithInvokeHooks(t *testing.T) {
+	before := func(ctx context.Context, toolName string, input map[string]any) {}
+	after := func(ctx context.Context, toolName string, result any, err error, dur time.Duration) {}
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithBeforeInvoke(before)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if err := WithAfterInvoke(after)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.beforeInvoke == nil || client.invokeOpts.afterInvoke == nil {
+			t.Error("Expected both invoke hooks to be set")
+		}
+	})
+
+	t.Run("Failure on nil hooks", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithBeforeInvoke(nil)(client); err == nil {
+			t.Error("Expected an error for nil before hook, but got none")
+		}
+		if err := WithAfterInvoke(nil)(client); err == nil {
+			t.Error("Expected an error for nil after hook, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithBeforeInvoke(before)(client)
+		_ = WithAfterInvoke(after)(client)
+		if err := WithBeforeInvoke(before)(client); err == nil {
+			t.Error("Expected an error when setting the before hook twice, but got none")
+		}
+		if err := WithAfterInvoke(after)(client); err == nil {
+			t.Error("Expected an error when setting the after hook twice, but got none")
+		}
+	})
+}
+
+func TestWithCallerIdentity(t *testing.T) {
+	resolve := func(ctx context.Context) (string, error) { return "user", nil }
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithCallerIdentity(resolve)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if err := WithCallerIdentityHeader("X-End-User")(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.callerIdentity == nil || client.invokeOpts.callerIdentityHeader != "X-End-User" {
+			t.Error("Expected caller identity function and header to be set")
+		}
+	})
+
+	t.Run("Failure on invalid input", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithCallerIdentity(nil)(client); err == nil {
+			t.Error("Expected an error for nil function, but got none")
+		}
+		if err := WithCallerIdentityHeader("")(client); err == nil {
+			t.Error("Expected an error for empty header name, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithCallerIdentity(resolve)(client)
+		if err := WithCallerIdentity(resolve)(client); err == nil {
+			t.Error("Expected an error when setting caller identity twice, but got none")
+		}
+
+		_ = WithCallerIdentityHeader("X-End-User")(client)
+		err := WithCallerIdentityHeader("X-Other-User")(client)
+		if err == nil || !strings.Contains(err.Error(), "WithCallerIdentityHeader is already set and cannot be overridden") {
+			t.Errorf("Expected an error when setting the caller identity header twice, got %v", err)
+		}
+		if client.invokeOpts.callerIdentityHeader != "X-End-User" {
+			t.Errorf("Expected the first header to be kept, got %q", client.invokeOpts.callerIdentityHeader)
+		}
+	})
+}
+
+func TestWithAuthHeaderFormatter(t *testing.T) {
+	formatter := func(service string) (string, func(string) string) { return "Authorization", nil }
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithAuthHeaderFormatter(formatter)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.authHeaderFormatter == nil {
+			t.Error("Expected auth header formatter to be set")
+		}
+	})
+
+	t.Run("Failure on nil function", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithAuthHeaderFormatter(nil)(client); err == nil {
+			t.Error("Expected an error for nil function, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithAuthHeaderFormatter(formatter)(client)
+		if err := WithAuthHeaderFormatter(formatter)(client); err == nil {
+			t.Error("Expected an error when setting the formatter twice, but got none")
+		}
+	})
+}
+
+func TestWithWarningHandler(t *testing.T) {
+	handler := func(toolName string, warnings []string) {}
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		err := WithWarningHandler(handler)(client)
+
+		if err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.warningHandler == nil {
+			t.Error("Expected warning handler to be set")
+		}
+	})
+
+	t.Run("Failure on nil handler", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithWarningHandler(nil)(client); err == nil {
+			t.Error("Expected an error for nil handler, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithWarningHandler(handler)(client)
+
+		if err := WithWarningHandler(handler)(client); err == nil {
+			t.Error("Expected an error when setting the warning handler twice, but got none")
+		}
+	})
+}
+
+func TestWithInputSanitizer(t *testing.T) {
+	fn := func(input map[string]any) (map[string]any, error) { return input, nil }
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithInputSanitizer(fn)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.inputSanitizer == nil {
+			t.Error("Expected input sanitizer to be set")
+		}
+	})
+
+	t.Run("Failure on nil function", func(t *testing.T) {
+		if err := WithInputSanitizer(nil)(newTestClient()); err == nil {
+			t.Error("Expected an error for a nil function, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithInputSanitizer(fn)(client)
+		if err := WithInputSanitizer(fn)(client); err == nil {
+			t.Error("Expected an error when setting the input sanitizer twice, but got none")
+		}
+	})
+}
+
+func TestWithHTTPTrace(t *testing.T) {
+	fn := func(toolName string, timing HTTPTiming) {}
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithHTTPTrace(fn)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.httpTrace == nil {
+			t.Error("Expected HTTP trace function to be set")
+		}
+	})
+
+	t.Run("Failure on nil function", func(t *testing.T) {
+		if err := WithHTTPTrace(nil)(newTestClient()); err == nil {
+			t.Error("Expected an error for a nil function, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithHTTPTrace(fn)(client)
+		if err := WithHTTPTrace(fn)(client); err == nil {
+			t.Error("Expected an error when setting the HTTP trace function twice, but got none")
+		}
+	})
+}
+
+func TestWithValidateUTF8(t *testing.T) {
+	client := newTestClient()
+	if client.invokeOpts.validateUTF8 {
+		t.Fatal("Expected UTF-8 validation to be off by default")
+	}
+	if err := WithValidateUTF8()(client); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if !client.invokeOpts.validateUTF8 {
+		t.Error("Expected UTF-8 validation to be enabled")
+	}
+}
+
+func TestWithObserverSampleRate(t *testing.T) {
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithObserverSampleRate(0.25)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if !client.invokeOpts.observerSampleRateSet || client.invokeOpts.observerSampleRate != 0.25 {
+			t.Errorf("Expected sample rate 0.25 to be set, got %+v", client.invokeOpts)
+		}
+	})
+
+	t.Run("Failure on out of range rates", func(t *testing.T) {
+		for _, rate := range []float64{-0.1, 1.5, math.NaN()} {
+			if err := WithObserverSampleRate(rate)(newTestClient()); err == nil {
+				t.Errorf("Expected an error for rate %v, but got none", rate)
+			}
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithObserverSampleRate(0.5)(client)
+		if err := WithObserverSampleRate(0.5)(client); err == nil {
+			t.Error("Expected an error when setting the sample rate twice, but got none")
+		}
+	})
+}
+
+func TestWithAttemptObserver(t *testing.T) {
+	fn := func(toolName string, attempt int, err error, willRetry bool) {}
+
+	t.Run("Success case", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithAttemptObserver(fn)(client); err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+		if client.invokeOpts.attemptObserver == nil {
+			t.Error("Expected attempt observer to be set")
+		}
+	})
+
+	t.Run("Failure on nil function", func(t *testing.T) {
+		if err := WithAttemptObserver(nil)(newTestClient()); err == nil {
+			t.Error("Expected an error for a nil function, but got none")
+		}
+	})
+
+	t.Run("Failure on setting twice", func(t *testing.T) {
+		client := newTestClient()
+		_ = WithAttemptObserver(fn)(client)
+		if err := WithAttemptObserver(fn)(client); err == nil {
+			t.Error("Expected an error when setting the attempt observer twice, but got none")
+		}
+	})
+}
+
+func TestWithRetryableStatusCodes(t *testing.T) {
+	statusErr := func(code int) error {
+		return fmt.Errorf("failed to invoke tool: %w", &transport.StatusError{StatusCode: code})
+	}
+
+	t.Run("Defaults retry 429 and 5xx only", func(t *testing.T) {
+		client := newTestClient()
+		for _, code := range []int{429, 500, 503} {
+			if !client.invokeOpts.retryable(statusErr(code)) {
+				t.Errorf("Expected status %d to be retryable by default", code)
+			}
+		}
+		for _, code := range []int{400, 403, 409} {
+			if client.invokeOpts.retryable(statusErr(code)) {
+				t.Errorf("Expected status %d not to be retryable by default", code)
+			}
+		}
+	})
+
+	t.Run("Custom 409 is retried and 400 is not", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithRetryableStatusCodes(http.StatusConflict)(client); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if !client.invokeOpts.retryable(statusErr(http.StatusConflict)) {
+			t.Error("Expected a configured 409 to be retryable")
+		}
+		if client.invokeOpts.retryable(statusErr(http.StatusBadRequest)) {
+			t.Error("Expected 400 not to be retryable")
+		}
+		if !client.invokeOpts.retryable(statusErr(http.StatusServiceUnavailable)) {
+			t.Error("Expected the default 503 to remain retryable")
+		}
+	})
+
+	t.Run("Only replaces the default set", func(t *testing.T) {
+		client := newTestClient()
+		if err := WithOnlyRetryableStatusCodes(http.StatusConflict)(client); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if !client.invokeOpts.retryable(statusErr(http.StatusConflict)) {
+			t.Error("Expected a configured 409 to be retryable")
+		}
+		if client.invokeOpts.retryable(statusErr(http.StatusServiceUnavailable)) {
+			t.Error("Expected 503 not to be retryable once the defaults are replaced")
+		}
+	})
+
+	t.Run("Errors without a status are not retryable", func(t *testing.T) {
+		client := newTestClient()
+		if client.invokeOpts.retryable(fmt.Errorf("tool execution resulted in error")) {
+			t.Error("Expected a plain error not to be retryable")
+		}
+	})
+
+	t.Run("Failure on invalid input", func(t *testing.T) {
+		if err := WithRetryableStatusCodes()(newTestClient()); err == nil {
+			t.Error("Expected an error for an empty status list, but got none")
+		}
+		if err := WithRetryableStatusCodes(700)(newTestClient()); err == nil {
+			t.Error("Expected an error for an invalid status code, but got none")
+		}
+	})
+}
+
 func TestToolOptions(t *testing.T) {
 	newTestConfig := func() *ToolConfig {
 		return newToolConfig()
@@ -264,6 +869,19 @@ func TestToolOptions(t *testing.T) {
 		}
 	})
 
+	t.Run("WithToolNameGlob", func(t *testing.T) {
+		config := newTestConfig()
+		if err := WithToolNameGlob("get-*")(config); err != nil {
+			t.Fatalf("WithToolNameGlob returned an unexpected error: %v", err)
+		}
+		if config.ToolFilter == nil {
+			t.Fatal("WithToolNameGlob failed: expected ToolFilter to be set")
+		}
+		if !config.ToolFilter("get-user") || config.ToolFilter("list-users") {
+			t.Error("ToolFilter did not match the glob pattern as expected")
+		}
+	})
+
 	t.Run("WithAuthTokenSource", func(t *testing.T) {
 		config := newTestConfig()
 		mockSource := &mockTokenSource{token: &oauth2.Token{AccessToken: "test-token"}}
@@ -316,6 +934,7 @@ func TestToolOptions(t *testing.T) {
 		_ = WithBindParamIntArray("scores", []int{10, 20})(config)
 		_ = WithBindParamFloatArray("coords", []float64{1.1, 2.2})(config)
 		_ = WithBindParamBoolArray("flags", []bool{true, false})(config)
+		_ = WithBindParamBytes("file", []byte("hello"))(config)
 
 		// Assertions
 		if config.BoundParams == nil {
@@ -339,6 +958,9 @@ func TestToolOptions(t *testing.T) {
 		if val, ok := config.BoundParams["isAdmin"].(bool); !ok || !val {
 			t.Errorf("Bool binding failed. Got: %T %v", config.BoundParams["isAdmin"], config.BoundParams["isAdmin"])
 		}
+		if val, ok := config.BoundParams["file"].(string); !ok || val != "aGVsbG8=" {
+			t.Errorf("Bytes binding failed. Expected base64 string, Got: %T %v", config.BoundParams["file"], config.BoundParams["file"])
+		}
 		if val, ok := config.BoundParams["tags"].([]string); !ok || !reflect.DeepEqual(val, []string{"a", "b"}) {
 			t.Errorf("StringArray binding failed. Got: %v", config.BoundParams["tags"])
 		}
@@ -407,6 +1029,26 @@ func TestToolOptions(t *testing.T) {
 			}
 		})
 
+		t.Run("WithToolFilter", func(t *testing.T) {
+			config := newTestConfig()
+			_ = WithToolNameGlob("get-*")(config)
+			err := WithToolFilter(func(string) bool { return true })(config)
+			if err == nil {
+				t.Error("Expected an error when setting a tool filter twice, but got nil")
+			}
+		})
+
+		t.Run("WithToolNameGlob invalid pattern", func(t *testing.T) {
+			config := newTestConfig()
+			err := WithToolNameGlob(`get-\`)(config)
+			if err == nil {
+				t.Fatal("Expected an error for an invalid glob pattern, but got nil")
+			}
+			if !strings.Contains(err.Error(), "trailing escape character") {
+				t.Errorf("Unexpected error message: %v", err)
+			}
+		})
+
 		t.Run("WithBindParam", func(t *testing.T) {
 			config := newTestConfig()
 			_ = WithBindParamString("user_id", "user-a")(config)
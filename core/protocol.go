@@ -30,6 +30,16 @@ const (
 	MCP = MCPv20250618
 
 	MCPLatest = MCPv20251125
+
+	// AutoProtocol negotiates the MCP version with the server: the client
+	// offers MCPLatest and, if the server answers with another supported
+	// version, switches to that version. The outcome is reported by
+	// ToolboxClient.NegotiatedProtocol.
+	AutoProtocol Protocol = "auto"
+
+	// Stdio launches a local MCP server as a subprocess and speaks to it over
+	// its standard input and output. It is selected by WithCommand.
+	Stdio Protocol = "stdio"
 )
 
 // GetSupportedMcpVersions returns a list of supported MCP protocol versions.
@@ -49,3 +59,26 @@ type ToolSchema = transport.ToolSchema
 
 // ParameterSchema defines the structure and validation logic for tool parameters.
 type ParameterSchema = transport.ParameterSchema
+
+// Resource describes a resource, such as a file or blob, exposed by a server.
+type Resource = transport.Resource
+
+// ResourceContents holds the contents of a resource.
+type ResourceContents = transport.ResourceContents
+
+// Prompt describes a prompt template exposed by a server.
+type Prompt = transport.Prompt
+
+// PromptResult is a prompt template rendered with its arguments.
+type PromptResult = transport.PromptResult
+
+// MCPError is a JSON-RPC error returned by an MCP server. Use errors.As to
+// inspect its code and data.
+type MCPError = transport.MCPError
+
+// ContentBlock is a single item of a tool result's content, such as text, an
+// image or an embedded resource.
+type ContentBlock = transport.ContentBlock
+
+// StreamChunk is a piece of a tool result delivered by InvokeStream.
+type StreamChunk = transport.StreamChunk
@@ -0,0 +1,62 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy decides how long Invoke waits before retrying a failed
+// attempt when retries are enabled with WithRetry.
+type BackoffStrategy interface {
+	// Backoff returns the delay before the next attempt, given the number of
+	// the attempt that just failed, starting at 1.
+	Backoff(attempt int) time.Duration
+}
+
+// BackoffFunc adapts an ordinary function to a BackoffStrategy.
+type BackoffFunc func(attempt int) time.Duration
+
+// Backoff calls f(attempt).
+func (f BackoffFunc) Backoff(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ConstantBackoff returns a BackoffStrategy that always waits for delay.
+func ConstantBackoff(delay time.Duration) BackoffStrategy {
+	return BackoffFunc(func(int) time.Duration { return delay })
+}
+
+// ExponentialBackoff returns a BackoffStrategy that doubles the delay after
+// every failed attempt, starting at initial and capped at maxDelay, and
+// applies full jitter so that many clients retrying at once do not
+// synchronize.
+func ExponentialBackoff(initial, maxDelay time.Duration) BackoffStrategy {
+	return BackoffFunc(func(attempt int) time.Duration {
+		delay := initial
+		for i := 1; i < attempt && delay < maxDelay; i++ {
+			delay *= 2
+		}
+		delay = min(delay, maxDelay)
+		if delay <= 0 {
+			return 0
+		}
+		return rand.N(delay + 1)
+	})
+}
+
+// defaultBackoff is used by WithRetry when no strategy is given.
+var defaultBackoff = ExponentialBackoff(100*time.Millisecond, 5*time.Second)
@@ -0,0 +1,393 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer creates an MCP server offering a single 'flaky' tool whose
+// calls answer with failStatus for the first failures calls and succeed
+// afterwards.
+func newFlakyServer(t *testing.T, failures int32, failStatus int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req mcpRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{
+				{Name: "flaky", Description: "f", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+			}}
+		case "tools/call":
+			if calls.Add(1) <= failures {
+				http.Error(w, "try again", failStatus)
+				return
+			}
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "done"}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	return server, &calls
+}
+
+func TestInvoke_Retry(t *testing.T) {
+	type attemptCall struct {
+		attempt   int
+		failed    bool
+		willRetry bool
+	}
+
+	t.Run("Succeeds on the third attempt", func(t *testing.T) {
+		server, calls := newFlakyServer(t, 2, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		var attempts []attemptCall
+		client, err := NewToolboxClient(server.URL,
+			WithHTTPClient(server.Client()),
+			WithProtocol(MCPv20250618),
+			WithAttemptObserver(func(toolName string, attempt int, err error, willRetry bool) {
+				attempts = append(attempts, attemptCall{attempt, err != nil, willRetry})
+			}),
+		)
+		require.NoError(t, err)
+
+		tool, err := client.LoadTool("flaky", context.Background(), WithRetry(3, ConstantBackoff(time.Millisecond)))
+		require.NoError(t, err)
+
+		result, err := tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, "done", result)
+		assert.EqualValues(t, 3, calls.Load())
+		assert.Equal(t, []attemptCall{{1, true, true}, {2, true, true}, {3, false, false}}, attempts)
+	})
+
+	t.Run("Returns the last error once attempts are exhausted", func(t *testing.T) {
+		server, calls := newFlakyServer(t, 5, http.StatusTooManyRequests)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(), WithRetry(2, ConstantBackoff(time.Millisecond)))
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		var statusErr *transport.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+		assert.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("Fails fast on non-retryable errors", func(t *testing.T) {
+		server, calls := newFlakyServer(t, 1, http.StatusBadRequest)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(), WithRetry(3, ConstantBackoff(time.Millisecond)))
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.Error(t, err)
+		assert.EqualValues(t, 1, calls.Load())
+
+		_, err = tool.Invoke(context.Background(), map[string]any{"unknown": 1})
+		require.Error(t, err)
+		assert.EqualValues(t, 1, calls.Load(), "validation failures must not reach the server")
+	})
+
+	t.Run("Cancellation aborts the backoff", func(t *testing.T) {
+		server, calls := newFlakyServer(t, 5, http.StatusServiceUnavailable)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(), WithRetry(5, ConstantBackoff(time.Hour)))
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = tool.Invoke(ctx, map[string]any{})
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.EqualValues(t, 1, calls.Load())
+	})
+}
+
+func TestInvoke_RetryableStatusCodes(t *testing.T) {
+	invoke := func(t *testing.T, failStatus int, opts ...ClientOption) (int32, error) {
+		server, calls := newFlakyServer(t, 1, failStatus)
+		t.Cleanup(server.Close)
+
+		opts = append([]ClientOption{WithHTTPClient(server.Client()), WithProtocol(MCPv20250618)}, opts...)
+		client, err := NewToolboxClient(server.URL, opts...)
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(), WithRetry(3, ConstantBackoff(time.Millisecond)))
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		return calls.Load(), err
+	}
+
+	t.Run("Configured status is retried", func(t *testing.T) {
+		calls, err := invoke(t, http.StatusConflict, WithRetryableStatusCodes(http.StatusConflict))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, calls)
+	})
+
+	t.Run("Other client errors are not retried", func(t *testing.T) {
+		calls, err := invoke(t, http.StatusBadRequest, WithRetryableStatusCodes(http.StatusConflict))
+		require.Error(t, err)
+		assert.EqualValues(t, 1, calls)
+	})
+
+	t.Run("Only configured statuses replace the defaults", func(t *testing.T) {
+		calls, err := invoke(t, http.StatusServiceUnavailable, WithOnlyRetryableStatusCodes(http.StatusConflict))
+		require.Error(t, err)
+		assert.EqualValues(t, 1, calls)
+
+		calls, err = invoke(t, http.StatusConflict, WithOnlyRetryableStatusCodes(http.StatusConflict))
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, calls)
+	})
+}
+
+func TestWithRetry(t *testing.T) {
+	t.Run("Sets the policy", func(t *testing.T) {
+		config := newToolConfig()
+		require.NoError(t, WithRetry(3, nil)(config))
+		assert.Equal(t, 3, config.MaxAttempts)
+		assert.NotNil(t, config.Backoff, "a default backoff should be used")
+	})
+
+	t.Run("Rejects invalid and duplicate policies", func(t *testing.T) {
+		assert.Error(t, WithRetry(0, nil)(newToolConfig()))
+
+		config := newToolConfig()
+		require.NoError(t, WithRetry(2, nil)(config))
+		assert.Error(t, WithRetry(3, nil)(config))
+	})
+
+	t.Run("ToolFrom cannot override a policy", func(t *testing.T) {
+		tool := &ToolboxTool{name: "t", transport: &dummyTransport{}}
+		derived, err := tool.ToolFrom(WithRetry(2, nil))
+		require.NoError(t, err)
+		assert.Equal(t, 2, derived.maxAttempts)
+		assert.Zero(t, tool.maxAttempts, "the parent tool must not change")
+
+		_, err = derived.ToolFrom(WithRetry(3, nil))
+		assert.ErrorContains(t, err, "cannot override existing retry policy")
+	})
+}
+
+func TestBackoffStrategies(t *testing.T) {
+	assert.Equal(t, 250*time.Millisecond, ConstantBackoff(250*time.Millisecond).Backoff(4))
+
+	exp := ExponentialBackoff(100*time.Millisecond, time.Second)
+	for attempt, ceiling := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		3:  400 * time.Millisecond,
+		10: time.Second,
+	} {
+		for range 20 {
+			delay := exp.Backoff(attempt)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling, "attempt %d", attempt)
+		}
+	}
+
+	custom := BackoffFunc(func(attempt int) time.Duration { return time.Duration(attempt) * time.Second })
+	assert.Equal(t, 3*time.Second, custom.Backoff(3))
+}
+
+// idempotencyAttempt records the idempotency key a 'tools/call' request
+// carried in its header and in its '_meta'.
+type idempotencyAttempt struct {
+	header string
+	meta   string
+}
+
+// newIdempotencyServer creates an MCP server offering a single 'flaky' tool
+// whose calls fail with 503 on odd attempts and succeed on even ones, so that
+// every invocation retried once succeeds on its second attempt.
+func newIdempotencyServer(t *testing.T) (*httptest.Server, func() []idempotencyAttempt) {
+	var mu sync.Mutex
+	var attempts []idempotencyAttempt
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     any    `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Meta map[string]any `json:"_meta"`
+			} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []mcpTool{
+				{Name: "flaky", Description: "f", InputSchema: map[string]any{"type": "object", "properties": map[string]any{}}},
+			}}
+		case "tools/call":
+			meta, _ := req.Params.Meta["idempotencyKey"].(string)
+			mu.Lock()
+			attempts = append(attempts, idempotencyAttempt{header: r.Header.Get("Idempotency-Key"), meta: meta})
+			n := len(attempts)
+			mu.Unlock()
+			if n%2 == 1 {
+				http.Error(w, "try again", http.StatusServiceUnavailable)
+				return
+			}
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": "done"}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(mcpRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	return server, func() []idempotencyAttempt {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(attempts)
+	}
+}
+
+func TestInvoke_IdempotencyKey(t *testing.T) {
+	t.Run("Fixed key is sent on every attempt", func(t *testing.T) {
+		server, attempts := newIdempotencyServer(t)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(),
+			WithRetry(2, ConstantBackoff(time.Millisecond)),
+			WithIdempotencyKey("order-42"),
+		)
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []idempotencyAttempt{{"order-42", "order-42"}, {"order-42", "order-42"}}, attempts())
+	})
+
+	t.Run("Auto key is stable across retries and distinct per call", func(t *testing.T) {
+		server, attempts := newIdempotencyServer(t)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(),
+			WithRetry(2, ConstantBackoff(time.Millisecond)),
+			WithAutoIdempotencyKey(),
+		)
+		require.NoError(t, err)
+
+		for range 2 {
+			_, err = tool.Invoke(context.Background(), map[string]any{})
+			require.NoError(t, err)
+		}
+
+		got := attempts()
+		require.Len(t, got, 4)
+		for _, a := range got {
+			assert.NotEmpty(t, a.header)
+			assert.Equal(t, a.header, a.meta, "the header and _meta must carry the same key")
+		}
+		assert.Equal(t, got[0].header, got[1].header, "retries of a call must share its key")
+		assert.Equal(t, got[2].header, got[3].header, "retries of a call must share its key")
+		assert.NotEqual(t, got[0].header, got[2].header, "distinct calls must get distinct keys")
+	})
+
+	t.Run("No key is sent by default", func(t *testing.T) {
+		server, attempts := newIdempotencyServer(t)
+		defer server.Close()
+
+		client, err := NewToolboxClient(server.URL, WithHTTPClient(server.Client()), WithProtocol(MCPv20250618))
+		require.NoError(t, err)
+		tool, err := client.LoadTool("flaky", context.Background(), WithRetry(2, ConstantBackoff(time.Millisecond)))
+		require.NoError(t, err)
+
+		_, err = tool.Invoke(context.Background(), map[string]any{})
+		require.NoError(t, err)
+		assert.Equal(t, []idempotencyAttempt{{}, {}}, attempts())
+	})
+
+	t.Run("Options reject empty keys and overrides", func(t *testing.T) {
+		config := &ToolConfig{}
+		assert.ErrorContains(t, WithIdempotencyKey("")(config), "must not be empty")
+		require.NoError(t, WithIdempotencyKey("a")(config))
+		assert.ErrorContains(t, WithAutoIdempotencyKey()(config), "already set")
+
+		tool := &ToolboxTool{name: "t", autoIdempotencyKey: true}
+		_, err := tool.ToolFrom(WithIdempotencyKey("b"))
+		assert.ErrorContains(t, err, "cannot override existing idempotency key")
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SchemaFromType builds the parameter list for a tool from a Go struct, in
+// field declaration order.
+//
+// Field names follow the `json` tag, and fields tagged `json:"-"` or not
+// exported are skipped. A field is required unless it is a pointer, or
+// unless a `required:"true"` / `required:"false"` tag says otherwise. An
+// optional `description` tag is copied into the schema. Embedded structs
+// are flattened as encoding/json does, nested structs become objects,
+// []byte becomes bytes, other slices and arrays become arrays, and maps
+// with string keys become objects whose values are described by
+// AdditionalProperties.
+//
+// Inputs:
+//   - v: A struct value, a pointer to a struct, or a nil pointer of a struct type.
+//
+// Returns:
+//
+//	A slice of ParameterSchema describing the struct's fields, or an error if
+//	v is not a struct or a field has a type that cannot be represented.
+func SchemaFromType(v any) ([]ParameterSchema, error) {
+	if v == nil {
+		return nil, fmt.Errorf("SchemaFromType: value cannot be nil")
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("SchemaFromType: expected a struct, got %s", t)
+	}
+	return structParameters(t)
+}
+
+// structParameters collects the parameter schemas for the fields of a struct type.
+func structParameters(t reflect.Type) ([]ParameterSchema, error) {
+	params := make([]ParameterSchema, 0, t.NumField())
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Flatten embedded structs without an explicit name, like encoding/json.
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded, err := structParameters(ft)
+				if err != nil {
+					return nil, err
+				}
+				params = append(params, embedded...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		required := field.Type.Kind() != reflect.Pointer
+		if req, ok := field.Tag.Lookup("required"); ok {
+			parsed, err := strconv.ParseBool(req)
+			if err != nil {
+				return nil, fmt.Errorf("field '%s' has an invalid required tag %q", field.Name, req)
+			}
+			required = parsed
+		}
+
+		param, err := typeToSchema(name, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		param.Required = required
+		param.Description = field.Tag.Get("description")
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// typeToSchema maps a Go type onto the equivalent toolbox parameter schema.
+func typeToSchema(name string, t reflect.Type) (ParameterSchema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	param := ParameterSchema{Name: name}
+	switch t.Kind() {
+	case reflect.String:
+		param.Type = "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		param.Type = "integer"
+	case reflect.Float32, reflect.Float64:
+		param.Type = "float"
+	case reflect.Bool:
+		param.Type = "boolean"
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			param.Type = "bytes"
+			break
+		}
+		items, err := typeToSchema("", t.Elem())
+		if err != nil {
+			return ParameterSchema{}, fmt.Errorf("field '%s': %w", name, err)
+		}
+		param.Type = "array"
+		param.Items = &items
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return ParameterSchema{}, fmt.Errorf("field '%s': map keys must be strings, got %s", name, t.Key())
+		}
+		param.Type = "object"
+		if t.Elem().Kind() == reflect.Interface {
+			param.AdditionalProperties = true
+			break
+		}
+		values, err := typeToSchema("", t.Elem())
+		if err != nil {
+			return ParameterSchema{}, fmt.Errorf("field '%s': %w", name, err)
+		}
+		// Typed values are only described for scalar maps; nested
+		// containers fall back to a generic object.
+		if values.Type == "object" || values.Type == "array" {
+			param.AdditionalProperties = true
+		} else {
+			param.AdditionalProperties = &values
+		}
+	case reflect.Struct:
+		param.Type = "object"
+		param.AdditionalProperties = true
+	default:
+		return ParameterSchema{}, fmt.Errorf("field '%s' has unsupported type %s", name, t)
+	}
+	return param, nil
+}
@@ -0,0 +1,118 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaAudit struct {
+	CreatedBy string `json:"created_by"`
+}
+
+type schemaAddress struct {
+	City string `json:"city"`
+}
+
+type schemaSample struct {
+	schemaAudit
+	Query    string         `json:"query" description:"The search query"`
+	Limit    *int           `json:"limit,omitempty"`
+	Score    float64        `json:"score" required:"false"`
+	Verbose  bool           `json:"verbose"`
+	Tags     []string       `json:"tags"`
+	Address  schemaAddress  `json:"address"`
+	Counts   map[string]int `json:"counts"`
+	Extra    map[string]any `json:"extra"`
+	Matrix   [][]float32    `json:"matrix"`
+	Internal string         `json:"-"`
+	Optional *string        `json:"optional" required:"true"`
+	Payload  []byte         `json:"payload"`
+	NoTag    uint8
+	hidden   string
+}
+
+func TestSchemaFromType(t *testing.T) {
+	t.Run("Generates schema for a sample struct", func(t *testing.T) {
+		params, err := SchemaFromType(&schemaSample{})
+		require.NoError(t, err)
+
+		expected := []ParameterSchema{
+			{Name: "created_by", Type: "string", Required: true},
+			{Name: "query", Type: "string", Required: true, Description: "The search query"},
+			{Name: "limit", Type: "integer"},
+			{Name: "score", Type: "float"},
+			{Name: "verbose", Type: "boolean", Required: true},
+			{Name: "tags", Type: "array", Required: true, Items: &ParameterSchema{Type: "string"}},
+			{Name: "address", Type: "object", Required: true, AdditionalProperties: true},
+			{Name: "counts", Type: "object", Required: true, AdditionalProperties: &ParameterSchema{Type: "integer"}},
+			{Name: "extra", Type: "object", Required: true, AdditionalProperties: true},
+			{Name: "matrix", Type: "array", Required: true, Items: &ParameterSchema{
+				Type:  "array",
+				Items: &ParameterSchema{Type: "float"},
+			}},
+			{Name: "optional", Type: "string", Required: true},
+			{Name: "payload", Type: "bytes", Required: true},
+			{Name: "NoTag", Type: "integer", Required: true},
+		}
+		assert.Equal(t, expected, params)
+
+		for _, p := range params {
+			assert.NoError(t, p.ValidateDefinition(), "generated schema for %q should be valid", p.Name)
+		}
+	})
+
+	t.Run("Accepts a struct value", func(t *testing.T) {
+		params, err := SchemaFromType(schemaAddress{})
+		require.NoError(t, err)
+		assert.Equal(t, []ParameterSchema{{Name: "city", Type: "string", Required: true}}, params)
+	})
+
+	t.Run("Rejects non-struct types", func(t *testing.T) {
+		_, err := SchemaFromType(42)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected a struct")
+
+		_, err = SchemaFromType(nil)
+		require.Error(t, err)
+	})
+
+	t.Run("Rejects unsupported field types", func(t *testing.T) {
+		_, err := SchemaFromType(struct {
+			Callback func() `json:"callback"`
+		}{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field 'callback' has unsupported type")
+
+		_, err = SchemaFromType(struct {
+			Lookup map[int]string `json:"lookup"`
+		}{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "map keys must be strings")
+	})
+
+	t.Run("Rejects an invalid required tag", func(t *testing.T) {
+		_, err := SchemaFromType(struct {
+			Name string `json:"name" required:"maybe"`
+		}{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid required tag")
+	})
+}
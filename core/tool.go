@@ -15,14 +15,21 @@
 package core
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"maps"
 
+	"github.com/google/uuid"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"golang.org/x/oauth2"
 )
@@ -39,6 +46,28 @@ type ToolboxTool struct {
 	requiredAuthnParams map[string][]string
 	requiredAuthzTokens []string
 	clientHeaderSources map[string]oauth2.TokenSource
+	toolsetHeaders      map[string]string
+	examples            []map[string]any
+	invokeOpts          *invokeOptions
+	userData            any
+	paramTransforms     map[string]func(v any) (any, error)
+	outputSchema        []ParameterSchema
+	maxAttempts         int
+	backoff             BackoffStrategy
+	invokeTimeout       time.Duration
+	// idempotencyKey is sent with every invocation, while autoIdempotencyKey
+	// generates a new key for each one.
+	idempotencyKey     string
+	autoIdempotencyKey bool
+	// schemaParameters holds every parameter of the tool as declared by the
+	// server, bound or not, so that bindings can be undone.
+	schemaParameters []ParameterSchema
+	// paramAliases maps alternative input names to the parameter they stand
+	// for, and caseInsensitiveParams enables matching input keys in any case.
+	paramAliases          map[string]string
+	caseInsensitiveParams bool
+	// aggregateValidation reports every input problem instead of the first.
+	aggregateValidation bool
 }
 
 // Name returns the tool's name.
@@ -59,8 +88,154 @@ func (tt *ToolboxTool) Parameters() []ParameterSchema {
 	return paramsCopy
 }
 
+// RequiredAuthn returns the authenticated parameters that still need an auth
+// token before the tool can be invoked, mapped to the auth services that can
+// provide each of them. Requirements met by an auth token source added to the
+// tool are not included. The returned map is a copy and is safe to modify.
+func (tt *ToolboxTool) RequiredAuthn() map[string][]string {
+	remaining, _, _ := identifyAuthRequirements(tt.requiredAuthnParams, nil, tt.authTokenSources)
+	for param, services := range remaining {
+		remaining[param] = slices.Clone(services)
+	}
+	return remaining
+}
+
+// RequiredAuthz returns the auth services of which at least one must provide a
+// token before the tool can be invoked, or an empty slice if the tool's
+// authorization requirement is already met. The returned slice is a copy and
+// is safe to modify.
+func (tt *ToolboxTool) RequiredAuthz() []string {
+	_, remaining, _ := identifyAuthRequirements(nil, tt.requiredAuthzTokens, tt.authTokenSources)
+	return append([]string{}, remaining...)
+}
+
+// ParamSource describes where the value of a tool parameter comes from.
+type ParamSource int
+
+const (
+	// UserSupplied parameters must be provided by the caller at invocation
+	// time. They are the ones listed by Parameters.
+	UserSupplied ParamSource = iota
+	// Bound parameters take a value bound to the tool, for example with
+	// WithBindParamString.
+	Bound
+	// AuthBacked parameters are filled by the server from an authentication
+	// token.
+	AuthBacked
+)
+
+// String returns the name of the parameter source.
+func (s ParamSource) String() string {
+	switch s {
+	case UserSupplied:
+		return "user"
+	case Bound:
+		return "bound"
+	case AuthBacked:
+		return "auth"
+	default:
+		return fmt.Sprintf("ParamSource(%d)", int(s))
+	}
+}
+
+// ParameterSources reports how each parameter of the tool is resolved,
+// covering bound and auth-backed parameters as well as those the user must
+// supply.
+//
+// Returns:
+//
+//	A map from every parameter name of the tool to its source.
+func (tt *ToolboxTool) ParameterSources() map[string]ParamSource {
+	sources := make(map[string]ParamSource, len(tt.schemaParameters))
+	for _, p := range tt.parameters {
+		sources[p.Name] = UserSupplied
+	}
+	for name := range tt.boundParams {
+		sources[name] = Bound
+	}
+	for name := range tt.requiredAuthnParams {
+		sources[name] = AuthBacked
+	}
+	// The declared parameters also cover auth parameters whose services
+	// have already been provided.
+	for _, p := range tt.schemaParameters {
+		if len(p.AuthSources) > 0 {
+			sources[p.Name] = AuthBacked
+		}
+	}
+	return sources
+}
+
+// Examples returns sample inputs for the tool as provided by the server,
+// restricted to the parameters a user must provide. Examples that only set
+// bound or auth-provided parameters are omitted.
+func (tt *ToolboxTool) Examples() []map[string]any {
+	unbound := make(map[string]struct{}, len(tt.parameters))
+	for _, p := range tt.parameters {
+		unbound[p.Name] = struct{}{}
+	}
+
+	var examples []map[string]any
+	for _, example := range tt.examples {
+		filtered := make(map[string]any, len(example))
+		for k, v := range example {
+			if _, ok := unbound[k]; ok {
+				filtered[k] = v
+			}
+		}
+		if len(filtered) > 0 {
+			examples = append(examples, filtered)
+		}
+	}
+	return examples
+}
+
 // InputSchema generates an OpenAPI JSON Schema for the tool's input parameters and returns it as raw bytes.
 func (tt *ToolboxTool) InputSchema() ([]byte, error) {
+	finalSchema, err := tt.ParametersSchema()
+	if err != nil {
+		return nil, err
+	}
+	// Include sample inputs to guide the model, when the server provides them.
+	if examples := tt.Examples(); len(examples) > 0 {
+		finalSchema["examples"] = examples
+	}
+
+	// Marshal the final map into an indented JSON string.
+	return json.MarshalIndent(finalSchema, "", "  ")
+}
+
+// ToOpenAIFunctionSchema converts the tool into the function definition used
+// by OpenAI's function calling, with the tool's name, description and a JSON
+// Schema of its unbound parameters. Bound parameters are not included, as
+// they are not supplied by the model.
+//
+// Returns:
+//
+//	The function definition as JSON, or an error if a parameter cannot be
+//	expressed as JSON Schema.
+func (tt *ToolboxTool) ToOpenAIFunctionSchema() (json.RawMessage, error) {
+	parameters, err := tt.ParametersSchema()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[string]any{
+		"name":        tt.name,
+		"description": tt.description,
+		"parameters":  parameters,
+	})
+}
+
+// ParametersSchema builds the JSON Schema object describing the tool's
+// unbound parameters, for adapters that need the schema as a map rather than
+// as encoded JSON. Unlike InputSchema, it does not include examples.
+//
+// Returns:
+//
+//	An object schema with "type", "properties" and, when any parameter is
+//	required, "required" keys, or an error if a parameter cannot be
+//	expressed as JSON Schema.
+func (tt *ToolboxTool) ParametersSchema() (map[string]any, error) {
 	properties := make(map[string]any)
 	required := make([]string, 0)
 
@@ -87,9 +262,7 @@ func (tt *ToolboxTool) InputSchema() ([]byte, error) {
 	if len(required) > 0 {
 		finalSchema["required"] = required
 	}
-
-	// Marshal the final map into an indented JSON string.
-	return json.MarshalIndent(finalSchema, "", "  ")
+	return finalSchema, nil
 }
 
 // DescribeParameters returns a single, human-readable string that describes all
@@ -111,6 +284,47 @@ func (tt *ToolboxTool) DescribeParameters() string {
 	return strings.Join(paramDescriptions, ", ")
 }
 
+// String returns a summary of the tool that is safe to log: its name,
+// description, and the names, types and requiredness of its parameters, with
+// only counts of its bound parameters, auth token sources and client headers.
+// Bound values are never included and token sources are never called. It is
+// also used for %v, %+v and %#v.
+func (tt *ToolboxTool) String() string {
+	if tt == nil {
+		return "<nil>"
+	}
+	params := make([]string, len(tt.parameters))
+	for i, p := range tt.parameters {
+		if p.Required {
+			params[i] = fmt.Sprintf("%s %s required", p.Name, p.Type)
+		} else {
+			params[i] = fmt.Sprintf("%s %s", p.Name, p.Type)
+		}
+	}
+	return fmt.Sprintf("ToolboxTool{name: %q, description: %q, parameters: [%s], boundParams: %d, authTokenSources: %d, clientHeaders: %d}",
+		tt.name, tt.description, strings.Join(params, ", "), len(tt.boundParams), len(tt.authTokenSources), len(tt.clientHeaderSources))
+}
+
+// GoString returns the same summary as String, so that %#v does not expose
+// the tool's bound values or token sources either.
+func (tt *ToolboxTool) GoString() string {
+	return tt.String()
+}
+
+// SetUserData attaches an arbitrary caller-defined value to the tool, such as
+// a UI category. The value is opaque to the SDK and is never sent to the
+// server. It is carried over to tools derived with ToolFrom.
+//
+// SetUserData is not safe to call concurrently with other uses of the tool.
+func (tt *ToolboxTool) SetUserData(v any) {
+	tt.userData = v
+}
+
+// UserData returns the value attached with SetUserData, or nil if none was set.
+func (tt *ToolboxTool) UserData() any {
+	return tt.userData
+}
+
 // ToolFrom creates a new, more specialized tool from an existing one by applying
 // additional options. This is useful for creating variations of a tool with
 // different bound parameters without modifying the original and
@@ -166,7 +380,15 @@ func (tt *ToolboxTool) ToolFrom(opts ...ToolOption) (*ToolboxTool, error) {
 				return nil, fmt.Errorf("unable to bind parameter: no parameter named '%s' on the tool", name)
 			}
 			// If it exists in the parent's bound params, it's an attempt to override.
-			return nil, fmt.Errorf("cannot override existing bound parameter: '%s'", name)
+			return nil, fmt.Errorf("%w: '%s'", ErrBoundParamOverride, name)
+		}
+
+		val, apply := unwrapStructBinding(val, schema)
+		if !apply {
+			continue
+		}
+		if err := validateStaticBinding(schema, val); err != nil {
+			return nil, fmt.Errorf("invalid bound parameter: %w", err)
 		}
 
 		if newTt.boundParamSchemas == nil {
@@ -180,6 +402,83 @@ func (tt *ToolboxTool) ToolFrom(opts ...ToolOption) (*ToolboxTool, error) {
 		newTt.boundParams[name] = val
 	}
 
+	// Remove the requested bindings inherited from the parent.
+	unbound := make(map[string]struct{}, len(config.UnbindParams))
+	for _, name := range config.UnbindParams {
+		if _, isBound := tt.boundParams[name]; !isBound {
+			return nil, fmt.Errorf("unable to unbind parameter: parameter '%s' is not bound on the tool", name)
+		}
+		if _, known := tt.boundParamSchemas[name]; !known && tt.schemaParameters == nil {
+			return nil, fmt.Errorf("unable to unbind parameter: the schema of parameter '%s' is unknown", name)
+		}
+		delete(newTt.boundParams, name)
+		delete(newTt.boundParamSchemas, name)
+		unbound[name] = struct{}{}
+	}
+
+	// Validate and merge new parameter transforms, preventing overrides.
+	for name, fn := range config.ParamTransforms {
+		if _, exists := paramNames[name]; !exists {
+			return nil, fmt.Errorf("unable to transform parameter: no parameter named '%s' on the tool", name)
+		}
+		if _, exists := newTt.paramTransforms[name]; exists {
+			return nil, fmt.Errorf("cannot override existing parameter transform: '%s'", name)
+		}
+		if newTt.paramTransforms == nil {
+			newTt.paramTransforms = make(map[string]func(v any) (any, error))
+		}
+		newTt.paramTransforms[name] = fn
+	}
+
+	// Validate and merge new parameter aliases, preventing overrides.
+	for alias, name := range config.ParamAliases {
+		if _, exists := paramNames[name]; !exists {
+			return nil, fmt.Errorf("unable to alias parameter: no parameter named '%s' on the tool", name)
+		}
+		if _, exists := paramNames[alias]; exists {
+			return nil, fmt.Errorf("unable to alias parameter '%s': '%s' is already a parameter of the tool", name, alias)
+		}
+		if _, exists := newTt.paramAliases[alias]; exists {
+			return nil, fmt.Errorf("cannot override existing parameter alias: '%s'", alias)
+		}
+		if newTt.paramAliases == nil {
+			newTt.paramAliases = make(map[string]string)
+		}
+		newTt.paramAliases[alias] = name
+	}
+	if config.caseInsensitiveSet {
+		newTt.caseInsensitiveParams = config.CaseInsensitiveParams
+	}
+	if config.aggregateValidationSet {
+		newTt.aggregateValidation = config.AggregateValidation
+	}
+
+	// Apply a retry policy, preventing overrides.
+	if config.MaxAttempts != 0 {
+		if newTt.maxAttempts != 0 {
+			return nil, fmt.Errorf("cannot override existing retry policy")
+		}
+		newTt.maxAttempts = config.MaxAttempts
+		newTt.backoff = config.Backoff
+	}
+
+	// Apply an invoke timeout, preventing overrides.
+	if config.InvokeTimeout != 0 {
+		if newTt.invokeTimeout != 0 {
+			return nil, fmt.Errorf("cannot override existing invoke timeout")
+		}
+		newTt.invokeTimeout = config.InvokeTimeout
+	}
+
+	// Apply an idempotency key, preventing overrides.
+	if config.IdempotencyKey != "" || config.AutoIdempotencyKey {
+		if newTt.idempotencyKey != "" || newTt.autoIdempotencyKey {
+			return nil, fmt.Errorf("cannot override existing idempotency key")
+		}
+		newTt.idempotencyKey = config.IdempotencyKey
+		newTt.autoIdempotencyKey = config.AutoIdempotencyKey
+	}
+
 	// Recalculate the remaining unbound parameters for the new tool.
 	var newParams []ParameterSchema
 	for _, p := range tt.parameters {
@@ -189,6 +488,23 @@ func (tt *ToolboxTool) ToolFrom(opts ...ToolOption) (*ToolboxTool, error) {
 	}
 	newTt.parameters = newParams
 
+	// Restore unbound parameters with their original schemas, keeping the
+	// order in which the server declared them when it is known.
+	if len(unbound) > 0 && tt.schemaParameters != nil {
+		newTt.parameters = nil
+		for _, p := range tt.schemaParameters {
+			_, wasUnbound := paramNames[p.Name]
+			_, isRestored := unbound[p.Name]
+			if _, isBound := newTt.boundParams[p.Name]; !isBound && (wasUnbound || isRestored) {
+				newTt.parameters = append(newTt.parameters, p)
+			}
+		}
+	} else {
+		for _, name := range slices.Sorted(maps.Keys(unbound)) {
+			newTt.parameters = append(newTt.parameters, tt.boundParamSchemas[name])
+		}
+	}
+
 	return newTt, nil
 }
 
@@ -206,6 +522,22 @@ func (tt *ToolboxTool) cloneToolboxTool() *ToolboxTool {
 		requiredAuthnParams: make(map[string][]string, len(tt.requiredAuthnParams)),
 		requiredAuthzTokens: make([]string, len(tt.requiredAuthzTokens)),
 		clientHeaderSources: make(map[string]oauth2.TokenSource, len(tt.clientHeaderSources)),
+		toolsetHeaders:      tt.toolsetHeaders,
+		examples:            tt.examples,
+		invokeOpts:          tt.invokeOpts,
+		userData:            tt.userData,
+		paramTransforms:     maps.Clone(tt.paramTransforms),
+		outputSchema:        tt.outputSchema,
+		maxAttempts:         tt.maxAttempts,
+		backoff:             tt.backoff,
+		invokeTimeout:       tt.invokeTimeout,
+		idempotencyKey:      tt.idempotencyKey,
+		schemaParameters:    slices.Clone(tt.schemaParameters),
+
+		paramAliases:          maps.Clone(tt.paramAliases),
+		caseInsensitiveParams: tt.caseInsensitiveParams,
+		autoIdempotencyKey:    tt.autoIdempotencyKey,
+		aggregateValidation:   tt.aggregateValidation,
 	}
 
 	if tt.boundParamSchemas != nil {
@@ -246,6 +578,25 @@ func (tt *ToolboxTool) cloneToolboxTool() *ToolboxTool {
 	return newTt
 }
 
+// InvokeResult is the outcome of a tool invocation together with the HTTP
+// response that carried it.
+type InvokeResult struct {
+	// Value is the parsed result, identical to what Invoke returns.
+	Value any
+	// StatusCode is the HTTP status code of the response, or 0 if the
+	// transport does not report it.
+	StatusCode int
+	// Header holds the HTTP response headers, such as rate-limit counters or
+	// a server request ID, or nil if the transport does not report them.
+	Header http.Header
+	// RawBody is the undecoded HTTP response body, or nil if the transport
+	// does not report it.
+	RawBody []byte
+	// Content holds every content block of the result, including images and
+	// embedded resources, or nil if the transport does not report them.
+	Content []ContentBlock
+}
+
 // Invoke executes the tool with the given input.
 //
 // Inputs:
@@ -259,7 +610,104 @@ func (tt *ToolboxTool) cloneToolboxTool() *ToolboxTool {
 //	'result' field) or a raw string. Returns an error if any step of the
 //	process fails.
 func (tt *ToolboxTool) Invoke(ctx context.Context, input map[string]any) (any, error) {
+	result, err := tt.InvokeRaw(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value, nil
+}
 
+// InvokeRaw executes the tool like Invoke, and also returns the HTTP status,
+// headers and raw body of the server's response. When the server answers
+// with an unexpected status, the returned error wraps a
+// *transport.StatusError that carries the response headers.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the API request.
+//   - input: A map of parameter names to values provided by the user for this
+//     specific invocation.
+//
+// Returns:
+//
+//	An *InvokeResult holding the parsed value and the response metadata, or
+//	nil and an error if any step of the process fails.
+func (tt *ToolboxTool) InvokeRaw(ctx context.Context, input map[string]any) (*InvokeResult, error) {
+	if tt.invokeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tt.invokeTimeout)
+		defer cancel()
+	}
+
+	opts := tt.options()
+	sampled := opts.sampleObservation()
+	if sampled && opts.beforeInvoke != nil {
+		opts.beforeInvoke(ctx, tt.name, maps.Clone(input))
+	}
+
+	start := time.Now()
+	result, err := tt.invoke(ctx, input, sampled)
+
+	opts.metrics().ObserveInvoke(tt.name, time.Since(start), err)
+
+	// Failed invocations are always observed, regardless of sampling.
+	if (sampled || err != nil) && opts.afterInvoke != nil {
+		var value any
+		if result != nil {
+			value = result.Value
+		}
+		opts.afterInvoke(ctx, tt.name, value, err, time.Since(start))
+	}
+	return result, err
+}
+
+// invoke performs the validation, header resolution and transport call
+// behind InvokeRaw. sampled reports whether observers should see a
+// successful invocation.
+func (tt *ToolboxTool) invoke(ctx context.Context, input map[string]any, sampled bool) (*InvokeResult, error) {
+	finalPayload, resolvedHeaders, err := tt.prepareInvocation(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var recorder *httpTimingRecorder
+	if tt.options().httpTrace != nil {
+		ctx, recorder = withHTTPTimingRecorder(ctx)
+	}
+	result, err := tt.invokeWithAttempts(ctx, finalPayload, resolvedHeaders)
+	if recorder != nil && (sampled || err != nil) {
+		tt.options().httpTrace(tt.name, recorder.finish())
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if handler := tt.options().warningHandler; handler != nil && len(result.Warnings) > 0 {
+		handler(tt.name, result.Warnings)
+	}
+
+	if tt.options().validateOutput && len(tt.outputSchema) > 0 {
+		if err := validateOutput(tt.outputSchema, result.Output); err != nil {
+			return nil, fmt.Errorf("result of tool '%s' does not match its output schema: %w", tt.name, err)
+		}
+	}
+
+	value := result.Output
+	if tt.options().numberAsJSON {
+		value = decodeResultNumbers(result.Output)
+	}
+	return &InvokeResult{
+		Value:      value,
+		StatusCode: result.StatusCode,
+		Header:     result.Header,
+		RawBody:    result.RawBody,
+		Content:    result.Content,
+	}, nil
+}
+
+// prepareInvocation checks the tool's auth requirements, builds the final
+// payload from the input and bound parameters, and resolves the request
+// headers for an invocation.
+func (tt *ToolboxTool) prepareInvocation(ctx context.Context, input map[string]any) (map[string]any, map[string]string, error) {
 	// Ensure all authentication tokens required by the tool are available.
 	if len(tt.requiredAuthnParams) > 0 || len(tt.requiredAuthzTokens) > 0 {
 		reqAuthServices := make(map[string]struct{})
@@ -275,59 +723,658 @@ func (tt *ToolboxTool) Invoke(ctx context.Context, input map[string]any) (any, e
 		// Check if each required service has a corresponding token source.
 		for service := range reqAuthServices {
 			if _, ok := tt.authTokenSources[service]; !ok {
-				return nil, fmt.Errorf("permission error: auth service '%s' is required to invoke this tool but was not provided", service)
+				return nil, nil, fmt.Errorf("%w: auth service '%s' is required to invoke this tool but was not provided", ErrPermission, service)
 			}
 		}
 	}
 
 	// Validate the user's input and merge it with pre-configured bound parameters.
-	finalPayload, err := tt.validateAndBuildPayload(input)
+	finalPayload, err := tt.validateAndBuildPayload(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("tool payload processing failed: %w", err)
+		return nil, nil, fmt.Errorf("tool payload processing failed: %w", err)
+	}
+	if tt.options().canonicalInput {
+		finalPayload, err = canonicalizePayload(finalPayload)
+		if err != nil {
+			return nil, nil, fmt.Errorf("tool payload processing failed: %w", err)
+		}
 	}
 
 	resolvedHeaders := make(map[string]string)
 
 	// Resolve Client Headers
 	for k, source := range tt.clientHeaderSources {
-		token, err := source.Token()
+		token, err := resolveToken(ctx, source)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve client header %s: %w", k, err)
+			tt.options().log().ErrorContext(ctx, "failed to resolve client header", "tool", tt.name, "header", k, "error", err)
+			return nil, nil, fmt.Errorf("failed to resolve client header %s: %w", k, err)
 		}
 		resolvedHeaders[k] = token.AccessToken
 	}
 
+	// Toolset-specific headers take precedence over client-wide ones.
+	maps.Copy(resolvedHeaders, tt.toolsetHeaders)
+
+	// Resolve the end-user identity for auditing, if configured.
+	if resolveIdentity := tt.options().callerIdentity; resolveIdentity != nil {
+		identity, err := resolveIdentity(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve caller identity: %w", err)
+		}
+		headerName := tt.options().callerIdentityHeader
+		if headerName == "" {
+			headerName = DefaultCallerIdentityHeader
+		}
+		resolvedHeaders[headerName] = identity
+	}
+
 	// Resolve Auth Headers
 	for name, source := range tt.authTokenSources {
-		token, err := source.Token()
+		token, err := resolveToken(ctx, source)
 		if err != nil {
-			return nil, fmt.Errorf("failed to resolve auth token %s: %w", name, err)
+			tt.options().log().ErrorContext(ctx, "failed to resolve auth token", "tool", tt.name, "service", name, "error", err)
+			return nil, nil, fmt.Errorf("failed to resolve auth token %s: %w", name, err)
 		}
-		// Toolbox HTTP protocol expects the suffix "_token"
-		headerName := fmt.Sprintf("%s_token", name)
-		resolvedHeaders[headerName] = token.AccessToken
+		headerName, value, err := tt.options().authHeader(name, token.AccessToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		resolvedHeaders[headerName] = value
+	}
+
+	// The headers are resolved once per logical call and reused by every
+	// retry attempt, so an idempotency key generated here stays stable.
+	if tt.idempotencyKey != "" {
+		resolvedHeaders[transport.IdempotencyKeyHeader] = tt.idempotencyKey
+	} else if tt.autoIdempotencyKey {
+		resolvedHeaders[transport.IdempotencyKeyHeader] = uuid.NewString()
 	}
 
 	checkSecureHeaders(tt.transport.BaseURL(), len(tt.authTokenSources) > 0)
 
-	response, err := tt.transport.InvokeTool(ctx, tt.name, finalPayload, resolvedHeaders)
+	return finalPayload, resolvedHeaders, nil
+}
+
+// PreparedRequest describes the request Invoke would send for a given input,
+// as built by DryRun.
+type PreparedRequest struct {
+	// Tool is the name of the tool to invoke.
+	Tool string
+	// URL is the address of the server endpoint.
+	URL string
+	// Method is the JSON-RPC method of the call, after any method overrides.
+	Method string
+	// Payload holds the final arguments, including bound parameters.
+	Payload map[string]any
+	// Headers holds the resolved request headers, including client headers
+	// and auth tokens.
+	Headers map[string]string
+}
+
+// Redacted returns a copy of the request in which every header value is
+// replaced by a placeholder, so that it can be displayed or logged without
+// exposing credentials.
+func (r *PreparedRequest) Redacted() *PreparedRequest {
+	redacted := *r
+	redacted.Headers = redactHeaders(r.Headers)
+	return &redacted
+}
+
+// DryRun prepares an invocation of the tool exactly as Invoke would, running
+// input validation, bound parameter resolution and header and auth token
+// assembly, but does not contact the server. It is useful for debugging and
+// for previewing a call before running it.
+//
+// Inputs:
+//   - ctx: The context for resolving bound parameters and headers.
+//   - input: A map of parameter names to values for this invocation.
+//
+// Returns:
+//
+//	The *PreparedRequest that Invoke would send, or an error if the
+//	invocation could not be prepared.
+func (tt *ToolboxTool) DryRun(ctx context.Context, input map[string]any) (*PreparedRequest, error) {
+	payload, headers, err := tt.prepareInvocation(ctx, input)
 	if err != nil {
 		return nil, err
 	}
 
-	return response, nil
+	method := "tools/call"
+	if namer, ok := tt.transport.(interface{ Method(string) string }); ok {
+		method = namer.Method(method)
+	}
+	return &PreparedRequest{
+		Tool:    tt.name,
+		URL:     tt.transport.BaseURL(),
+		Method:  method,
+		Payload: payload,
+		Headers: headers,
+	}, nil
 }
 
-// validateAndBuildPayload performs manual type validation and applies bound parameters.
+// InvokeContent executes the tool and returns every content block of its
+// result, so that images, embedded resources and other non-text content can be
+// accessed. Invoke keeps returning the text content only.
+//
+// When the transport does not report content blocks, the tool's output is
+// returned as a single text block.
 //
 // Inputs:
-//   - input: The map of parameters provided by the user for this invocation.
+//   - ctx: The context to control the lifecycle of the API request.
+//   - input: A map of parameter names to values for this invocation.
 //
 // Returns:
 //
-//	A map representing the final, validated JSON payload, or an error if
-//	validation or parameter resolution fails.
-func (tt *ToolboxTool) validateAndBuildPayload(input map[string]any) (map[string]any, error) {
+//	The content blocks of the result, in the order the server sent them, or
+//	an error if the invocation fails.
+func (tt *ToolboxTool) InvokeContent(ctx context.Context, input map[string]any) ([]ContentBlock, error) {
+	result, err := tt.InvokeRaw(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if result.Content != nil {
+		return result.Content, nil
+	}
+
+	text, err := tt.outputText(result.Value)
+	if err != nil {
+		return nil, err
+	}
+	return []ContentBlock{{Type: "text", Text: text}}, nil
+}
+
+// InvokeJSON executes the tool with input given as raw JSON arguments, as
+// commonly produced by LLM frameworks and agents.
+//
+// JSON numbers are decoded according to the parameter they are given for:
+// whole numbers for integer parameters, including the items of integer
+// arrays and the values of integer maps, become int64, and every other number
+// becomes float64, so that integer parameters validate as they would with
+// native Go values.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the API request.
+//   - rawArgs: A JSON object mapping parameter names to values.
+//
+// Returns:
+//
+//	The result from the tool's execution, or an error if the input is not a
+//	valid JSON object or the invocation fails.
+func (tt *ToolboxTool) InvokeJSON(ctx context.Context, rawArgs json.RawMessage) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(rawArgs))
+	decoder.UseNumber()
+	var input map[string]any
+	err := decoder.Decode(&input)
+	if err == nil {
+		if _, tokenErr := decoder.Token(); tokenErr != io.EOF {
+			err = fmt.Errorf("unexpected data after the JSON object")
+		}
+	}
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("tool '%s' expects a JSON object as input, but got a JSON %s", tt.name, typeErr.Value)
+		}
+		return nil, fmt.Errorf("failed to parse JSON input for tool '%s': %w", tt.name, err)
+	}
+	if input == nil {
+		return nil, fmt.Errorf("tool '%s' expects a JSON object as input, but got null", tt.name)
+	}
+
+	// Look up the schema under the name each key resolves to, so aliased
+	// keys are decoded like the parameter they stand for.
+	params := make(map[string]*ParameterSchema, len(tt.parameters))
+	for i := range tt.parameters {
+		params[tt.parameters[i].Name] = &tt.parameters[i]
+	}
+	resolve := tt.inputKeyResolver()
+	for key, value := range input {
+		input[key] = coerceJSONNumbers(params[resolve(key)], value)
+	}
+	return tt.Invoke(ctx, input)
+}
+
+// InvokeInto executes the tool and decodes its JSON result into a value of
+// type T, sparing callers the type assertion and json.Unmarshal that a raw
+// Invoke result usually needs.
+//
+// A "null" result yields the zero value of T. When T is string, a plain-text
+// result is returned as is.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the API request.
+//   - tool: The tool to invoke.
+//   - input: A map of parameter names to values for this invocation.
+//
+// Returns:
+//
+//	The decoded result and a nil error, or the zero value of T and an error
+//	if the invocation fails or the result cannot be decoded into T.
+func InvokeInto[T any](ctx context.Context, tool *ToolboxTool, input map[string]any) (T, error) {
+	var out T
+	result, err := tool.Invoke(ctx, input)
+	if err != nil {
+		return out, err
+	}
+
+	var data []byte
+	if s, ok := result.(string); ok {
+		data = []byte(s)
+	} else if data, err = json.Marshal(result); err != nil {
+		return out, fmt.Errorf("failed to encode result of tool '%s': %w", tool.name, err)
+	}
+
+	if !json.Valid(data) {
+		if text, ok := any(&out).(*string); ok {
+			*text = string(data)
+			return out, nil
+		}
+		return out, fmt.Errorf("result of tool '%s' is not JSON and cannot be decoded into %T: %q", tool.name, out, truncate(string(data), 100))
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to decode result of tool '%s' into %T: %w", tool.name, out, err)
+	}
+	return out, nil
+}
+
+// Validate checks that everything the tool resolves at invocation time is
+// currently available, without invoking the tool. It runs every
+// function-based bound parameter and every client header and auth token
+// source, and reports all failures together.
+//
+// Inputs:
+//   - ctx: The context for the validation. Parameters bound from the context
+//     are resolved from it, as they would be by Invoke.
+//
+// Returns:
+//
+//	nil if every source resolved, or an error naming each failing source.
+func (tt *ToolboxTool) Validate(ctx context.Context) error {
+	var errorMessages []string
+
+	for _, name := range slices.Sorted(maps.Keys(tt.boundParams)) {
+		value, isFunc, err := resolveBoundParam(ctx, tt.boundParams[name])
+		if !isFunc {
+			continue
+		}
+		if err != nil {
+			errorMessages = append(errorMessages, fmt.Sprintf("bound parameter '%s': %v", name, err))
+			continue
+		}
+		if schema, ok := tt.boundParamSchemas[name]; ok {
+			if err := schema.ValidateType(value); err != nil {
+				errorMessages = append(errorMessages, fmt.Sprintf("bound parameter '%s': %v", name, err))
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tt.clientHeaderSources)) {
+		if _, err := resolveToken(ctx, tt.clientHeaderSources[name]); err != nil {
+			errorMessages = append(errorMessages, fmt.Sprintf("client header '%s': %v", name, err))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(tt.authTokenSources)) {
+		if _, err := resolveToken(ctx, tt.authTokenSources[name]); err != nil {
+			errorMessages = append(errorMessages, fmt.Sprintf("auth token '%s': %v", name, err))
+		}
+	}
+
+	if len(errorMessages) > 0 {
+		return fmt.Errorf("validation failed for tool '%s': %s", tt.name, strings.Join(errorMessages, "; "))
+	}
+	return nil
+}
+
+// InvokePositional executes the tool with arguments given in the same order
+// as the tool's unbound parameters, as returned by Parameters.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the API request.
+//   - args: One value per unbound parameter, in declaration order.
+//
+// Returns:
+//
+//	The result from the tool's execution, or an error if the number of
+//	arguments does not match the number of parameters or the invocation fails.
+func (tt *ToolboxTool) InvokePositional(ctx context.Context, args ...any) (any, error) {
+	if len(args) != len(tt.parameters) {
+		return nil, fmt.Errorf("tool '%s' expects %d positional arguments but got %d", tt.name, len(tt.parameters), len(args))
+	}
+
+	input := make(map[string]any, len(args))
+	for i, p := range tt.parameters {
+		input[p.Name] = args[i]
+	}
+	return tt.Invoke(ctx, input)
+}
+
+// InvokeLines executes the tool and emits each JSON value of its result as it
+// is read, for tools that stream newline-delimited JSON rows. A result made
+// of a single JSON document is emitted as one element.
+//
+// When the transport supports streaming, values are emitted while the
+// response body is still being received; otherwise the complete result is
+// split into values once it has arrived.
+//
+// Input validation, bound parameters and headers are applied as for Invoke,
+// but the call is made once: a partly read stream cannot be retried, so
+// WithRetry and WithInvokeTimeout do not apply, and the WithBeforeInvoke,
+// WithAfterInvoke, WithAttemptObserver and WithMetricsObserver observers are
+// not called.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request. Cancelling it
+//     stops the stream.
+//   - input: A map of parameter names to values for this invocation.
+//
+// Returns:
+//
+//	A channel of JSON values, closed when the result is exhausted, and a
+//	channel that receives at most one error and is then closed.
+func (tt *ToolboxTool) InvokeLines(ctx context.Context, input map[string]any) (<-chan json.RawMessage, <-chan error) {
+	lines := make(chan json.RawMessage)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(lines)
+
+		body, err := tt.openResultStream(ctx, input)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer body.Close()
+
+		decoder := json.NewDecoder(body)
+		for {
+			var line json.RawMessage
+			if err := decoder.Decode(&line); err != nil {
+				if err == io.EOF {
+					return
+				}
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					err = ctxErr
+				}
+				errs <- fmt.Errorf("failed to read streamed result of tool '%s': %w", tt.name, err)
+				return
+			}
+
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return lines, errs
+}
+
+// openResultStream prepares an invocation and returns a reader over the
+// tool's result, streaming it when the transport supports it.
+func (tt *ToolboxTool) openResultStream(ctx context.Context, input map[string]any) (io.ReadCloser, error) {
+	finalPayload, resolvedHeaders, err := tt.prepareInvocation(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if si, ok := tt.transport.(transport.StreamInvoker); ok {
+		return si.InvokeToolStream(ctx, tt.name, finalPayload, resolvedHeaders)
+	}
+
+	result, err := tt.invokeTransport(ctx, finalPayload, resolvedHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if handler := tt.options().warningHandler; handler != nil && len(result.Warnings) > 0 {
+		handler(tt.name, result.Warnings)
+	}
+
+	output, err := tt.outputText(result.Output)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(strings.NewReader(output)), nil
+}
+
+// InvokeStream executes the tool and emits its result in chunks as the server
+// produces them, for servers that answer with Server-Sent Events. Progress
+// messages are emitted as text deltas, and the tool's output as the final
+// chunk. A failure after the stream has started is reported in a terminal
+// chunk carrying the error.
+//
+// When the transport does not support event streams, the complete result is
+// emitted as a single final chunk.
+//
+// As with InvokeLines, the call is made once, without the retries, timeout
+// and observers that Invoke applies.
+//
+// Inputs:
+//   - ctx: The context to control the lifecycle of the request. Cancelling it
+//     stops the stream.
+//   - input: A map of parameter names to values for this invocation.
+//
+// Returns:
+//
+//	A channel of chunks, closed after the final or terminal chunk, or an error
+//	if the invocation could not be started.
+func (tt *ToolboxTool) InvokeStream(ctx context.Context, input map[string]any) (<-chan StreamChunk, error) {
+	finalPayload, resolvedHeaders, err := tt.prepareInvocation(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if esi, ok := tt.transport.(transport.EventStreamInvoker); ok {
+		return esi.InvokeToolEvents(ctx, tt.name, finalPayload, resolvedHeaders)
+	}
+
+	result, err := tt.invokeTransport(ctx, finalPayload, resolvedHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if handler := tt.options().warningHandler; handler != nil && len(result.Warnings) > 0 {
+		handler(tt.name, result.Warnings)
+	}
+	output, err := tt.outputText(result.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan StreamChunk, 1)
+	chunks <- StreamChunk{Text: output, Final: true}
+	close(chunks)
+	return chunks, nil
+}
+
+// outputText returns a tool's output as text, encoding non-string outputs as
+// JSON.
+func (tt *ToolboxTool) outputText(output any) (string, error) {
+	if text, ok := output.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(output)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result of tool '%s': %w", tt.name, err)
+	}
+	return string(encoded), nil
+}
+
+// invokeWithAttempts makes the transport call for an invocation, retrying
+// transient failures according to the tool's retry policy, and reports each
+// attempt to the attempt observer, if one is registered.
+func (tt *ToolboxTool) invokeWithAttempts(ctx context.Context, payload map[string]any, headers map[string]string) (*transport.InvokeResult, error) {
+	opts := tt.options()
+	logger := opts.log()
+	for attempt := 1; ; attempt++ {
+		logger.DebugContext(ctx, "invoking tool", "tool", tt.name, "attempt", attempt, "headers", redactHeaders(headers))
+		result, err := tt.invokeTransport(ctx, payload, headers)
+		willRetry := err != nil && attempt < tt.maxAttempts && ctx.Err() == nil && opts.retryable(err)
+		switch {
+		case err == nil:
+			logger.DebugContext(ctx, "tool invocation succeeded", "tool", tt.name, "attempt", attempt, "status", result.StatusCode)
+		case willRetry:
+			logger.WarnContext(ctx, "tool invocation failed, retrying", "tool", tt.name, "attempt", attempt, "error", err)
+		default:
+			logger.ErrorContext(ctx, "tool invocation failed", "tool", tt.name, "attempt", attempt, "error", err)
+		}
+		if opts.attemptObserver != nil {
+			opts.attemptObserver(tt.name, attempt, err, willRetry)
+		}
+		if !willRetry {
+			return result, err
+		}
+
+		backoff := tt.backoff
+		if backoff == nil {
+			backoff = defaultBackoff
+		}
+		timer := time.NewTimer(backoff.Backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("retry aborted after attempt %d: %w", attempt, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// invokeTransport calls the tool on the underlying transport, using the
+// structured result path when the transport supports it.
+func (tt *ToolboxTool) invokeTransport(ctx context.Context, payload map[string]any, headers map[string]string) (*transport.InvokeResult, error) {
+	if ri, ok := tt.transport.(transport.ResultInvoker); ok {
+		return ri.InvokeToolResult(ctx, tt.name, payload, headers)
+	}
+
+	output, err := tt.transport.InvokeTool(ctx, tt.name, payload, headers)
+	if err != nil {
+		return nil, err
+	}
+	return &transport.InvokeResult{Output: output}, nil
+}
+
+// options returns the client-wide invocation settings for the tool.
+func (tt *ToolboxTool) options() *invokeOptions {
+	if tt.invokeOpts == nil {
+		return &defaultInvokeOptions
+	}
+	return tt.invokeOpts
+}
+
+// CheckInput validates input for the tool without invoking it, for example to
+// vet a model's proposed tool call before deciding whether to run it. It
+// reports unknown parameters, type mismatches and missing required
+// parameters, but does not resolve bound parameters, fetch auth tokens or
+// contact the server.
+//
+// Inputs:
+//   - input: The map of parameters that would be passed to Invoke.
+//
+// Returns:
+//
+//	A nil error if Invoke would accept the input, or the validation error it
+//	would report.
+func (tt *ToolboxTool) CheckInput(input map[string]any) error {
+	_, err := tt.checkInput(input)
+	return err
+}
+
+// checkInput runs the configured input sanitizer and validates the resulting
+// input against the tool's unbound parameters.
+//
+// Returns:
+//
+//	The sanitized input, or an error if it is rejected or fails validation.
+func (tt *ToolboxTool) checkInput(input map[string]any) (map[string]any, error) {
+	input, err := tt.prepareInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := tt.validateInput(input, tt.aggregateValidation)
+	switch len(errs) {
+	case 0:
+		return input, nil
+	case 1:
+		return nil, errs[0]
+	default:
+		return nil, errors.Join(errs...)
+	}
+}
+
+// prepareInput sanitizes the input, renames aliased keys and applies the
+// parameter transforms, ahead of validation.
+func (tt *ToolboxTool) prepareInput(input map[string]any) (map[string]any, error) {
+	// Let the configured sanitizer clean up the input before it is validated.
+	if sanitize := tt.options().inputSanitizer; sanitize != nil {
+		sanitized, err := sanitize(maps.Clone(input))
+		if err != nil {
+			return nil, fmt.Errorf("input rejected for tool '%s': %w", tt.name, err)
+		}
+		input = sanitized
+	}
+
+	// Rename keys given under an alias or in another case to their parameter.
+	if len(tt.paramAliases) > 0 || tt.caseInsensitiveParams {
+		normalized, err := tt.normalizeInputKeys(input)
+		if err != nil {
+			return nil, err
+		}
+		input = normalized
+	}
+
+	// Normalize the values of parameters that have a transform configured.
+	if len(tt.paramTransforms) > 0 {
+		input = maps.Clone(input)
+		for name, transform := range tt.paramTransforms {
+			value, ok := input[name]
+			if !ok {
+				continue
+			}
+			transformed, err := transform(value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to transform parameter '%s': %w", name, err)
+			}
+			input[name] = transformed
+		}
+	}
+
+	return input, nil
+}
+
+// ValidateInput checks the input against the tool's parameters without
+// invoking the tool, and reports every problem found, such as missing
+// required parameters, values of the wrong type or outside their enum, and
+// unexpected keys.
+//
+// Inputs:
+//   - input: A map of parameter names to values, as would be given to Invoke.
+//
+// Returns:
+//
+//	The validation errors, in a stable order, or nil if the input is valid.
+func (tt *ToolboxTool) ValidateInput(input map[string]any) []error {
+	input, err := tt.prepareInput(input)
+	if err != nil {
+		return []error{err}
+	}
+	return tt.validateInput(input, true)
+}
+
+// validateInput checks the prepared input against the tool's parameters. It
+// stops at the first problem unless all is set, in which case it collects
+// every one.
+func (tt *ToolboxTool) validateInput(input map[string]any, all bool) []error {
+	var errs []error
+	// fail records an error and reports whether validation should stop.
+	fail := func(err error) bool {
+		errs = append(errs, err)
+		return !all
+	}
+
 	// Create a map of the parameter schema for efficient lookups by name
 	paramSchema := make(map[string]ParameterSchema)
 	for _, p := range tt.parameters {
@@ -335,90 +1382,171 @@ func (tt *ToolboxTool) validateAndBuildPayload(input map[string]any) (map[string
 	}
 
 	// Validate user input against the schema.
-	for key, value := range input {
+	for _, key := range slices.Sorted(maps.Keys(input)) {
+		value := input[key]
 		param, isUnbound := paramSchema[key]
 		_, isBound := tt.boundParams[key]
 
 		// An input key is invalid if it's neither an expected unbound parameter
 		// nor a parameter that has been pre-configured (bound).
 		if !isUnbound || isBound {
-			return nil, fmt.Errorf("unexpected parameter '%s' provided", key)
+			err := fmt.Errorf("%w '%s' provided", ErrUnexpectedParam, key)
+			if suggestion := suggestParameterName(key, tt.parameters); !isUnbound && suggestion != "" {
+				err = fmt.Errorf("%w '%s' provided; did you mean '%s'?", ErrUnexpectedParam, key, suggestion)
+			}
+			if fail(err) {
+				return errs
+			}
+			continue
 		}
 
-		// If the parameter is a valid unbound parameter, validate its type.
-		if isUnbound {
-			if err := param.ValidateType(value); err != nil {
-				return nil, err
+		// The parameter is a valid unbound parameter, so validate its type.
+		if err := param.ValidateType(value); err != nil {
+			if fail(err) {
+				return errs
 			}
+			continue
 		}
-	}
-
-	// Initialize the final payload with the validated user input.
-	finalPayload := make(map[string]any, len(input)+len(tt.boundParams))
-	for k, v := range input {
-		if _, ok := paramSchema[k]; ok && v != nil {
-			finalPayload[k] = v
+		if tt.options().validateUTF8 {
+			if err := validateUTF8(key, value); err != nil {
+				if fail(err) {
+					return errs
+				}
+			}
 		}
 	}
 
 	for _, param := range tt.parameters {
-		_, isProvided := finalPayload[param.Name]
 		_, isBound := tt.boundParams[param.Name]
+		value, provided := input[param.Name]
+		// A default only stands in for a parameter the caller left out, not
+		// for one explicitly set to nil.
+		if isBound || value != nil || (!provided && param.Default != nil) {
+			continue
+		}
+		if param.Required {
+			if fail(fmt.Errorf("%w '%s'", ErrMissingRequiredParam, param.Name)) {
+				return errs
+			}
+		}
+	}
+
+	return errs
+}
+
+// normalizeInputKeys renames the input keys that are aliases of a parameter,
+// or that match a parameter or alias in another case when case-insensitive
+// matching is enabled, to the parameter's name. Keys that match nothing are
+// kept for validation to report.
+//
+// Returns:
+//
+//	The normalized input, or an error if two keys refer to the same parameter.
+func (tt *ToolboxTool) normalizeInputKeys(input map[string]any) (map[string]any, error) {
+	resolve := tt.inputKeyResolver()
+	normalized := make(map[string]any, len(input))
+	givenAs := make(map[string]string, len(input))
+	for _, key := range slices.Sorted(maps.Keys(input)) {
+		name := resolve(key)
+		if other, exists := givenAs[name]; exists {
+			return nil, fmt.Errorf("parameters '%s' and '%s' both refer to parameter '%s'", other, key, name)
+		}
+		givenAs[name] = key
+		normalized[name] = input[key]
+	}
+	return normalized, nil
+}
+
+// inputKeyResolver returns a function mapping an input key to the name of the
+// parameter it refers to, following aliases and, when enabled, matching in
+// any case. Keys that match nothing are returned unchanged.
+func (tt *ToolboxTool) inputKeyResolver() func(key string) string {
+	names := make([]string, 0, len(tt.parameters)+len(tt.boundParams))
+	for _, p := range tt.parameters {
+		names = append(names, p.Name)
+	}
+	names = append(names, slices.Sorted(maps.Keys(tt.boundParams))...)
 
-		if !isProvided && !isBound {
-			if param.Default != nil {
-				finalPayload[param.Name] = param.Default
-			} else if param.Required {
-				return nil, fmt.Errorf("missing required parameter '%s'", param.Name)
+	return func(key string) string {
+		if slices.Contains(names, key) {
+			return key
+		}
+		if name, ok := tt.paramAliases[key]; ok {
+			return name
+		}
+		if tt.caseInsensitiveParams {
+			for _, name := range names {
+				if strings.EqualFold(key, name) {
+					return name
+				}
+			}
+			for _, alias := range slices.Sorted(maps.Keys(tt.paramAliases)) {
+				if strings.EqualFold(key, alias) {
+					return tt.paramAliases[alias]
+				}
 			}
 		}
+		return key
+	}
+}
+
+// validateAndBuildPayload performs manual type validation and applies bound parameters.
+//
+// Inputs:
+//   - ctx: The invocation context, from which context-bound parameters are read.
+//   - input: The map of parameters provided by the user for this invocation.
+//
+// Returns:
+//
+//	A map representing the final, validated JSON payload, or an error if
+//	validation or parameter resolution fails.
+func (tt *ToolboxTool) validateAndBuildPayload(ctx context.Context, input map[string]any) (map[string]any, error) {
+	input, err := tt.checkInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	// Initialize the final payload with the validated user input and fill in
+	// defaults for parameters that were not provided. A parameter explicitly
+	// set to nil is left unset rather than defaulted.
+	finalPayload := make(map[string]any, len(input)+len(tt.boundParams))
+	// Whole numbers given for integer parameters are sent as integers.
+	for _, param := range tt.parameters {
+		v, provided := input[param.Name]
+		if v != nil {
+			finalPayload[param.Name] = coerceIntegers(v, &param)
+		} else if _, isBound := tt.boundParams[param.Name]; !provided && !isBound && param.Default != nil {
+			finalPayload[param.Name] = coerceIntegers(param.Default, &param)
+		}
 	}
 
 	// Loop through the bound parameters and add them to the payload.
 	for paramName, boundVal := range tt.boundParams {
-		var resolvedValue any
-		var resolveErr error
-		// A bound parameter can be a static value or a function that must be
-		// executed at invocation time to resolve the value.
-		switch v := boundVal.(type) {
-		case func() (string, error):
-			resolvedValue, resolveErr = v()
-		case func() (int, error):
-			resolvedValue, resolveErr = v()
-		case func() (float64, error):
-			resolvedValue, resolveErr = v()
-		case func() (bool, error):
-			resolvedValue, resolveErr = v()
-		case func() ([]string, error):
-			resolvedValue, resolveErr = v()
-		case func() ([]int, error):
-			resolvedValue, resolveErr = v()
-		case func() ([]float64, error):
-			resolvedValue, resolveErr = v()
-		case func() ([]bool, error):
-			resolvedValue, resolveErr = v()
-		case func() (map[string]string, error):
-			resolvedValue, resolveErr = v()
-		case func() (map[string]int, error):
-			resolvedValue, resolveErr = v()
-		case func() (map[string]float64, error):
-			resolvedValue, resolveErr = v()
-		case func() (map[string]bool, error):
-			resolvedValue, resolveErr = v()
-		case func() (map[string]any, error):
-			resolvedValue, resolveErr = v()
-		default:
-			resolvedValue = boundVal
-		}
+		resolvedValue, isFunc, resolveErr := resolveBoundParam(ctx, boundVal)
 		if resolveErr != nil {
 			return nil, fmt.Errorf("failed to resolve bound parameter function for '%s': %w", paramName, resolveErr)
 		}
 
+		// A function that resolves to nil leaves an optional parameter unset,
+		// but cannot satisfy a required one.
+		if isFunc && isNilValue(resolvedValue) {
+			if tt.boundParamSchemas[paramName].Required {
+				return nil, fmt.Errorf("bound parameter '%s' resolved to nil but is required", paramName)
+			}
+			continue
+		}
+
 		// Apply delayed schema validation
 		if schema, ok := tt.boundParamSchemas[paramName]; ok {
 			if err := schema.ValidateType(resolvedValue); err != nil {
 				return nil, fmt.Errorf("resolved bound parameter '%s' failed validation: %w", paramName, err)
 			}
+			resolvedValue = coerceIntegers(resolvedValue, &schema)
+		}
+		if tt.options().validateUTF8 {
+			if err := validateUTF8(paramName, resolvedValue); err != nil {
+				return nil, fmt.Errorf("resolved bound parameter '%s' failed validation: %w", paramName, err)
+			}
 		}
 
 		finalPayload[paramName] = resolvedValue
@@ -426,3 +1554,43 @@ func (tt *ToolboxTool) validateAndBuildPayload(input map[string]any) (map[string
 
 	return finalPayload, nil
 }
+
+// resolveBoundParam returns the value of a bound parameter. A bound parameter
+// can be a static value, or a function that must be executed at invocation
+// time to resolve the value, possibly from ctx; isFunc reports which one it
+// was.
+func resolveBoundParam(ctx context.Context, boundVal any) (value any, isFunc bool, err error) {
+	switch v := boundVal.(type) {
+	case contextParam:
+		value, err = v(ctx)
+	case func() (string, error):
+		value, err = v()
+	case func() (int, error):
+		value, err = v()
+	case func() (float64, error):
+		value, err = v()
+	case func() (bool, error):
+		value, err = v()
+	case func() ([]string, error):
+		value, err = v()
+	case func() ([]int, error):
+		value, err = v()
+	case func() ([]float64, error):
+		value, err = v()
+	case func() ([]bool, error):
+		value, err = v()
+	case func() (map[string]string, error):
+		value, err = v()
+	case func() (map[string]int, error):
+		value, err = v()
+	case func() (map[string]float64, error):
+		value, err = v()
+	case func() (map[string]bool, error):
+		value, err = v()
+	case func() (map[string]any, error):
+		value, err = v()
+	default:
+		return boundVal, false, nil
+	}
+	return value, true, err
+}
@@ -27,8 +27,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	mcp "github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp/v20250618"
@@ -51,6 +54,20 @@ func (d *dummyTransport) InvokeTool(ctx context.Context, name string, p map[stri
 	return nil, nil
 }
 
+// recordingTransport captures the payload and headers of the last invocation.
+type recordingTransport struct {
+	dummyTransport
+	output  any
+	payload map[string]any
+	headers map[string]string
+}
+
+func (r *recordingTransport) InvokeTool(ctx context.Context, name string, p map[string]any, h map[string]string) (any, error) {
+	r.payload = p
+	r.headers = h
+	return r.output, nil
+}
+
 func TestToolboxTool_Getters(t *testing.T) {
 	sampleParams := []ParameterSchema{
 		{Name: "param_one", Type: "string"},
@@ -248,6 +265,24 @@ func TestToolFrom(t *testing.T) {
 		}
 	})
 
+	t.Run("Negative Test - binding a wrong-typed static value", func(t *testing.T) {
+		tool := getTestTool()
+		_, err := tool.ToolFrom(WithBindParamInt("city", 42))
+		if err == nil {
+			t.Fatal("Expected an error when binding a wrong-typed value, but got nil")
+		}
+		if !strings.Contains(err.Error(), "bound parameter 'city' failed validation") {
+			t.Errorf("Incorrect error message for wrong-typed binding. Got: %q", err.Error())
+		}
+	})
+
+	t.Run("Binding a function defers type validation", func(t *testing.T) {
+		tool := getTestTool()
+		if _, err := tool.ToolFrom(WithBindParamIntFunc("city", func() (int, error) { return 42, nil })); err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+	})
+
 	t.Run("Negative Test - conflicting options are provided", func(t *testing.T) {
 		tool := getTestTool()
 		_, err := tool.ToolFrom(
@@ -263,6 +298,85 @@ func TestToolFrom(t *testing.T) {
 	})
 }
 
+func TestToolFrom_UnbindParam(t *testing.T) {
+	declared := []ParameterSchema{
+		{Name: "city", Type: "string", Required: true},
+		{Name: "units", Type: "string", Required: true},
+		{Name: "days", Type: "integer"},
+	}
+	baseTool := &ToolboxTool{
+		name:              "weather",
+		parameters:        []ParameterSchema{declared[0], declared[2]},
+		boundParams:       map[string]any{"units": "celsius"},
+		boundParamSchemas: map[string]ParameterSchema{"units": declared[1]},
+		schemaParameters:  declared,
+		transport:         &dummyTransport{baseURL: "http://example.com"},
+	}
+
+	t.Run("Unbound parameter becomes required input again", func(t *testing.T) {
+		newTool, err := baseTool.ToolFrom(WithUnbindParam("units"))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		if _, ok := newTool.boundParams["units"]; ok {
+			t.Error("Expected 'units' to be removed from the bound parameters")
+		}
+		if !reflect.DeepEqual(newTool.Parameters(), declared) {
+			t.Errorf("Expected parameters %v in declaration order, got %v", declared, newTool.Parameters())
+		}
+		if _, ok := baseTool.boundParams["units"]; !ok {
+			t.Error("The parent tool must keep its binding")
+		}
+
+		err = newTool.CheckInput(map[string]any{"city": "Oslo"})
+		if err == nil || !strings.Contains(err.Error(), "units") {
+			t.Errorf("Expected a missing 'units' error, got %v", err)
+		}
+		if err := newTool.CheckInput(map[string]any{"city": "Oslo", "units": "kelvin"}); err != nil {
+			t.Errorf("Expected the restored parameter to be accepted, got %v", err)
+		}
+	})
+
+	t.Run("Unbinding and binding other parameters together", func(t *testing.T) {
+		newTool, err := baseTool.ToolFrom(WithUnbindParam("units"), WithBindParamString("city", "Oslo"))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		want := []ParameterSchema{declared[1], declared[2]}
+		if !reflect.DeepEqual(newTool.Parameters(), want) {
+			t.Errorf("Expected parameters %v, got %v", want, newTool.Parameters())
+		}
+	})
+
+	t.Run("Falls back to the bound schema without a declaration", func(t *testing.T) {
+		tool := baseTool.cloneToolboxTool()
+		tool.schemaParameters = nil
+		newTool, err := tool.ToolFrom(WithUnbindParam("units"))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		if params := newTool.Parameters(); len(params) != 3 || params[2].Name != "units" {
+			t.Errorf("Expected 'units' to be restored, got %v", params)
+		}
+	})
+
+	t.Run("Negative Test - unbinding a parameter that is not bound", func(t *testing.T) {
+		for _, name := range []string{"city", "missing"} {
+			_, err := baseTool.ToolFrom(WithUnbindParam(name))
+			if err == nil || !strings.Contains(err.Error(), "is not bound on the tool") {
+				t.Errorf("Expected a not-bound error for '%s', got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("Negative Test - duplicate unbinding", func(t *testing.T) {
+		_, err := baseTool.ToolFrom(WithUnbindParam("units"), WithUnbindParam("units"))
+		if err == nil || !strings.Contains(err.Error(), "already unbound") {
+			t.Errorf("Expected a duplicate unbinding error, got %v", err)
+		}
+	})
+}
+
 func TestCloneToolboxTool(t *testing.T) {
 	// 1. Setup an original tool with populated maps and slices to test deep copying.
 	originalTransport := &dummyTransport{baseURL: "http://example.com"}
@@ -361,658 +475,2704 @@ func TestCloneToolboxTool(t *testing.T) {
 	})
 }
 
-func TestValidateAndBuildPayload(t *testing.T) {
-	// A base tool where some parameters are unbound and others are bound.
-	baseTool := &ToolboxTool{
-		parameters: []ParameterSchema{
-			{Name: "city", Type: "string"},
-			{Name: "days", Type: "integer"},
-			// "units" and "api_key" are NOT in this slice because they are bound.
-		},
-		boundParams: map[string]any{
-			"units": "metric", // A static bound parameter
-			"api_key": func() (string, error) { // A function-based bound parameter
-				return "secret-key", nil
+func TestToolboxTool_RequiredAuth(t *testing.T) {
+	newTool := func() *ToolboxTool {
+		return &ToolboxTool{
+			name:       "get_orders",
+			transport:  &dummyTransport{},
+			parameters: []ParameterSchema{{Name: "status", Type: "string"}},
+			requiredAuthnParams: map[string][]string{
+				"user_id": {"google", "github"},
 			},
-		},
+			requiredAuthzTokens: []string{"admin", "superuser"},
+			authTokenSources:    map[string]oauth2.TokenSource{},
+		}
 	}
 
-	t.Run("Happy Path - combines user input and bound params", func(t *testing.T) {
-		input := map[string]any{
-			"city": "London",
-			"days": 5,
+	t.Run("returns outstanding requirements", func(t *testing.T) {
+		tool := newTool()
+		wantAuthn := map[string][]string{"user_id": {"google", "github"}}
+		if got := tool.RequiredAuthn(); !reflect.DeepEqual(got, wantAuthn) {
+			t.Errorf("RequiredAuthn() = %v, want %v", got, wantAuthn)
 		}
-
-		payload, err := baseTool.validateAndBuildPayload(input)
-		if err != nil {
-			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		wantAuthz := []string{"admin", "superuser"}
+		if got := tool.RequiredAuthz(); !reflect.DeepEqual(got, wantAuthz) {
+			t.Errorf("RequiredAuthz() = %v, want %v", got, wantAuthz)
 		}
+	})
 
-		expectedPayload := map[string]any{
-			"city":    "London",
-			"days":    5,
-			"units":   "metric",
-			"api_key": "secret-key",
-		}
+	t.Run("returns deep copies", func(t *testing.T) {
+		tool := newTool()
 
-		if !reflect.DeepEqual(payload, expectedPayload) {
-			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		authn := tool.RequiredAuthn()
+		authn["user_id"][0] = "tampered"
+		authn["extra"] = []string{"other"}
+		authz := tool.RequiredAuthz()
+		authz[0] = "tampered"
+
+		if got := tool.requiredAuthnParams["user_id"][0]; got != "google" {
+			t.Errorf("Modifying RequiredAuthn() changed the tool's services to %q", got)
+		}
+		if _, ok := tool.requiredAuthnParams["extra"]; ok {
+			t.Error("Modifying RequiredAuthn() added a requirement to the tool")
+		}
+		if got := tool.requiredAuthzTokens[0]; got != "admin" {
+			t.Errorf("Modifying RequiredAuthz() changed the tool's tokens to %q", got)
 		}
 	})
 
-	t.Run("Happy Path - resolves map and map function bound parameters", func(t *testing.T) {
-		toolWithMaps := &ToolboxTool{
-			parameters: []ParameterSchema{
-				{Name: "query", Type: "string"},
-			},
-			boundParams: map[string]any{
-				"static_map": map[string]string{"key": "value"},
-				"func_map": func() (map[string]int, error) {
-					return map[string]int{"count": 42}, nil
-				},
-			},
-		}
+	t.Run("excludes requirements met by added token sources", func(t *testing.T) {
+		tool := newTool()
 
-		input := map[string]any{
-			"query": "test query",
+		withGoogle, err := tool.ToolFrom(WithAuthTokenString("google", "token"))
+		if err != nil {
+			t.Fatalf("ToolFrom returned an unexpected error: %v", err)
+		}
+		if got := withGoogle.RequiredAuthn(); len(got) != 0 {
+			t.Errorf("Expected no outstanding authn requirements, got %v", got)
+		}
+		if got := withGoogle.RequiredAuthz(); !reflect.DeepEqual(got, []string{"admin", "superuser"}) {
+			t.Errorf("Expected authz requirements to remain, got %v", got)
 		}
 
-		payload, err := toolWithMaps.validateAndBuildPayload(input)
+		withAdmin, err := withGoogle.ToolFrom(WithAuthTokenString("superuser", "token"))
 		if err != nil {
-			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+			t.Fatalf("ToolFrom returned an unexpected error: %v", err)
 		}
-
-		expectedPayload := map[string]any{
-			"query":      "test query",
-			"static_map": map[string]string{"key": "value"},
-			"func_map":   map[string]int{"count": 42},
+		if got := withAdmin.RequiredAuthz(); got == nil || len(got) != 0 {
+			t.Errorf("Expected a non-nil empty slice, got %#v", got)
 		}
 
-		if !reflect.DeepEqual(payload, expectedPayload) {
-			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		// The parent tool is unaffected.
+		if got := tool.RequiredAuthn(); len(got) != 1 {
+			t.Errorf("Expected the parent tool to still require auth, got %v", got)
 		}
 	})
 
-	t.Run("Negative Test - fails on type validation error", func(t *testing.T) {
-		input := map[string]any{
-			"city": "Paris",
-			"days": "five", // Incorrect type
-		}
-
-		_, err := baseTool.validateAndBuildPayload(input)
-
-		if err == nil {
-			t.Fatal("Expected a type validation error, but got nil")
+	t.Run("no requirements", func(t *testing.T) {
+		tool := &ToolboxTool{name: "public", transport: &dummyTransport{}}
+		if got := tool.RequiredAuthn(); got == nil || len(got) != 0 {
+			t.Errorf("Expected a non-nil empty map, got %#v", got)
 		}
-		if !strings.Contains(err.Error(), "expects an integer, but got string") {
-			t.Errorf("Incorrect error message for type mismatch. Got: %v", err)
+		if got := tool.RequiredAuthz(); got == nil || len(got) != 0 {
+			t.Errorf("Expected a non-nil empty slice, got %#v", got)
 		}
 	})
+}
 
-	t.Run("Negative Test - fails on extra parameter provided in input", func(t *testing.T) {
-		input := map[string]any{
-			"city":        "Tokyo",
-			"extra_param": "this should now cause an error",
+func TestToolboxTool_AuthHeaderFormatter(t *testing.T) {
+	newTool := func(opts *invokeOptions) *ToolboxTool {
+		return &ToolboxTool{
+			name:       "get_weather",
+			transport:  &dummyTransport{baseURL: "https://example.com"},
+			parameters: []ParameterSchema{{Name: "city", Type: "string"}},
+			authTokenSources: map[string]oauth2.TokenSource{
+				"weather_api": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "secret"}),
+			},
+			invokeOpts: opts,
 		}
+	}
+	input := map[string]any{"city": "London"}
 
-		_, err := baseTool.validateAndBuildPayload(input)
-
-		if err == nil {
-			t.Fatal("Expected an error for extra parameter, but got nil")
+	t.Run("default appends _token suffix", func(t *testing.T) {
+		_, headers, err := newTool(nil).prepareInvocation(context.Background(), input)
+		if err != nil {
+			t.Fatalf("prepareInvocation returned an unexpected error: %v", err)
 		}
-		if !strings.Contains(err.Error(), "unexpected parameter 'extra_param' provided") {
-			t.Errorf("Incorrect error message for extra parameter. Got: %v", err)
+		want := map[string]string{"weather_api_token": "secret"}
+		if !reflect.DeepEqual(headers, want) {
+			t.Errorf("Expected headers %v, got %v", want, headers)
 		}
 	})
 
-	t.Run("Success on nested object in payload", func(t *testing.T) {
-		// Create a tool with an object (map) parameter
-		toolWithMap := &ToolboxTool{
-			parameters: []ParameterSchema{
-				{Name: "metadata", Type: "object"},
+	t.Run("custom bearer formatter", func(t *testing.T) {
+		var services []string
+		opts := &invokeOptions{
+			authHeaderFormatter: func(service string) (string, func(string) string) {
+				services = append(services, service)
+				return "Authorization", func(token string) string { return "Bearer " + token }
 			},
 		}
+		_, headers, err := newTool(opts).prepareInvocation(context.Background(), input)
+		if err != nil {
+			t.Fatalf("prepareInvocation returned an unexpected error: %v", err)
+		}
+		want := map[string]string{"Authorization": "Bearer secret"}
+		if !reflect.DeepEqual(headers, want) {
+			t.Errorf("Expected headers %v, got %v", want, headers)
+		}
+		if !reflect.DeepEqual(services, []string{"weather_api"}) {
+			t.Errorf("Expected formatter to be called for 'weather_api', got %v", services)
+		}
+	})
 
-		// Try to provide a nested map as the value for the object parameter
-		input := map[string]any{
-			"metadata": map[string]any{
-				"inner": map[string]any{"nested": "value"},
+	t.Run("nil value function sends token unchanged", func(t *testing.T) {
+		opts := &invokeOptions{
+			authHeaderFormatter: func(service string) (string, func(string) string) {
+				return "X-Service-Token", nil
 			},
 		}
-
-		_, err := toolWithMap.validateAndBuildPayload(input)
+		_, headers, err := newTool(opts).prepareInvocation(context.Background(), input)
 		if err != nil {
-			t.Fatalf("Expected nested maps to be accepted for object parameters, but got an error: %v", err)
+			t.Fatalf("prepareInvocation returned an unexpected error: %v", err)
+		}
+		if got := headers["X-Service-Token"]; got != "secret" {
+			t.Errorf("Expected header 'X-Service-Token' to be 'secret', got %q", got)
 		}
 	})
 
-	t.Run("Success - bound map can contain nested structures", func(t *testing.T) {
-		toolWithNestedMap := &ToolboxTool{
-			boundParams: map[string]any{
-				"invalid_map": map[string]any{
-					"nested_key": map[string]string{"k": "v"},
-				},
+	t.Run("invalid header name", func(t *testing.T) {
+		opts := &invokeOptions{
+			authHeaderFormatter: func(service string) (string, func(string) string) {
+				return "bad header", nil
 			},
 		}
-
-		_, err := toolWithNestedMap.validateAndBuildPayload(map[string]any{})
-		if err != nil {
-			t.Fatalf("Expected nested maps to be accepted for object parameters, but got an error: %v", err)
+		_, _, err := newTool(opts).prepareInvocation(context.Background(), input)
+		if err == nil || !strings.Contains(err.Error(), "invalid header for service 'weather_api'") {
+			t.Errorf("Expected an invalid header error, got: %v", err)
 		}
 	})
+}
 
-	t.Run("Negative Test - fails when bound function returns an error", func(t *testing.T) {
-		toolWithFailingFunc := &ToolboxTool{
-			boundParams: map[string]any{
-				"api_key": func() (string, error) {
-					return "", errors.New("failed to retrieve key")
-				},
+func TestToolboxTool_SentinelErrors(t *testing.T) {
+	newTool := func() *ToolboxTool {
+		return &ToolboxTool{
+			name:      "get_weather",
+			transport: &dummyTransport{},
+			parameters: []ParameterSchema{
+				{Name: "city", Type: "string", Required: true},
+				{Name: "days", Type: "integer"},
 			},
+			boundParams:       map[string]any{"units": "metric"},
+			boundParamSchemas: map[string]ParameterSchema{"units": {Name: "units", Type: "string"}},
+			authTokenSources:  map[string]oauth2.TokenSource{},
 		}
+	}
 
-		_, err := toolWithFailingFunc.validateAndBuildPayload(map[string]any{})
+	testCases := []struct {
+		name    string
+		tool    func() *ToolboxTool
+		input   map[string]any
+		target  error
+		message string
+	}{
+		{
+			name:    "missing required parameter",
+			tool:    newTool,
+			input:   map[string]any{"days": 3},
+			target:  ErrMissingRequiredParam,
+			message: "missing required parameter 'city'",
+		},
+		{
+			name:    "unknown parameter",
+			tool:    newTool,
+			input:   map[string]any{"city": "Paris", "country": "FR"},
+			target:  ErrUnexpectedParam,
+			message: "unexpected parameter 'country' provided",
+		},
+		{
+			name:    "bound parameter",
+			tool:    newTool,
+			input:   map[string]any{"city": "Paris", "units": "imperial"},
+			target:  ErrUnexpectedParam,
+			message: "unexpected parameter 'units' provided",
+		},
+		{
+			name: "missing auth token",
+			tool: func() *ToolboxTool {
+				tool := newTool()
+				tool.requiredAuthzTokens = []string{"google"}
+				return tool
+			},
+			input:   map[string]any{"city": "Paris"},
+			target:  ErrPermission,
+			message: "permission error: auth service 'google' is required",
+		},
+		{
+			name: "rejected by the server",
+			tool: func() *ToolboxTool {
+				tool := newTool()
+				tool.transport = &slowTransport{err: &transport.StatusError{StatusCode: http.StatusUnauthorized, Body: "bad token"}}
+				return tool
+			},
+			input:   map[string]any{"city": "Paris"},
+			target:  ErrUnauthorized,
+			message: "API request failed with status 401",
+		},
+	}
 
-		if err == nil {
-			t.Fatal("Expected an error from a failing bound function, but got nil")
-		}
-		if !strings.Contains(err.Error(), "failed to resolve bound parameter function for 'api_key'") {
-			t.Errorf("Incorrect error message for function resolution failure. Got: %v", err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.tool().Invoke(context.Background(), tc.input)
+			if !errors.Is(err, tc.target) {
+				t.Fatalf("Expected errors.Is(err, %v) to be true, got: %v", tc.target, err)
+			}
+			if !strings.Contains(err.Error(), tc.message) {
+				t.Errorf("Expected error to contain %q, got: %v", tc.message, err)
+			}
+		})
+	}
+
+	t.Run("validateAndBuildPayload", func(t *testing.T) {
+		_, err := newTool().validateAndBuildPayload(context.Background(), map[string]any{})
+		if !errors.Is(err, ErrMissingRequiredParam) {
+			t.Errorf("Expected ErrMissingRequiredParam, got: %v", err)
 		}
 	})
 
-	t.Run("Negative Test - fails when user input targets a bound parameter", func(t *testing.T) {
-		// This test ensures that if a parameter is bound, the user cannot
-		// attempt to provide a value for it.
-		toolWithBoundUnits := &ToolboxTool{
-			parameters: []ParameterSchema{{Name: "city", Type: "string"}},
-			boundParams: map[string]any{
-				"units": "metric",
-			},
+	t.Run("forbidden status details", func(t *testing.T) {
+		tool := newTool()
+		tool.transport = &slowTransport{err: &transport.StatusError{StatusCode: http.StatusForbidden, Body: "denied"}}
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "Paris"})
+		if !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("Expected ErrUnauthorized, got: %v", err)
 		}
-
-		input := map[string]any{
-			"city":  "UserCity",
-			"units": "imperial", // User tries to provide a value for a bound param
+		var statusErr *transport.StatusError
+		if !errors.As(err, &statusErr) || statusErr.Body != "denied" {
+			t.Errorf("Expected the StatusError to be in the chain, got: %v", err)
 		}
+	})
 
-		_, err := toolWithBoundUnits.validateAndBuildPayload(input)
+	t.Run("other statuses are not unauthorized", func(t *testing.T) {
+		tool := newTool()
+		tool.transport = &slowTransport{err: &transport.StatusError{StatusCode: http.StatusBadRequest}}
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "Paris"})
+		if err == nil || errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Expected a non-unauthorized error, got: %v", err)
+		}
+	})
 
-		if err == nil {
-			t.Fatal("Expected an error when providing input for a bound parameter, but got nil")
+	t.Run("bound parameter override", func(t *testing.T) {
+		_, err := newTool().ToolFrom(WithBindParamString("units", "imperial"))
+		if !errors.Is(err, ErrBoundParamOverride) {
+			t.Fatalf("Expected ErrBoundParamOverride, got: %v", err)
 		}
-		if !strings.Contains(err.Error(), "unexpected parameter 'units' provided") {
-			t.Errorf("Incorrect error message for bound parameter override. Got: %v", err)
+		if !strings.Contains(err.Error(), "cannot override existing bound parameter: 'units'") {
+			t.Errorf("Unexpected error message: %v", err)
 		}
 	})
+}
 
-	t.Run("Default parameter is injected when not provided", func(t *testing.T) {
-		toolWithDefault := &ToolboxTool{
-			parameters: []ParameterSchema{
-				{Name: "city", Type: "string"},
-				{Name: "units", Type: "string", Default: "metric"},
-			},
-			boundParams: map[string]any{},
-		}
+func TestToolboxTool_UserData(t *testing.T) {
+	type uiInfo struct{ Category string }
 
-		input := map[string]any{
-			"city": "London",
+	tool := &ToolboxTool{
+		name:       "search",
+		transport:  &dummyTransport{},
+		parameters: []ParameterSchema{{Name: "query", Type: "string"}},
+	}
+	if tool.UserData() != nil {
+		t.Fatalf("Expected no user data on a new tool, got %v", tool.UserData())
+	}
+
+	tool.SetUserData(uiInfo{Category: "lookup"})
+
+	t.Run("Survives cloning", func(t *testing.T) {
+		clone := tool.cloneToolboxTool()
+		if got, ok := clone.UserData().(uiInfo); !ok || got.Category != "lookup" {
+			t.Errorf("Expected clone to carry user data, got %#v", clone.UserData())
 		}
+	})
 
-		payload, err := toolWithDefault.validateAndBuildPayload(input)
+	t.Run("Survives derivation and can be replaced independently", func(t *testing.T) {
+		derived, err := tool.ToolFrom(WithBindParamString("query", "golang"))
 		if err != nil {
-			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
 		}
-
-		expectedPayload := map[string]any{
-			"city":  "London",
-			"units": "metric", // Injected default
+		if got, ok := derived.UserData().(uiInfo); !ok || got.Category != "lookup" {
+			t.Errorf("Expected derived tool to carry user data, got %#v", derived.UserData())
 		}
 
-		if !reflect.DeepEqual(payload, expectedPayload) {
-			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		derived.SetUserData("replaced")
+		if got := tool.UserData().(uiInfo); got.Category != "lookup" {
+			t.Errorf("Replacing the derived tool's user data affected the original, got %#v", got)
 		}
 	})
 
-	t.Run("User input overrides default parameter", func(t *testing.T) {
-		toolWithDefault := &ToolboxTool{
-			parameters: []ParameterSchema{
-				{Name: "city", Type: "string"},
-				{Name: "units", Type: "string", Default: "metric"},
-			},
-			boundParams: map[string]any{},
+	t.Run("Is not sent to the server", func(t *testing.T) {
+		tr := &recordingTransport{}
+		sent := &ToolboxTool{name: "search", transport: tr, userData: "secret-ui-data"}
+		if _, err := sent.Invoke(context.Background(), map[string]any{}); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
 		}
-
-		input := map[string]any{
-			"city":  "New York",
-			"units": "imperial", // User overrides default
+		if len(tr.payload) != 0 {
+			t.Errorf("Expected an empty payload, got %v", tr.payload)
 		}
+		for k, v := range tr.headers {
+			if v == "secret-ui-data" {
+				t.Errorf("User data leaked into header %q", k)
+			}
+		}
+	})
+}
+
+func TestToolboxTool_CheckInput(t *testing.T) {
+	var resolved bool
+	tool := &ToolboxTool{
+		name:      "search",
+		transport: &dummyTransport{},
+		parameters: []ParameterSchema{
+			{Name: "query", Type: "string", Required: true},
+			{Name: "limit", Type: "integer"},
+		},
+		boundParams: map[string]any{
+			"tenant": func() (string, error) {
+				resolved = true
+				return "", errors.New("bound functions must not be resolved")
+			},
+		},
+		authTokenSources: map[string]oauth2.TokenSource{
+			"google": &failingTokenSource{},
+		},
+	}
+
+	t.Run("Valid input", func(t *testing.T) {
+		if err := tool.CheckInput(map[string]any{"query": "books", "limit": 5}); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if resolved {
+			t.Error("Expected bound parameter functions not to be resolved")
+		}
+	})
+
+	t.Run("Type error", func(t *testing.T) {
+		err := tool.CheckInput(map[string]any{"query": "books", "limit": "five"})
+		if err == nil {
+			t.Fatal("Expected a type error, got nil")
+		}
+		if !strings.Contains(err.Error(), "parameter 'limit' expects an integer") {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Missing required parameter", func(t *testing.T) {
+		err := tool.CheckInput(map[string]any{"limit": 5})
+		if err == nil || err.Error() != "missing required parameter 'query'" {
+			t.Errorf("Expected a missing parameter error, got %v", err)
+		}
+	})
+
+	t.Run("Unknown and bound parameters are rejected", func(t *testing.T) {
+		if err := tool.CheckInput(map[string]any{"query": "books", "tenant": "acme"}); err == nil {
+			t.Error("Expected an error for providing a bound parameter, got nil")
+		}
+		if err := tool.CheckInput(map[string]any{"query": "books", "page": 2}); err == nil {
+			t.Error("Expected an error for an unknown parameter, got nil")
+		}
+	})
+}
+
+func TestToolboxTool_ValidateUTF8(t *testing.T) {
+	newTool := func(opts *invokeOptions) *ToolboxTool {
+		return &ToolboxTool{
+			name:      "annotate",
+			transport: &dummyTransport{},
+			parameters: []ParameterSchema{
+				{Name: "note", Type: "string"},
+				{Name: "tags", Type: "array", Items: &ParameterSchema{Type: "string"}},
+				{Name: "labels", Type: "object", AdditionalProperties: &ParameterSchema{Type: "string"}},
+			},
+			invokeOpts: opts,
+		}
+	}
+	invalid := "caf\xc3\x28"
+	strict := newTool(&invokeOptions{validateUTF8: true})
+
+	t.Run("Valid strings are accepted", func(t *testing.T) {
+		input := map[string]any{
+			"note":   "café ☕",
+			"tags":   []string{"naïve", "日本"},
+			"labels": map[string]any{"lang": "español"},
+		}
+		if err := strict.CheckInput(input); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	testCases := []struct {
+		name  string
+		input map[string]any
+		param string
+	}{
+		{name: "Invalid string", input: map[string]any{"note": invalid}, param: "note"},
+		{name: "Invalid array element", input: map[string]any{"tags": []any{"ok", invalid}}, param: "tags"},
+		{name: "Invalid object value", input: map[string]any{"labels": map[string]string{"lang": "\xff\xfe"}}, param: "labels"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := strict.CheckInput(tc.input)
+			want := fmt.Sprintf("parameter '%s' contains invalid UTF-8", tc.param)
+			if err == nil || err.Error() != want {
+				t.Errorf("Expected error %q, got %v", want, err)
+			}
+
+			if err := newTool(nil).CheckInput(tc.input); err != nil {
+				t.Errorf("Expected invalid UTF-8 to be accepted by default, got %v", err)
+			}
+		})
+	}
+
+	t.Run("Bound values are checked", func(t *testing.T) {
+		tool := newTool(&invokeOptions{validateUTF8: true})
+		tool.boundParams = map[string]any{"note": invalid}
+		_, err := tool.validateAndBuildPayload(context.Background(), map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "parameter 'note' contains invalid UTF-8") {
+			t.Errorf("Expected an invalid UTF-8 error for the bound parameter, got %v", err)
+		}
+	})
+}
+
+func TestToolboxTool_Invoke_ValidateOutput(t *testing.T) {
+	outputSchema := []ParameterSchema{
+		{Name: "city", Type: "string", Required: true},
+		{Name: "temperature", Type: "float", Required: true},
+		{Name: "readings", Type: "array", Items: &ParameterSchema{Type: "integer"}},
+	}
+	newTool := func(output any, validate bool) *ToolboxTool {
+		return &ToolboxTool{
+			name:         "weather",
+			transport:    &slowTransport{output: output},
+			parameters:   []ParameterSchema{},
+			outputSchema: outputSchema,
+			invokeOpts:   &invokeOptions{validateOutput: validate},
+		}
+	}
+
+	t.Run("Conforming result passes", func(t *testing.T) {
+		output := `{"city": "Oslo", "temperature": 3, "readings": [1, 2, 3], "extra": true}`
+		result, err := newTool(output, true).Invoke(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result != output {
+			t.Errorf("Expected the result to be returned unchanged, got %v", result)
+		}
+	})
+
+	testCases := []struct {
+		name    string
+		output  any
+		wantErr string
+	}{
+		{name: "Wrong field type", output: `{"city": "Oslo", "temperature": "cold"}`, wantErr: "parameter 'temperature' expects an float"},
+		{name: "Missing required field", output: `{"temperature": 3.5}`, wantErr: "missing required field 'city'"},
+		{name: "Wrong array element", output: `{"city": "Oslo", "temperature": 3.5, "readings": [1, 2.5]}`, wantErr: "error in array 'readings' at index 1"},
+		{name: "Not an object", output: `["Oslo"]`, wantErr: "result is not a JSON object"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newTool(tc.output, true).Invoke(context.Background(), map[string]any{})
+			if err == nil {
+				t.Fatal("Expected an output validation error, got nil")
+			}
+			if !strings.Contains(err.Error(), "result of tool 'weather' does not match its output schema") ||
+				!strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tc.wantErr, err)
+			}
+
+			if _, err := newTool(tc.output, false).Invoke(context.Background(), map[string]any{}); err != nil {
+				t.Errorf("Expected no validation by default, got %v", err)
+			}
+		})
+	}
+}
+
+func TestToolboxTool_ParameterTransform(t *testing.T) {
+	lowercase := func(v any) (any, error) {
+		s, ok := v.(string)
+		if !ok {
+			return v, nil
+		}
+		return strings.ToLower(strings.TrimSpace(s)), nil
+	}
+	baseTool := &ToolboxTool{
+		name:      "invite",
+		transport: &dummyTransport{},
+		parameters: []ParameterSchema{
+			{Name: "email", Type: "string", Required: true},
+			{Name: "age", Type: "integer"},
+		},
+	}
+
+	t.Run("Lowercases an email parameter", func(t *testing.T) {
+		tool, err := baseTool.ToolFrom(WithParameterTransform("email", lowercase))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		input := map[string]any{"email": "  Jane.Doe@Example.COM "}
+		payload, err := tool.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if payload["email"] != "jane.doe@example.com" {
+			t.Errorf("Expected normalized email, got %v", payload["email"])
+		}
+		if input["email"] != "  Jane.Doe@Example.COM " {
+			t.Errorf("Expected the caller's input to be left untouched, got %v", input["email"])
+		}
+	})
+
+	t.Run("Transform runs before type validation", func(t *testing.T) {
+		parseAge := func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return strconv.Atoi(s)
+			}
+			return v, nil
+		}
+		tool, err := baseTool.ToolFrom(WithParameterTransform("age", parseAge))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		payload, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"email": "a@b.c", "age": "42"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if payload["age"] != 42 {
+			t.Errorf("Expected age to be converted to 42, got %#v", payload["age"])
+		}
+	})
+
+	t.Run("Negative Test - transform error rejects the input", func(t *testing.T) {
+		reject := func(v any) (any, error) { return nil, errors.New("not a company address") }
+		tool, err := baseTool.ToolFrom(WithParameterTransform("email", reject))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		_, err = tool.validateAndBuildPayload(context.Background(), map[string]any{"email": "jane@gmail.com"})
+		if err == nil || err.Error() != "failed to transform parameter 'email': not a company address" {
+			t.Errorf("Expected the transform error, got %v", err)
+		}
+	})
+
+	t.Run("Negative Test - unknown or duplicate parameter", func(t *testing.T) {
+		if _, err := baseTool.ToolFrom(WithParameterTransform("phone", lowercase)); err == nil {
+			t.Error("Expected an error for a transform on an unknown parameter, got nil")
+		}
+		tool, _ := baseTool.ToolFrom(WithParameterTransform("email", lowercase))
+		if _, err := tool.ToolFrom(WithParameterTransform("email", lowercase)); err == nil {
+			t.Error("Expected an error when overriding an existing transform, got nil")
+		}
+	})
+}
+
+func TestToolboxTool_ParamAliases(t *testing.T) {
+	baseTool := &ToolboxTool{
+		name:      "query",
+		transport: &dummyTransport{},
+		parameters: []ParameterSchema{
+			{Name: "num_rows", Type: "integer"},
+			{Name: "table", Type: "string", Required: true},
+		},
+		boundParams: map[string]any{"region": "emea"},
+	}
+
+	t.Run("Resolves an alias to its parameter", func(t *testing.T) {
+		tool, err := baseTool.ToolFrom(WithParamAliases(map[string][]string{"num_rows": {"numRows", "limit"}}))
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		payload, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"numRows": 5, "table": "orders"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := map[string]any{"num_rows": 5, "table": "orders", "region": "emea"}
+		if !reflect.DeepEqual(payload, expected) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expected, payload)
+		}
+
+		// Aliases are case-sensitive unless case-insensitive matching is on.
+		if _, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"NumRows": 5, "table": "orders"}); err == nil {
+			t.Error("Expected an error for an alias in another case, got nil")
+		}
+	})
+
+	t.Run("Matches parameters and aliases in any case", func(t *testing.T) {
+		tool, err := baseTool.ToolFrom(
+			WithCaseInsensitiveParams(true),
+			WithParamAliases(map[string][]string{"num_rows": {"numRows"}}),
+		)
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		payload, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"NUMROWS": 5, "Table": "orders"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := map[string]any{"num_rows": 5, "table": "orders", "region": "emea"}
+		if !reflect.DeepEqual(payload, expected) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expected, payload)
+		}
+
+		// A bound parameter is still rejected, whatever its case.
+		_, err = tool.validateAndBuildPayload(context.Background(), map[string]any{"table": "orders", "Region": "us"})
+		if err == nil || !strings.Contains(err.Error(), "unexpected parameter 'region' provided") {
+			t.Errorf("Expected a bound parameter error, got %v", err)
+		}
+	})
+
+	t.Run("Negative Test - conflicting inputs", func(t *testing.T) {
+		tool, err := baseTool.ToolFrom(
+			WithCaseInsensitiveParams(true),
+			WithParamAliases(map[string][]string{"num_rows": {"numRows"}}),
+		)
+		if err != nil {
+			t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+		}
+		input := map[string]any{"numRows": 5, "num_rows": 6, "table": "orders"}
+		_, err = tool.validateAndBuildPayload(context.Background(), input)
+		if err == nil || err.Error() != "parameters 'numRows' and 'num_rows' both refer to parameter 'num_rows'" {
+			t.Errorf("Expected a conflict error, got %v", err)
+		}
+
+		_, err = tool.validateAndBuildPayload(context.Background(), map[string]any{"Table": "a", "table": "b"})
+		if err == nil || err.Error() != "parameters 'Table' and 'table' both refer to parameter 'table'" {
+			t.Errorf("Expected a conflict error, got %v", err)
+		}
+	})
+
+	t.Run("Negative Test - invalid aliases", func(t *testing.T) {
+		if _, err := baseTool.ToolFrom(WithParamAliases(map[string][]string{"rows": {"r"}})); err == nil {
+			t.Error("Expected an error for an alias of an unknown parameter, got nil")
+		}
+		if _, err := baseTool.ToolFrom(WithParamAliases(map[string][]string{"num_rows": {"table"}})); err == nil {
+			t.Error("Expected an error for an alias that is another parameter, got nil")
+		}
+		tool, _ := baseTool.ToolFrom(WithParamAliases(map[string][]string{"num_rows": {"limit"}}))
+		if _, err := tool.ToolFrom(WithParamAliases(map[string][]string{"table": {"limit"}})); err == nil {
+			t.Error("Expected an error when overriding an existing alias, got nil")
+		}
+	})
+}
+
+func TestValidateAndBuildPayload(t *testing.T) {
+	// A base tool where some parameters are unbound and others are bound.
+	baseTool := &ToolboxTool{
+		parameters: []ParameterSchema{
+			{Name: "city", Type: "string"},
+			{Name: "days", Type: "integer"},
+			// "units" and "api_key" are NOT in this slice because they are bound.
+		},
+		boundParams: map[string]any{
+			"units": "metric", // A static bound parameter
+			"api_key": func() (string, error) { // A function-based bound parameter
+				return "secret-key", nil
+			},
+		},
+	}
+
+	t.Run("Happy Path - combines user input and bound params", func(t *testing.T) {
+		input := map[string]any{
+			"city": "London",
+			"days": 5,
+		}
+
+		payload, err := baseTool.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		}
+
+		expectedPayload := map[string]any{
+			"city":    "London",
+			"days":    5,
+			"units":   "metric",
+			"api_key": "secret-key",
+		}
+
+		if !reflect.DeepEqual(payload, expectedPayload) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		}
+	})
+
+	t.Run("Happy Path - resolves map and map function bound parameters", func(t *testing.T) {
+		toolWithMaps := &ToolboxTool{
+			parameters: []ParameterSchema{
+				{Name: "query", Type: "string"},
+			},
+			boundParams: map[string]any{
+				"static_map": map[string]string{"key": "value"},
+				"func_map": func() (map[string]int, error) {
+					return map[string]int{"count": 42}, nil
+				},
+			},
+		}
+
+		input := map[string]any{
+			"query": "test query",
+		}
+
+		payload, err := toolWithMaps.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		}
+
+		expectedPayload := map[string]any{
+			"query":      "test query",
+			"static_map": map[string]string{"key": "value"},
+			"func_map":   map[string]int{"count": 42},
+		}
+
+		if !reflect.DeepEqual(payload, expectedPayload) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		}
+	})
+
+	t.Run("Negative Test - fails on type validation error", func(t *testing.T) {
+		input := map[string]any{
+			"city": "Paris",
+			"days": "five", // Incorrect type
+		}
+
+		_, err := baseTool.validateAndBuildPayload(context.Background(), input)
+
+		if err == nil {
+			t.Fatal("Expected a type validation error, but got nil")
+		}
+		if !strings.Contains(err.Error(), "expects an integer, but got string") {
+			t.Errorf("Incorrect error message for type mismatch. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - fails on extra parameter provided in input", func(t *testing.T) {
+		input := map[string]any{
+			"city":        "Tokyo",
+			"extra_param": "this should now cause an error",
+		}
+
+		_, err := baseTool.validateAndBuildPayload(context.Background(), input)
+
+		if err == nil {
+			t.Fatal("Expected an error for extra parameter, but got nil")
+		}
+		if !strings.Contains(err.Error(), "unexpected parameter 'extra_param' provided") {
+			t.Errorf("Incorrect error message for extra parameter. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - hints at the intended parameter on casing mismatches", func(t *testing.T) {
+		tool := &ToolboxTool{
+			name: "query",
+			parameters: []ParameterSchema{
+				{Name: "num_rows", Type: "integer", Required: true},
+				{Name: "tableName", Type: "string"},
+			},
+		}
+
+		testCases := []struct {
+			key        string
+			suggestion string
+		}{
+			{"numRows", "num_rows"},
+			{"NUM_ROWS", "num_rows"},
+			{"table_name", "tableName"},
+			{"table-name", "tableName"},
+		}
+		for _, tc := range testCases {
+			_, err := tool.validateAndBuildPayload(context.Background(), map[string]any{tc.key: 1})
+			if err == nil {
+				t.Fatalf("Expected an error for near-miss parameter %q, but got nil", tc.key)
+			}
+			expected := fmt.Sprintf("unexpected parameter '%s' provided; did you mean '%s'?", tc.key, tc.suggestion)
+			if err.Error() != expected {
+				t.Errorf("Expected error %q, got %q", expected, err.Error())
+			}
+		}
+
+		_, err := tool.validateAndBuildPayload(context.Background(), map[string]any{"rows": 1})
+		if err == nil || strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("Expected no hint for an unrelated parameter, got: %v", err)
+		}
+	})
+
+	t.Run("Input sanitizer", func(t *testing.T) {
+		stripInternal := func(input map[string]any) (map[string]any, error) {
+			for k := range input {
+				if strings.HasPrefix(k, "_") {
+					delete(input, k)
+				}
+			}
+			return input, nil
+		}
+		newTool := func(sanitizer func(map[string]any) (map[string]any, error)) *ToolboxTool {
+			return &ToolboxTool{
+				name:       "search",
+				parameters: []ParameterSchema{{Name: "query", Type: "string"}},
+				invokeOpts: &invokeOptions{inputSanitizer: sanitizer},
+			}
+		}
+
+		t.Run("Strips underscore-prefixed keys before validation", func(t *testing.T) {
+			input := map[string]any{"query": "books", "_trace": "abc", "_internal": true}
+			payload, err := newTool(stripInternal).validateAndBuildPayload(context.Background(), input)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(payload, map[string]any{"query": "books"}) {
+				t.Errorf("Expected sanitized payload, got %v", payload)
+			}
+			if len(input) != 3 {
+				t.Errorf("Expected the caller's input to be left untouched, got %v", input)
+			}
+		})
+
+		t.Run("Negative Test - without a sanitizer the key is rejected", func(t *testing.T) {
+			_, err := newTool(nil).validateAndBuildPayload(context.Background(), map[string]any{"query": "books", "_trace": "abc"})
+			if err == nil || err.Error() != "unexpected parameter '_trace' provided" {
+				t.Errorf("Expected an unexpected parameter error, got %v", err)
+			}
+		})
+
+		t.Run("Negative Test - sanitizer rejects the input", func(t *testing.T) {
+			reject := func(map[string]any) (map[string]any, error) {
+				return nil, errors.New("policy violation")
+			}
+			_, err := newTool(reject).validateAndBuildPayload(context.Background(), map[string]any{"query": "books"})
+			if err == nil || err.Error() != "input rejected for tool 'search': policy violation" {
+				t.Errorf("Expected the sanitizer's error, got %v", err)
+			}
+		})
+	})
+
+	t.Run("Bound function resolving to nil", func(t *testing.T) {
+		newTool := func(required bool) *ToolboxTool {
+			return &ToolboxTool{
+				name: "query",
+				boundParams: map[string]any{
+					"filters": func() (map[string]any, error) { return nil, nil },
+				},
+				boundParamSchemas: map[string]ParameterSchema{
+					"filters": {Name: "filters", Type: "object", Required: required},
+				},
+			}
+		}
+
+		t.Run("Negative Test - fails for a required parameter", func(t *testing.T) {
+			_, err := newTool(true).validateAndBuildPayload(context.Background(), nil)
+			if err == nil {
+				t.Fatal("Expected an error for a required parameter resolving to nil, but got nil")
+			}
+			if err.Error() != "bound parameter 'filters' resolved to nil but is required" {
+				t.Errorf("Incorrect error message. Got: %v", err)
+			}
+		})
+
+		t.Run("Omits an optional parameter", func(t *testing.T) {
+			payload, err := newTool(false).validateAndBuildPayload(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, ok := payload["filters"]; ok {
+				t.Errorf("Expected optional parameter to be omitted, got payload %v", payload)
+			}
+		})
+
+		t.Run("Keeps an empty non-nil value", func(t *testing.T) {
+			tool := newTool(true)
+			tool.boundParams["filters"] = func() (map[string]any, error) { return map[string]any{}, nil }
+			payload, err := tool.validateAndBuildPayload(context.Background(), nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, ok := payload["filters"]; !ok {
+				t.Error("Expected an empty, non-nil map to be kept in the payload")
+			}
+		})
+	})
+
+	t.Run("Success on nested object in payload", func(t *testing.T) {
+		// Create a tool with an object (map) parameter
+		toolWithMap := &ToolboxTool{
+			parameters: []ParameterSchema{
+				{Name: "metadata", Type: "object"},
+			},
+		}
+
+		// Try to provide a nested map as the value for the object parameter
+		input := map[string]any{
+			"metadata": map[string]any{
+				"inner": map[string]any{"nested": "value"},
+			},
+		}
+
+		_, err := toolWithMap.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("Expected nested maps to be accepted for object parameters, but got an error: %v", err)
+		}
+	})
+
+	t.Run("Success - bound map can contain nested structures", func(t *testing.T) {
+		toolWithNestedMap := &ToolboxTool{
+			boundParams: map[string]any{
+				"invalid_map": map[string]any{
+					"nested_key": map[string]string{"k": "v"},
+				},
+			},
+		}
+
+		_, err := toolWithNestedMap.validateAndBuildPayload(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Expected nested maps to be accepted for object parameters, but got an error: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - fails when bound function returns an error", func(t *testing.T) {
+		toolWithFailingFunc := &ToolboxTool{
+			boundParams: map[string]any{
+				"api_key": func() (string, error) {
+					return "", errors.New("failed to retrieve key")
+				},
+			},
+		}
+
+		_, err := toolWithFailingFunc.validateAndBuildPayload(context.Background(), map[string]any{})
+
+		if err == nil {
+			t.Fatal("Expected an error from a failing bound function, but got nil")
+		}
+		if !strings.Contains(err.Error(), "failed to resolve bound parameter function for 'api_key'") {
+			t.Errorf("Incorrect error message for function resolution failure. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - fails when user input targets a bound parameter", func(t *testing.T) {
+		// This test ensures that if a parameter is bound, the user cannot
+		// attempt to provide a value for it.
+		toolWithBoundUnits := &ToolboxTool{
+			parameters: []ParameterSchema{{Name: "city", Type: "string"}},
+			boundParams: map[string]any{
+				"units": "metric",
+			},
+		}
+
+		input := map[string]any{
+			"city":  "UserCity",
+			"units": "imperial", // User tries to provide a value for a bound param
+		}
+
+		_, err := toolWithBoundUnits.validateAndBuildPayload(context.Background(), input)
+
+		if err == nil {
+			t.Fatal("Expected an error when providing input for a bound parameter, but got nil")
+		}
+		if !strings.Contains(err.Error(), "unexpected parameter 'units' provided") {
+			t.Errorf("Incorrect error message for bound parameter override. Got: %v", err)
+		}
+	})
+
+	t.Run("Default parameter is injected when not provided", func(t *testing.T) {
+		toolWithDefault := &ToolboxTool{
+			parameters: []ParameterSchema{
+				{Name: "city", Type: "string"},
+				{Name: "units", Type: "string", Default: "metric"},
+			},
+			boundParams: map[string]any{},
+		}
+
+		input := map[string]any{
+			"city": "London",
+		}
+
+		payload, err := toolWithDefault.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		}
+
+		expectedPayload := map[string]any{
+			"city":  "London",
+			"units": "metric", // Injected default
+		}
+
+		if !reflect.DeepEqual(payload, expectedPayload) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		}
+	})
+
+	t.Run("User input overrides default parameter", func(t *testing.T) {
+		toolWithDefault := &ToolboxTool{
+			parameters: []ParameterSchema{
+				{Name: "city", Type: "string"},
+				{Name: "units", Type: "string", Default: "metric"},
+			},
+			boundParams: map[string]any{},
+		}
+
+		input := map[string]any{
+			"city":  "New York",
+			"units": "imperial", // User overrides default
+		}
+
+		payload, err := toolWithDefault.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		}
+
+		expectedPayload := map[string]any{
+			"city":  "New York",
+			"units": "imperial", // User input wins
+		}
+
+		if !reflect.DeepEqual(payload, expectedPayload) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		}
+	})
+
+	t.Run("Explicit nil opts out of the default", func(t *testing.T) {
+		toolWithDefault := &ToolboxTool{
+			parameters: []ParameterSchema{
+				{Name: "city", Type: "string"},
+				{Name: "units", Type: "string", Default: "metric"},
+			},
+			boundParams: map[string]any{},
+		}
+
+		payload, err := toolWithDefault.validateAndBuildPayload(context.Background(), map[string]any{"city": "Paris", "units": nil})
+		if err != nil {
+			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		}
+
+		expectedPayload := map[string]any{"city": "Paris"}
+		if !reflect.DeepEqual(payload, expectedPayload) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		}
+	})
+
+	t.Run("Missing required parameter with default is valid", func(t *testing.T) {
+		toolWithRequiredDefault := &ToolboxTool{
+			parameters: []ParameterSchema{
+				// Required is true, but it has a default, so it's okay if missing from input
+				{Name: "format", Type: "string", Required: true, Default: "json"},
+			},
+			boundParams: map[string]any{},
+		}
+
+		// Input is completely empty
+		input := map[string]any{}
+
+		payload, err := toolWithRequiredDefault.validateAndBuildPayload(context.Background(), input)
+		if err != nil {
+			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+		}
+
+		expectedPayload := map[string]any{
+			"format": "json", // Injected default
+		}
+
+		if !reflect.DeepEqual(payload, expectedPayload) {
+			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+		}
+	})
+
+	t.Run("Explicit nil for required parameter with default is rejected", func(t *testing.T) {
+		toolWithRequiredDefault := &ToolboxTool{
+			parameters: []ParameterSchema{
+				{Name: "format", Type: "string", Required: true, Default: "json"},
+			},
+			boundParams: map[string]any{},
+		}
+
+		input := map[string]any{"format": nil}
+		payload, err := toolWithRequiredDefault.validateAndBuildPayload(context.Background(), input)
+		if err == nil || !strings.Contains(err.Error(), "'format' is required") {
+			t.Fatalf("Expected a required parameter error, got payload %v and error %v", payload, err)
+		}
+
+		errs := toolWithRequiredDefault.validateInput(input, true)
+		if len(errs) == 0 || !errors.Is(errors.Join(errs...), ErrMissingRequiredParam) {
+			t.Errorf("Expected the default not to satisfy the required check, got %v", errs)
+		}
+	})
+}
+
+type errorReader struct{}
+
+func (er *errorReader) Read(p []byte) (n int, err error) {
+	return 0, errors.New("simulated read error")
+}
+
+// failingTransport is a custom transport to inject a failing reader
+type failingTransport struct{}
+
+func (ft *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(&errorReader{}),
+	}, nil
+}
+
+// JSON-RPC Structures
+type jsonRPCRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	ID      any    `json:"id,omitempty"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type jsonRPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   any             `json:"error,omitempty"`
+}
+
+type mcpToolCallParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+func TestToolboxTool_Invoke(t *testing.T) {
+	// A base tool for successful invocations
+	createBaseTool := func(httpClient *http.Client, baseURL string) *ToolboxTool {
+		tr, _ := mcp.New(baseURL, httpClient, "test-client", "1.0.0")
+
+		return &ToolboxTool{
+			name:        "weather",
+			description: "Get the weather",
+			transport:   tr,
+			parameters: []ParameterSchema{
+				{Name: "city", Type: "string"},
+			},
+			boundParams: map[string]any{
+				"units": "metric",
+			},
+			authTokenSources: map[string]oauth2.TokenSource{
+				"weather_api": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "api-token-123"}),
+			},
+			clientHeaderSources: map[string]oauth2.TokenSource{
+				"X-Client-Version": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "v1.0.0"}),
+			},
+			requiredAuthzTokens: []string{"weather_api"},
+		}
+	}
+
+	// MCP Mock Server Factory
+	newMockMCPServer := func(handler func(req jsonRPCRequest) (any, error)) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Basic header validation
+			if r.Header.Get("Content-Type") != "application/json" {
+				http.Error(w, "invalid content type", http.StatusBadRequest)
+				return
+			}
+
+			// Read body
+			body, _ := io.ReadAll(r.Body)
+			var req jsonRPCRequest
+			json.Unmarshal(body, &req)
+
+			// Handle Handshake
+			if req.Method == "initialize" {
+				res := map[string]any{
+					"protocolVersion": "2025-06-18",
+					"capabilities":    map[string]any{"tools": map[string]any{}},
+					"serverInfo":      map[string]any{"name": "mock", "version": "1"},
+				}
+				resp, _ := json.Marshal(res)
+				json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resp})
+				return
+			}
+			if req.Method == "notifications/initialized" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			// Invoke custom handler for 'tools/call'
+			res, err := handler(req)
+			w.Header().Set("Content-Type", "application/json")
+
+			resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
+			if err != nil {
+				// MCP error struct
+				resp.Error = map[string]any{
+					"code":    -32000,
+					"message": err.Error(),
+				}
+			} else {
+				resBytes, _ := json.Marshal(res)
+				resp.Result = resBytes
+			}
+			json.NewEncoder(w).Encode(resp)
+		}))
+	}
+
+	// loadWeatherTool loads the 'weather' tool from the server through a
+	// client configured with the given options.
+	loadWeatherTool := func(t *testing.T, server *httptest.Server, opts ...ClientOption) *ToolboxTool {
+		t.Helper()
+		opts = append([]ClientOption{WithHTTPClient(server.Client()), WithProtocol(MCPv20250618)}, opts...)
+		client, err := NewToolboxClient(server.URL, opts...)
+		if err != nil {
+			t.Fatalf("NewToolboxClient failed unexpectedly: %v", err)
+		}
+		tool, err := client.LoadTool("weather", context.Background())
+		if err != nil {
+			t.Fatalf("LoadTool failed unexpectedly: %v", err)
+		}
+		return tool
+	}
+
+	// weatherToolList answers tools/list with the 'weather' tool.
+	weatherToolList := map[string]any{"tools": []map[string]any{{
+		"name":        "weather",
+		"inputSchema": map[string]any{"type": "object", "properties": map[string]any{"city": map[string]any{"type": "string"}}},
+	}}}
+
+	t.Run("Successful invocation", func(t *testing.T) {
+		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
+			var params mcpToolCallParams
+			argsBytes, _ := json.Marshal(req.Params)
+			json.Unmarshal(argsBytes, &params)
+
+			// Validate payload
+			if params.Name != "weather" {
+				return nil, fmt.Errorf("unexpected tool name: %s", params.Name)
+			}
+			city, _ := params.Arguments["city"].(string)
+			units, _ := params.Arguments["units"].(string)
+
+			if city != "London" || units != "metric" {
+				return nil, fmt.Errorf("incorrect args")
+			}
+
+			return map[string]any{
+				"content": []map[string]string{
+					{"type": "text", "text": "sunny"},
+				},
+			}, nil
+		})
+		defer server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		result, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if result != "sunny" {
+			t.Errorf("Expected result 'sunny', got '%v'", result)
+		}
+	})
+
+	t.Run("Applies correct _token suffix to auth headers but not client headers", func(t *testing.T) {
+		checkHeaders := func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Custom-Header") != "client-val" {
+				t.Errorf("Expected client header 'X-Custom-Header' to be 'client-val', got %q", r.Header.Get("X-Custom-Header"))
+			}
+
+			// Assert auth token header is present with suffix
+			if r.Header.Get("my_auth_token") != "auth-val" {
+				t.Errorf("Expected auth header 'my_auth_token' to be 'auth-val', got %q", r.Header.Get("my_auth_token"))
+			}
+
+			// Assert auth token header is NOT present without suffix
+			if r.Header.Get("my_auth") != "" {
+				t.Errorf("Auth header 'my_auth' should not exist, but it does")
+			}
+		}
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checkHeaders(w, r)
+			// Respond to handshake/call minimally
+			body, _ := io.ReadAll(r.Body)
+			var req jsonRPCRequest
+			json.Unmarshal(body, &req)
+
+			if req.Method == "initialize" {
+				res, _ := json.Marshal(map[string]any{"protocolVersion": "2025-06-18", "capabilities": map[string]any{"tools": map[string]any{}}, "serverInfo": map[string]any{"name": "mock", "version": "1"}})
+				json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
+				return
+			}
+			if req.Method == "notifications/initialized" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			// tools/call
+			res, _ := json.Marshal(map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}})
+			json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
+		}))
+		defer server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		// Add the specific headers for this test
+		tool.clientHeaderSources["X-Custom-Header"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "client-val"})
+		tool.authTokenSources["my_auth"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "auth-val"})
+
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+	})
+
+	t.Run("Auth token headers overwrite client headers with the same final name", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("special_api_token") != "auth_value_should_win" {
+				t.Errorf("Expected header 'special_api_token' to be overwritten by auth token source, but it was not. Got: %q", r.Header.Get("special_api_token"))
+			}
+			// Respond OK
+			body, _ := io.ReadAll(r.Body)
+			var req jsonRPCRequest
+			json.Unmarshal(body, &req)
+			if req.Method == "initialize" {
+				res, _ := json.Marshal(map[string]any{"protocolVersion": "2025-06-18", "capabilities": map[string]any{"tools": map[string]any{}}, "serverInfo": map[string]any{"name": "mock", "version": "1"}})
+				json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
+				return
+			}
+			if req.Method == "notifications/initialized" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			res, _ := json.Marshal(map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}})
+			json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
+		}))
+		defer server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		tool.clientHeaderSources["special_api_token"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "client_value_should_lose"})
+		tool.authTokenSources["special_api"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "auth_value_should_win"})
+
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when required auth is missing", func(t *testing.T) {
+		tool := createBaseTool(http.DefaultClient, "")
+		tool.requiredAuthzTokens = []string{"required_service"} // This service is not in authTokenSources
+
+		_, err := tool.Invoke(context.Background(), nil)
+
+		if err == nil {
+			t.Fatal("Expected an error for missing auth service, but got nil")
+		}
+		if !strings.Contains(err.Error(), "permission error: auth service 'required_service' is required") {
+			t.Errorf("Incorrect error message for missing auth. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when payload validation fails", func(t *testing.T) {
+		tool := createBaseTool(http.DefaultClient, "")
+
+		// Pass an extra parameter, which should be rejected
+		_, err := tool.Invoke(context.Background(), map[string]any{"extra": "param"})
+
+		if err == nil {
+			t.Fatal("Expected an error from payload validation, but got nil")
+		}
+		if !strings.Contains(err.Error(), "unexpected parameter 'extra' provided") {
+			t.Errorf("Incorrect error message for payload validation. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when server returns an error status", func(t *testing.T) {
+		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
+			return nil, errors.New("invalid city format")
+		})
+		defer server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+
+		if err == nil {
+			t.Fatal("Expected an error from a non-200 server response, but got nil")
+		}
+		// Updated error message expectation for MCP transport
+		if !strings.Contains(err.Error(), "invalid city format") {
+			t.Errorf("Incorrect error message for server error. Got: %v", err)
+		}
+	})
+
+	t.Run("Success Path - Handles non-json successful response", func(t *testing.T) {
+		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
+			return map[string]any{
+				"content": []map[string]string{
+					{"type": "text", "text": "Plain text success message"},
+				},
+			}, nil
+		})
+		defer server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		result, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly for plain text response: %v", err)
+		}
+		if result != "Plain text success message" {
+			t.Errorf("Expected plain text result, got '%v'", result)
+		}
+	})
+
+	t.Run("Negative Test - Fails when required AuthN (param-level) is missing", func(t *testing.T) {
+		tool := createBaseTool(http.DefaultClient, "")
+		// This tool requires a 'google' token for one of its parameters.
+		tool.requiredAuthnParams = map[string][]string{
+			"user_location": {"google"},
+		}
+		// The base tool does not provide the 'google' token source.
+
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+
+		if err == nil {
+			t.Fatal("Expected an error for missing AuthN service, but got nil")
+		}
+		if !strings.Contains(err.Error(), "permission error: auth service 'google' is required") {
+			t.Errorf("Incorrect error message for missing param-level auth. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when required AuthZ (tool-level) is missing", func(t *testing.T) {
+		tool := createBaseTool(http.DefaultClient, "")
+		tool.requiredAuthzTokens = []string{"required_service"} // This service is not in authTokenSources
+
+		_, err := tool.Invoke(context.Background(), nil)
+
+		if err == nil {
+			t.Fatal("Expected an error for missing auth service, but got nil")
+		}
+		if !strings.Contains(err.Error(), "permission error: auth service 'required_service' is required") {
+			t.Errorf("Incorrect error message for missing tool-level auth. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when server returns an error status with non-JSON body", func(t *testing.T) {
+		// MCP server returns 500
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("Internal Server Error"))
+		}))
+		defer server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+
+		if err == nil {
+			t.Fatal("Expected an error from a non-200 server response, but got nil")
+		}
+		if !strings.Contains(err.Error(), "API request failed with status 500") {
+			t.Errorf("Incorrect error message for non-JSON server error. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when API call itself fails", func(t *testing.T) {
+		// Create a server and immediately close it to simulate a network error
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		server.Close()
+
+		tool := createBaseTool(server.Client(), server.URL)
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+
+		if err == nil {
+			t.Fatal("Expected an error from a failed API call, but got nil")
+		}
+		if !strings.Contains(err.Error(), "http request failed") {
+			t.Errorf("Incorrect error message for failed API call. Got: %v", err)
+		}
+	})
+
+	t.Run("Negative Test - Fails when reading response body fails", func(t *testing.T) {
+		// The mock server for this test case is intentionally minimal,
+		// as the failure is injected via the custom http.Client.
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK) // Needed to pass status check in doRPC
+		}))
+		defer server.Close()
+
+		failingClient := &http.Client{Transport: &failingTransport{}}
+		tool := createBaseTool(failingClient, server.URL)
+
+		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		if err == nil {
+			t.Fatal("Expected an error from a failing response body read, but got nil")
+		}
+		if !strings.Contains(err.Error(), "read body failed") {
+			t.Errorf("Incorrect error message for failed body read. Got: %v", err)
+		}
+	})
+
+	t.Run("Success Path - Reports server warnings to the handler", func(t *testing.T) {
+		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
+			if req.Method == "tools/list" {
+				return weatherToolList, nil
+			}
+			return map[string]any{
+				"content": []map[string]string{
+					{"type": "text", "text": "sunny"},
+				},
+				"warnings": []string{"results truncated"},
+				"_meta": map[string]any{
+					"warnings": []string{"units parameter is deprecated"},
+				},
+			}, nil
+		})
+		defer server.Close()
+
+		var gotTool string
+		var gotWarnings []string
+		tool := loadWeatherTool(t, server, WithWarningHandler(func(toolName string, warnings []string) {
+			gotTool = toolName
+			gotWarnings = warnings
+		}))
+
+		result, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if result != "sunny" {
+			t.Errorf("Expected result 'sunny', got '%v'", result)
+		}
+		if gotTool != "weather" {
+			t.Errorf("Expected warning handler to receive tool 'weather', got %q", gotTool)
+		}
+		expected := []string{"results truncated", "units parameter is deprecated"}
+		if !reflect.DeepEqual(gotWarnings, expected) {
+			t.Errorf("Expected warnings %v, got %v", expected, gotWarnings)
+		}
+	})
+
+	t.Run("Success Path - Handler is not called without warnings", func(t *testing.T) {
+		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
+			if req.Method == "tools/list" {
+				return weatherToolList, nil
+			}
+			return map[string]any{
+				"content": []map[string]string{
+					{"type": "text", "text": "sunny"},
+				},
+			}, nil
+		})
+		defer server.Close()
+
+		called := false
+		tool := loadWeatherTool(t, server, WithWarningHandler(func(string, []string) { called = true }))
+
+		if _, err := tool.Invoke(context.Background(), map[string]any{"city": "London"}); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if called {
+			t.Error("Expected warning handler not to be called when the server sent no warnings")
+		}
+	})
+
+	t.Run("Reports HTTP timings", func(t *testing.T) {
+		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
+			time.Sleep(10 * time.Millisecond)
+			return map[string]any{
+				"content": []map[string]string{{"type": "text", "text": "sunny"}},
+			}, nil
+		})
+		defer server.Close()
+
+		var calls int
+		var gotName string
+		var timing HTTPTiming
+		tool := createBaseTool(server.Client(), server.URL)
+		tool.invokeOpts = &invokeOptions{
+			httpTrace: func(toolName string, tm HTTPTiming) {
+				calls++
+				gotName = toolName
+				timing = tm
+			},
+		}
+
+		if _, err := tool.Invoke(context.Background(), map[string]any{"city": "London"}); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if calls != 1 {
+			t.Fatalf("Expected the trace callback to fire once, got %d", calls)
+		}
+		if gotName != "weather" {
+			t.Errorf("Expected tool name 'weather', got %q", gotName)
+		}
+		if timing.Total <= 0 {
+			t.Errorf("Expected a non-zero total time, got %v", timing.Total)
+		}
+		if timing.FirstByte < 10*time.Millisecond {
+			t.Errorf("Expected time to first byte to include server time, got %v", timing.FirstByte)
+		}
+		if timing.Requests == 0 {
+			t.Error("Expected at least one HTTP request to be recorded")
+		}
+		if timing.Total < timing.FirstByte {
+			t.Errorf("Expected total %v to cover time to first byte %v", timing.Total, timing.FirstByte)
+		}
+	})
+
+}
+func TestToolboxTool_Invoke_BindParamFromContext(t *testing.T) {
+	type ctxKey string
+	rt := &recordingTransport{output: "ok"}
+	baseTool := &ToolboxTool{
+		name: "search",
+		parameters: []ParameterSchema{
+			{Name: "tenant", Type: "string", Required: true},
+			{Name: "limit", Type: "integer"},
+			{Name: "ratio", Type: "float"},
+			{Name: "verbose", Type: "boolean"},
+			{Name: "query", Type: "string"},
+		},
+		transport: rt,
+	}
+	tool, err := baseTool.ToolFrom(
+		WithBindParamStringFromContext("tenant", ctxKey("tenant")),
+		WithBindParamIntFromContext("limit", ctxKey("limit")),
+		WithBindParamFloatFromContext("ratio", ctxKey("ratio")),
+		WithBindParamBoolFromContext("verbose", ctxKey("verbose")),
+	)
+	if err != nil {
+		t.Fatalf("ToolFrom failed unexpectedly: %v", err)
+	}
+
+	newCtx := func(tenant any) context.Context {
+		ctx := context.WithValue(context.Background(), ctxKey("tenant"), tenant)
+		ctx = context.WithValue(ctx, ctxKey("limit"), int64(10))
+		ctx = context.WithValue(ctx, ctxKey("ratio"), float32(0.5))
+		return context.WithValue(ctx, ctxKey("verbose"), true)
+	}
+
+	t.Run("Values are read from the invocation context", func(t *testing.T) {
+		for _, tenant := range []string{"acme", "globex"} {
+			if _, err := tool.Invoke(newCtx(tenant), map[string]any{"query": "q"}); err != nil {
+				t.Fatalf("Invoke failed unexpectedly: %v", err)
+			}
+			want := map[string]any{"tenant": tenant, "limit": 10, "ratio": 0.5, "verbose": true, "query": "q"}
+			if !reflect.DeepEqual(rt.payload, want) {
+				t.Errorf("Expected payload %v, got %v", want, rt.payload)
+			}
+		}
+	})
+
+	t.Run("Missing context value", func(t *testing.T) {
+		// A nil value is the same as no value at all.
+		ctx := newCtx(nil)
+		_, err := tool.Invoke(ctx, map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "context has no value for key 'tenant'") {
+			t.Errorf("Expected a missing context value error, got %v", err)
+		}
+		if err := tool.Validate(ctx); err == nil || !strings.Contains(err.Error(), "bound parameter 'tenant'") {
+			t.Errorf("Expected Validate to report the missing value, got %v", err)
+		}
+	})
+
+	t.Run("Context value of the wrong type", func(t *testing.T) {
+		_, err := tool.Invoke(newCtx(42), map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "is a int, not a string") {
+			t.Errorf("Expected a type error, got %v", err)
+		}
+	})
+}
+
+func TestToolboxTool_InvokePositional(t *testing.T) {
+	newTool := func(tr transport.Transport) *ToolboxTool {
+		return &ToolboxTool{
+			name:      "search",
+			transport: tr,
+			parameters: []ParameterSchema{
+				{Name: "query", Type: "string"},
+				{Name: "limit", Type: "integer"},
+			},
+			boundParams: map[string]any{"lang": "en"},
+		}
+	}
 
-		payload, err := toolWithDefault.validateAndBuildPayload(input)
+	t.Run("Zips arguments against parameters in order", func(t *testing.T) {
+		tr := &recordingTransport{output: "ok"}
+		tool := newTool(tr)
+
+		result, err := tool.InvokePositional(context.Background(), "books", 5)
 		if err != nil {
-			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+			t.Fatalf("InvokePositional failed unexpectedly: %v", err)
+		}
+		if result != "ok" {
+			t.Errorf("Expected result 'ok', got '%v'", result)
+		}
+		expected := map[string]any{"query": "books", "limit": 5, "lang": "en"}
+		if !reflect.DeepEqual(tr.payload, expected) {
+			t.Errorf("Expected payload %v, got %v", expected, tr.payload)
 		}
+	})
 
-		expectedPayload := map[string]any{
-			"city":  "New York",
-			"units": "imperial", // User input wins
+	t.Run("Negative Test - Fails on too few arguments", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr)
+
+		_, err := tool.InvokePositional(context.Background(), "books")
+		if err == nil {
+			t.Fatal("Expected an arity error, but got nil")
+		}
+		if !strings.Contains(err.Error(), "expects 2 positional arguments but got 1") {
+			t.Errorf("Incorrect error message for arity mismatch. Got: %v", err)
+		}
+		if tr.payload != nil {
+			t.Error("Transport should not be called on arity mismatch")
 		}
+	})
 
-		if !reflect.DeepEqual(payload, expectedPayload) {
-			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+	t.Run("Negative Test - Fails on too many arguments", func(t *testing.T) {
+		tool := newTool(&recordingTransport{})
+
+		_, err := tool.InvokePositional(context.Background(), "books", 5, "extra")
+		if err == nil {
+			t.Fatal("Expected an arity error, but got nil")
+		}
+		if !strings.Contains(err.Error(), "expects 2 positional arguments but got 3") {
+			t.Errorf("Incorrect error message for arity mismatch. Got: %v", err)
 		}
 	})
+}
 
-	t.Run("Missing required parameter with default is valid", func(t *testing.T) {
-		toolWithRequiredDefault := &ToolboxTool{
+func TestToolboxTool_Invoke_CanonicalInput(t *testing.T) {
+	newTool := func(tr transport.Transport, canonical bool) *ToolboxTool {
+		return &ToolboxTool{
+			name:      "query",
+			transport: tr,
 			parameters: []ParameterSchema{
-				// Required is true, but it has a default, so it's okay if missing from input
-				{Name: "format", Type: "string", Required: true, Default: "json"},
+				{Name: "filters", Type: "array", Items: &ParameterSchema{Type: "object"}},
+				{Name: "limit", Type: "integer"},
 			},
-			boundParams: map[string]any{},
+			boundParams: map[string]any{
+				"tenant": func() (string, error) { return "acme", nil },
+			},
+			invokeOpts: &invokeOptions{canonicalInput: canonical},
+		}
+	}
+
+	invokeAndEncode := func(t *testing.T, tool *ToolboxTool, tr *recordingTransport, input map[string]any) []byte {
+		t.Helper()
+		if _, err := tool.Invoke(context.Background(), input); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		encoded, err := json.Marshal(tr.payload)
+		if err != nil {
+			t.Fatalf("Failed to encode payload: %v", err)
 		}
+		return encoded
+	}
 
-		// Input is completely empty
-		input := map[string]any{}
+	t.Run("Identical inputs produce byte-identical payloads", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr, true)
+		input := map[string]any{
+			"filters": []any{map[string]int{"min": 2, "max": 9}, map[string]any{"max": 5, "min": 1}},
+			"limit":   10,
+		}
+
+		first := invokeAndEncode(t, tool, tr, input)
+		for i := 0; i < 20; i++ {
+			if got := invokeAndEncode(t, tool, tr, input); !bytes.Equal(first, got) {
+				t.Fatalf("Payload changed between runs:\nfirst: %s\ngot:   %s", first, got)
+			}
+		}
+
+		expected := `{"filters":[{"max":9,"min":2},{"max":5,"min":1}],"limit":10,"tenant":"acme"}`
+		if string(first) != expected {
+			t.Errorf("Expected canonical payload %s, got %s", expected, first)
+		}
+	})
+
+	t.Run("Equivalent values canonicalize to the same payload", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr, true)
+
+		fromTypedMap := invokeAndEncode(t, tool, tr, map[string]any{
+			"filters": []any{map[string]int{"min": 1}},
+			"limit":   int64(5),
+		})
+		fromMap := invokeAndEncode(t, tool, tr, map[string]any{
+			"filters": []any{map[string]any{"min": 1}},
+			"limit":   5,
+		})
+		if !bytes.Equal(fromTypedMap, fromMap) {
+			t.Errorf("Expected identical payloads, got %s and %s", fromTypedMap, fromMap)
+		}
+		if _, ok := tr.payload["filters"].([]any)[0].(map[string]any); !ok {
+			t.Errorf("Expected canonical payload to contain plain maps, got %T", tr.payload["filters"].([]any)[0])
+		}
+	})
+
+	t.Run("Payload is passed through unchanged when disabled", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr, false)
+		f := map[string]int{"min": 1}
+
+		if _, err := tool.Invoke(context.Background(), map[string]any{"filters": []any{f}, "limit": 5}); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if _, ok := tr.payload["filters"].([]any)[0].(map[string]int); !ok {
+			t.Errorf("Expected original typed map in payload, got %T", tr.payload["filters"].([]any)[0])
+		}
+	})
+}
+
+// slowTransport sleeps before returning a fixed output or error.
+type slowTransport struct {
+	dummyTransport
+	delay  time.Duration
+	output any
+	err    error
+}
 
-		payload, err := toolWithRequiredDefault.validateAndBuildPayload(input)
+func (s *slowTransport) InvokeTool(ctx context.Context, name string, p map[string]any, h map[string]string) (any, error) {
+	time.Sleep(s.delay)
+	return s.output, s.err
+}
+
+// streamingTransport posts invocations to an HTTP server and returns the raw
+// response body, like a transport that supports streamed results.
+type streamingTransport struct {
+	dummyTransport
+	url    string
+	client *http.Client
+}
+
+func (s *streamingTransport) InvokeToolStream(ctx context.Context, name string, p map[string]any, h map[string]string) (io.ReadCloser, error) {
+	body, _ := json.Marshal(p)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+func TestToolboxTool_InvokeLines(t *testing.T) {
+	collect := func(lines <-chan json.RawMessage, errs <-chan error) ([]string, error) {
+		var got []string
+		for line := range lines {
+			got = append(got, string(line))
+		}
+		return got, <-errs
+	}
+
+	t.Run("Streams NDJSON lines as they arrive", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flusher := w.(http.Flusher)
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			fmt.Fprintln(w, `{"row":1}`)
+			flusher.Flush()
+			<-release
+			fmt.Fprintln(w, `{"row":2}`)
+			fmt.Fprintln(w)
+			fmt.Fprintln(w, `{"row":3}`)
+		}))
+		defer server.Close()
+		var releaseOnce sync.Once
+		defer releaseOnce.Do(func() { close(release) })
+
+		tool := &ToolboxTool{
+			name:       "rows",
+			transport:  &streamingTransport{url: server.URL, client: server.Client()},
+			parameters: []ParameterSchema{{Name: "table", Type: "string"}},
+		}
+		lines, errs := tool.InvokeLines(context.Background(), map[string]any{"table": "orders"})
+
+		// The first row must be delivered before the server finishes writing.
+		first := <-lines
+		if string(first) != `{"row":1}` {
+			t.Fatalf("Expected the first row, got %s", first)
+		}
+		releaseOnce.Do(func() { close(release) })
+
+		rest, err := collect(lines, errs)
 		if err != nil {
-			t.Fatalf("validateAndBuildPayload failed unexpectedly: %v", err)
+			t.Fatalf("Expected no error, got %v", err)
 		}
+		if !reflect.DeepEqual(rest, []string{`{"row":2}`, `{"row":3}`}) {
+			t.Errorf("Unexpected remaining rows: %v", rest)
+		}
+	})
 
-		expectedPayload := map[string]any{
-			"format": "json", // Injected default
+	t.Run("Splits a buffered NDJSON result", func(t *testing.T) {
+		tool := &ToolboxTool{name: "rows", transport: &recordingTransport{output: "{\"row\":1}\n{\"row\":2}\n"}}
+		got, err := collect(tool.InvokeLines(context.Background(), map[string]any{}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, []string{`{"row":1}`, `{"row":2}`}) {
+			t.Errorf("Unexpected rows: %v", got)
 		}
+	})
 
-		if !reflect.DeepEqual(payload, expectedPayload) {
-			t.Errorf("Payload mismatch.\nExpected: %v\nGot:      %v", expectedPayload, payload)
+	t.Run("Emits a single JSON document as one element", func(t *testing.T) {
+		tool := &ToolboxTool{name: "rows", transport: &recordingTransport{output: "{\n  \"rows\": [1, 2]\n}"}}
+		got, err := collect(tool.InvokeLines(context.Background(), map[string]any{}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(got) != 1 {
+			t.Fatalf("Expected one element, got %d: %v", len(got), got)
+		}
+	})
+
+	t.Run("Reports validation errors on the error channel", func(t *testing.T) {
+		tool := &ToolboxTool{name: "rows", transport: &recordingTransport{}}
+		got, err := collect(tool.InvokeLines(context.Background(), map[string]any{"unknown": 1}))
+		if len(got) != 0 {
+			t.Errorf("Expected no rows, got %v", got)
+		}
+		if err == nil || !strings.Contains(err.Error(), "unexpected parameter 'unknown' provided") {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	})
+
+	t.Run("Stops when the context is cancelled", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintln(w, `{"row":1}`)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		tool := &ToolboxTool{name: "rows", transport: &streamingTransport{url: server.URL, client: server.Client()}}
+		lines, errs := tool.InvokeLines(ctx, map[string]any{})
+
+		<-lines
+		cancel()
+
+		_, err := collect(lines, errs)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected a cancellation error, got %v", err)
 		}
 	})
 }
 
-type errorReader struct{}
+// eventStreamTransport is a transport whose event streams replay a fixed
+// list of chunks, recording the payload of the call.
+type eventStreamTransport struct {
+	recordingTransport
+	chunks []StreamChunk
+}
 
-func (er *errorReader) Read(p []byte) (n int, err error) {
-	return 0, errors.New("simulated read error")
+func (s *eventStreamTransport) InvokeToolEvents(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (<-chan StreamChunk, error) {
+	s.payload = payload
+	chunks := make(chan StreamChunk, len(s.chunks))
+	for _, chunk := range s.chunks {
+		chunks <- chunk
+	}
+	close(chunks)
+	return chunks, nil
 }
 
-// failingTransport is a custom transport to inject a failing reader
-type failingTransport struct{}
+func TestToolboxTool_InvokeStream(t *testing.T) {
+	collect := func(chunks <-chan StreamChunk, err error) ([]StreamChunk, error) {
+		if err != nil {
+			return nil, err
+		}
+		var got []StreamChunk
+		for chunk := range chunks {
+			got = append(got, chunk)
+		}
+		return got, nil
+	}
 
-func (ft *failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	return &http.Response{
-		StatusCode: http.StatusOK,
-		Body:       io.NopCloser(&errorReader{}),
-	}, nil
+	t.Run("Uses the transport's event stream", func(t *testing.T) {
+		want := []StreamChunk{{Text: "step 1"}, {Text: "step 2"}, {Text: "done", Final: true}}
+		tr := &eventStreamTransport{chunks: want}
+		tool := &ToolboxTool{
+			name:        "report",
+			transport:   tr,
+			parameters:  []ParameterSchema{{Name: "year", Type: "integer"}},
+			boundParams: map[string]any{"region": "emea"},
+		}
+
+		got, err := collect(tool.InvokeStream(context.Background(), map[string]any{"year": 2025}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unexpected chunks: %v", got)
+		}
+		if !reflect.DeepEqual(tr.payload, map[string]any{"year": 2025, "region": "emea"}) {
+			t.Errorf("Unexpected payload: %v", tr.payload)
+		}
+	})
+
+	t.Run("Emits the whole result as one chunk without event streams", func(t *testing.T) {
+		tool := &ToolboxTool{name: "report", transport: &recordingTransport{output: map[string]any{"total": 3}}}
+		got, err := collect(tool.InvokeStream(context.Background(), map[string]any{}))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, []StreamChunk{{Text: `{"total":3}`, Final: true}}) {
+			t.Errorf("Unexpected chunks: %v", got)
+		}
+	})
+
+	t.Run("Reports validation errors before streaming", func(t *testing.T) {
+		tool := &ToolboxTool{name: "report", transport: &eventStreamTransport{}}
+		_, err := tool.InvokeStream(context.Background(), map[string]any{"unknown": 1})
+		if err == nil || !strings.Contains(err.Error(), "unexpected parameter 'unknown' provided") {
+			t.Errorf("Expected a validation error, got %v", err)
+		}
+	})
 }
 
-// JSON-RPC Structures
-type jsonRPCRequest struct {
-	JSONRPC string `json:"jsonrpc"`
-	Method  string `json:"method"`
-	ID      any    `json:"id,omitempty"`
-	Params  any    `json:"params,omitempty"`
+// countingTransport counts invocations and fails every one with err.
+type countingTransport struct {
+	dummyTransport
+	calls int
+	err   error
 }
 
-type jsonRPCResponse struct {
-	JSONRPC string          `json:"jsonrpc"`
-	ID      any             `json:"id"`
-	Result  json.RawMessage `json:"result,omitempty"`
-	Error   any             `json:"error,omitempty"`
+func (c *countingTransport) InvokeTool(ctx context.Context, name string, p map[string]any, h map[string]string) (any, error) {
+	c.calls++
+	return nil, c.err
 }
 
-type mcpToolCallParams struct {
-	Name      string         `json:"name"`
-	Arguments map[string]any `json:"arguments"`
+// countingMetricsObserver counts the invocations it observes.
+type countingMetricsObserver struct {
+	NoopMetricsObserver
+	invokes int
 }
 
-func TestToolboxTool_Invoke(t *testing.T) {
-	// A base tool for successful invocations
-	createBaseTool := func(httpClient *http.Client, baseURL string) *ToolboxTool {
-		tr, _ := mcp.New(baseURL, httpClient, "test-client", "1.0.0")
+func (c *countingMetricsObserver) ObserveInvoke(tool string, duration time.Duration, err error) {
+	c.invokes++
+}
 
-		return &ToolboxTool{
-			name:        "weather",
-			description: "Get the weather",
+// The streaming methods make a single attempt and skip the observers that
+// Invoke runs; this pins down the documented difference.
+func TestToolboxTool_StreamingBypassesInvokePipeline(t *testing.T) {
+	newTool := func() (*ToolboxTool, *countingTransport, *countingMetricsObserver, *int) {
+		tr := &countingTransport{err: &transport.StatusError{StatusCode: http.StatusServiceUnavailable}}
+		metrics := &countingMetricsObserver{}
+		hooks := new(int)
+		tool := &ToolboxTool{
+			name:        "report",
 			transport:   tr,
-			parameters: []ParameterSchema{
-				{Name: "city", Type: "string"},
-			},
-			boundParams: map[string]any{
-				"units": "metric",
-			},
-			authTokenSources: map[string]oauth2.TokenSource{
-				"weather_api": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "api-token-123"}),
-			},
-			clientHeaderSources: map[string]oauth2.TokenSource{
-				"X-Client-Version": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "v1.0.0"}),
+			maxAttempts: 3,
+			backoff:     ConstantBackoff(time.Millisecond),
+			invokeOpts: &invokeOptions{
+				metricsObserver: metrics,
+				beforeInvoke:    func(ctx context.Context, toolName string, input map[string]any) { *hooks++ },
+				afterInvoke: func(ctx context.Context, toolName string, result any, err error, dur time.Duration) {
+					*hooks++
+				},
+				attemptObserver: func(toolName string, attempt int, err error, willRetry bool) { *hooks++ },
 			},
-			requiredAuthzTokens: []string{"weather_api"},
 		}
+		return tool, tr, metrics, hooks
 	}
 
-	// MCP Mock Server Factory
-	newMockMCPServer := func(handler func(req jsonRPCRequest) (any, error)) *httptest.Server {
-		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Basic header validation
-			if r.Header.Get("Content-Type") != "application/json" {
-				http.Error(w, "invalid content type", http.StatusBadRequest)
-				return
-			}
+	t.Run("Invoke retries and observes", func(t *testing.T) {
+		tool, tr, metrics, hooks := newTool()
+		if _, err := tool.Invoke(context.Background(), map[string]any{}); err == nil {
+			t.Fatal("Expected an error")
+		}
+		if tr.calls != 3 || metrics.invokes != 1 || *hooks != 5 {
+			t.Errorf("Expected 3 calls, 1 metric and 5 hook calls, got %d, %d and %d", tr.calls, metrics.invokes, *hooks)
+		}
+	})
 
-			// Read body
-			body, _ := io.ReadAll(r.Body)
-			var req jsonRPCRequest
-			json.Unmarshal(body, &req)
+	t.Run("InvokeLines makes a single unobserved call", func(t *testing.T) {
+		tool, tr, metrics, hooks := newTool()
+		lines, errs := tool.InvokeLines(context.Background(), map[string]any{})
+		for range lines {
+		}
+		if err := <-errs; err == nil {
+			t.Fatal("Expected an error")
+		}
+		if tr.calls != 1 || metrics.invokes != 0 || *hooks != 0 {
+			t.Errorf("Expected 1 call and no observations, got %d, %d and %d", tr.calls, metrics.invokes, *hooks)
+		}
+	})
 
-			// Handle Handshake
-			if req.Method == "initialize" {
-				res := map[string]any{
-					"protocolVersion": "2025-06-18",
-					"capabilities":    map[string]any{"tools": map[string]any{}},
-					"serverInfo":      map[string]any{"name": "mock", "version": "1"},
-				}
-				resp, _ := json.Marshal(res)
-				json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resp})
-				return
-			}
-			if req.Method == "notifications/initialized" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
+	t.Run("InvokeStream makes a single unobserved call", func(t *testing.T) {
+		tool, tr, metrics, hooks := newTool()
+		if _, err := tool.InvokeStream(context.Background(), map[string]any{}); err == nil {
+			t.Fatal("Expected an error")
+		}
+		if tr.calls != 1 || metrics.invokes != 0 || *hooks != 0 {
+			t.Errorf("Expected 1 call and no observations, got %d, %d and %d", tr.calls, metrics.invokes, *hooks)
+		}
+	})
+}
 
-			// Invoke custom handler for 'tools/call'
-			res, err := handler(req)
-			w.Header().Set("Content-Type", "application/json")
+func TestToolboxTool_Invoke_NumberAsJSONNumber(t *testing.T) {
+	const largeResult = `{"id":9007199254740993,"rows":[{"total":12345678901234567890}]}`
 
-			resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
-			if err != nil {
-				// MCP error struct
-				resp.Error = map[string]any{
-					"code":    -32000,
-					"message": err.Error(),
-				}
-			} else {
-				resBytes, _ := json.Marshal(res)
-				resp.Result = resBytes
-			}
-			json.NewEncoder(w).Encode(resp)
-		}))
+	newTool := func(output any, numberAsJSON bool) *ToolboxTool {
+		return &ToolboxTool{
+			name:       "lookup",
+			transport:  &recordingTransport{output: output},
+			invokeOpts: &invokeOptions{numberAsJSON: numberAsJSON},
+		}
 	}
 
-	t.Run("Successful invocation", func(t *testing.T) {
-		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
-			var params mcpToolCallParams
-			argsBytes, _ := json.Marshal(req.Params)
-			json.Unmarshal(argsBytes, &params)
+	t.Run("Preserves large integers exactly", func(t *testing.T) {
+		result, err := newTool(largeResult, true).Invoke(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		obj, ok := result.(map[string]any)
+		if !ok {
+			t.Fatalf("Expected a decoded object, got %T", result)
+		}
+		if id, ok := obj["id"].(json.Number); !ok || id.String() != "9007199254740993" {
+			t.Errorf("Expected id to be json.Number 9007199254740993, got %#v", obj["id"])
+		}
+		total := obj["rows"].([]any)[0].(map[string]any)["total"]
+		if n, ok := total.(json.Number); !ok || n.String() != "12345678901234567890" {
+			t.Errorf("Expected nested total to be preserved, got %#v", total)
+		}
+	})
 
-			// Validate payload
-			if params.Name != "weather" {
-				return nil, fmt.Errorf("unexpected tool name: %s", params.Name)
-			}
-			city, _ := params.Arguments["city"].(string)
-			units, _ := params.Arguments["units"].(string)
+	t.Run("Non-JSON results are returned unchanged", func(t *testing.T) {
+		result, err := newTool("plain text", true).Invoke(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if result != "plain text" {
+			t.Errorf("Expected the raw string result, got %#v", result)
+		}
+	})
+
+	t.Run("Default behavior is unchanged", func(t *testing.T) {
+		result, err := newTool(largeResult, false).Invoke(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
+		}
+		if result != largeResult {
+			t.Errorf("Expected the raw string result, got %#v", result)
+		}
+	})
+}
 
-			if city != "London" || units != "metric" {
-				return nil, fmt.Errorf("incorrect args")
-			}
+func TestToolboxTool_Invoke_Hooks(t *testing.T) {
+	type afterCall struct {
+		toolName string
+		result   any
+		err      error
+		dur      time.Duration
+	}
 
-			return map[string]any{
-				"content": []map[string]string{
-					{"type": "text", "text": "sunny"},
+	newTool := func(tr transport.Transport, before *[]map[string]any, after *[]afterCall) *ToolboxTool {
+		return &ToolboxTool{
+			name:       "lookup",
+			transport:  tr,
+			parameters: []ParameterSchema{{Name: "id", Type: "string"}},
+			invokeOpts: &invokeOptions{
+				beforeInvoke: func(ctx context.Context, toolName string, input map[string]any) {
+					if toolName != "lookup" {
+						t.Errorf("Expected before hook tool name 'lookup', got %q", toolName)
+					}
+					*before = append(*before, input)
+					// Hooks receive a copy; mutating it must not affect the invocation.
+					if input != nil {
+						input["id"] = "tampered"
+					}
 				},
-			}, nil
-		})
-		defer server.Close()
+				afterInvoke: func(ctx context.Context, toolName string, result any, err error, dur time.Duration) {
+					*after = append(*after, afterCall{toolName, result, err, dur})
+				},
+			},
+		}
+	}
 
-		tool := createBaseTool(server.Client(), server.URL)
-		result, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+	t.Run("Both hooks fire on success with timing", func(t *testing.T) {
+		var before []map[string]any
+		var after []afterCall
+		tr := &slowTransport{delay: 20 * time.Millisecond, output: "found"}
+		tool := newTool(tr, &before, &after)
+		input := map[string]any{"id": "42"}
 
+		result, err := tool.Invoke(context.Background(), input)
 		if err != nil {
 			t.Fatalf("Invoke failed unexpectedly: %v", err)
 		}
-		if result != "sunny" {
-			t.Errorf("Expected result 'sunny', got '%v'", result)
+		if result != "found" {
+			t.Errorf("Expected result 'found', got %v", result)
+		}
+		if input["id"] != "42" {
+			t.Errorf("Caller input was mutated by the before hook: %v", input)
+		}
+		if len(before) != 1 || len(after) != 1 {
+			t.Fatalf("Expected each hook to fire once, got before=%d after=%d", len(before), len(after))
+		}
+		if after[0].toolName != "lookup" || after[0].result != "found" || after[0].err != nil {
+			t.Errorf("Unexpected after hook call: %+v", after[0])
+		}
+		if after[0].dur < tr.delay {
+			t.Errorf("Expected duration of at least %v, got %v", tr.delay, after[0].dur)
 		}
 	})
 
-	t.Run("Applies correct _token suffix to auth headers but not client headers", func(t *testing.T) {
-		checkHeaders := func(w http.ResponseWriter, r *http.Request) {
-			if r.Header.Get("X-Custom-Header") != "client-val" {
-				t.Errorf("Expected client header 'X-Custom-Header' to be 'client-val', got %q", r.Header.Get("X-Custom-Header"))
-			}
+	t.Run("After hook receives transport errors", func(t *testing.T) {
+		var before []map[string]any
+		var after []afterCall
+		tool := newTool(&slowTransport{err: errors.New("server down")}, &before, &after)
 
-			// Assert auth token header is present with suffix
-			if r.Header.Get("my_auth_token") != "auth-val" {
-				t.Errorf("Expected auth header 'my_auth_token' to be 'auth-val', got %q", r.Header.Get("my_auth_token"))
-			}
+		_, err := tool.Invoke(context.Background(), map[string]any{"id": "42"})
+		if err == nil {
+			t.Fatal("Expected an error, but got nil")
+		}
+		if len(after) != 1 || !errors.Is(after[0].err, err) {
+			t.Fatalf("Expected after hook to receive the invocation error, got %+v", after)
+		}
+	})
 
-			// Assert auth token header is NOT present without suffix
-			if r.Header.Get("my_auth") != "" {
-				t.Errorf("Auth header 'my_auth' should not exist, but it does")
-			}
+	t.Run("Hooks run when validation fails", func(t *testing.T) {
+		var before []map[string]any
+		var after []afterCall
+		tr := &recordingTransport{}
+		tool := newTool(tr, &before, &after)
+
+		_, err := tool.Invoke(context.Background(), map[string]any{"id": 7})
+		if err == nil {
+			t.Fatal("Expected a validation error, but got nil")
+		}
+		if tr.payload != nil {
+			t.Error("Transport should not be called when validation fails")
 		}
+		if len(before) != 1 {
+			t.Errorf("Expected before hook to fire once, got %d", len(before))
+		}
+		if len(after) != 1 || after[0].err == nil || !strings.Contains(after[0].err.Error(), "tool payload processing failed") {
+			t.Errorf("Expected after hook to receive the validation error, got %+v", after)
+		}
+	})
+}
 
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			checkHeaders(w, r)
-			// Respond to handshake/call minimally
-			body, _ := io.ReadAll(r.Body)
-			var req jsonRPCRequest
-			json.Unmarshal(body, &req)
+func TestToolboxTool_Invoke_ObserverSampling(t *testing.T) {
+	type counts struct{ before, after, traces int }
 
-			if req.Method == "initialize" {
-				res, _ := json.Marshal(map[string]any{"protocolVersion": "2025-06-18", "capabilities": map[string]any{"tools": map[string]any{}}, "serverInfo": map[string]any{"name": "mock", "version": "1"}})
-				json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
-				return
+	newTool := func(rate float64, tr transport.Transport, c *counts) *ToolboxTool {
+		return &ToolboxTool{
+			name:       "lookup",
+			transport:  tr,
+			parameters: []ParameterSchema{{Name: "id", Type: "string"}},
+			invokeOpts: &invokeOptions{
+				beforeInvoke: func(ctx context.Context, toolName string, input map[string]any) {
+					c.before++
+				},
+				afterInvoke: func(ctx context.Context, toolName string, result any, err error, dur time.Duration) {
+					c.after++
+				},
+				httpTrace: func(toolName string, timing HTTPTiming) {
+					c.traces++
+				},
+				observerSampleRate:    rate,
+				observerSampleRateSet: true,
+			},
+		}
+	}
+
+	t.Run("Rate 0 skips successful invocations", func(t *testing.T) {
+		var c counts
+		tool := newTool(0, &slowTransport{output: "found"}, &c)
+		for range 10 {
+			if _, err := tool.Invoke(context.Background(), map[string]any{"id": "42"}); err != nil {
+				t.Fatalf("Invoke failed unexpectedly: %v", err)
 			}
-			if req.Method == "notifications/initialized" {
-				w.WriteHeader(http.StatusOK)
-				return
+		}
+		if c != (counts{}) {
+			t.Errorf("Expected no observations, got %+v", c)
+		}
+	})
+
+	t.Run("Rate 0 still observes errors", func(t *testing.T) {
+		var c counts
+		tool := newTool(0, &slowTransport{err: errors.New("server down")}, &c)
+		if _, err := tool.Invoke(context.Background(), map[string]any{"id": "42"}); err == nil {
+			t.Fatal("Expected an error, but got nil")
+		}
+		if c.after != 1 || c.traces != 1 {
+			t.Errorf("Expected the failure to be observed once, got %+v", c)
+		}
+		if c.before != 0 {
+			t.Errorf("Expected the before hook not to fire for an unsampled invocation, got %d", c.before)
+		}
+	})
+
+	t.Run("Rate 1 observes every invocation", func(t *testing.T) {
+		var c counts
+		tool := newTool(1, &slowTransport{output: "found"}, &c)
+		for range 10 {
+			if _, err := tool.Invoke(context.Background(), map[string]any{"id": "42"}); err != nil {
+				t.Fatalf("Invoke failed unexpectedly: %v", err)
 			}
-			// tools/call
-			res, _ := json.Marshal(map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}})
-			json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
-		}))
-		defer server.Close()
+		}
+		if c != (counts{before: 10, after: 10, traces: 10}) {
+			t.Errorf("Expected every invocation to be observed, got %+v", c)
+		}
+	})
+}
 
-		tool := createBaseTool(server.Client(), server.URL)
-		// Add the specific headers for this test
-		tool.clientHeaderSources["X-Custom-Header"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "client-val"})
-		tool.authTokenSources["my_auth"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "auth-val"})
+func TestToolboxTool_Invoke_AttemptObserver(t *testing.T) {
+	type attemptCall struct {
+		toolName  string
+		attempt   int
+		err       error
+		willRetry bool
+	}
+	newTool := func(tr transport.Transport, calls *[]attemptCall) *ToolboxTool {
+		return &ToolboxTool{
+			name:       "lookup",
+			transport:  tr,
+			parameters: []ParameterSchema{{Name: "id", Type: "string"}},
+			invokeOpts: &invokeOptions{
+				attemptObserver: func(toolName string, attempt int, err error, willRetry bool) {
+					*calls = append(*calls, attemptCall{toolName, attempt, err, willRetry})
+				},
+			},
+		}
+	}
 
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
-		if err != nil {
+	t.Run("Successful attempt is reported", func(t *testing.T) {
+		var calls []attemptCall
+		tool := newTool(&slowTransport{output: "found"}, &calls)
+		if _, err := tool.Invoke(context.Background(), map[string]any{"id": "42"}); err != nil {
 			t.Fatalf("Invoke failed unexpectedly: %v", err)
 		}
+		want := []attemptCall{{"lookup", 1, nil, false}}
+		if !reflect.DeepEqual(calls, want) {
+			t.Errorf("Expected observer calls %+v, got %+v", want, calls)
+		}
 	})
 
-	t.Run("Auth token headers overwrite client headers with the same final name", func(t *testing.T) {
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			if r.Header.Get("special_api_token") != "auth_value_should_win" {
-				t.Errorf("Expected header 'special_api_token' to be overwritten by auth token source, but it was not. Got: %q", r.Header.Get("special_api_token"))
-			}
-			// Respond OK
-			body, _ := io.ReadAll(r.Body)
-			var req jsonRPCRequest
-			json.Unmarshal(body, &req)
-			if req.Method == "initialize" {
-				res, _ := json.Marshal(map[string]any{"protocolVersion": "2025-06-18", "capabilities": map[string]any{"tools": map[string]any{}}, "serverInfo": map[string]any{"name": "mock", "version": "1"}})
-				json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
-				return
-			}
-			if req.Method == "notifications/initialized" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-			res, _ := json.Marshal(map[string]any{"content": []map[string]string{{"type": "text", "text": "ok"}}})
-			json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: res})
-		}))
-		defer server.Close()
+	t.Run("Failed attempt is reported", func(t *testing.T) {
+		var calls []attemptCall
+		serverErr := errors.New("server down")
+		tool := newTool(&slowTransport{err: serverErr}, &calls)
+		if _, err := tool.Invoke(context.Background(), map[string]any{"id": "42"}); err == nil {
+			t.Fatal("Expected an error, but got nil")
+		}
+		if len(calls) != 1 || calls[0].attempt != 1 || !errors.Is(calls[0].err, serverErr) || calls[0].willRetry {
+			t.Errorf("Expected a single failed attempt without retry, got %+v", calls)
+		}
+	})
 
-		tool := createBaseTool(server.Client(), server.URL)
-		tool.clientHeaderSources["special_api_token"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "client_value_should_lose"})
-		tool.authTokenSources["special_api"] = oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "auth_value_should_win"})
+	t.Run("Validation failures make no attempt", func(t *testing.T) {
+		var calls []attemptCall
+		tool := newTool(&slowTransport{output: "found"}, &calls)
+		if _, err := tool.Invoke(context.Background(), map[string]any{"id": 7}); err == nil {
+			t.Fatal("Expected a validation error, but got nil")
+		}
+		if len(calls) != 0 {
+			t.Errorf("Expected no attempts, got %+v", calls)
+		}
+	})
+}
 
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+func TestInvokeInto(t *testing.T) {
+	type hotel struct {
+		Name  string `json:"name"`
+		Stars int    `json:"stars"`
+	}
+	newTool := func(output any) *ToolboxTool {
+		return &ToolboxTool{name: "hotels", transport: &slowTransport{output: output}, parameters: []ParameterSchema{}}
+	}
+
+	t.Run("Struct target", func(t *testing.T) {
+		got, err := InvokeInto[hotel](context.Background(), newTool(`{"name": "Ritz", "stars": 5}`), map[string]any{})
 		if err != nil {
-			t.Fatalf("Invoke failed unexpectedly: %v", err)
+			t.Fatalf("InvokeInto failed unexpectedly: %v", err)
+		}
+		if got != (hotel{Name: "Ritz", Stars: 5}) {
+			t.Errorf("Unexpected result: %+v", got)
 		}
 	})
 
-	t.Run("Negative Test - Fails when required auth is missing", func(t *testing.T) {
-		tool := createBaseTool(http.DefaultClient, "")
-		tool.requiredAuthzTokens = []string{"required_service"} // This service is not in authTokenSources
+	t.Run("Slice target", func(t *testing.T) {
+		got, err := InvokeInto[[]hotel](context.Background(), newTool(`[{"name": "Ritz", "stars": 5}, {"name": "Inn", "stars": 2}]`), map[string]any{})
+		if err != nil {
+			t.Fatalf("InvokeInto failed unexpectedly: %v", err)
+		}
+		want := []hotel{{Name: "Ritz", Stars: 5}, {Name: "Inn", Stars: 2}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %+v, got %+v", want, got)
+		}
+	})
 
-		_, err := tool.Invoke(context.Background(), nil)
+	t.Run("Non-string results are decoded too", func(t *testing.T) {
+		got, err := InvokeInto[hotel](context.Background(), newTool(map[string]any{"name": "Ritz", "stars": 5}), map[string]any{})
+		if err != nil || got.Name != "Ritz" || got.Stars != 5 {
+			t.Errorf("Unexpected result %+v, err %v", got, err)
+		}
+	})
 
-		if err == nil {
-			t.Fatal("Expected an error for missing auth service, but got nil")
+	t.Run("Null yields the zero value", func(t *testing.T) {
+		got, err := InvokeInto[*hotel](context.Background(), newTool("null"), map[string]any{})
+		if err != nil || got != nil {
+			t.Errorf("Expected nil and no error, got %+v, %v", got, err)
 		}
-		if !strings.Contains(err.Error(), "permission error: auth service 'required_service' is required") {
-			t.Errorf("Incorrect error message for missing auth. Got: %v", err)
+		gotStruct, err := InvokeInto[hotel](context.Background(), newTool("null"), map[string]any{})
+		if err != nil || gotStruct != (hotel{}) {
+			t.Errorf("Expected the zero struct and no error, got %+v, %v", gotStruct, err)
 		}
 	})
 
-	t.Run("Negative Test - Fails when payload validation fails", func(t *testing.T) {
-		tool := createBaseTool(http.DefaultClient, "")
+	t.Run("Plain text", func(t *testing.T) {
+		_, err := InvokeInto[hotel](context.Background(), newTool("no hotels found"), map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "result of tool 'hotels' is not JSON") {
+			t.Errorf("Expected a descriptive error for plain text, got %v", err)
+		}
 
-		// Pass an extra parameter, which should be rejected
-		_, err := tool.Invoke(context.Background(), map[string]any{"extra": "param"})
+		text, err := InvokeInto[string](context.Background(), newTool("no hotels found"), map[string]any{})
+		if err != nil || text != "no hotels found" {
+			t.Errorf("Expected plain text to be returned for a string target, got %q, %v", text, err)
+		}
+	})
 
-		if err == nil {
-			t.Fatal("Expected an error from payload validation, but got nil")
+	t.Run("Mismatched JSON", func(t *testing.T) {
+		_, err := InvokeInto[hotel](context.Background(), newTool(`{"stars": "five"}`), map[string]any{})
+		if err == nil || !strings.Contains(err.Error(), "failed to decode result of tool 'hotels'") {
+			t.Errorf("Expected a decode error, got %v", err)
 		}
-		if !strings.Contains(err.Error(), "unexpected parameter 'extra' provided") {
-			t.Errorf("Incorrect error message for payload validation. Got: %v", err)
+	})
+
+	t.Run("Invocation errors are returned", func(t *testing.T) {
+		tool := &ToolboxTool{name: "hotels", transport: &slowTransport{err: errors.New("server down")}, parameters: []ParameterSchema{}}
+		if _, err := InvokeInto[hotel](context.Background(), tool, map[string]any{}); err == nil {
+			t.Error("Expected the invocation error, got nil")
 		}
 	})
+}
 
-	t.Run("Negative Test - Fails when server returns an error status", func(t *testing.T) {
-		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
-			return nil, errors.New("invalid city format")
-		})
-		defer server.Close()
+func TestToolboxTool_Validate(t *testing.T) {
+	t.Run("Succeeds when all sources resolve", func(t *testing.T) {
+		tool := &ToolboxTool{
+			name: "lookup",
+			boundParams: map[string]any{
+				"static":  "value",
+				"dynamic": func() (string, error) { return "ok", nil },
+			},
+			boundParamSchemas: map[string]ParameterSchema{
+				"dynamic": {Name: "dynamic", Type: "string"},
+			},
+			clientHeaderSources: map[string]oauth2.TokenSource{
+				"X-Api-Key": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "key"}),
+			},
+			authTokenSources: map[string]oauth2.TokenSource{
+				"google": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}),
+			},
+		}
 
-		tool := createBaseTool(server.Client(), server.URL)
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		if err := tool.Validate(context.Background()); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("Reports every failing source", func(t *testing.T) {
+		calls := 0
+		tool := &ToolboxTool{
+			name: "lookup",
+			boundParams: map[string]any{
+				"secret": func() (string, error) { return "", errors.New("secret unreachable") },
+				"count": func() (string, error) {
+					calls++
+					return "not-a-number", nil
+				},
+			},
+			boundParamSchemas: map[string]ParameterSchema{
+				"count": {Name: "count", Type: "integer"},
+			},
+			clientHeaderSources: map[string]oauth2.TokenSource{
+				"X-Api-Key": &failingTokenSource{},
+			},
+			authTokenSources: map[string]oauth2.TokenSource{
+				"google": &failingTokenSource{},
+			},
+		}
 
+		err := tool.Validate(context.Background())
 		if err == nil {
-			t.Fatal("Expected an error from a non-200 server response, but got nil")
+			t.Fatal("Expected a validation error, but got nil")
+		}
+		for _, want := range []string{
+			"validation failed for tool 'lookup'",
+			"bound parameter 'secret': secret unreachable",
+			"bound parameter 'count': parameter 'count' expects an integer",
+			"client header 'X-Api-Key': token source failed as designed",
+			"auth token 'google': token source failed as designed",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to contain %q, got: %v", want, err)
+			}
 		}
-		// Updated error message expectation for MCP transport
-		if !strings.Contains(err.Error(), "invalid city format") {
-			t.Errorf("Incorrect error message for server error. Got: %v", err)
+		if calls != 1 {
+			t.Errorf("Expected bound function to be resolved once, got %d", calls)
 		}
 	})
 
-	t.Run("Success Path - Handles non-json successful response", func(t *testing.T) {
-		server := newMockMCPServer(func(req jsonRPCRequest) (any, error) {
-			return map[string]any{
-				"content": []map[string]string{
-					{"type": "text", "text": "Plain text success message"},
-				},
-			}, nil
-		})
-		defer server.Close()
+	t.Run("Does not call the transport", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := &ToolboxTool{name: "lookup", transport: tr}
 
-		tool := createBaseTool(server.Client(), server.URL)
-		result, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		if err := tool.Validate(context.Background()); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if tr.payload != nil {
+			t.Error("Validate must not invoke the tool")
+		}
+	})
+}
+
+func TestToolboxTool_InvokeJSON(t *testing.T) {
+	newTool := func(tr transport.Transport) *ToolboxTool {
+		return &ToolboxTool{
+			name:      "search",
+			transport: tr,
+			parameters: []ParameterSchema{
+				{Name: "query", Type: "string", Required: true},
+				{Name: "tags", Type: "array", Items: &ParameterSchema{Type: "string"}},
+				{Name: "limit", Type: "integer"},
+				{Name: "score", Type: "float"},
+				{Name: "pages", Type: "array", Items: &ParameterSchema{Type: "integer"}},
+				{Name: "quotas", Type: "object", AdditionalProperties: &ParameterSchema{Type: "integer"}},
+			},
+		}
+	}
+
+	t.Run("Decodes numbers according to the parameter types", func(t *testing.T) {
+		tr := &recordingTransport{output: "ok"}
+		tool := newTool(tr)
 
+		_, err := tool.InvokeJSON(context.Background(), json.RawMessage(
+			`{"query": "books", "limit": 10, "score": 3, "pages": [1, 9007199254740993], "quotas": {"a": 2}}`))
 		if err != nil {
-			t.Fatalf("Invoke failed unexpectedly for plain text response: %v", err)
+			t.Fatalf("InvokeJSON failed unexpectedly: %v", err)
 		}
-		if result != "Plain text success message" {
-			t.Errorf("Expected plain text result, got '%v'", result)
+		expected := map[string]any{
+			"query":  "books",
+			"limit":  int64(10),
+			"score":  float64(3),
+			"pages":  []any{int64(1), int64(9007199254740993)},
+			"quotas": map[string]any{"a": int64(2)},
+		}
+		if !reflect.DeepEqual(tr.payload, expected) {
+			t.Errorf("Expected payload %v, got %v", expected, tr.payload)
 		}
 	})
 
-	t.Run("Negative Test - Fails when required AuthN (param-level) is missing", func(t *testing.T) {
-		tool := createBaseTool(http.DefaultClient, "")
-		// This tool requires a 'google' token for one of its parameters.
-		tool.requiredAuthnParams = map[string][]string{
-			"user_location": {"google"},
+	t.Run("Decodes numbers given under an alias or in another case", func(t *testing.T) {
+		tr := &recordingTransport{output: "ok"}
+		tool := newTool(tr)
+		tool.paramAliases = map[string]string{"max": "limit"}
+		tool.caseInsensitiveParams = true
+
+		_, err := tool.InvokeJSON(context.Background(), json.RawMessage(
+			`{"query": "books", "max": 9007199254740993, "Pages": [9007199254740995]}`))
+		if err != nil {
+			t.Fatalf("InvokeJSON failed unexpectedly: %v", err)
 		}
-		// The base tool does not provide the 'google' token source.
+		expected := map[string]any{
+			"query": "books",
+			"limit": int64(9007199254740993),
+			"pages": []any{int64(9007199254740995)},
+		}
+		if !reflect.DeepEqual(tr.payload, expected) {
+			t.Errorf("Expected payload %v, got %v", expected, tr.payload)
+		}
+	})
 
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+	t.Run("Invokes with a valid JSON object", func(t *testing.T) {
+		tr := &recordingTransport{output: "ok"}
+		tool := newTool(tr)
 
-		if err == nil {
-			t.Fatal("Expected an error for missing AuthN service, but got nil")
+		result, err := tool.InvokeJSON(context.Background(), json.RawMessage(`{"query": "books", "tags": ["a", "b"]}`))
+		if err != nil {
+			t.Fatalf("InvokeJSON failed unexpectedly: %v", err)
 		}
-		if !strings.Contains(err.Error(), "permission error: auth service 'google' is required") {
-			t.Errorf("Incorrect error message for missing param-level auth. Got: %v", err)
+		if result != "ok" {
+			t.Errorf("Expected result 'ok', got '%v'", result)
+		}
+		expected := map[string]any{"query": "books", "tags": []any{"a", "b"}}
+		if !reflect.DeepEqual(tr.payload, expected) {
+			t.Errorf("Expected payload %v, got %v", expected, tr.payload)
 		}
 	})
 
-	t.Run("Negative Test - Fails when required AuthZ (tool-level) is missing", func(t *testing.T) {
-		tool := createBaseTool(http.DefaultClient, "")
-		tool.requiredAuthzTokens = []string{"required_service"} // This service is not in authTokenSources
+	testCases := []struct {
+		name      string
+		input     string
+		expectErr string
+	}{
+		{"Negative Test - Rejects a JSON array", `["books"]`, "expects a JSON object as input, but got a JSON array"},
+		{"Negative Test - Rejects a JSON string", `"books"`, "expects a JSON object as input, but got a JSON string"},
+		{"Negative Test - Rejects null", `null`, "expects a JSON object as input, but got null"},
+		{"Negative Test - Rejects broken JSON", `{"query": "books"`, "failed to parse JSON input for tool 'search'"},
+		{"Negative Test - Rejects trailing data", `{"query": "books"} {}`, "failed to parse JSON input for tool 'search'"},
+		{"Negative Test - Rejects a fractional integer", `{"query": "books", "limit": 2.5}`, "expects an integer, but got float64"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tr := &recordingTransport{}
+			_, err := newTool(tr).InvokeJSON(context.Background(), json.RawMessage(tc.input))
+			if err == nil {
+				t.Fatal("Expected an error, but got nil")
+			}
+			if !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("Expected error containing %q, got: %v", tc.expectErr, err)
+			}
+			if tr.payload != nil {
+				t.Error("Transport should not be called for invalid input")
+			}
+		})
+	}
+}
 
-		_, err := tool.Invoke(context.Background(), nil)
+func TestToolboxTool_Invoke_CallerIdentity(t *testing.T) {
+	type ctxKey struct{}
 
-		if err == nil {
-			t.Fatal("Expected an error for missing auth service, but got nil")
-		}
-		if !strings.Contains(err.Error(), "permission error: auth service 'required_service' is required") {
-			t.Errorf("Incorrect error message for missing tool-level auth. Got: %v", err)
+	newTool := func(tr transport.Transport, opts *invokeOptions) *ToolboxTool {
+		return &ToolboxTool{name: "audit", transport: tr, invokeOpts: opts}
+	}
+	resolveFromContext := func(ctx context.Context) (string, error) {
+		user, ok := ctx.Value(ctxKey{}).(string)
+		if !ok {
+			return "", errors.New("no user in context")
 		}
-	})
-
-	t.Run("Negative Test - Fails when server returns an error status with non-JSON body", func(t *testing.T) {
-		// MCP server returns 500
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusInternalServerError)
-			_, _ = w.Write([]byte("Internal Server Error"))
-		}))
-		defer server.Close()
+		return user, nil
+	}
 
-		tool := createBaseTool(server.Client(), server.URL)
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+	t.Run("Sends the resolved identity in the default header", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr, &invokeOptions{callerIdentity: resolveFromContext})
+		ctx := context.WithValue(context.Background(), ctxKey{}, "alice@example.com")
 
-		if err == nil {
-			t.Fatal("Expected an error from a non-200 server response, but got nil")
+		if _, err := tool.Invoke(ctx, nil); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
 		}
-		if !strings.Contains(err.Error(), "API request failed with status 500") {
-			t.Errorf("Incorrect error message for non-JSON server error. Got: %v", err)
+		if got := tr.headers["X-Caller-Identity"]; got != "alice@example.com" {
+			t.Errorf("Expected X-Caller-Identity 'alice@example.com', got %q", got)
 		}
 	})
 
-	t.Run("Negative Test - Fails when API call itself fails", func(t *testing.T) {
-		// Create a server and immediately close it to simulate a network error
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
-		server.Close()
-
-		tool := createBaseTool(server.Client(), server.URL)
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+	t.Run("Uses a custom header name", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr, &invokeOptions{callerIdentity: resolveFromContext, callerIdentityHeader: "X-End-User"})
+		ctx := context.WithValue(context.Background(), ctxKey{}, "bob")
 
-		if err == nil {
-			t.Fatal("Expected an error from a failed API call, but got nil")
+		if _, err := tool.Invoke(ctx, nil); err != nil {
+			t.Fatalf("Invoke failed unexpectedly: %v", err)
 		}
-		if !strings.Contains(err.Error(), "http request failed") {
-			t.Errorf("Incorrect error message for failed API call. Got: %v", err)
+		if got := tr.headers["X-End-User"]; got != "bob" {
+			t.Errorf("Expected X-End-User 'bob', got %q", got)
+		}
+		if _, ok := tr.headers["X-Caller-Identity"]; ok {
+			t.Error("Default header should not be sent when a custom header is configured")
 		}
 	})
 
-	t.Run("Negative Test - Fails when reading response body fails", func(t *testing.T) {
-		// The mock server for this test case is intentionally minimal,
-		// as the failure is injected via the custom http.Client.
-		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK) // Needed to pass status check in doRPC
-		}))
-		defer server.Close()
-
-		failingClient := &http.Client{Transport: &failingTransport{}}
-		tool := createBaseTool(failingClient, server.URL)
+	t.Run("Negative Test - Resolution error aborts the invocation", func(t *testing.T) {
+		tr := &recordingTransport{}
+		tool := newTool(tr, &invokeOptions{callerIdentity: resolveFromContext})
 
-		_, err := tool.Invoke(context.Background(), map[string]any{"city": "London"})
+		_, err := tool.Invoke(context.Background(), nil)
 		if err == nil {
-			t.Fatal("Expected an error from a failing response body read, but got nil")
+			t.Fatal("Expected an error when the identity cannot be resolved, but got nil")
 		}
-		if !strings.Contains(err.Error(), "read body failed") {
-			t.Errorf("Incorrect error message for failed body read. Got: %v", err)
+		if !strings.Contains(err.Error(), "failed to resolve caller identity: no user in context") {
+			t.Errorf("Incorrect error message. Got: %v", err)
+		}
+		if tr.headers != nil {
+			t.Error("Transport should not be called when identity resolution fails")
 		}
 	})
-
 }
+
 func TestToolboxTool_Invoke_HttpsWarning(t *testing.T) {
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
@@ -1066,6 +3226,56 @@ func TestToolboxTool_Invoke_HttpsWarning(t *testing.T) {
 }
 
 // TestInputSchema tests the JSON output of the InputSchema method.
+func TestToolboxTool_Examples(t *testing.T) {
+	tool := &ToolboxTool{
+		parameters: []ParameterSchema{
+			{Name: "query", Type: "string", Required: true},
+		},
+		boundParams: map[string]any{"limit": 10},
+		examples: []map[string]any{
+			{"query": "books", "limit": 5},
+			{"limit": 1},
+		},
+	}
+
+	t.Run("Examples are restricted to unbound parameters", func(t *testing.T) {
+		expected := []map[string]any{{"query": "books"}}
+		if got := tool.Examples(); !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected examples %v, got %v", expected, got)
+		}
+		if _, ok := tool.examples[0]["limit"]; !ok {
+			t.Error("Examples must not modify the tool's stored examples")
+		}
+	})
+
+	t.Run("Examples appear in the input schema", func(t *testing.T) {
+		schemaBytes, err := tool.InputSchema()
+		if err != nil {
+			t.Fatalf("InputSchema failed: %v", err)
+		}
+		expectedJSON := `{
+			"type": "object",
+			"properties": {"query": {"type": "string"}},
+			"required": ["query"],
+			"examples": [{"query": "books"}]
+		}`
+		var got, want any
+		_ = json.Unmarshal(schemaBytes, &got)
+		_ = json.Unmarshal([]byte(expectedJSON), &want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected schema %s, got %s", expectedJSON, schemaBytes)
+		}
+	})
+
+	t.Run("Schema has no examples key without examples", func(t *testing.T) {
+		plain := &ToolboxTool{parameters: tool.parameters}
+		schemaBytes, _ := plain.InputSchema()
+		if strings.Contains(string(schemaBytes), "examples") {
+			t.Errorf("Expected no examples in schema, got %s", schemaBytes)
+		}
+	})
+}
+
 func TestInputSchema(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -1328,3 +3538,443 @@ func TestInputSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestToOpenAIFunctionSchema(t *testing.T) {
+	testCases := []struct {
+		name     string
+		tool     *ToolboxTool
+		expected map[string]any
+	}{
+		{
+			name: "search-rows with a bound parameter",
+			tool: &ToolboxTool{
+				name:        "search-rows",
+				description: "Search rows by email.",
+				parameters: []ParameterSchema{
+					{Name: "email", Type: "string", Description: "The email to search for.", Required: true},
+					{Name: "data", Type: "string", Description: "The row to narrow down the search.", Default: "row2"},
+				},
+				boundParams:       map[string]any{"id": 3},
+				boundParamSchemas: map[string]ParameterSchema{"id": {Name: "id", Type: "integer", Description: "The id to narrow down the search."}},
+				examples:          []map[string]any{{"email": "a@b.c"}},
+			},
+			expected: map[string]any{
+				"name":        "search-rows",
+				"description": "Search rows by email.",
+				"parameters": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"email": map[string]any{"type": "string", "description": "The email to search for."},
+						"data":  map[string]any{"type": "string", "description": "The row to narrow down the search.", "default": "row2"},
+					},
+					"required": []any{"email"},
+				},
+			},
+		},
+		{
+			name: "process-data with maps, arrays and floats",
+			tool: &ToolboxTool{
+				name:        "process-data",
+				description: "Process data.",
+				parameters: []ParameterSchema{
+					{Name: "execution_context", Type: "object", Description: "A flexible set of key-value pairs for the execution environment.", AdditionalProperties: true, Required: true},
+					{Name: "user_scores", Type: "object", Description: "A map of user IDs to their scores.", AdditionalProperties: &ParameterSchema{Type: "integer"}, Required: true},
+					{Name: "feature_flags", Type: "object", Description: "Optional feature flags.", AdditionalProperties: &ParameterSchema{Type: "boolean"}},
+					{Name: "weights", Type: "array", Description: "Weights.", Items: &ParameterSchema{Type: "float"}},
+				},
+			},
+			expected: map[string]any{
+				"name":        "process-data",
+				"description": "Process data.",
+				"parameters": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"execution_context": map[string]any{
+							"type":                 "object",
+							"description":          "A flexible set of key-value pairs for the execution environment.",
+							"additionalProperties": true,
+						},
+						"user_scores": map[string]any{
+							"type":                 "object",
+							"description":          "A map of user IDs to their scores.",
+							"additionalProperties": map[string]any{"type": "integer"},
+						},
+						"feature_flags": map[string]any{
+							"type":                 "object",
+							"description":          "Optional feature flags.",
+							"additionalProperties": map[string]any{"type": "boolean"},
+						},
+						"weights": map[string]any{
+							"type":        "array",
+							"description": "Weights.",
+							"items":       map[string]any{"type": "number"},
+						},
+					},
+					"required": []any{"execution_context", "user_scores"},
+				},
+			},
+		},
+		{
+			name: "Tool without parameters",
+			tool: &ToolboxTool{name: "ping", description: "Ping."},
+			expected: map[string]any{
+				"name":        "ping",
+				"description": "Ping.",
+				"parameters":  map[string]any{"type": "object", "properties": map[string]any{}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			raw, err := tc.tool.ToOpenAIFunctionSchema()
+			if err != nil {
+				t.Fatalf("ToOpenAIFunctionSchema failed unexpectedly: %v", err)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(raw, &got); err != nil {
+				t.Fatalf("Result is not valid JSON: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("Schema mismatch.\nGot:  %v\nWant: %v", got, tc.expected)
+			}
+		})
+	}
+
+	t.Run("Unsupported nested maps", func(t *testing.T) {
+		tool := &ToolboxTool{
+			name:       "nested",
+			parameters: []ParameterSchema{{Name: "m", Type: "object", AdditionalProperties: &ParameterSchema{Type: "array"}}},
+		}
+		if _, err := tool.ToOpenAIFunctionSchema(); err == nil {
+			t.Error("Expected an error for a typed map of arrays, got nil")
+		}
+	})
+}
+
+func TestParametersSchema(t *testing.T) {
+	tool := &ToolboxTool{
+		name: "search-rows",
+		parameters: []ParameterSchema{
+			{Name: "email", Type: "string", Description: "The email to search for.", Required: true},
+			{Name: "limit", Type: "integer", Description: "The row limit."},
+		},
+		boundParams: map[string]any{"id": 3},
+		examples:    []map[string]any{{"email": "a@b.c"}},
+	}
+
+	got, err := tool.ParametersSchema()
+	if err != nil {
+		t.Fatalf("ParametersSchema failed unexpectedly: %v", err)
+	}
+	expected := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"email": map[string]any{"type": "string", "description": "The email to search for."},
+			"limit": map[string]any{"type": "integer", "description": "The row limit."},
+		},
+		"required": []string{"email"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Schema mismatch.\nGot:  %v\nWant: %v", got, expected)
+	}
+}
+
+// contentTransport is a transport whose structured results carry a fixed
+// list of content blocks.
+type contentTransport struct {
+	recordingTransport
+	content []ContentBlock
+}
+
+func (c *contentTransport) InvokeToolResult(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (*transport.InvokeResult, error) {
+	return &transport.InvokeResult{Output: c.output, Content: c.content}, nil
+}
+
+func TestToolboxTool_InvokeContent(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G'}
+
+	t.Run("Returns mixed text and image content", func(t *testing.T) {
+		want := []ContentBlock{
+			{Type: "text", Text: "Here is the chart"},
+			{Type: "image", Data: png, MimeType: "image/png"},
+		}
+		tool := &ToolboxTool{name: "chart", transport: &contentTransport{
+			recordingTransport: recordingTransport{output: "Here is the chart"},
+			content:            want,
+		}}
+
+		got, err := tool.InvokeContent(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unexpected content: %v", got)
+		}
+
+		text, err := tool.Invoke(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if text != "Here is the chart" {
+			t.Errorf("Invoke must keep returning the text only, got %v", text)
+		}
+	})
+
+	t.Run("Returns image-only content", func(t *testing.T) {
+		want := []ContentBlock{{Type: "image", Data: png, MimeType: "image/png"}}
+		tool := &ToolboxTool{name: "chart", transport: &contentTransport{
+			recordingTransport: recordingTransport{output: "null"},
+			content:            want,
+		}}
+
+		got, err := tool.InvokeContent(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Unexpected content: %v", got)
+		}
+	})
+
+	t.Run("Falls back to a text block without content blocks", func(t *testing.T) {
+		tool := &ToolboxTool{name: "report", transport: &recordingTransport{output: map[string]any{"total": 3}}}
+
+		got, err := tool.InvokeContent(context.Background(), map[string]any{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, []ContentBlock{{Type: "text", Text: `{"total":3}`}}) {
+			t.Errorf("Unexpected content: %v", got)
+		}
+	})
+}
+
+func TestToolboxTool_AggregateValidation(t *testing.T) {
+	newTool := func(aggregate bool) *ToolboxTool {
+		return &ToolboxTool{
+			name:      "paint",
+			transport: &recordingTransport{},
+			parameters: []ParameterSchema{
+				{Name: "name", Type: "string", Required: true},
+				{Name: "age", Type: "integer", Required: true},
+				{Name: "color", Type: "string", Enum: []any{"red", "blue"}},
+			},
+			aggregateValidation: aggregate,
+		}
+	}
+	// Every kind of problem at once: a wrong type, a value outside the enum,
+	// an unexpected key and a missing required parameter.
+	input := map[string]any{"age": "old", "color": "purple", "colr": "red"}
+
+	t.Run("ValidateInput reports every problem in one pass", func(t *testing.T) {
+		errs := newTool(false).ValidateInput(input)
+		if len(errs) != 4 {
+			t.Fatalf("Expected 4 errors, got %d: %v", len(errs), errs)
+		}
+		if !strings.Contains(errs[0].Error(), "'age'") {
+			t.Errorf("Expected a type error for 'age', got %v", errs[0])
+		}
+		if !strings.Contains(errs[1].Error(), "must be one of") {
+			t.Errorf("Expected an enum error for 'color', got %v", errs[1])
+		}
+		if !errors.Is(errs[2], ErrUnexpectedParam) || !strings.Contains(errs[2].Error(), "'colr'") {
+			t.Errorf("Expected an unexpected parameter error for 'colr', got %v", errs[2])
+		}
+		if !errors.Is(errs[3], ErrMissingRequiredParam) || !strings.Contains(errs[3].Error(), "'name'") {
+			t.Errorf("Expected a missing parameter error for 'name', got %v", errs[3])
+		}
+
+		if errs := newTool(false).ValidateInput(map[string]any{"name": "Ada", "age": 36}); errs != nil {
+			t.Errorf("Expected no errors for valid input, got %v", errs)
+		}
+	})
+
+	t.Run("Invoke joins every problem when enabled", func(t *testing.T) {
+		_, err := newTool(true).Invoke(context.Background(), input)
+		if err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+		if !errors.Is(err, ErrUnexpectedParam) || !errors.Is(err, ErrMissingRequiredParam) {
+			t.Errorf("Expected the joined error to match every sentinel, got %v", err)
+		}
+		for _, want := range []string{"'age'", "must be one of", "'colr'", "'name'"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected the error to mention %s, got %v", want, err)
+			}
+		}
+	})
+
+	t.Run("Invoke fails fast by default", func(t *testing.T) {
+		_, err := newTool(false).Invoke(context.Background(), input)
+		if err == nil {
+			t.Fatal("Expected an error, got nil")
+		}
+		if strings.Contains(err.Error(), "\n") {
+			t.Errorf("Expected a single error, got %v", err)
+		}
+	})
+
+	t.Run("Option is applied and cannot be overridden", func(t *testing.T) {
+		derived, err := newTool(false).ToolFrom(WithAggregateValidation(true))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !derived.aggregateValidation {
+			t.Error("Expected aggregate validation to be enabled on the derived tool")
+		}
+		config := &ToolConfig{}
+		if err := WithAggregateValidation(true)(config); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := WithAggregateValidation(false)(config); err == nil {
+			t.Error("Expected an error when setting aggregate validation twice")
+		}
+	})
+}
+
+func TestToolboxTool_DryRun(t *testing.T) {
+	tr := &recordingTransport{dummyTransport: dummyTransport{baseURL: "https://toolbox.example.com/mcp/"}, output: "ok"}
+	tool := &ToolboxTool{
+		name:       "orders",
+		transport:  tr,
+		parameters: []ParameterSchema{{Name: "status", Type: "string", Required: true}},
+		boundParams: map[string]any{
+			"region": func() (string, error) { return "emea", nil },
+		},
+		boundParamSchemas: map[string]ParameterSchema{"region": {Name: "region", Type: "string"}},
+		clientHeaderSources: map[string]oauth2.TokenSource{
+			"X-Tenant": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "acme"}),
+		},
+		authTokenSources: map[string]oauth2.TokenSource{
+			"google": oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "secret"}),
+		},
+	}
+	input := map[string]any{"status": "open"}
+
+	prepared, err := tool.DryRun(context.Background(), input)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if tr.payload != nil {
+		t.Fatal("DryRun must not call the transport")
+	}
+
+	if _, err := tool.Invoke(context.Background(), input); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(prepared.Payload, tr.payload) {
+		t.Errorf("Prepared payload %v does not match the sent payload %v", prepared.Payload, tr.payload)
+	}
+	if !reflect.DeepEqual(prepared.Headers, tr.headers) {
+		t.Errorf("Prepared headers %v do not match the sent headers %v", prepared.Headers, tr.headers)
+	}
+	want := &PreparedRequest{
+		Tool:    "orders",
+		URL:     "https://toolbox.example.com/mcp/",
+		Method:  "tools/call",
+		Payload: map[string]any{"status": "open", "region": "emea"},
+		Headers: map[string]string{"X-Tenant": "acme", "google_token": "secret"},
+	}
+	if !reflect.DeepEqual(prepared, want) {
+		t.Errorf("DryRun() = %+v, want %+v", prepared, want)
+	}
+
+	redacted := prepared.Redacted()
+	if redacted.Headers["google_token"] != "REDACTED" || redacted.Headers["X-Tenant"] != "REDACTED" {
+		t.Errorf("Expected every header to be redacted, got %v", redacted.Headers)
+	}
+	if prepared.Headers["google_token"] != "secret" {
+		t.Error("Redacted must not modify the original request")
+	}
+
+	if _, err := tool.DryRun(context.Background(), map[string]any{}); !errors.Is(err, ErrMissingRequiredParam) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+func TestToolboxTool_IntegerCoercion(t *testing.T) {
+	tr := &recordingTransport{output: "ok"}
+	tool := &ToolboxTool{
+		name:      "paginate",
+		transport: tr,
+		parameters: []ParameterSchema{
+			{Name: "limit", Type: "integer"},
+			{Name: "ids", Type: "array", Items: &ParameterSchema{Name: "id", Type: "integer"}},
+			{Name: "ratio", Type: "float"},
+		},
+	}
+
+	t.Run("whole numbers are sent as integers", func(t *testing.T) {
+		ids := []any{float64(1), json.Number("2")}
+		input := map[string]any{"limit": float64(10), "ids": ids, "ratio": float64(3)}
+		if _, err := tool.Invoke(context.Background(), input); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		want := map[string]any{"limit": int64(10), "ids": []any{int64(1), int64(2)}, "ratio": float64(3)}
+		if !reflect.DeepEqual(tr.payload, want) {
+			t.Errorf("Expected payload %#v, got %#v", want, tr.payload)
+		}
+		if _, ok := ids[0].(float64); !ok {
+			t.Error("Coercion must not modify the caller's input")
+		}
+	})
+
+	t.Run("json.Number is accepted", func(t *testing.T) {
+		if _, err := tool.Invoke(context.Background(), map[string]any{"limit": json.Number("25")}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if tr.payload["limit"] != int64(25) {
+			t.Errorf("Expected limit to be int64(25), got %#v", tr.payload["limit"])
+		}
+	})
+
+	t.Run("fractional values are rejected", func(t *testing.T) {
+		_, err := tool.Invoke(context.Background(), map[string]any{"limit": 2.5})
+		if err == nil || !strings.Contains(err.Error(), "expects an integer, but got float64 with value 2.5") {
+			t.Errorf("Expected an integer type error, got %v", err)
+		}
+	})
+}
+
+func TestToolboxTool_String(t *testing.T) {
+	authSource := &countingTokenSource{}
+	headerSource := &countingTokenSource{}
+	tool := &ToolboxTool{
+		name:        "lookup",
+		description: "Looks up an order",
+		parameters: []ParameterSchema{
+			{Name: "order_id", Type: "string", Required: true},
+			{Name: "limit", Type: "integer"},
+		},
+		boundParams:         map[string]any{"api_key": "sk-secret-value", "region": "emea"},
+		authTokenSources:    map[string]oauth2.TokenSource{"google": authSource},
+		clientHeaderSources: map[string]oauth2.TokenSource{"Authorization": headerSource},
+	}
+
+	want := `ToolboxTool{name: "lookup", description: "Looks up an order", parameters: [order_id string required, limit integer], boundParams: 2, authTokenSources: 1, clientHeaders: 1}`
+	if got := tool.String(); got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		got := fmt.Sprintf(format, tool)
+		if got != want {
+			t.Errorf("Sprintf(%q) = %s, want %s", format, got, want)
+		}
+		for _, secret := range []string{"sk-secret-value", "emea", "api_key"} {
+			if strings.Contains(got, secret) {
+				t.Errorf("Sprintf(%q) leaked %q: %s", format, secret, got)
+			}
+		}
+	}
+
+	if authSource.calls.Load() != 0 || headerSource.calls.Load() != 0 {
+		t.Error("String must not call token sources")
+	}
+
+	var nilTool *ToolboxTool
+	if got := nilTool.String(); got != "<nil>" {
+		t.Errorf("String() on a nil tool = %s, want <nil>", got)
+	}
+}
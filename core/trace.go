@@ -0,0 +1,126 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// HTTPTiming holds the low-level phase timings collected for the HTTP
+// requests made by a single tool invocation. When an invocation makes
+// several requests, such as the session handshake before the first call,
+// the phase timings are summed across them.
+type HTTPTiming struct {
+	// DNSLookup is the time spent resolving host names.
+	DNSLookup time.Duration
+	// Connect is the time spent establishing TCP connections.
+	Connect time.Duration
+	// TLSHandshake is the time spent in TLS handshakes.
+	TLSHandshake time.Duration
+	// FirstByte is the time between writing a request and receiving the first
+	// byte of its response, which approximates server processing time.
+	FirstByte time.Duration
+	// Total is the wall-clock time of the whole transport call.
+	Total time.Duration
+	// Requests is the number of HTTP requests that were made.
+	Requests int
+	// ReusedConns is the number of requests that reused a pooled connection.
+	ReusedConns int
+}
+
+// httpTimingRecorder accumulates HTTPTiming from httptrace callbacks, which
+// may be invoked from several goroutines.
+type httpTimingRecorder struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	timing       HTTPTiming
+}
+
+// withHTTPTimingRecorder returns a context carrying a ClientTrace that
+// records into a new recorder.
+func withHTTPTimingRecorder(ctx context.Context) (context.Context, *httpTimingRecorder) {
+	r := &httpTimingRecorder{start: time.Now()}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.timing.Requests++
+			if info.Reused {
+				r.timing.ReusedConns++
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.timing.DNSLookup += time.Since(r.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.connectStart = time.Now()
+		},
+		ConnectDone: func(string, string, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.timing.Connect += time.Since(r.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.timing.TLSHandshake += time.Since(r.tlsStart)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if !r.wroteRequest.IsZero() {
+				r.timing.FirstByte += time.Since(r.wroteRequest)
+			}
+		},
+	}
+	return httptrace.WithClientTrace(ctx, trace), r
+}
+
+// finish returns the collected timings, with Total measured up to now.
+func (r *httpTimingRecorder) finish() HTTPTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timing := r.timing
+	timing.Total = time.Since(r.start)
+	return timing
+}
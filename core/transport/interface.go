@@ -16,6 +16,8 @@ package transport
 
 import (
 	"context"
+	"errors"
+	"io"
 )
 
 type Transport interface {
@@ -30,3 +32,99 @@ type Transport interface {
 	// InvokeTool executes a tool.
 	InvokeTool(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (any, error)
 }
+
+// Initializer is an optional interface implemented by transports that
+// establish a session with the server before their first request.
+type Initializer interface {
+	// EnsureInitialized performs the session handshake if it has not yet
+	// completed, and reports its outcome.
+	EnsureInitialized(ctx context.Context, headers map[string]string) error
+}
+
+// Pinger is an optional interface implemented by transports that can check
+// that the server is reachable and speaks a compatible protocol.
+type Pinger interface {
+	// Ping performs the session handshake if it has not yet completed, checks
+	// that the server still answers, and returns the version it reported.
+	Ping(ctx context.Context, headers map[string]string) (string, error)
+}
+
+// ErrToolSearchNotSupported is returned when tool search is requested from a
+// transport or server that does not support it.
+var ErrToolSearchNotSupported = errors.New("tool search is not supported by the server")
+
+// ErrManifestTooLarge is returned when a server response carrying tool
+// definitions is larger than the transport's maximum manifest size.
+var ErrManifestTooLarge = errors.New("manifest exceeds max size")
+
+// ToolSearcher is an optional interface implemented by transports that can
+// ask the server for the tools matching a query, instead of listing them all.
+type ToolSearcher interface {
+	// SearchTools fetches the tools matching the query.
+	SearchTools(ctx context.Context, query string, headers map[string]string) (*ManifestSchema, error)
+}
+
+// ErrToolsetListingNotSupported is returned when the available toolsets are
+// requested from a transport or server that cannot enumerate them.
+var ErrToolsetListingNotSupported = errors.New("listing toolsets is not supported by the server")
+
+// ToolsetLister is an optional interface implemented by transports that can
+// ask the server for the names of the toolsets it exposes.
+type ToolsetLister interface {
+	// ListToolsets fetches the names of the available toolsets.
+	ListToolsets(ctx context.Context, headers map[string]string) ([]string, error)
+}
+
+// ErrResourcesNotSupported is returned when resources are requested from a
+// transport or server that does not support them.
+var ErrResourcesNotSupported = errors.New("server does not support resources")
+
+// ResourceReader is an optional interface implemented by transports that can
+// list and read the resources exposed by the server.
+type ResourceReader interface {
+	// ListResources fetches the resources exposed by the server.
+	ListResources(ctx context.Context, headers map[string]string) ([]Resource, error)
+
+	// ReadResource fetches the contents of the resource with the given URI.
+	ReadResource(ctx context.Context, uri string, headers map[string]string) ([]ResourceContents, error)
+}
+
+// ErrPromptsNotSupported is returned when prompts are requested from a
+// transport or server that does not support them.
+var ErrPromptsNotSupported = errors.New("server does not support prompts")
+
+// PromptGetter is an optional interface implemented by transports that can
+// list and render the prompt templates exposed by the server.
+type PromptGetter interface {
+	// ListPrompts fetches the prompt templates exposed by the server.
+	ListPrompts(ctx context.Context, headers map[string]string) ([]Prompt, error)
+
+	// GetPrompt renders the named prompt template with the given arguments.
+	GetPrompt(ctx context.Context, name string, args map[string]string, headers map[string]string) (*PromptResult, error)
+}
+
+// StreamInvoker is an optional interface implemented by transports that can
+// hand back a tool's raw response body as it arrives, for tools that stream
+// newline-delimited JSON.
+type StreamInvoker interface {
+	// InvokeToolStream executes a tool and returns its response body. The
+	// caller must close the returned reader.
+	InvokeToolStream(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (io.ReadCloser, error)
+}
+
+// EventStreamInvoker is an optional interface implemented by transports that
+// can receive a tool's response as a stream of Server-Sent Events, reporting
+// progress before the result arrives.
+type EventStreamInvoker interface {
+	// InvokeToolEvents executes a tool and returns a channel of the chunks of
+	// its response. The channel is closed after a final chunk, or after a
+	// terminal chunk carrying an error.
+	InvokeToolEvents(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (<-chan StreamChunk, error)
+}
+
+// ResultInvoker is an optional interface implemented by transports that can
+// report invocation metadata, such as server warnings, alongside the output.
+type ResultInvoker interface {
+	// InvokeToolResult executes a tool and returns its structured result.
+	InvokeToolResult(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (*InvokeResult, error)
+}
@@ -15,21 +15,106 @@
 package mcp
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 )
 
+// CancelNotificationTimeout bounds the best-effort 'notifications/cancelled'
+// message sent to the server after a caller abandons an in-flight request.
+const CancelNotificationTimeout = 5 * time.Second
+
+// CancelledNotificationParams builds the parameters of a
+// 'notifications/cancelled' message for the request with the given ID,
+// using the context's cancellation cause as the reason.
+func CancelledNotificationParams(ctx context.Context, requestID any) map[string]any {
+	params := map[string]any{"requestId": requestID}
+	if cause := context.Cause(ctx); cause != nil {
+		params["reason"] = cause.Error()
+	}
+	return params
+}
+
+// CallToolMeta builds the '_meta' of a 'tools/call' request from the request
+// headers, carrying the invocation's idempotency key so that it reaches the
+// server even when headers do not, as over stdio. It returns nil if there is
+// nothing to attach.
+func CallToolMeta(headers map[string]string) map[string]any {
+	key := headers[transport.IdempotencyKeyHeader]
+	if key == "" {
+		return nil
+	}
+	return map[string]any{"idempotencyKey": key}
+}
+
+// DefaultMaxManifestSize is the default limit, in bytes, on the size of a
+// response carrying tool definitions.
+const DefaultMaxManifestSize int64 = 8 << 20
+
+// Option configures optional behavior of an MCP transport.
+type Option func(*BaseMcpTransport)
+
+// WithMaxManifestSize limits the size, in bytes, of the responses that carry
+// tool definitions. Non-positive values keep the default.
+func WithMaxManifestSize(n int64) Option {
+	return func(b *BaseMcpTransport) {
+		if n > 0 {
+			b.maxManifestSize = n
+		}
+	}
+}
+
+// WithMethodOverrides maps canonical MCP method names, such as "tools/list",
+// to the names a non-standard server expects instead. Methods without an
+// entry keep their spec name.
+func WithMethodOverrides(overrides map[string]string) Option {
+	return func(b *BaseMcpTransport) {
+		b.methodOverrides = maps.Clone(overrides)
+	}
+}
+
+// WithLogger sets the logger that receives the transport's structured debug
+// logs, such as the progress of the protocol handshake. A nil logger keeps
+// logging disabled.
+func WithLogger(logger *slog.Logger) Option {
+	return func(b *BaseMcpTransport) {
+		if logger != nil {
+			b.logger = logger
+		}
+	}
+}
+
+// WithRequestIDGenerator sets the function that produces the IDs of JSON-RPC
+// requests, for example to embed a trace ID or to make IDs predictable in
+// tests. Each call must return a distinct string or number. A nil function
+// keeps the default of random UUIDs.
+func WithRequestIDGenerator(fn func() any) Option {
+	return func(b *BaseMcpTransport) {
+		if fn != nil {
+			b.requestIDGenerator = fn
+		}
+	}
+}
+
 // ToolContent represents a single item in the tool result content list.
 type ToolContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string                      `json:"type"`
+	Text     string                      `json:"text"`
+	Data     []byte                      `json:"data,omitempty"`
+	MimeType string                      `json:"mimeType,omitempty"`
+	Resource *transport.ResourceContents `json:"resource,omitempty"`
 }
 
 // BaseMcpTransport holds the common state and logic for MCP HTTP transports.
@@ -40,6 +125,32 @@ type BaseMcpTransport struct {
 	initOnce      sync.Once
 	initErr       error
 
+	// maxManifestSize bounds the responses read by ReadManifestBody.
+	maxManifestSize int64
+	// methodOverrides renames methods for non-standard servers.
+	methodOverrides map[string]string
+	// logger receives structured debug logs; it discards them by default.
+	logger *slog.Logger
+	// requestIDGenerator produces JSON-RPC request IDs; nil means random
+	// UUIDs.
+	requestIDGenerator func() any
+
+	// SupportsToolSearch records whether the server advertised the 'search'
+	// tools capability during the handshake.
+	SupportsToolSearch bool
+
+	// SupportsToolsetListing records whether the server advertised the
+	// 'toolsets' tools capability during the handshake.
+	SupportsToolsetListing bool
+
+	// SupportsResources records whether the server advertised the
+	// 'resources' capability during the handshake.
+	SupportsResources bool
+
+	// SupportsPrompts records whether the server advertised the 'prompts'
+	// capability during the handshake.
+	SupportsPrompts bool
+
 	// HandshakeHook is the abstract method _initialize_session.
 	// The specific version implementation will assign this function.
 	HandshakeHook func(ctx context.Context, headers map[string]string) error
@@ -51,7 +162,7 @@ func (b *BaseMcpTransport) BaseURL() string {
 }
 
 // NewBaseTransport creates a new base transport.
-func NewBaseTransport(baseURL string, client *http.Client) (*BaseMcpTransport, error) {
+func NewBaseTransport(baseURL string, client *http.Client, opts ...Option) (*BaseMcpTransport, error) {
 	if client == nil {
 		client = &http.Client{}
 	}
@@ -76,27 +187,155 @@ func NewBaseTransport(baseURL string, client *http.Client) (*BaseMcpTransport, e
 	// Ensure trailing slash
 	fullURL += "/"
 
-	return &BaseMcpTransport{
-		baseURL:    fullURL,
-		HTTPClient: client,
-	}, nil
+	b := &BaseMcpTransport{
+		baseURL:         fullURL,
+		maxManifestSize: DefaultMaxManifestSize,
+		HTTPClient:      client,
+		logger:          slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b, nil
+}
+
+// NewLocalBaseTransport creates a base transport for a server that is not
+// reached over HTTP, such as a subprocess spoken to over stdio. The given
+// address is reported by BaseURL as is.
+func NewLocalBaseTransport(address string, opts ...Option) *BaseMcpTransport {
+	b := &BaseMcpTransport{
+		baseURL:         address,
+		maxManifestSize: DefaultMaxManifestSize,
+		logger:          slog.New(slog.DiscardHandler),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Method returns the name to send to the server for a canonical MCP method,
+// honoring any override set with WithMethodOverrides.
+func (b *BaseMcpTransport) Method(canonical string) string {
+	if name, ok := b.methodOverrides[canonical]; ok {
+		return name
+	}
+	return canonical
+}
+
+// NewRequestID returns the ID for a new JSON-RPC request, from the configured
+// generator or, by default, a random UUID.
+func (b *BaseMcpTransport) NewRequestID() any {
+	if b.requestIDGenerator != nil {
+		return b.requestIDGenerator()
+	}
+	return uuid.New().String()
+}
+
+// RequestIDKey returns a comparable key for a JSON-RPC request ID, for
+// matching responses to requests. IDs are keyed by their JSON encoding, so
+// that a numeric ID matches the float64 it decodes to in a response.
+func RequestIDKey(id any) string {
+	encoded, err := json.Marshal(id)
+	if err != nil {
+		return fmt.Sprint(id)
+	}
+	return string(encoded)
+}
+
+// ReadManifestBody reads a response body that carries tool definitions,
+// failing with transport.ErrManifestTooLarge once it exceeds the maximum
+// manifest size instead of buffering it whole.
+func (b *BaseMcpTransport) ReadManifestBody(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, b.maxManifestSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > b.maxManifestSize {
+		return nil, fmt.Errorf("%w of %d bytes", transport.ErrManifestTooLarge, b.maxManifestSize)
+	}
+	return data, nil
+}
+
+// ReadEvents parses a Server-Sent Events stream, calling fn with the data of
+// each event in order. It stops at the end of the stream, when fn reports
+// that it is done, or when fn returns an error, which ReadEvents returns. A
+// single line of the stream may not exceed the maximum manifest size.
+func (b *BaseMcpTransport) ReadEvents(r io.Reader, fn func(data []byte) (done bool, err error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), int(b.maxManifestSize))
+
+	var data []byte
+	pending := false
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			// A blank line dispatches the event accumulated so far.
+			if !pending {
+				continue
+			}
+			done, err := fn(data)
+			if err != nil || done {
+				return err
+			}
+			data, pending = nil, false
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		if field != "data" {
+			// Comments and the event, id and retry fields carry nothing the
+			// transport needs.
+			continue
+		}
+		if pending {
+			data = append(data, '\n')
+		}
+		data = append(data, strings.TrimPrefix(value, " ")...)
+		pending = true
+	}
+	return scanner.Err()
+}
+
+// Logger returns the transport's logger, which discards its records unless
+// one was set with WithLogger.
+func (b *BaseMcpTransport) Logger() *slog.Logger {
+	if b.logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return b.logger
 }
 
 // EnsureInitialized guarantees the session is ready before making requests.
+// The handshake runs once per transport: concurrent callers wait for the
+// first one to complete it, and later calls return its outcome without
+// contacting the server, so that every request reuses the same session.
 func (b *BaseMcpTransport) EnsureInitialized(ctx context.Context, headers map[string]string) error {
 	b.initOnce.Do(func() {
-		if b.HandshakeHook != nil {
-			b.initErr = b.HandshakeHook(ctx, headers)
-		} else {
+		if b.HandshakeHook == nil {
 			b.initErr = fmt.Errorf("transport initialization logic (HandshakeHook) not defined")
+			return
+		}
+		logger := b.Logger()
+		logger.DebugContext(ctx, "starting MCP handshake", "url", b.baseURL)
+		b.initErr = b.HandshakeHook(ctx, headers)
+		if b.initErr != nil {
+			logger.ErrorContext(ctx, "MCP handshake failed", "url", b.baseURL, "error", b.initErr)
+			return
 		}
+		logger.DebugContext(ctx, "MCP handshake completed", "url", b.baseURL, "serverVersion", b.ServerVersion)
 	})
 	return b.initErr
 }
 
-// ProcessToolResultContent processes the tool result content, handling multiple JSON objects.
-// It filters for text content, attempts to merge valid JSON objects into an array,
-// or falls back to concatenation.
+// ProcessToolResultContent processes the tool result content, joining its
+// text blocks. Servers may split one JSON value across several blocks, or
+// send several JSON values in separate blocks, so the blocks are assembled as:
+//  1. their concatenation, if it is valid JSON;
+//  2. otherwise a JSON array of the blocks, if each is a valid JSON value;
+//  3. otherwise their concatenation as-is.
+//
+// An empty result is returned as "null".
 func (b *BaseMcpTransport) ProcessToolResultContent(content []ToolContent) string {
 	// Filter content where type is "text"
 	var texts []string
@@ -106,59 +345,113 @@ func (b *BaseMcpTransport) ProcessToolResultContent(content []ToolContent) strin
 		}
 	}
 
-	// Handle multiple JSON objects
-	if len(texts) > 1 {
-		allValidObjects := true
-		for _, t := range texts {
-			var js map[string]any
-			if err := json.Unmarshal([]byte(t), &js); err != nil {
-				allValidObjects = false
-				break
-			}
-		}
+	finalStr := strings.Join(texts, "")
+	if finalStr == "" {
+		return "null"
+	}
+	if len(texts) < 2 || json.Valid([]byte(finalStr)) {
+		return finalStr
+	}
 
-		if allValidObjects {
-			// Join with commas and wrap in brackets to create a JSON array string
-			return "[" + strings.Join(texts, ",") + "]"
+	// Handle multiple JSON values
+	for _, t := range texts {
+		if !json.Valid([]byte(t)) {
+			return finalStr
 		}
 	}
+	// Join with commas and wrap in brackets to create a JSON array string
+	return "[" + strings.Join(texts, ",") + "]"
+}
 
-	finalStr := strings.Join(texts, "")
+// ContentBlocks converts the content of a tool result into content blocks,
+// keeping the images, resources and other non-text items that
+// ProcessToolResultContent leaves out.
+func (b *BaseMcpTransport) ContentBlocks(content []ToolContent) []transport.ContentBlock {
+	blocks := make([]transport.ContentBlock, len(content))
+	for i, c := range content {
+		blocks[i] = transport.ContentBlock{
+			Type:     c.Type,
+			Text:     c.Text,
+			Data:     c.Data,
+			MimeType: c.MimeType,
+			Resource: c.Resource,
+		}
+	}
+	return blocks
+}
 
-	// 4. Handle empty result case
-	if finalStr == "" {
-		return "null"
+// CollectWarnings gathers the non-fatal warnings a server attached to a tool
+// result, either as a top-level 'warnings' field or as a 'warnings' array in
+// the result's '_meta'.
+func (b *BaseMcpTransport) CollectWarnings(warnings []string, meta map[string]any) []string {
+	collected := make([]string, 0, len(warnings))
+	collected = append(collected, warnings...)
+
+	if metaWarnings, ok := meta["warnings"].([]any); ok {
+		for _, w := range metaWarnings {
+			if s, ok := w.(string); ok {
+				collected = append(collected, s)
+			}
+		}
 	}
 
-	return finalStr
+	if len(collected) == 0 {
+		return nil
+	}
+	return collected
 }
 
 // ConvertToolDefinition converts the raw tool dictionary into a transport.ToolSchema.
 func (b *BaseMcpTransport) ConvertToolDefinition(toolData map[string]any) (transport.ToolSchema, error) {
-	var paramAuth map[string]any
+	var paramAuth map[string][]string
 	var invokeAuth []string
 
 	if meta, ok := toolData["_meta"].(map[string]any); ok {
-		if pa, ok := meta["toolbox/authParam"].(map[string]any); ok {
-			paramAuth = pa
-		}
-		if ia, ok := meta["toolbox/authInvoke"].([]any); ok {
-			invokeAuth = make([]string, 0, len(ia))
-			for _, v := range ia {
-				if s, ok := v.(string); ok {
-					invokeAuth = append(invokeAuth, s)
-				}
-			}
+		var err error
+		if paramAuth, err = parseParamAuth(meta["toolbox/authParam"]); err != nil {
+			return transport.ToolSchema{}, err
+		}
+		if invokeAuth, err = parseStringList(meta["toolbox/authInvoke"]); err != nil {
+			return transport.ToolSchema{}, fmt.Errorf("invalid 'toolbox/authInvoke' metadata: %w", err)
 		}
 	}
 
 	description, _ := toolData["description"].(string)
 	inputSchema, _ := toolData["inputSchema"].(map[string]any)
-	properties, _ := inputSchema["properties"].(map[string]any)
+
+	// Examples may be given in the input schema or in the tool's '_meta'.
+	examples := collectExamples(inputSchema["examples"])
+	if meta, ok := toolData["_meta"].(map[string]any); ok {
+		examples = append(examples, collectExamples(meta["examples"])...)
+	}
+
+	parameters := parseObjectProperties(inputSchema)
+	for i := range parameters {
+		parameters[i].AuthSources = paramAuth[parameters[i].Name]
+	}
+
+	var outputSchema []transport.ParameterSchema
+	if raw, ok := toolData["outputSchema"].(map[string]any); ok {
+		outputSchema = parseObjectProperties(raw)
+	}
+
+	return transport.ToolSchema{
+		Description:  description,
+		Parameters:   parameters,
+		AuthRequired: invokeAuth,
+		Examples:     examples,
+		OutputSchema: outputSchema,
+	}, nil
+}
+
+// parseObjectProperties converts the properties of an object JSON schema,
+// such as a tool's input or output schema, into a parameter list.
+func parseObjectProperties(schema map[string]any) []transport.ParameterSchema {
+	properties, _ := schema["properties"].(map[string]any)
 
 	// Create lookup set for required fields
 	requiredSet := make(map[string]bool)
-	if reqList, ok := inputSchema["required"].([]any); ok {
+	if reqList, ok := schema["required"].([]any); ok {
 		for _, r := range reqList {
 			if s, ok := r.(string); ok {
 				requiredSet[s] = true
@@ -175,33 +468,75 @@ func (b *BaseMcpTransport) ConvertToolDefinition(toolData map[string]any) (trans
 			continue
 		}
 
-		// Handle Auth Sources for this specific parameter
-		var authSources []string
-		if paramAuth != nil {
-			if sourcesRaw, ok := paramAuth[propertyName]; ok {
-				if sourcesList, ok := sourcesRaw.([]any); ok {
-					authSources = make([]string, 0, len(sourcesList))
-					for _, s := range sourcesList {
-						if str, ok := s.(string); ok {
-							authSources = append(authSources, str)
-						}
-					}
-				}
+		// Recursively parse the property
+		parameters = append(parameters, parseProperty(propertyName, definitionMap, requiredSet[propertyName]))
+	}
+	return parameters
+}
+
+// parseParamAuth reads the 'toolbox/authParam' metadata, which maps parameter
+// names to the auth sources that supply them. Each entry may be a single
+// string or a list of strings.
+func parseParamAuth(raw any) (map[string][]string, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case map[string][]string:
+		return v, nil
+	case map[string]any:
+		paramAuth := make(map[string][]string, len(v))
+		for name, sources := range v {
+			list, err := parseStringList(sources)
+			if err != nil {
+				return nil, fmt.Errorf("invalid 'toolbox/authParam' metadata for parameter '%s': %w", name, err)
 			}
+			paramAuth[name] = list
 		}
+		return paramAuth, nil
+	default:
+		return nil, fmt.Errorf("invalid 'toolbox/authParam' metadata: expected an object, got %T", raw)
+	}
+}
 
-		// Recursively parse the property
-		param := parseProperty(propertyName, definitionMap, requiredSet[propertyName])
-		param.AuthSources = authSources
-
-		parameters = append(parameters, param)
+// parseStringList accepts a single string, a []string or a []any of strings,
+// and returns the strings as a list.
+func parseStringList(raw any) ([]string, error) {
+	switch v := raw.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []string:
+		return v, nil
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a list of strings, but found an element of type %T", item)
+			}
+			list = append(list, s)
+		}
+		return list, nil
+	default:
+		return nil, fmt.Errorf("expected a string or a list of strings, got %T", raw)
 	}
+}
 
-	return transport.ToolSchema{
-		Description:  description,
-		Parameters:   parameters,
-		AuthRequired: invokeAuth,
-	}, nil
+// collectExamples extracts the example inputs from a raw 'examples' array,
+// skipping entries that are not JSON objects.
+func collectExamples(raw any) []map[string]any {
+	list, ok := raw.([]any)
+	if !ok {
+		return nil
+	}
+	var examples []map[string]any
+	for _, item := range list {
+		if example, ok := item.(map[string]any); ok {
+			examples = append(examples, example)
+		}
+	}
+	return examples
 }
 
 // parseProperty is the recursive helper to create ParameterSchema
@@ -210,6 +545,10 @@ func parseProperty(name string, definitionMap map[string]any, isRequired bool) t
 	if paramType == "" {
 		paramType = "string"
 	}
+	// Base64-encoded binary data is surfaced as its own type.
+	if paramType == "string" && getString(definitionMap, "format") == "byte" {
+		paramType = "bytes"
+	}
 
 	param := transport.ParameterSchema{
 		Name:        name,
@@ -221,6 +560,16 @@ func parseProperty(name string, definitionMap map[string]any, isRequired bool) t
 	if defaultValue, ok := definitionMap["default"]; ok {
 		param.Default = defaultValue
 	}
+	if enum, ok := definitionMap["enum"].([]any); ok {
+		param.Enum = enum
+	}
+	param.Minimum = getNumber(definitionMap, "minimum")
+	param.Maximum = getNumber(definitionMap, "maximum")
+	param.MinLength = getCount(definitionMap, "minLength")
+	param.MaxLength = getCount(definitionMap, "maxLength")
+	param.MinItems = getCount(definitionMap, "minItems")
+	param.MaxItems = getCount(definitionMap, "maxItems")
+	param.Pattern = getString(definitionMap, "pattern")
 
 	switch param.Type {
 	case "object":
@@ -253,3 +602,31 @@ func getString(m map[string]any, key string) string {
 	}
 	return ""
 }
+
+// getNumber returns the numeric value of a key, or nil if it is absent or
+// not a number.
+func getNumber(m map[string]any, key string) *float64 {
+	var n float64
+	switch v := m[key].(type) {
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	default:
+		return nil
+	}
+	return &n
+}
+
+// getCount returns the value of a key holding a non-negative whole number,
+// or nil if it is absent or not one.
+func getCount(m map[string]any, key string) *int {
+	n := getNumber(m, key)
+	if n == nil || *n < 0 || *n != float64(int(*n)) {
+		return nil
+	}
+	count := int(*n)
+	return &count
+}
@@ -19,7 +19,11 @@ package mcp
 import (
 	"context"
 	"errors"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 )
 
 func TestNewBaseTransport(t *testing.T) {
@@ -68,6 +72,65 @@ func TestNewBaseTransport(t *testing.T) {
 	}
 }
 
+func TestReadManifestBody(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil, WithMaxManifestSize(8))
+
+	data, err := tr.ReadManifestBody(strings.NewReader("12345678"))
+	if err != nil || string(data) != "12345678" {
+		t.Errorf("Expected the body within the limit to be read, got %q, %v", data, err)
+	}
+
+	_, err = tr.ReadManifestBody(strings.NewReader("123456789"))
+	if !errors.Is(err, transport.ErrManifestTooLarge) {
+		t.Errorf("Expected ErrManifestTooLarge, got %v", err)
+	}
+
+	def, _ := NewBaseTransport("http://example.com", nil)
+	if def.maxManifestSize != DefaultMaxManifestSize {
+		t.Errorf("Expected the default limit %d, got %d", DefaultMaxManifestSize, def.maxManifestSize)
+	}
+}
+
+func TestReadEvents(t *testing.T) {
+	b := &BaseMcpTransport{maxManifestSize: DefaultMaxManifestSize}
+	stream := ": keep-alive\r\n\r\n" +
+		"event: message\ndata: first\n\n" +
+		"data: second\ndata:  line\r\n\r\n" +
+		"id: 3\n\n" +
+		"data: third\n\n" +
+		"data: unterminated"
+
+	var got []string
+	err := b.ReadEvents(strings.NewReader(stream), func(data []byte) (bool, error) {
+		got = append(got, string(data))
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("ReadEvents() error = %v", err)
+	}
+	want := []string{"first", "second\n line", "third"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadEvents() events = %q, want %q", got, want)
+	}
+
+	got = nil
+	err = b.ReadEvents(strings.NewReader(stream), func(data []byte) (bool, error) {
+		got = append(got, string(data))
+		return len(got) == 2, nil
+	})
+	if err != nil || len(got) != 2 {
+		t.Errorf("ReadEvents() should stop once fn is done, got %q, %v", got, err)
+	}
+
+	boom := errors.New("boom")
+	err = b.ReadEvents(strings.NewReader(stream), func(data []byte) (bool, error) {
+		return false, boom
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("ReadEvents() error = %v, want %v", err, boom)
+	}
+}
+
 func TestEnsureInitialized(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		tr, _ := NewBaseTransport("http://example.com", nil)
@@ -309,6 +372,307 @@ func TestConvertToolDefinitionWithDefaults(t *testing.T) {
 	}
 }
 
+func TestConvertToolDefinitionEnum(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil)
+
+	rawTool := map[string]any{
+		"name": "enum_tool",
+		"inputSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"color": map[string]any{"type": "string", "enum": []any{"red", "green"}},
+				"sizes": map[string]any{
+					"type":  "array",
+					"items": map[string]any{"type": "integer", "enum": []any{float64(1), float64(2)}},
+				},
+			},
+		},
+	}
+
+	schema, err := tr.ConvertToolDefinition(rawTool)
+	if err != nil {
+		t.Fatalf("ConvertToolDefinition failed: %v", err)
+	}
+
+	for _, p := range schema.Parameters {
+		switch p.Name {
+		case "color":
+			if !reflect.DeepEqual(p.Enum, []any{"red", "green"}) {
+				t.Errorf("Expected color enum [red green], got %v", p.Enum)
+			}
+		case "sizes":
+			if p.Items == nil || !reflect.DeepEqual(p.Items.Enum, []any{float64(1), float64(2)}) {
+				t.Errorf("Expected sizes items enum [1 2], got %+v", p.Items)
+			}
+		}
+	}
+}
+
+func TestConvertToolDefinitionBounds(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil)
+
+	rawTool := map[string]any{
+		"name": "bounds_tool",
+		"inputSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"age":  map[string]any{"type": "integer", "minimum": float64(18), "maximum": 65},
+				"code": map[string]any{"type": "string", "minLength": float64(2), "maxLength": float64(3), "pattern": "^[A-Z]+$"},
+				"ids":  map[string]any{"type": "array", "minItems": float64(1), "maxItems": 1.5},
+			},
+		},
+	}
+
+	schema, err := tr.ConvertToolDefinition(rawTool)
+	if err != nil {
+		t.Fatalf("ConvertToolDefinition failed: %v", err)
+	}
+
+	for _, p := range schema.Parameters {
+		switch p.Name {
+		case "age":
+			if p.Minimum == nil || *p.Minimum != 18 || p.Maximum == nil || *p.Maximum != 65 {
+				t.Errorf("Expected age bounds [18, 65], got %v, %v", p.Minimum, p.Maximum)
+			}
+		case "code":
+			if p.MinLength == nil || *p.MinLength != 2 || p.MaxLength == nil || *p.MaxLength != 3 {
+				t.Errorf("Expected code lengths [2, 3], got %v, %v", p.MinLength, p.MaxLength)
+			}
+			if p.Pattern != "^[A-Z]+$" {
+				t.Errorf("Expected code pattern '^[A-Z]+$', got %q", p.Pattern)
+			}
+			if p.Minimum != nil || p.MinItems != nil {
+				t.Errorf("Expected unset bounds to stay nil, got %v, %v", p.Minimum, p.MinItems)
+			}
+		case "ids":
+			if p.MinItems == nil || *p.MinItems != 1 {
+				t.Errorf("Expected ids min items 1, got %v", p.MinItems)
+			}
+			if p.MaxItems != nil {
+				t.Errorf("Expected a fractional maxItems to be ignored, got %v", *p.MaxItems)
+			}
+		}
+	}
+}
+
+func TestConvertToolDefinitionBytesFormat(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil)
+
+	rawTool := map[string]any{
+		"name": "upload",
+		"inputSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"file": map[string]any{"type": "string", "format": "byte"},
+				"name": map[string]any{"type": "string", "format": "uri"},
+			},
+		},
+	}
+
+	schema, err := tr.ConvertToolDefinition(rawTool)
+	if err != nil {
+		t.Fatalf("ConvertToolDefinition failed: %v", err)
+	}
+
+	types := make(map[string]string)
+	for _, p := range schema.Parameters {
+		types[p.Name] = p.Type
+	}
+	if types["file"] != "bytes" {
+		t.Errorf("Expected 'file' to map to type 'bytes', got %q", types["file"])
+	}
+	if types["name"] != "string" {
+		t.Errorf("Expected 'name' to remain type 'string', got %q", types["name"])
+	}
+}
+
+func TestConvertToolDefinitionExamples(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil)
+
+	rawTool := map[string]any{
+		"name": "search",
+		"inputSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string"},
+			},
+			"examples": []any{
+				map[string]any{"query": "books"},
+				"not an object",
+			},
+		},
+		"_meta": map[string]any{
+			"examples": []any{
+				map[string]any{"query": "movies"},
+			},
+		},
+	}
+
+	schema, err := tr.ConvertToolDefinition(rawTool)
+	if err != nil {
+		t.Fatalf("ConvertToolDefinition failed: %v", err)
+	}
+
+	expected := []map[string]any{
+		{"query": "books"},
+		{"query": "movies"},
+	}
+	if !reflect.DeepEqual(schema.Examples, expected) {
+		t.Errorf("Expected examples %v, got %v", expected, schema.Examples)
+	}
+}
+
+func TestConvertToolDefinitionOutputSchema(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil)
+
+	rawTool := map[string]any{
+		"name":        "weather",
+		"inputSchema": map[string]any{"type": "object", "properties": map[string]any{}},
+		"outputSchema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city": map[string]any{"type": "string", "description": "City name"},
+			},
+			"required": []any{"city"},
+		},
+	}
+
+	schema, err := tr.ConvertToolDefinition(rawTool)
+	if err != nil {
+		t.Fatalf("ConvertToolDefinition failed: %v", err)
+	}
+
+	if len(schema.OutputSchema) != 1 {
+		t.Fatalf("Expected 1 output field, got %d", len(schema.OutputSchema))
+	}
+	field := schema.OutputSchema[0]
+	if field.Name != "city" || field.Type != "string" || !field.Required || field.Description != "City name" {
+		t.Errorf("Unexpected output field: %+v", field)
+	}
+
+	delete(rawTool, "outputSchema")
+	schema, err = tr.ConvertToolDefinition(rawTool)
+	if err != nil {
+		t.Fatalf("ConvertToolDefinition failed: %v", err)
+	}
+	if schema.OutputSchema != nil {
+		t.Errorf("Expected no output schema, got %+v", schema.OutputSchema)
+	}
+}
+
+func TestConvertToolDefinitionAuthMetaShapes(t *testing.T) {
+	tr, _ := NewBaseTransport("http://example.com", nil)
+
+	newTool := func(meta map[string]any) map[string]any {
+		return map[string]any{
+			"name": "secure",
+			"inputSchema": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"user_id": map[string]any{"type": "string"},
+				},
+			},
+			"_meta": meta,
+		}
+	}
+
+	validCases := []struct {
+		name            string
+		meta            map[string]any
+		wantAuthInvoke  []string
+		wantAuthSources []string
+	}{
+		{
+			name: "Single strings",
+			meta: map[string]any{
+				"toolbox/authParam":  map[string]any{"user_id": "google"},
+				"toolbox/authInvoke": "github",
+			},
+			wantAuthInvoke:  []string{"github"},
+			wantAuthSources: []string{"google"},
+		},
+		{
+			name: "String slices",
+			meta: map[string]any{
+				"toolbox/authParam":  map[string]any{"user_id": []string{"google", "okta"}},
+				"toolbox/authInvoke": []string{"github"},
+			},
+			wantAuthInvoke:  []string{"github"},
+			wantAuthSources: []string{"google", "okta"},
+		},
+		{
+			name: "Decoded JSON lists",
+			meta: map[string]any{
+				"toolbox/authParam":  map[string]any{"user_id": []any{"google"}},
+				"toolbox/authInvoke": []any{"github", "gitlab"},
+			},
+			wantAuthInvoke:  []string{"github", "gitlab"},
+			wantAuthSources: []string{"google"},
+		},
+		{
+			name: "Typed parameter map",
+			meta: map[string]any{
+				"toolbox/authParam": map[string][]string{"user_id": {"google"}},
+			},
+			wantAuthSources: []string{"google"},
+		},
+	}
+
+	for _, tc := range validCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schema, err := tr.ConvertToolDefinition(newTool(tc.meta))
+			if err != nil {
+				t.Fatalf("ConvertToolDefinition failed: %v", err)
+			}
+			if !reflect.DeepEqual(schema.AuthRequired, tc.wantAuthInvoke) {
+				t.Errorf("Expected AuthRequired %v, got %v", tc.wantAuthInvoke, schema.AuthRequired)
+			}
+			if len(schema.Parameters) != 1 || !reflect.DeepEqual(schema.Parameters[0].AuthSources, tc.wantAuthSources) {
+				t.Errorf("Expected AuthSources %v, got %+v", tc.wantAuthSources, schema.Parameters)
+			}
+		})
+	}
+
+	invalidCases := []struct {
+		name    string
+		meta    map[string]any
+		wantErr string
+	}{
+		{
+			name:    "Numeric authInvoke",
+			meta:    map[string]any{"toolbox/authInvoke": 42},
+			wantErr: "invalid 'toolbox/authInvoke' metadata: expected a string or a list of strings, got int",
+		},
+		{
+			name:    "Non-string list element",
+			meta:    map[string]any{"toolbox/authInvoke": []any{"github", true}},
+			wantErr: "invalid 'toolbox/authInvoke' metadata: expected a list of strings, but found an element of type bool",
+		},
+		{
+			name:    "authParam is not an object",
+			meta:    map[string]any{"toolbox/authParam": []any{"google"}},
+			wantErr: "invalid 'toolbox/authParam' metadata: expected an object, got []interface {}",
+		},
+		{
+			name:    "authParam entry of the wrong type",
+			meta:    map[string]any{"toolbox/authParam": map[string]any{"user_id": 1.5}},
+			wantErr: "invalid 'toolbox/authParam' metadata for parameter 'user_id': expected a string or a list of strings, got float64",
+		},
+	}
+
+	for _, tc := range invalidCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tr.ConvertToolDefinition(newTool(tc.meta))
+			if err == nil {
+				t.Fatal("Expected an error, but got nil")
+			}
+			if err.Error() != tc.wantErr {
+				t.Errorf("Expected error %q, got %q", tc.wantErr, err.Error())
+			}
+		})
+	}
+}
+
 func TestProcessToolResultContent(t *testing.T) {
 	// Setup a dummy transport (ProcessToolResultContent is a pure function, so state doesn't matter)
 	tr, _ := NewBaseTransport("http://example.com", nil)
@@ -356,6 +720,44 @@ func TestProcessToolResultContent(t *testing.T) {
 			},
 			expected: "12",
 		},
+		{
+			// A single JSON object split across blocks is reassembled
+			name: "Split JSON object",
+			content: []ToolContent{
+				{Type: "text", Text: `{"rows": [1, `},
+				{Type: "text", Text: `2, 3], "total"`},
+				{Type: "text", Text: `: 3}`},
+			},
+			expected: `{"rows": [1, 2, 3], "total": 3}`,
+		},
+		{
+			// A JSON string split across blocks is reassembled
+			name: "Split JSON string",
+			content: []ToolContent{
+				{Type: "text", Text: `"hello, `},
+				{Type: "text", Text: `world"`},
+			},
+			expected: `"hello, world"`,
+		},
+		{
+			// Several complete JSON values of different kinds become an array
+			name: "Mixed JSON values",
+			content: []ToolContent{
+				{Type: "text", Text: `{"a": 1}`},
+				{Type: "text", Text: `[2, 3]`},
+				{Type: "text", Text: `"four"`},
+			},
+			expected: `[{"a": 1},[2, 3],"four"]`,
+		},
+		{
+			// A split object followed by plain text is concatenated as-is
+			name: "Split JSON with plain text",
+			content: []ToolContent{
+				{Type: "text", Text: `{"a": `},
+				{Type: "text", Text: `1} done`},
+			},
+			expected: `{"a": 1} done`,
+		},
 		{
 			// Empty
 			name:     "Empty",
@@ -0,0 +1,499 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stdio implements the MCP stdio transport, which launches a local
+// server as a subprocess and exchanges newline-delimited JSON-RPC messages
+// with it over its standard input and output.
+package stdio
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
+)
+
+const (
+	ProtocolVersion = "2025-06-18"
+)
+
+// ErrClosed is returned by requests made after the transport was closed.
+var ErrClosed = errors.New("stdio transport is closed")
+
+// Ensure that McpTransport implements the Transport interface.
+var (
+	_ transport.Transport     = &McpTransport{}
+	_ transport.ResultInvoker = &McpTransport{}
+	_ transport.Pinger        = &McpTransport{}
+	_ io.Closer               = &McpTransport{}
+)
+
+// McpTransport implements the MCP stdio transport. The server process is
+// started on first use and runs until Close is called.
+//
+// Messages are not sent over HTTP, so request headers, including auth
+// tokens, are not forwarded to the server.
+type McpTransport struct {
+	*mcp.BaseMcpTransport
+	command         string
+	args            []string
+	protocolVersion string
+	clientName      string
+	clientVersion   string
+
+	// mu guards the server process and the transport's lifecycle.
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	closed bool
+	// done is closed once the server's output is exhausted, after which
+	// readErr holds the reason.
+	done    chan struct{}
+	readErr error
+
+	// writeMu serializes the messages written to the server's input.
+	writeMu sync.Mutex
+
+	// pendingMu guards pending, which routes each response to the request
+	// waiting for it, keyed by mcp.RequestIDKey.
+	pendingMu sync.Mutex
+	pending   map[string]chan jsonRPCMessage
+}
+
+// New creates a stdio transport that runs the given command with args. The
+// command is not started until the first request.
+func New(command string, args []string, clientName string, clientVersion string, opts ...mcp.Option) (*McpTransport, error) {
+	if command == "" {
+		return nil, fmt.Errorf("stdio transport requires a command")
+	}
+	if clientVersion == "" {
+		clientVersion = mcp.SDKVersion
+	}
+
+	t := &McpTransport{
+		BaseMcpTransport: mcp.NewLocalBaseTransport("stdio:"+command, opts...),
+		command:          command,
+		args:             append([]string(nil), args...),
+		protocolVersion:  ProtocolVersion,
+		clientName:       clientName,
+		clientVersion:    clientVersion,
+		pending:          make(map[string]chan jsonRPCMessage),
+	}
+	t.HandshakeHook = t.initializeSession
+
+	return t, nil
+}
+
+// Ping performs the session handshake if it has not yet completed, then
+// sends a 'ping' request to check that the server still answers. It returns
+// the version the server reported during the handshake.
+func (t *McpTransport) Ping(ctx context.Context, headers map[string]string) (string, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return "", err
+	}
+
+	var result map[string]any
+	if err := t.sendRequest(ctx, "ping", map[string]any{}, &result); err != nil {
+		return "", fmt.Errorf("ping failed: %w", err)
+	}
+	return t.ServerVersion, nil
+}
+
+// ListTools fetches available tools. The stdio transport serves a single
+// endpoint, so only the default toolset can be listed.
+func (t *McpTransport) ListTools(ctx context.Context, toolsetName string, headers map[string]string) (*transport.ManifestSchema, error) {
+	if toolsetName != "" {
+		return nil, fmt.Errorf("failed to list tools: toolset '%s' cannot be selected over the stdio transport", toolsetName)
+	}
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+
+	var result listToolsResult
+	if err := t.sendRequest(ctx, "tools/list", map[string]any{}, &result); err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	manifest := &transport.ManifestSchema{
+		ServerVersion: t.ServerVersion,
+		Tools:         make(map[string]transport.ToolSchema),
+	}
+
+	for i, tool := range result.Tools {
+		if tool.Name == "" {
+			return nil, fmt.Errorf("received invalid tool definition at index %d: missing 'name' field", i)
+		}
+		if _, ok := manifest.Tools[tool.Name]; ok {
+			return nil, fmt.Errorf("received duplicate tool definition at index %d: tool '%s' is already defined", i, tool.Name)
+		}
+
+		rawTool := map[string]any{
+			"name":        tool.Name,
+			"description": tool.Description,
+			"inputSchema": tool.InputSchema,
+		}
+		if tool.OutputSchema != nil {
+			rawTool["outputSchema"] = tool.OutputSchema
+		}
+		if tool.Meta != nil {
+			rawTool["_meta"] = tool.Meta
+		}
+
+		toolSchema, err := t.ConvertToolDefinition(rawTool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema for tool %s: %w", tool.Name, err)
+		}
+
+		manifest.Tools[tool.Name] = toolSchema
+	}
+
+	return manifest, nil
+}
+
+// GetTool fetches a single tool
+func (t *McpTransport) GetTool(ctx context.Context, toolName string, headers map[string]string) (*transport.ManifestSchema, error) {
+	manifest, err := t.ListTools(ctx, "", headers)
+	if err != nil {
+		return nil, err
+	}
+
+	tool, exists := manifest.Tools[toolName]
+	if !exists {
+		return nil, fmt.Errorf("tool '%s' not found", toolName)
+	}
+
+	return &transport.ManifestSchema{
+		ServerVersion: manifest.ServerVersion,
+		Tools:         map[string]transport.ToolSchema{toolName: tool},
+	}, nil
+}
+
+// InvokeTool executes a tool
+func (t *McpTransport) InvokeTool(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (any, error) {
+	result, err := t.InvokeToolResult(ctx, toolName, payload, headers)
+	if err != nil {
+		return "", err
+	}
+	return result.Output, nil
+}
+
+// InvokeToolResult executes a tool and returns its output together with any
+// warnings reported by the server and the raw response message.
+func (t *McpTransport) InvokeToolResult(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (*transport.InvokeResult, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	params := callToolRequestParams{
+		Name:      toolName,
+		Arguments: payload,
+		Meta:      mcp.CallToolMeta(headers),
+	}
+
+	requestID := t.NewRequestID()
+	var result callToolResult
+	var raw transport.InvokeResult
+	if err := t.sendRequestWithID(ctx, requestID, "tools/call", params, &result, &raw); err != nil {
+		if ctx.Err() != nil {
+			t.notifyCancelled(ctx, requestID)
+		}
+		return nil, fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)
+	}
+
+	if result.IsError {
+		return nil, fmt.Errorf("tool execution resulted in error")
+	}
+
+	baseContent := make([]mcp.ToolContent, len(result.Content))
+	for i, item := range result.Content {
+		baseContent[i] = mcp.ToolContent{
+			Type:     item.Type,
+			Text:     item.Text,
+			Data:     item.Data,
+			MimeType: item.MimeType,
+			Resource: item.Resource,
+		}
+	}
+
+	raw.Output = t.ProcessToolResultContent(baseContent)
+	raw.Content = t.ContentBlocks(baseContent)
+	raw.Warnings = t.CollectWarnings(result.Warnings, result.Meta)
+	return &raw, nil
+}
+
+// Close stops the server process. Requests in flight fail, and the transport
+// cannot be used afterwards.
+func (t *McpTransport) Close() error {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed = true
+	cmd, stdin, done := t.cmd, t.stdin, t.done
+	t.mu.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+	_ = stdin.Close()
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to stop server process: %w", err)
+	}
+	<-done
+	return nil
+}
+
+// initializeSession performs the initial handshake with the server.
+func (t *McpTransport) initializeSession(ctx context.Context, headers map[string]string) error {
+	params := initializeRequestParams{
+		ProtocolVersion: t.protocolVersion,
+		Capabilities:    clientCapabilities{},
+		ClientInfo: implementation{
+			Name:    t.clientName,
+			Version: t.clientVersion,
+		},
+	}
+
+	var result initializeResult
+	if err := t.sendRequest(ctx, "initialize", params, &result); err != nil {
+		return err
+	}
+
+	// Protocol Version Check
+	if result.ProtocolVersion != t.protocolVersion {
+		return &transport.VersionMismatchError{ClientVersion: t.protocolVersion, ServerVersion: result.ProtocolVersion}
+	}
+
+	// Capabilities Check
+	if result.Capabilities.Tools == nil {
+		return fmt.Errorf("server does not support the 'tools' capability")
+	}
+
+	t.ServerVersion = result.ServerInfo.Version
+
+	// Confirm Handshake
+	return t.sendNotification("notifications/initialized", map[string]any{})
+}
+
+// start launches the server process unless it is already running.
+func (t *McpTransport) start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return ErrClosed
+	}
+	if t.cmd != nil {
+		return nil
+	}
+
+	cmd := exec.Command(t.command, t.args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open server input: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open server output: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start server command '%s': %w", t.command, err)
+	}
+	t.Logger().Debug("started MCP server process", "command", t.command, "pid", cmd.Process.Pid)
+
+	t.cmd = cmd
+	t.stdin = stdin
+	t.done = make(chan struct{})
+	go t.readLoop(cmd, stdout, t.done)
+	return nil
+}
+
+// readLoop reads the newline-delimited messages written by the server until
+// its output is exhausted, then reaps the process and closes done.
+func (t *McpTransport) readLoop(cmd *exec.Cmd, stdout io.Reader, done chan struct{}) {
+	reader := bufio.NewReader(stdout)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			t.dispatch(line)
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		t.readErr = fmt.Errorf("server process exited: %w", err)
+	} else {
+		t.readErr = fmt.Errorf("server process exited")
+	}
+	t.Logger().Debug("MCP server process exited", "command", t.command, "error", t.readErr)
+	close(done)
+}
+
+// dispatch routes a message from the server to the request waiting for it.
+// Requests from the server are answered, and notifications are ignored.
+func (t *McpTransport) dispatch(line []byte) {
+	var msg jsonRPCMessage
+	if err := json.Unmarshal(line, &msg); err != nil {
+		t.Logger().Debug("ignoring malformed message from MCP server", "error", err)
+		return
+	}
+	msg.raw = line
+
+	if msg.Method != "" {
+		if msg.ID != nil {
+			go t.answer(msg)
+		}
+		return
+	}
+
+	key := mcp.RequestIDKey(msg.ID)
+	t.pendingMu.Lock()
+	ch, ok := t.pending[key]
+	delete(t.pending, key)
+	t.pendingMu.Unlock()
+	if ok {
+		ch <- msg
+	}
+}
+
+// answer replies to a request sent by the server. Only 'ping' is supported.
+func (t *McpTransport) answer(req jsonRPCMessage) {
+	resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.Method == "ping" {
+		resp.Result = map[string]any{}
+	} else {
+		resp.Error = &jsonRPCError{Code: -32601, Message: fmt.Sprintf("method '%s' not supported", req.Method)}
+	}
+	_ = t.write(resp)
+}
+
+// sendRequest sends a standard JSON-RPC request to the server.
+func (t *McpTransport) sendRequest(ctx context.Context, method string, params any, dest any) error {
+	return t.sendRequestWithID(ctx, t.NewRequestID(), method, params, dest, nil)
+}
+
+// sendRequestWithID sends a JSON-RPC request with a caller-chosen ID and
+// waits for its response. When raw is non-nil, it receives the response
+// message as it was read.
+func (t *McpTransport) sendRequestWithID(ctx context.Context, id any, method string, params any, dest any, raw *transport.InvokeResult) error {
+	if err := t.start(); err != nil {
+		return err
+	}
+
+	key := mcp.RequestIDKey(id)
+	ch := make(chan jsonRPCMessage, 1)
+	t.pendingMu.Lock()
+	t.pending[key] = ch
+	t.pendingMu.Unlock()
+	defer func() {
+		t.pendingMu.Lock()
+		delete(t.pending, key)
+		t.pendingMu.Unlock()
+	}()
+
+	req := jsonRPCRequest{
+		JSONRPC: "2.0",
+		Method:  t.Method(method),
+		ID:      id,
+		Params:  params,
+	}
+	if err := t.write(req); err != nil {
+		return err
+	}
+
+	var msg jsonRPCMessage
+	select {
+	case msg = <-ch:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.done:
+		// The response may have been read just before the server exited.
+		select {
+		case msg = <-ch:
+		default:
+			return t.readErr
+		}
+	}
+
+	if method == "tools/list" {
+		if _, err := t.ReadManifestBody(bytes.NewReader(msg.raw)); err != nil {
+			return err
+		}
+	}
+	if raw != nil {
+		raw.RawBody = msg.raw
+	}
+
+	// Check RPC Error
+	if msg.Error != nil {
+		return msg.Error.toError()
+	}
+
+	// Decode Result into specific struct
+	if err := json.Unmarshal(msg.Result, dest); err != nil {
+		return fmt.Errorf("failed to parse result data: %w", err)
+	}
+
+	return nil
+}
+
+// sendNotification sends a standard JSON-RPC notification (no response expected).
+func (t *McpTransport) sendNotification(method string, params any) error {
+	if err := t.start(); err != nil {
+		return err
+	}
+	return t.write(jsonRPCNotification{
+		JSONRPC: "2.0",
+		Method:  t.Method(method),
+		Params:  params,
+	})
+}
+
+// notifyCancelled makes a best-effort attempt to tell the server to stop
+// working on a request the caller has abandoned. Failures are ignored so that
+// the caller still sees the original cancellation error.
+func (t *McpTransport) notifyCancelled(ctx context.Context, requestID any) {
+	_ = t.sendNotification("notifications/cancelled", mcp.CancelledNotificationParams(ctx, requestID))
+}
+
+// write sends a single message to the server, terminated by a newline. The
+// JSON encoding escapes newlines within strings, so every message fits on a
+// single line.
+func (t *McpTransport) write(msg any) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("marshal failed: %w", err)
+	}
+
+	t.mu.Lock()
+	stdin := t.stdin
+	t.mu.Unlock()
+
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := stdin.Write(append(payload, '\n')); err != nil {
+		return fmt.Errorf("write to server failed: %w", err)
+	}
+	return nil
+}
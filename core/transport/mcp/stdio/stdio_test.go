@@ -0,0 +1,193 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdio
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildEchoServer compiles the echo MCP server in testdata and returns the
+// path of its binary.
+func buildEchoServer(t *testing.T) string {
+	t.Helper()
+	bin := filepath.Join(t.TempDir(), "echoserver")
+	out, err := exec.Command("go", "build", "-o", bin, "./testdata/echoserver").CombinedOutput()
+	require.NoError(t, err, "failed to build echo server: %s", out)
+	return bin
+}
+
+func TestStdioTransport(t *testing.T) {
+	bin := buildEchoServer(t)
+	ctx := context.Background()
+
+	newTransport := func(t *testing.T, opts ...mcp.Option) *McpTransport {
+		tr, err := New(bin, nil, "test-client", "", opts...)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = tr.Close() })
+		return tr
+	}
+
+	t.Run("Starts the server on first use", func(t *testing.T) {
+		tr := newTransport(t)
+		assert.Nil(t, tr.cmd, "the server must not start before the first request")
+		assert.Equal(t, "stdio:"+bin, tr.BaseURL())
+
+		manifest, err := tr.ListTools(ctx, "", nil)
+		require.NoError(t, err)
+		assert.NotNil(t, tr.cmd)
+		assert.Equal(t, "1.0.0", manifest.ServerVersion)
+		require.Contains(t, manifest.Tools, "echo")
+
+		echo := manifest.Tools["echo"]
+		assert.Equal(t, "Echoes a message.", echo.Description)
+		require.Len(t, echo.Parameters, 1)
+		assert.Equal(t, transport.ParameterSchema{Name: "message", Type: "string", Description: "The message to echo.", Required: true}, echo.Parameters[0])
+	})
+
+	t.Run("GetTool and InvokeTool", func(t *testing.T) {
+		tr := newTransport(t)
+
+		manifest, err := tr.GetTool(ctx, "echo", nil)
+		require.NoError(t, err)
+		assert.Len(t, manifest.Tools, 1)
+
+		_, err = tr.GetTool(ctx, "missing", nil)
+		assert.ErrorContains(t, err, "tool 'missing' not found")
+
+		result, err := tr.InvokeTool(ctx, "echo", map[string]any{"message": "hello\nworld"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hello\nworld", result)
+
+		_, err = tr.InvokeTool(ctx, "unknown", map[string]any{}, nil)
+		assert.ErrorContains(t, err, "tool execution resulted in error")
+	})
+
+	t.Run("Ping", func(t *testing.T) {
+		tr := newTransport(t)
+		version, err := tr.Ping(ctx, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "1.0.0", version)
+	})
+
+	t.Run("Concurrent requests are matched to their responses", func(t *testing.T) {
+		tr := newTransport(t)
+
+		var wg sync.WaitGroup
+		messages := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+		results := make([]any, len(messages))
+		errs := make([]error, len(messages))
+		for i, message := range messages {
+			wg.Go(func() {
+				results[i], errs[i] = tr.InvokeTool(ctx, "echo", map[string]any{"message": message}, nil)
+			})
+		}
+		wg.Wait()
+
+		for i, message := range messages {
+			require.NoError(t, errs[i])
+			assert.Equal(t, message, results[i])
+		}
+	})
+
+	t.Run("Numeric request IDs are matched to their responses", func(t *testing.T) {
+		var next atomic.Int64
+		tr := newTransport(t, mcp.WithRequestIDGenerator(func() any { return next.Add(1) }))
+
+		result, err := tr.InvokeTool(ctx, "echo", map[string]any{"message": "counted"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "counted", result)
+		assert.EqualValues(t, 2, next.Load(), "initialize and tools/call must each take an ID")
+	})
+
+	t.Run("Cancellation abandons the request", func(t *testing.T) {
+		tr := newTransport(t)
+
+		callCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err := tr.InvokeTool(callCtx, "hang", map[string]any{}, nil)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+
+		result, err := tr.InvokeTool(ctx, "echo", map[string]any{"message": "still alive"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "still alive", result)
+	})
+
+	t.Run("Reports a server that exits", func(t *testing.T) {
+		tr := newTransport(t)
+
+		_, err := tr.InvokeTool(ctx, "exit", map[string]any{}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "server process exited")
+
+		_, err = tr.InvokeTool(ctx, "echo", map[string]any{"message": "hi"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("Close kills the server", func(t *testing.T) {
+		tr := newTransport(t)
+		_, err := tr.ListTools(ctx, "", nil)
+		require.NoError(t, err)
+
+		require.NoError(t, tr.Close())
+		assert.NotNil(t, tr.cmd.ProcessState, "the server process must have been reaped")
+		require.NoError(t, tr.Close(), "Close must be idempotent")
+
+		_, err = tr.InvokeTool(ctx, "echo", map[string]any{"message": "hi"}, nil)
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+
+	t.Run("Close before first use", func(t *testing.T) {
+		tr := newTransport(t)
+		require.NoError(t, tr.Close())
+		_, err := tr.ListTools(ctx, "", nil)
+		assert.ErrorIs(t, err, ErrClosed)
+	})
+
+	t.Run("Rejects toolsets and missing commands", func(t *testing.T) {
+		tr := newTransport(t)
+		_, err := tr.ListTools(ctx, "travel", nil)
+		assert.ErrorContains(t, err, "toolset 'travel' cannot be selected")
+
+		_, err = New("", nil, "test-client", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("Fails to start an unknown command", func(t *testing.T) {
+		tr, err := New(filepath.Join(t.TempDir(), "missing"), nil, "test-client", "")
+		require.NoError(t, err)
+		_, err = tr.ListTools(ctx, "", nil)
+		assert.ErrorContains(t, err, "failed to start server command")
+	})
+
+	t.Run("Enforces the maximum manifest size", func(t *testing.T) {
+		tr := newTransport(t, mcp.WithMaxManifestSize(64))
+		_, err := tr.ListTools(ctx, "", nil)
+		assert.ErrorIs(t, err, transport.ErrManifestTooLarge)
+	})
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command echoserver is a minimal MCP server speaking the stdio transport,
+// used by the stdio transport tests. It offers the tools:
+//   - echo: returns its 'message' argument.
+//   - hang: never answers.
+//   - exit: terminates the server.
+//
+// With -pidfile, the server writes its process ID to the file on receiving
+// the initialize request. With -initialize=hang it then never answers, and
+// with -initialize=reject it reports no tools capability.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"os"
+	"strconv"
+)
+
+type message struct {
+	ID     any             `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+func main() {
+	pidFile := flag.String("pidfile", "", "file to write the process ID to on initialize")
+	initialize := flag.String("initialize", "", "how to answer initialize: hang or reject")
+	flag.Parse()
+
+	encoder := json.NewEncoder(os.Stdout)
+	reply := func(id any, result any) {
+		_ = encoder.Encode(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		switch msg.Method {
+		case "initialize":
+			if *pidFile != "" {
+				_ = os.WriteFile(*pidFile, []byte(strconv.Itoa(os.Getpid())), 0o600)
+			}
+			if *initialize == "hang" {
+				continue
+			}
+			capabilities := map[string]any{"tools": map[string]any{}}
+			if *initialize == "reject" {
+				capabilities = map[string]any{}
+			}
+			var params struct {
+				ProtocolVersion string `json:"protocolVersion"`
+			}
+			_ = json.Unmarshal(msg.Params, &params)
+			// Ping the client before answering, to exercise its handling of
+			// requests sent by the server.
+			_ = encoder.Encode(map[string]any{"jsonrpc": "2.0", "id": "server-ping", "method": "ping"})
+			reply(msg.ID, map[string]any{
+				"protocolVersion": params.ProtocolVersion,
+				"capabilities":    capabilities,
+				"serverInfo":      map[string]any{"name": "echoserver", "version": "1.0.0"},
+			})
+		case "ping":
+			reply(msg.ID, map[string]any{})
+		case "tools/list":
+			reply(msg.ID, map[string]any{"tools": []map[string]any{
+				{
+					"name":        "echo",
+					"description": "Echoes a message.",
+					"inputSchema": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"message": map[string]any{"type": "string", "description": "The message to echo."}},
+						"required":   []string{"message"},
+					},
+				},
+				{"name": "hang", "description": "Never answers.", "inputSchema": map[string]any{"type": "object"}},
+				{"name": "exit", "description": "Terminates the server.", "inputSchema": map[string]any{"type": "object"}},
+			}})
+		case "tools/call":
+			var params struct {
+				Name      string         `json:"name"`
+				Arguments map[string]any `json:"arguments"`
+			}
+			_ = json.Unmarshal(msg.Params, &params)
+			switch params.Name {
+			case "echo":
+				text, _ := params.Arguments["message"].(string)
+				reply(msg.ID, map[string]any{"content": []map[string]any{{"type": "text", "text": text}}})
+			case "hang":
+			case "exit":
+				os.Exit(3)
+			default:
+				reply(msg.ID, map[string]any{"content": []map[string]any{{"type": "text", "text": "unknown tool"}}, "isError": true})
+			}
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stdio
+
+import (
+	"encoding/json"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
+
+// jsonRPCRequest represents a standard JSON-RPC 2.0 request.
+type jsonRPCRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	ID      any    `json:"id,omitempty"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// jsonRPCNotification represents a standard JSON-RPC 2.0 notification (no ID).
+type jsonRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+// jsonRPCResponse represents a standard JSON-RPC 2.0 response sent by the
+// client, in reply to a request from the server.
+type jsonRPCResponse struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      any           `json:"id"`
+	Result  any           `json:"result,omitempty"`
+	Error   *jsonRPCError `json:"error,omitempty"`
+}
+
+// jsonRPCMessage represents any JSON-RPC 2.0 message read from the server:
+// a response to one of the client's requests, or a request or notification
+// of the server's own.
+type jsonRPCMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      any             `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+
+	// raw holds the message as it was read.
+	raw []byte
+}
+
+// jsonRPCError represents the error object inside a JSON-RPC response.
+type jsonRPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// implementation describes the name and version of the client.
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// clientCapabilities describes the features supported by the client.
+type clientCapabilities map[string]any
+
+// serverCapabilities describes the features supported by the server.
+type serverCapabilities struct {
+	Prompts map[string]any `json:"prompts,omitempty"`
+	Tools   map[string]any `json:"tools,omitempty"`
+}
+
+// initializeRequestParams holds the parameters for the 'initialize' handshake.
+type initializeRequestParams struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    clientCapabilities `json:"capabilities"`
+	ClientInfo      implementation     `json:"clientInfo"`
+}
+
+// initializeResult holds the response from the 'initialize' handshake.
+type initializeResult struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    serverCapabilities `json:"capabilities"`
+	ServerInfo      implementation     `json:"serverInfo"`
+	Instructions    string             `json:"instructions,omitempty"`
+}
+
+// mcpTool represents a single tool definition from the server.
+type mcpTool struct {
+	Name         string         `json:"name"`
+	Description  string         `json:"description,omitempty"`
+	InputSchema  map[string]any `json:"inputSchema"`
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
+	Meta         map[string]any `json:"_meta,omitempty"`
+}
+
+// listToolsResult holds the response from the 'tools/list' method.
+type listToolsResult struct {
+	Tools []mcpTool `json:"tools"`
+}
+
+// callToolRequestParams holds the parameters for the 'tools/call' method.
+type callToolRequestParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+	Meta      map[string]any `json:"_meta,omitempty"`
+}
+
+// textContent represents a single content block in a tool's output, such as
+// text, an image or an embedded resource.
+type textContent struct {
+	Type     string                      `json:"type"`
+	Text     string                      `json:"text"`
+	Data     []byte                      `json:"data,omitempty"`
+	MimeType string                      `json:"mimeType,omitempty"`
+	Resource *transport.ResourceContents `json:"resource,omitempty"`
+}
+
+// callToolResult holds the response from the 'tools/call' method.
+type callToolResult struct {
+	Content  []textContent  `json:"content"`
+	IsError  bool           `json:"isError"`
+	Warnings []string       `json:"warnings,omitempty"`
+	Meta     map[string]any `json:"_meta,omitempty"`
+}
+
+// toError converts the JSON-RPC error into a *transport.MCPError.
+func (e *jsonRPCError) toError() error {
+	return &transport.MCPError{Code: e.Code, Message: e.Message, Data: e.Data}
+}
@@ -20,10 +20,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"mime"
 	"net/http"
 	"net/url"
 
-	"github.com/google/uuid"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
 )
@@ -33,7 +34,16 @@ const (
 )
 
 // Ensure that McpTransport implements the Transport interface.
-var _ transport.Transport = &McpTransport{}
+var (
+	_ transport.Transport          = &McpTransport{}
+	_ transport.ResultInvoker      = &McpTransport{}
+	_ transport.ToolSearcher       = &McpTransport{}
+	_ transport.ToolsetLister      = &McpTransport{}
+	_ transport.ResourceReader     = &McpTransport{}
+	_ transport.PromptGetter       = &McpTransport{}
+	_ transport.Pinger             = &McpTransport{}
+	_ transport.EventStreamInvoker = &McpTransport{}
+)
 
 // McpTransport implements the MCP v2025-03-26 protocol.
 type McpTransport struct {
@@ -46,8 +56,8 @@ type McpTransport struct {
 }
 
 // New creates a new version-specific transport instance.
-func New(baseURL string, client *http.Client, clientName string, clientVersion string) (*McpTransport, error) {
-	baseTransport, err := mcp.NewBaseTransport(baseURL, client)
+func New(baseURL string, client *http.Client, clientName string, clientVersion string, opts ...mcp.Option) (*McpTransport, error) {
+	baseTransport, err := mcp.NewBaseTransport(baseURL, client, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -87,14 +97,171 @@ func (t *McpTransport) ListTools(ctx context.Context, toolsetName string, header
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
 
+	return t.buildManifest(result.Tools)
+}
+
+// SearchTools asks the server for the tools matching a query. It requires the
+// server to advertise the 'search' tools capability.
+func (t *McpTransport) SearchTools(ctx context.Context, query string, headers map[string]string) (*transport.ManifestSchema, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsToolSearch {
+		return nil, transport.ErrToolSearchNotSupported
+	}
+
+	var result listToolsResult
+	if _, err := t.sendRequest(ctx, t.BaseURL(), "tools/search", searchToolsRequestParams{Query: query}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to search tools: %w", err)
+	}
+
+	return t.buildManifest(result.Tools)
+}
+
+// Ping performs the session handshake if it has not yet completed, then
+// sends a 'ping' request to check that the server still answers. It returns
+// the version the server reported during the handshake.
+func (t *McpTransport) Ping(ctx context.Context, headers map[string]string) (string, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return "", err
+	}
+
+	var result map[string]any
+	if _, err := t.sendRequest(ctx, t.BaseURL(), "ping", map[string]any{}, headers, &result); err != nil {
+		return "", fmt.Errorf("ping failed: %w", err)
+	}
+	return t.ServerVersion, nil
+}
+
+// ListToolsets asks the server for the names of the toolsets it exposes. It
+// requires the server to advertise the 'toolsets' tools capability.
+func (t *McpTransport) ListToolsets(ctx context.Context, headers map[string]string) ([]string, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsToolsetListing {
+		return nil, transport.ErrToolsetListingNotSupported
+	}
+
+	var result listToolsetsResult
+	if _, err := t.sendRequest(ctx, t.BaseURL(), "toolsets/list", map[string]any{}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to list toolsets: %w", err)
+	}
+
+	names := make([]string, 0, len(result.Toolsets))
+	for _, toolset := range result.Toolsets {
+		names = append(names, toolset.Name)
+	}
+	return names, nil
+}
+
+// ListResources asks the server for the resources it exposes, following
+// pagination cursors until every page has been read. It requires the server
+// to advertise the 'resources' capability.
+func (t *McpTransport) ListResources(ctx context.Context, headers map[string]string) ([]transport.Resource, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsResources {
+		return nil, transport.ErrResourcesNotSupported
+	}
+
+	var resources []transport.Resource
+	cursor := ""
+	for {
+		var result listResourcesResult
+		if _, err := t.sendRequest(ctx, t.BaseURL(), "resources/list", paginatedRequestParams{Cursor: cursor}, headers, &result); err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		resources = append(resources, result.Resources...)
+		if result.NextCursor == "" {
+			return resources, nil
+		}
+		if result.NextCursor == cursor {
+			return nil, fmt.Errorf("failed to list resources: server repeated cursor '%s'", cursor)
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// ReadResource asks the server for the contents of the resource with the
+// given URI. It requires the server to advertise the 'resources' capability.
+func (t *McpTransport) ReadResource(ctx context.Context, uri string, headers map[string]string) ([]transport.ResourceContents, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsResources {
+		return nil, transport.ErrResourcesNotSupported
+	}
+
+	var result readResourceResult
+	if _, err := t.sendRequest(ctx, t.BaseURL(), "resources/read", readResourceRequestParams{URI: uri}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to read resource '%s': %w", uri, err)
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts asks the server for the prompt templates it exposes, following
+// pagination cursors until every page has been read. It requires the server
+// to advertise the 'prompts' capability.
+func (t *McpTransport) ListPrompts(ctx context.Context, headers map[string]string) ([]transport.Prompt, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsPrompts {
+		return nil, transport.ErrPromptsNotSupported
+	}
+
+	var prompts []transport.Prompt
+	cursor := ""
+	for {
+		var result listPromptsResult
+		if _, err := t.sendRequest(ctx, t.BaseURL(), "prompts/list", paginatedRequestParams{Cursor: cursor}, headers, &result); err != nil {
+			return nil, fmt.Errorf("failed to list prompts: %w", err)
+		}
+		prompts = append(prompts, result.Prompts...)
+		if result.NextCursor == "" {
+			return prompts, nil
+		}
+		if result.NextCursor == cursor {
+			return nil, fmt.Errorf("failed to list prompts: server repeated cursor '%s'", cursor)
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// GetPrompt asks the server to render the named prompt template with the
+// given arguments. It requires the server to advertise the 'prompts'
+// capability.
+func (t *McpTransport) GetPrompt(ctx context.Context, name string, args map[string]string, headers map[string]string) (*transport.PromptResult, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsPrompts {
+		return nil, transport.ErrPromptsNotSupported
+	}
+
+	var result transport.PromptResult
+	if _, err := t.sendRequest(ctx, t.BaseURL(), "prompts/get", getPromptRequestParams{Name: name, Arguments: args}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to get prompt '%s': %w", name, err)
+	}
+	return &result, nil
+}
+
+// buildManifest converts the tool definitions returned by the server into a
+// manifest.
+func (t *McpTransport) buildManifest(tools []mcpTool) (*transport.ManifestSchema, error) {
 	manifest := &transport.ManifestSchema{
 		ServerVersion: t.ServerVersion,
 		Tools:         make(map[string]transport.ToolSchema),
 	}
-	for i, tool := range result.Tools {
+	for i, tool := range tools {
 		if tool.Name == "" {
 			return nil, fmt.Errorf("received invalid tool definition at index %d: missing 'name' field", i)
 		}
+		if _, ok := manifest.Tools[tool.Name]; ok {
+			return nil, fmt.Errorf("received duplicate tool definition at index %d: tool '%s' is already defined", i, tool.Name)
+		}
 
 		rawTool := map[string]any{
 			"name":        tool.Name,
@@ -135,34 +302,187 @@ func (t *McpTransport) GetTool(ctx context.Context, toolName string, headers map
 
 // InvokeTool executes a tool
 func (t *McpTransport) InvokeTool(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (any, error) {
-	if err := t.EnsureInitialized(ctx, headers); err != nil {
+	result, err := t.InvokeToolResult(ctx, toolName, payload, headers)
+	if err != nil {
 		return "", err
 	}
+	return result.Output, nil
+}
+
+// InvokeToolResult executes a tool and returns its output together with any
+// warnings reported by the server and the HTTP metadata of the response.
+func (t *McpTransport) InvokeToolResult(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (*transport.InvokeResult, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
 
 	params := callToolRequestParams{
 		Name:      toolName,
 		Arguments: payload,
+		Meta:      mcp.CallToolMeta(headers),
 	}
+	requestID := t.NewRequestID()
 	var result callToolResult
-	if _, err := t.sendRequest(ctx, t.BaseURL(), "tools/call", params, headers, &result); err != nil {
-		return "", fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)
+	var raw transport.InvokeResult
+	if _, err := t.sendRequestWithID(ctx, t.BaseURL(), requestID, "tools/call", params, headers, &result, &raw); err != nil {
+		if ctx.Err() != nil {
+			t.notifyCancelled(ctx, requestID, headers)
+		}
+		return nil, fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)
 	}
 
 	if result.IsError {
-		return "", fmt.Errorf("tool execution resulted in error")
+		return nil, fmt.Errorf("tool execution resulted in error")
+	}
+
+	raw.Output = t.toolOutput(result)
+	raw.Content = t.ContentBlocks(toolContent(result))
+	raw.Warnings = t.CollectWarnings(result.Warnings, result.Meta)
+	return &raw, nil
+}
+
+// InvokeToolEvents executes a tool, letting the server answer with a stream
+// of Server-Sent Events. Progress messages the server reports for the call
+// are emitted as text deltas, followed by the tool's output as the final
+// chunk. A server that answers with a plain JSON response yields the final
+// chunk only.
+func (t *McpTransport) InvokeToolEvents(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (<-chan transport.StreamChunk, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+
+	// The request ID doubles as the progress token, so that progress
+	// notifications can be matched to this call.
+	requestID := t.NewRequestID()
+	meta := mcp.CallToolMeta(headers)
+	if meta == nil {
+		meta = make(map[string]any)
+	}
+	meta["progressToken"] = requestID
+	req := jsonRPCRequest{
+		JSONRPC: "2.0",
+		Method:  t.Method("tools/call"),
+		ID:      requestID,
+		Params: callToolRequestParams{
+			Name:      toolName,
+			Arguments: payload,
+			Meta:      meta,
+		},
+	}
+	resp, err := t.openEventStream(ctx, req, headers)
+	if err != nil {
+		if ctx.Err() != nil {
+			t.notifyCancelled(ctx, requestID, headers)
+		}
+		return nil, fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)
 	}
 
+	chunks := make(chan transport.StreamChunk, 1)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		send := func(chunk transport.StreamChunk) error {
+			select {
+			case chunks <- chunk:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		finished := false
+		handle := func(data []byte) (bool, error) {
+			var msg jsonRPCMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				return false, fmt.Errorf("response unmarshal failed: %w", err)
+			}
+
+			if msg.Method == "notifications/progress" {
+				var params progressNotificationParams
+				if err := json.Unmarshal(msg.Params, &params); err != nil || mcp.RequestIDKey(params.ProgressToken) != mcp.RequestIDKey(requestID) || params.Message == "" {
+					return false, nil
+				}
+				return false, send(transport.StreamChunk{Text: params.Message})
+			}
+			if msg.Method != "" || mcp.RequestIDKey(msg.ID) != mcp.RequestIDKey(requestID) {
+				// Other requests and notifications from the server do not
+				// concern this call.
+				return false, nil
+			}
+
+			finished = true
+			if msg.Error != nil {
+				return true, msg.Error.toError()
+			}
+			var result callToolResult
+			if err := json.Unmarshal(msg.Result, &result); err != nil {
+				return true, fmt.Errorf("failed to parse result data: %w", err)
+			}
+			if result.IsError {
+				return true, fmt.Errorf("tool execution resulted in error")
+			}
+			return true, send(transport.StreamChunk{Text: t.toolOutput(result), Final: true})
+		}
+
+		var err error
+		if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/event-stream" {
+			err = t.ReadEvents(resp.Body, handle)
+		} else {
+			var body []byte
+			if body, err = io.ReadAll(resp.Body); err == nil {
+				_, err = handle(body)
+			}
+		}
+		if err == nil && !finished {
+			err = fmt.Errorf("stream ended before the tool result was received")
+		}
+		if err == nil {
+			return
+		}
+
+		ctxErr := ctx.Err()
+		if ctxErr == nil {
+			// Wait for the caller to take any pending chunk so the error is
+			// never dropped.
+			send(transport.StreamChunk{Err: fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)})
+			return
+		}
+
+		t.notifyCancelled(ctx, requestID, headers)
+		// The caller has stopped waiting for data, so make room for the
+		// terminal chunk rather than block on a pending one.
+		select {
+		case <-chunks:
+		default:
+		}
+		select {
+		case chunks <- transport.StreamChunk{Err: fmt.Errorf("failed to invoke tool '%s': %w", toolName, ctxErr)}:
+		default:
+		}
+	}()
+
+	return chunks, nil
+}
+
+// toolOutput converts the content of a tool result into the tool's output.
+func (t *McpTransport) toolOutput(result callToolResult) string {
+	return t.ProcessToolResultContent(toolContent(result))
+}
+
+// toolContent converts the content of a tool result into its shared form.
+func toolContent(result callToolResult) []mcp.ToolContent {
 	baseContent := make([]mcp.ToolContent, len(result.Content))
 	for i, item := range result.Content {
 		baseContent[i] = mcp.ToolContent{
-			Type: item.Type,
-			Text: item.Text,
+			Type:     item.Type,
+			Text:     item.Text,
+			Data:     item.Data,
+			MimeType: item.MimeType,
+			Resource: item.Resource,
 		}
 	}
-
-	output := t.ProcessToolResultContent(baseContent)
-
-	return output, nil
+	return baseContent
 }
 
 // initializeSession performs the initial handshake and extracts the Session ID.
@@ -179,19 +499,19 @@ func (t *McpTransport) initializeSession(ctx context.Context, headers map[string
 	req := jsonRPCRequest{
 		JSONRPC: "2.0",
 		Method:  "initialize",
-		ID:      uuid.New().String(),
+		ID:      t.NewRequestID(),
 		Params:  params,
 	}
 
 	// Capture headers to check for Session ID
-	respHeaders, err := t.doRPC(ctx, t.BaseURL(), req, headers, &result)
+	respHeaders, err := t.doRPC(ctx, t.BaseURL(), req, headers, &result, nil)
 	if err != nil {
 		return err
 	}
 
 	// Protocol Version Check
 	if result.ProtocolVersion != t.protocolVersion {
-		return fmt.Errorf("MCP version mismatch: client (%s) != server (%s)", t.protocolVersion, result.ProtocolVersion)
+		return &transport.VersionMismatchError{ClientVersion: t.protocolVersion, ServerVersion: result.ProtocolVersion}
 	}
 
 	// Capabilities Check
@@ -200,6 +520,10 @@ func (t *McpTransport) initializeSession(ctx context.Context, headers map[string
 	}
 
 	t.ServerVersion = result.ServerInfo.Version
+	t.SupportsToolSearch = result.Capabilities.Tools["search"] == true
+	t.SupportsToolsetListing = result.Capabilities.Tools["toolsets"] == true
+	t.SupportsResources = result.Capabilities.Resources != nil
+	t.SupportsPrompts = result.Capabilities.Prompts != nil
 
 	// Session ID Extraction: Check the Headers.
 	sessionId := respHeaders.Get("Mcp-Session-Id")
@@ -216,8 +540,16 @@ func (t *McpTransport) initializeSession(ctx context.Context, headers map[string
 
 // sendRequest sends a JSON-RPC request and injects the Session ID if active.
 func (t *McpTransport) sendRequest(ctx context.Context, url string, method string, params any, headers map[string]string, dest any) (http.Header, error) {
+	return t.sendRequestWithID(ctx, url, t.NewRequestID(), method, params, headers, dest, nil)
+}
 
-	// Initialize headers map if it is nil
+// sendRequestWithID sends a JSON-RPC request with a caller-chosen ID. When
+// raw is non-nil, it receives the HTTP metadata of the response.
+func (t *McpTransport) sendRequestWithID(ctx context.Context, url string, id any, method string, params any, headers map[string]string, dest any, raw *transport.InvokeResult) (http.Header, error) {
+
+	// Copy the headers, which may be shared by concurrent callers, before
+	// adding the session to them.
+	headers = maps.Clone(headers)
 	if headers == nil {
 		headers = make(map[string]string)
 	}
@@ -230,18 +562,19 @@ func (t *McpTransport) sendRequest(ctx context.Context, url string, method strin
 	// Construct the standard JSON-RPC request (Params are NOT modified)
 	req := jsonRPCRequest{
 		JSONRPC: "2.0",
-		Method:  method,
-		ID:      uuid.New().String(),
+		Method:  t.Method(method),
+		ID:      id,
 		Params:  params,
 	}
 
-	return t.doRPC(ctx, url, req, headers, dest)
+	return t.doRPC(ctx, url, req, headers, dest, raw)
 }
 
 // sendNotification sends a JSON-RPC notification and injects the Session ID if active.
 func (t *McpTransport) sendNotification(ctx context.Context, method string, params any, headers map[string]string) (http.Header, error) {
 
-	// Initialize headers map
+	// Copy the headers before adding the session to them.
+	headers = maps.Clone(headers)
 	if headers == nil {
 		headers = make(map[string]string)
 	}
@@ -254,16 +587,25 @@ func (t *McpTransport) sendNotification(ctx context.Context, method string, para
 	// Construct the standard JSON-RPC notification
 	req := jsonRPCNotification{
 		JSONRPC: "2.0",
-		Method:  method,
+		Method:  t.Method(method),
 		Params:  params,
 	}
 
 	// Pass the headers to doRPC
-	return t.doRPC(ctx, t.BaseURL(), req, headers, nil)
+	return t.doRPC(ctx, t.BaseURL(), req, headers, nil, nil)
+}
+
+// notifyCancelled makes a best-effort attempt to tell the server to stop
+// working on a request the caller has abandoned. Failures are ignored so that
+// the caller still sees the original cancellation error.
+func (t *McpTransport) notifyCancelled(ctx context.Context, requestID any, headers map[string]string) {
+	notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mcp.CancelNotificationTimeout)
+	defer cancel()
+	_, _ = t.sendNotification(notifyCtx, "notifications/cancelled", mcp.CancelledNotificationParams(ctx, requestID), headers)
 }
 
 // doRPC performs the HTTP POST, returns headers, and handles JSON-RPC wrapping.
-func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, headers map[string]string, dest any) (http.Header, error) {
+func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, headers map[string]string, dest any, raw *transport.InvokeResult) (http.Header, error) {
 	payload, err := json.Marshal(reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("marshal failed: %w", err)
@@ -298,17 +640,27 @@ func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, heade
 	} else {
 		// Any other code, OR a 202/204 when we expected a result, is a failure.
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Body: string(body), Header: resp.Header}
 	}
 
 	if dest == nil {
 		return resp.Header, nil
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	var bodyBytes []byte
+	if t.isManifestRequest(reqBody) {
+		bodyBytes, err = t.ReadManifestBody(resp.Body)
+	} else {
+		bodyBytes, err = io.ReadAll(resp.Body)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("read body failed: %w", err)
 	}
+	if raw != nil {
+		raw.StatusCode = resp.StatusCode
+		raw.Header = resp.Header
+		raw.RawBody = bodyBytes
+	}
 	var rpcResp jsonRPCResponse
 	if err := json.Unmarshal(bodyBytes, &rpcResp); err != nil {
 		return nil, fmt.Errorf("response unmarshal failed: %w", err)
@@ -316,7 +668,7 @@ func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, heade
 
 	// Check RPC Error
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("MCP request failed with code %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return nil, rpcResp.Error.toError()
 	}
 
 	// Decode Result into specific struct
@@ -327,3 +679,45 @@ func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, heade
 
 	return resp.Header, nil
 }
+
+// openEventStream posts a request that the server may answer with either a
+// JSON response or a stream of Server-Sent Events, and returns the response
+// for the caller to read and close.
+func (t *McpTransport) openEventStream(ctx context.Context, req jsonRPCRequest, headers map[string]string) (*http.Response, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", t.BaseURL(), bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	if t.sessionId != "" {
+		httpReq.Header.Set("Mcp-Session-Id", t.sessionId)
+	}
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := t.HTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("http request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, &transport.StatusError{StatusCode: resp.StatusCode, Body: string(body), Header: resp.Header}
+	}
+	return resp, nil
+}
+
+// isManifestRequest reports whether a request returns tool definitions, whose
+// response size is bounded by the transport's maximum manifest size.
+func (t *McpTransport) isManifestRequest(reqBody any) bool {
+	req, ok := reqBody.(jsonRPCRequest)
+	return ok && (req.Method == t.Method("tools/list") || req.Method == t.Method("tools/search"))
+}
@@ -20,11 +20,14 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
 	"testing"
+	"time"
 
 	"maps"
 
@@ -210,6 +213,39 @@ func TestSessionId_Injection_InvokeTool(t *testing.T) {
 	assert.Equal(t, "application/json", callReq.Headers.Get("Accept"), "Accept header missing or incorrect")
 }
 
+func TestSessionId_ReusedWithoutModifyingHeaders(t *testing.T) {
+	server := newMockMCPServer()
+	defer server.Close()
+
+	server.handlers["tools/call"] = func(params json.RawMessage) (any, map[string]string, error) {
+		return callToolResult{Content: []textContent{{Type: "text", Text: "OK"}}}, nil, nil
+	}
+	server.handlers["tools/list"] = func(params json.RawMessage) (any, map[string]string, error) {
+		return listToolsResult{Tools: []mcpTool{}}, nil, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	headers := map[string]string{"Authorization": "Bearer token"}
+	for range 3 {
+		_, err := client.InvokeTool(context.Background(), "test-tool", map[string]any{}, headers)
+		require.NoError(t, err)
+	}
+	_, err := client.ListTools(context.Background(), "", headers)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{"Authorization": "Bearer token"}, headers, "the caller's headers must not be modified")
+
+	initializeCount := 0
+	for _, req := range server.requests {
+		if req.Body.Method == "initialize" {
+			initializeCount++
+			continue
+		}
+		assert.Equal(t, "session-12345", req.Headers.Get("Mcp-Session-Id"), "request %q must reuse the session", req.Body.Method)
+	}
+	assert.Equal(t, 1, initializeCount)
+}
+
 func TestSessionId_Injection_ListTools(t *testing.T) {
 	server := newMockMCPServer()
 	defer server.Close()
@@ -383,6 +419,11 @@ func TestRequest_ServerError(t *testing.T) {
 	_, err := client.ListTools(context.Background(), "", nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "API request failed with status 500")
+
+	var statusErr *transport.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	assert.Equal(t, "Internal Error", statusErr.Body)
 }
 
 func TestRequest_BadJSON(t *testing.T) {
@@ -709,4 +750,248 @@ func TestNew_ClientVersion(t *testing.T) {
 			t.Errorf("expected clientVersion %q, got %q", mcp.SDKVersion, tr2.clientVersion)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestInvokeTool_CancellationNotification(t *testing.T) {
+	server := newMockMCPServer()
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	callStarted := make(chan struct{})
+	cancelled := make(chan map[string]any, 1)
+
+	server.handlers["tools/call"] = func(params json.RawMessage) (any, map[string]string, error) {
+		close(callStarted)
+		<-release
+		return callToolResult{}, nil, nil
+	}
+	server.handlers["notifications/cancelled"] = func(params json.RawMessage) (any, map[string]string, error) {
+		var p map[string]any
+		_ = json.Unmarshal(params, &p)
+		cancelled <- p
+		return nil, nil, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	// Complete the handshake up front so only the tool call is in flight.
+	require.NoError(t, client.EnsureInitialized(context.Background(), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-callStarted
+		cancel()
+	}()
+
+	_, err := client.InvokeTool(ctx, "slow-tool", map[string]any{}, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case p := <-cancelled:
+		var callID any
+		for _, req := range server.requests {
+			if req.Body.Method == "tools/call" {
+				callID = req.Body.ID
+			}
+		}
+		require.NotNil(t, callID)
+		assert.Equal(t, callID, p["requestId"])
+		assert.Equal(t, context.Canceled.Error(), p["reason"])
+	default:
+		t.Fatal("Expected a notifications/cancelled message after the caller cancelled")
+	}
+}
+
+// newEventStreamServer starts a server that completes the handshake and
+// answers 'tools/call' with a stream of Server-Sent Events. The stream
+// function receives the call's request ID and progress token, and a function
+// that writes one event.
+func newEventStreamServer(t *testing.T, stream func(r *http.Request, id any, token any, emit func(msg any))) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     any            `json:"id"`
+			Method string         `json:"method"`
+			Params map[string]any `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Mcp-Session-Id", "session-sse")
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"jsonrpc": "2.0",
+				"id":      req.ID,
+				"result": initializeResult{
+					ProtocolVersion: ProtocolVersion,
+					Capabilities:    serverCapabilities{Tools: map[string]any{"listChanged": true}},
+					ServerInfo:      implementation{Name: "sse-server", Version: "1.0.0"},
+				},
+			})
+		case "tools/call":
+			assert.Equal(t, "application/json, text/event-stream", r.Header.Get("Accept"))
+			assert.Equal(t, "session-sse", r.Header.Get("Mcp-Session-Id"))
+			meta, _ := req.Params["_meta"].(map[string]any)
+
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			flusher := w.(http.Flusher)
+			stream(r, req.ID, meta["progressToken"], func(msg any) {
+				data, _ := json.Marshal(msg)
+				_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", data)
+				flusher.Flush()
+			})
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+}
+
+func progressEvent(token any, message string) map[string]any {
+	return map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "notifications/progress",
+		"params":  map[string]any{"progressToken": token, "progress": 1, "message": message},
+	}
+}
+
+func collectChunks(t *testing.T, chunks <-chan transport.StreamChunk) []transport.StreamChunk {
+	t.Helper()
+	var got []transport.StreamChunk
+	for chunk := range chunks {
+		got = append(got, chunk)
+	}
+	return got
+}
+
+func TestInvokeToolEvents(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Delivers progress and the result in order", func(t *testing.T) {
+		server := newEventStreamServer(t, func(r *http.Request, id any, token any, emit func(msg any)) {
+			emit(progressEvent(token, "step 1"))
+			emit(progressEvent("another-call", "not ours"))
+			emit(progressEvent(token, "step 2"))
+			emit(map[string]any{"jsonrpc": "2.0", "id": "server-ping", "method": "ping"})
+			emit(progressEvent(token, "step 3"))
+			emit(map[string]any{"jsonrpc": "2.0", "id": id, "result": map[string]any{
+				"content": []map[string]any{{"type": "text", "text": "done"}},
+			}})
+		})
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		chunks, err := client.InvokeToolEvents(ctx, "report", map[string]any{"year": 2025}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []transport.StreamChunk{
+			{Text: "step 1"},
+			{Text: "step 2"},
+			{Text: "step 3"},
+			{Text: "done", Final: true},
+		}, collectChunks(t, chunks))
+	})
+
+	t.Run("Ends with an error chunk when the call fails mid-stream", func(t *testing.T) {
+		server := newEventStreamServer(t, func(r *http.Request, id any, token any, emit func(msg any)) {
+			emit(progressEvent(token, "step 1"))
+			emit(map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "boom"}})
+		})
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		chunks, err := client.InvokeToolEvents(ctx, "report", map[string]any{}, nil)
+		require.NoError(t, err)
+
+		got := collectChunks(t, chunks)
+		require.Len(t, got, 2)
+		assert.Equal(t, transport.StreamChunk{Text: "step 1"}, got[0])
+		assert.False(t, got[1].Final)
+		assert.ErrorContains(t, got[1].Err, "MCP request failed with code -32000: boom")
+	})
+
+	t.Run("Keeps the error chunk behind an unread progress chunk", func(t *testing.T) {
+		sent := make(chan struct{})
+		server := newEventStreamServer(t, func(r *http.Request, id any, token any, emit func(msg any)) {
+			emit(progressEvent(token, "partial"))
+			emit(map[string]any{"jsonrpc": "2.0", "id": id, "error": map[string]any{"code": -32000, "message": "boom"}})
+			close(sent)
+		})
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		chunks, err := client.InvokeToolEvents(ctx, "report", map[string]any{}, nil)
+		require.NoError(t, err)
+
+		// Give the reader time to reach the error while "partial" is unread.
+		<-sent
+		time.Sleep(50 * time.Millisecond)
+
+		got := collectChunks(t, chunks)
+		require.Len(t, got, 2)
+		assert.Equal(t, transport.StreamChunk{Text: "partial"}, got[0])
+		assert.ErrorContains(t, got[1].Err, "MCP request failed with code -32000: boom")
+	})
+
+	t.Run("Ends with an error chunk when the stream stops early", func(t *testing.T) {
+		server := newEventStreamServer(t, func(r *http.Request, id any, token any, emit func(msg any)) {
+			emit(progressEvent(token, "step 1"))
+		})
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		chunks, err := client.InvokeToolEvents(ctx, "report", map[string]any{}, nil)
+		require.NoError(t, err)
+
+		got := collectChunks(t, chunks)
+		require.Len(t, got, 2)
+		assert.ErrorContains(t, got[1].Err, "stream ended before the tool result was received")
+	})
+
+	t.Run("Cancellation mid-stream ends with the context error", func(t *testing.T) {
+		server := newEventStreamServer(t, func(r *http.Request, id any, token any, emit func(msg any)) {
+			emit(progressEvent(token, "step 1"))
+			<-r.Context().Done()
+		})
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		callCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		chunks, err := client.InvokeToolEvents(callCtx, "report", map[string]any{}, nil)
+		require.NoError(t, err)
+
+		first := <-chunks
+		assert.Equal(t, transport.StreamChunk{Text: "step 1"}, first)
+		cancel()
+
+		got := collectChunks(t, chunks)
+		require.Len(t, got, 1)
+		assert.ErrorIs(t, got[0].Err, context.Canceled)
+	})
+
+	t.Run("A JSON response yields a single final chunk", func(t *testing.T) {
+		server := newMockMCPServer()
+		defer server.Close()
+		server.handlers["tools/call"] = func(params json.RawMessage) (any, map[string]string, error) {
+			return callToolResult{Content: []textContent{{Type: "text", Text: "all at once"}}}, nil, nil
+		}
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		chunks, err := client.InvokeToolEvents(ctx, "report", map[string]any{}, nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, []transport.StreamChunk{{Text: "all at once", Final: true}}, collectChunks(t, chunks))
+	})
+
+	t.Run("Reports an unexpected status", func(t *testing.T) {
+		server := newMockMCPServer()
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		_, err := client.InvokeToolEvents(ctx, "report", map[string]any{}, nil)
+		var statusErr *transport.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	})
+}
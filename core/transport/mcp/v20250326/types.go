@@ -14,7 +14,11 @@
 
 package mcp20250326
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
+)
 
 // jsonRPCRequest represents a standard JSON-RPC 2.0 request.
 type jsonRPCRequest struct {
@@ -39,11 +43,30 @@ type jsonRPCResponse struct {
 	Error   *jsonRPCError   `json:"error,omitempty"`
 }
 
+// jsonRPCMessage is any JSON-RPC 2.0 message received on an event stream:
+// a response, or a request or notification sent by the server.
+type jsonRPCMessage struct {
+	ID     any             `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *jsonRPCError   `json:"error,omitempty"`
+}
+
+// progressNotificationParams holds the parameters of a
+// 'notifications/progress' message.
+type progressNotificationParams struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
+
 // jsonRPCError represents the error object inside a JSON-RPC response.
 type jsonRPCError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Data    any    `json:"data,omitempty"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
 // implementation describes the name and version of the client.
@@ -57,8 +80,9 @@ type clientCapabilities map[string]any
 
 // serverCapabilities describes the features supported by the server.
 type serverCapabilities struct {
-	Prompts map[string]any `json:"prompts,omitempty"`
-	Tools   map[string]any `json:"tools,omitempty"`
+	Prompts   map[string]any `json:"prompts,omitempty"`
+	Tools     map[string]any `json:"tools,omitempty"`
+	Resources map[string]any `json:"resources,omitempty"`
 }
 
 // initializeRequestParams holds the parameters for the 'initialize' handshake.
@@ -89,20 +113,81 @@ type listToolsResult struct {
 	Tools []mcpTool `json:"tools"`
 }
 
+// searchToolsRequestParams holds the parameters for the 'tools/search' method.
+type searchToolsRequestParams struct {
+	Query string `json:"query"`
+}
+
+// mcpToolset describes a single toolset exposed by the server.
+type mcpToolset struct {
+	Name string `json:"name"`
+}
+
+// listToolsetsResult holds the response from the 'toolsets/list' method.
+type listToolsetsResult struct {
+	Toolsets []mcpToolset `json:"toolsets"`
+}
+
+// paginatedRequestParams holds the parameters for list methods that return
+// results in pages.
+type paginatedRequestParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// listResourcesResult holds the response from the 'resources/list' method.
+type listResourcesResult struct {
+	Resources  []transport.Resource `json:"resources"`
+	NextCursor string               `json:"nextCursor,omitempty"`
+}
+
+// readResourceRequestParams holds the parameters for the 'resources/read' method.
+type readResourceRequestParams struct {
+	URI string `json:"uri"`
+}
+
+// readResourceResult holds the response from the 'resources/read' method.
+type readResourceResult struct {
+	Contents []transport.ResourceContents `json:"contents"`
+}
+
+// listPromptsResult holds the response from the 'prompts/list' method.
+type listPromptsResult struct {
+	Prompts    []transport.Prompt `json:"prompts"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// getPromptRequestParams holds the parameters for the 'prompts/get' method.
+type getPromptRequestParams struct {
+	Name      string            `json:"name"`
+	Arguments map[string]string `json:"arguments,omitempty"`
+}
+
 // callToolRequestParams holds the parameters for the 'tools/call' method.
 type callToolRequestParams struct {
 	Name      string         `json:"name"`
 	Arguments map[string]any `json:"arguments"`
+	Meta      map[string]any `json:"_meta,omitempty"`
 }
 
-// textContent represents a single text block in a tool's output.
+// textContent represents a single content block in a tool's output, such as
+// text, an image or an embedded resource.
 type textContent struct {
-	Type string `json:"type"`
-	Text string `json:"text"`
+	Type     string                      `json:"type"`
+	Text     string                      `json:"text"`
+	Data     []byte                      `json:"data,omitempty"`
+	MimeType string                      `json:"mimeType,omitempty"`
+	Resource *transport.ResourceContents `json:"resource,omitempty"`
 }
 
 // callToolResult holds the response from the 'tools/call' method.
 type callToolResult struct {
-	Content []textContent `json:"content"`
-	IsError bool          `json:"isError"`
+	Content  []textContent  `json:"content"`
+	IsError  bool           `json:"isError"`
+	Warnings []string       `json:"warnings,omitempty"`
+	Meta     map[string]any `json:"_meta,omitempty"`
+}
+
+// toError converts the JSON-RPC error into a *transport.MCPError.
+func (e *jsonRPCError) toError() error {
+	return &transport.MCPError{Code: e.Code, Message: e.Message, Data: e.Data}
 }
@@ -23,7 +23,6 @@ import (
 	"net/http"
 	"net/url"
 
-	"github.com/google/uuid"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
 )
@@ -33,7 +32,15 @@ const (
 )
 
 // Ensure that McpTransport implements the Transport interface.
-var _ transport.Transport = &McpTransport{}
+var (
+	_ transport.Transport      = &McpTransport{}
+	_ transport.ResultInvoker  = &McpTransport{}
+	_ transport.ToolSearcher   = &McpTransport{}
+	_ transport.ToolsetLister  = &McpTransport{}
+	_ transport.ResourceReader = &McpTransport{}
+	_ transport.PromptGetter   = &McpTransport{}
+	_ transport.Pinger         = &McpTransport{}
+)
 
 // McpTransport implements the MCP v2025-06-18 protocol.
 type McpTransport struct {
@@ -44,8 +51,8 @@ type McpTransport struct {
 }
 
 // New creates a new version-specific transport instance.
-func New(baseURL string, client *http.Client, clientName string, clientVersion string) (*McpTransport, error) {
-	baseTransport, err := mcp.NewBaseTransport(baseURL, client)
+func New(baseURL string, client *http.Client, clientName string, clientVersion string, opts ...mcp.Option) (*McpTransport, error) {
+	baseTransport, err := mcp.NewBaseTransport(baseURL, client, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -84,21 +91,181 @@ func (t *McpTransport) ListTools(ctx context.Context, toolsetName string, header
 		return nil, fmt.Errorf("failed to list tools: %w", err)
 	}
 
+	return t.buildManifest(result.Tools)
+}
+
+// SearchTools asks the server for the tools matching a query. It requires the
+// server to advertise the 'search' tools capability.
+func (t *McpTransport) SearchTools(ctx context.Context, query string, headers map[string]string) (*transport.ManifestSchema, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsToolSearch {
+		return nil, transport.ErrToolSearchNotSupported
+	}
+
+	var result listToolsResult
+	if err := t.sendRequest(ctx, t.BaseURL(), "tools/search", searchToolsRequestParams{Query: query}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to search tools: %w", err)
+	}
+
+	return t.buildManifest(result.Tools)
+}
+
+// Ping performs the session handshake if it has not yet completed, then
+// sends a 'ping' request to check that the server still answers. It returns
+// the version the server reported during the handshake.
+func (t *McpTransport) Ping(ctx context.Context, headers map[string]string) (string, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return "", err
+	}
+
+	var result map[string]any
+	if err := t.sendRequest(ctx, t.BaseURL(), "ping", map[string]any{}, headers, &result); err != nil {
+		return "", fmt.Errorf("ping failed: %w", err)
+	}
+	return t.ServerVersion, nil
+}
+
+// ListToolsets asks the server for the names of the toolsets it exposes. It
+// requires the server to advertise the 'toolsets' tools capability.
+func (t *McpTransport) ListToolsets(ctx context.Context, headers map[string]string) ([]string, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsToolsetListing {
+		return nil, transport.ErrToolsetListingNotSupported
+	}
+
+	var result listToolsetsResult
+	if err := t.sendRequest(ctx, t.BaseURL(), "toolsets/list", map[string]any{}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to list toolsets: %w", err)
+	}
+
+	names := make([]string, 0, len(result.Toolsets))
+	for _, toolset := range result.Toolsets {
+		names = append(names, toolset.Name)
+	}
+	return names, nil
+}
+
+// ListResources asks the server for the resources it exposes, following
+// pagination cursors until every page has been read. It requires the server
+// to advertise the 'resources' capability.
+func (t *McpTransport) ListResources(ctx context.Context, headers map[string]string) ([]transport.Resource, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsResources {
+		return nil, transport.ErrResourcesNotSupported
+	}
+
+	var resources []transport.Resource
+	cursor := ""
+	for {
+		var result listResourcesResult
+		if err := t.sendRequest(ctx, t.BaseURL(), "resources/list", paginatedRequestParams{Cursor: cursor}, headers, &result); err != nil {
+			return nil, fmt.Errorf("failed to list resources: %w", err)
+		}
+		resources = append(resources, result.Resources...)
+		if result.NextCursor == "" {
+			return resources, nil
+		}
+		if result.NextCursor == cursor {
+			return nil, fmt.Errorf("failed to list resources: server repeated cursor '%s'", cursor)
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// ReadResource asks the server for the contents of the resource with the
+// given URI. It requires the server to advertise the 'resources' capability.
+func (t *McpTransport) ReadResource(ctx context.Context, uri string, headers map[string]string) ([]transport.ResourceContents, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsResources {
+		return nil, transport.ErrResourcesNotSupported
+	}
+
+	var result readResourceResult
+	if err := t.sendRequest(ctx, t.BaseURL(), "resources/read", readResourceRequestParams{URI: uri}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to read resource '%s': %w", uri, err)
+	}
+	return result.Contents, nil
+}
+
+// ListPrompts asks the server for the prompt templates it exposes, following
+// pagination cursors until every page has been read. It requires the server
+// to advertise the 'prompts' capability.
+func (t *McpTransport) ListPrompts(ctx context.Context, headers map[string]string) ([]transport.Prompt, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsPrompts {
+		return nil, transport.ErrPromptsNotSupported
+	}
+
+	var prompts []transport.Prompt
+	cursor := ""
+	for {
+		var result listPromptsResult
+		if err := t.sendRequest(ctx, t.BaseURL(), "prompts/list", paginatedRequestParams{Cursor: cursor}, headers, &result); err != nil {
+			return nil, fmt.Errorf("failed to list prompts: %w", err)
+		}
+		prompts = append(prompts, result.Prompts...)
+		if result.NextCursor == "" {
+			return prompts, nil
+		}
+		if result.NextCursor == cursor {
+			return nil, fmt.Errorf("failed to list prompts: server repeated cursor '%s'", cursor)
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// GetPrompt asks the server to render the named prompt template with the
+// given arguments. It requires the server to advertise the 'prompts'
+// capability.
+func (t *McpTransport) GetPrompt(ctx context.Context, name string, args map[string]string, headers map[string]string) (*transport.PromptResult, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
+	if !t.SupportsPrompts {
+		return nil, transport.ErrPromptsNotSupported
+	}
+
+	var result transport.PromptResult
+	if err := t.sendRequest(ctx, t.BaseURL(), "prompts/get", getPromptRequestParams{Name: name, Arguments: args}, headers, &result); err != nil {
+		return nil, fmt.Errorf("failed to get prompt '%s': %w", name, err)
+	}
+	return &result, nil
+}
+
+// buildManifest converts the tool definitions returned by the server into a
+// manifest.
+func (t *McpTransport) buildManifest(tools []mcpTool) (*transport.ManifestSchema, error) {
 	manifest := &transport.ManifestSchema{
 		ServerVersion: t.ServerVersion,
 		Tools:         make(map[string]transport.ToolSchema),
 	}
 
-	for i, tool := range result.Tools {
+	for i, tool := range tools {
 		if tool.Name == "" {
 			return nil, fmt.Errorf("received invalid tool definition at index %d: missing 'name' field", i)
 		}
+		if _, ok := manifest.Tools[tool.Name]; ok {
+			return nil, fmt.Errorf("received duplicate tool definition at index %d: tool '%s' is already defined", i, tool.Name)
+		}
 
 		rawTool := map[string]any{
 			"name":        tool.Name,
 			"description": tool.Description,
 			"inputSchema": tool.InputSchema,
 		}
+		if tool.OutputSchema != nil {
+			rawTool["outputSchema"] = tool.OutputSchema
+		}
 		if tool.Meta != nil {
 			rawTool["_meta"] = tool.Meta
 		}
@@ -134,34 +301,56 @@ func (t *McpTransport) GetTool(ctx context.Context, toolName string, headers map
 
 // InvokeTool executes a tool
 func (t *McpTransport) InvokeTool(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (any, error) {
-	if err := t.EnsureInitialized(ctx, headers); err != nil {
+	result, err := t.InvokeToolResult(ctx, toolName, payload, headers)
+	if err != nil {
 		return "", err
 	}
+	return result.Output, nil
+}
+
+// InvokeToolResult executes a tool and returns its output together with any
+// warnings reported by the server and the HTTP metadata of the response.
+func (t *McpTransport) InvokeToolResult(ctx context.Context, toolName string, payload map[string]any, headers map[string]string) (*transport.InvokeResult, error) {
+	if err := t.EnsureInitialized(ctx, headers); err != nil {
+		return nil, err
+	}
 	params := callToolRequestParams{
 		Name:      toolName,
 		Arguments: payload,
+		Meta:      mcp.CallToolMeta(headers),
 	}
 
+	requestID := t.NewRequestID()
 	var result callToolResult
-	if err := t.sendRequest(ctx, t.BaseURL(), "tools/call", params, headers, &result); err != nil {
-		return "", fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)
+	var raw transport.InvokeResult
+	if err := t.sendRequestWithID(ctx, t.BaseURL(), requestID, "tools/call", params, headers, &result, &raw); err != nil {
+		if ctx.Err() != nil {
+			t.notifyCancelled(ctx, requestID, headers)
+		}
+		return nil, fmt.Errorf("failed to invoke tool '%s': %w", toolName, err)
 	}
 
 	if result.IsError {
-		return "", fmt.Errorf("tool execution resulted in error")
+		return nil, fmt.Errorf("tool execution resulted in error")
 	}
 
 	baseContent := make([]mcp.ToolContent, len(result.Content))
 	for i, item := range result.Content {
 		baseContent[i] = mcp.ToolContent{
-			Type: item.Type,
-			Text: item.Text,
+			Type:     item.Type,
+			Text:     item.Text,
+			Data:     item.Data,
+			MimeType: item.MimeType,
+			Resource: item.Resource,
 		}
 	}
 
 	output := t.ProcessToolResultContent(baseContent)
 
-	return output, nil
+	raw.Output = output
+	raw.Content = t.ContentBlocks(baseContent)
+	raw.Warnings = t.CollectWarnings(result.Warnings, result.Meta)
+	return &raw, nil
 }
 
 // initializeSession performs the initial handshake with the server.
@@ -182,7 +371,7 @@ func (t *McpTransport) initializeSession(ctx context.Context, headers map[string
 
 	// Protocol Version Check
 	if result.ProtocolVersion != t.protocolVersion {
-		return fmt.Errorf("MCP version mismatch: client (%s) != server (%s)", t.protocolVersion, result.ProtocolVersion)
+		return &transport.VersionMismatchError{ClientVersion: t.protocolVersion, ServerVersion: result.ProtocolVersion}
 	}
 
 	// Capabilities Check
@@ -191,6 +380,10 @@ func (t *McpTransport) initializeSession(ctx context.Context, headers map[string
 	}
 
 	t.ServerVersion = result.ServerInfo.Version
+	t.SupportsToolSearch = result.Capabilities.Tools["search"] == true
+	t.SupportsToolsetListing = result.Capabilities.Tools["toolsets"] == true
+	t.SupportsResources = result.Capabilities.Resources != nil
+	t.SupportsPrompts = result.Capabilities.Prompts != nil
 
 	// Confirm Handshake
 	return t.sendNotification(ctx, "notifications/initialized", map[string]any{}, headers)
@@ -198,28 +391,43 @@ func (t *McpTransport) initializeSession(ctx context.Context, headers map[string
 
 // sendRequest sends a standard JSON-RPC request to the server.
 func (t *McpTransport) sendRequest(ctx context.Context, url string, method string, params any, headers map[string]string, dest any) error {
+	return t.sendRequestWithID(ctx, url, t.NewRequestID(), method, params, headers, dest, nil)
+}
+
+// sendRequestWithID sends a JSON-RPC request with a caller-chosen ID. When
+// raw is non-nil, it receives the HTTP metadata of the response.
+func (t *McpTransport) sendRequestWithID(ctx context.Context, url string, id any, method string, params any, headers map[string]string, dest any, raw *transport.InvokeResult) error {
 	req := jsonRPCRequest{
 		JSONRPC: "2.0",
-		Method:  method,
-		ID:      uuid.New().String(),
+		Method:  t.Method(method),
+		ID:      id,
 		Params:  params,
 	}
-	return t.doRPC(ctx, url, req, headers, dest)
+	return t.doRPC(ctx, url, req, headers, dest, raw)
 }
 
 // sendNotification sends a standard JSON-RPC notification (no response expected).
 func (t *McpTransport) sendNotification(ctx context.Context, method string, params any, headers map[string]string) error {
 	req := jsonRPCNotification{
 		JSONRPC: "2.0",
-		Method:  method,
+		Method:  t.Method(method),
 		Params:  params,
 	}
-	return t.doRPC(ctx, t.BaseURL(), req, headers, nil)
+	return t.doRPC(ctx, t.BaseURL(), req, headers, nil, nil)
+}
+
+// notifyCancelled makes a best-effort attempt to tell the server to stop
+// working on a request the caller has abandoned. Failures are ignored so that
+// the caller still sees the original cancellation error.
+func (t *McpTransport) notifyCancelled(ctx context.Context, requestID any, headers map[string]string) {
+	notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), mcp.CancelNotificationTimeout)
+	defer cancel()
+	_ = t.sendNotification(notifyCtx, "notifications/cancelled", mcp.CancelledNotificationParams(ctx, requestID), headers)
 }
 
 // doRPC performs the low-level HTTP POST and handles JSON-RPC wrapping/unwrapping.
 // v2025-06-18: Injects 'MCP-Protocol-Version' header.
-func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, headers map[string]string, dest any) error {
+func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, headers map[string]string, dest any, raw *transport.InvokeResult) error {
 	payload, err := json.Marshal(reqBody)
 	if err != nil {
 		return fmt.Errorf("marshal failed: %w", err)
@@ -256,17 +464,27 @@ func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, heade
 	} else {
 		// Any other code, OR a 202/204 when we expected a result, is a failure.
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return &transport.StatusError{StatusCode: resp.StatusCode, Body: string(body), Header: resp.Header}
 	}
 
 	if dest == nil {
 		return nil
 	}
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+	var bodyBytes []byte
+	if t.isManifestRequest(reqBody) {
+		bodyBytes, err = t.ReadManifestBody(resp.Body)
+	} else {
+		bodyBytes, err = io.ReadAll(resp.Body)
+	}
 	if err != nil {
 		return fmt.Errorf("read body failed: %w", err)
 	}
+	if raw != nil {
+		raw.StatusCode = resp.StatusCode
+		raw.Header = resp.Header
+		raw.RawBody = bodyBytes
+	}
 
 	// Decode RPC Envelope
 	var rpcResp jsonRPCResponse
@@ -276,7 +494,7 @@ func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, heade
 
 	// Check RPC Error
 	if rpcResp.Error != nil {
-		return fmt.Errorf("MCP request failed with code %d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return rpcResp.Error.toError()
 	}
 
 	// Decode Result into specific struct
@@ -287,3 +505,10 @@ func (t *McpTransport) doRPC(ctx context.Context, url string, reqBody any, heade
 
 	return nil
 }
+
+// isManifestRequest reports whether a request returns tool definitions, whose
+// response size is bounded by the transport's maximum manifest size.
+func (t *McpTransport) isManifestRequest(reqBody any) bool {
+	req, ok := reqBody.(jsonRPCRequest)
+	return ok && (req.Method == t.Method("tools/list") || req.Method == t.Method("tools/search"))
+}
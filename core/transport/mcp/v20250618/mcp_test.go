@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
 	"testing"
 
@@ -409,6 +410,11 @@ func TestRequest_ServerError(t *testing.T) {
 	_, err := client.ListTools(context.Background(), "", nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "API request failed with status 500")
+
+	var statusErr *transport.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	assert.Equal(t, "Internal Error", statusErr.Body)
 }
 
 func TestRequest_BadJSON(t *testing.T) {
@@ -675,4 +681,54 @@ func TestNew_ClientVersion(t *testing.T) {
 			t.Errorf("expected clientVersion %q, got %q", mcp.SDKVersion, tr2.clientVersion)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestInvokeTool_CancellationNotification(t *testing.T) {
+	server := newMockMCPServer(t)
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	callStarted := make(chan struct{})
+	cancelled := make(chan map[string]any, 1)
+
+	server.handlers["tools/call"] = func(params json.RawMessage) (any, error) {
+		close(callStarted)
+		<-release
+		return callToolResult{}, nil
+	}
+	server.handlers["notifications/cancelled"] = func(params json.RawMessage) (any, error) {
+		var p map[string]any
+		_ = json.Unmarshal(params, &p)
+		cancelled <- p
+		return nil, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	// Complete the handshake up front so only the tool call is in flight.
+	require.NoError(t, client.EnsureInitialized(context.Background(), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-callStarted
+		cancel()
+	}()
+
+	_, err := client.InvokeTool(ctx, "slow-tool", map[string]any{}, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case p := <-cancelled:
+		var callID any
+		for _, req := range server.requests {
+			if req.Body.Method == "tools/call" {
+				callID = req.Body.ID
+			}
+		}
+		require.NotNil(t, callID)
+		assert.Equal(t, callID, p["requestId"])
+		assert.Equal(t, context.Canceled.Error(), p["reason"])
+	default:
+		t.Fatal("Expected a notifications/cancelled message after the caller cancelled")
+	}
+}
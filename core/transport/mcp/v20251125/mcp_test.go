@@ -23,6 +23,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport/mcp"
 	"testing"
 
@@ -297,7 +298,7 @@ func TestProtocolMismatch(t *testing.T) {
 	server.handlers["initialize"] = func(params json.RawMessage) (any, error) {
 		return initializeResult{
 			ProtocolVersion: "2099-01-01", // Future version
-			Capabilities:    serverCapabilities{Tools: map[string]any{}},
+			Capabilities:    serverCapabilities{Tools: map[string]any{"listChanged": true}},
 			ServerInfo:      implementation{Name: "mock", Version: "1.0"},
 		}, nil
 	}
@@ -409,6 +410,11 @@ func TestRequest_ServerError(t *testing.T) {
 	_, err := client.ListTools(context.Background(), "", nil)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "API request failed with status 500")
+
+	var statusErr *transport.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+	assert.Equal(t, "Internal Error", statusErr.Body)
 }
 
 func TestRequest_BadJSON(t *testing.T) {
@@ -477,6 +483,46 @@ func TestInvokeTool_RPCError(t *testing.T) {
 	assert.Contains(t, err.Error(), "internal server error")
 }
 
+func TestInvokeTool_JSONRPCErrorDetails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.ID == nil {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		resp := jsonRPCResponse{JSONRPC: "2.0", ID: req.ID}
+		if req.Method == "initialize" {
+			resp.Result = asRawMessage(initializeResult{
+				ProtocolVersion: "2025-11-25",
+				Capabilities:    serverCapabilities{Tools: map[string]any{"listChanged": false}},
+				ServerInfo:      implementation{Name: "mock", Version: "1.0.0"},
+			})
+		} else {
+			resp.Error = &jsonRPCError{
+				Code:    -32602,
+				Message: "Invalid params",
+				Data:    json.RawMessage(`{"field":"city"}`),
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	_, err := client.InvokeTool(context.Background(), "tool", nil, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MCP request failed with code -32602: Invalid params")
+
+	var mcpErr *transport.MCPError
+	require.ErrorAs(t, err, &mcpErr)
+	assert.Equal(t, -32602, mcpErr.Code)
+	assert.Equal(t, "Invalid params", mcpErr.Message)
+	assert.JSONEq(t, `{"field":"city"}`, string(mcpErr.Data))
+}
+
 func TestInvokeTool_ComplexContent(t *testing.T) {
 	server := newMockMCPServer(t)
 	defer server.Close()
@@ -497,6 +543,50 @@ func TestInvokeTool_ComplexContent(t *testing.T) {
 	assert.Equal(t, "Part 1 Part 2", res)
 }
 
+func TestInvokeToolResult_ContentBlocks(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G'}
+
+	t.Run("Mixed text and image", func(t *testing.T) {
+		server := newMockMCPServer(t)
+		defer server.Close()
+
+		server.handlers["tools/call"] = func(params json.RawMessage) (any, error) {
+			return map[string]any{"content": []map[string]any{
+				{"type": "text", "text": "Here is the chart"},
+				{"type": "image", "data": "iVBORw==", "mimeType": "image/png"},
+				{"type": "resource", "resource": map[string]any{"uri": "file:///report.txt", "mimeType": "text/plain", "text": "report"}},
+			}}, nil
+		}
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		res, err := client.InvokeToolResult(context.Background(), "t", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "Here is the chart", res.Output)
+		assert.Equal(t, []transport.ContentBlock{
+			{Type: "text", Text: "Here is the chart"},
+			{Type: "image", Data: png, MimeType: "image/png"},
+			{Type: "resource", Resource: &transport.ResourceContents{URI: "file:///report.txt", MimeType: "text/plain", Text: "report"}},
+		}, res.Content)
+	})
+
+	t.Run("Image only", func(t *testing.T) {
+		server := newMockMCPServer(t)
+		defer server.Close()
+
+		server.handlers["tools/call"] = func(params json.RawMessage) (any, error) {
+			return map[string]any{"content": []map[string]any{
+				{"type": "image", "data": "iVBORw==", "mimeType": "image/png"},
+			}}, nil
+		}
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		res, err := client.InvokeToolResult(context.Background(), "t", nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "null", res.Output)
+		assert.Equal(t, []transport.ContentBlock{{Type: "image", Data: png, MimeType: "image/png"}}, res.Content)
+	})
+}
+
 func TestInvokeTool_EmptyResult(t *testing.T) {
 	server := newMockMCPServer(t)
 	defer server.Close()
@@ -675,4 +765,206 @@ func TestNew_ClientVersion(t *testing.T) {
 			t.Errorf("expected clientVersion %q, got %q", mcp.SDKVersion, tr2.clientVersion)
 		}
 	})
-}
\ No newline at end of file
+}
+func TestInvokeTool_CancellationNotification(t *testing.T) {
+	server := newMockMCPServer(t)
+	defer server.Close()
+
+	release := make(chan struct{})
+	defer close(release)
+	callStarted := make(chan struct{})
+	cancelled := make(chan map[string]any, 1)
+
+	server.handlers["tools/call"] = func(params json.RawMessage) (any, error) {
+		close(callStarted)
+		<-release
+		return callToolResult{}, nil
+	}
+	server.handlers["notifications/cancelled"] = func(params json.RawMessage) (any, error) {
+		var p map[string]any
+		_ = json.Unmarshal(params, &p)
+		cancelled <- p
+		return nil, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	// Complete the handshake up front so only the tool call is in flight.
+	require.NoError(t, client.EnsureInitialized(context.Background(), nil))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-callStarted
+		cancel()
+	}()
+
+	_, err := client.InvokeTool(ctx, "slow-tool", map[string]any{}, nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	select {
+	case p := <-cancelled:
+		var callID any
+		for _, req := range server.requests {
+			if req.Body.Method == "tools/call" {
+				callID = req.Body.ID
+			}
+		}
+		require.NotNil(t, callID)
+		assert.Equal(t, callID, p["requestId"])
+		assert.Equal(t, context.Canceled.Error(), p["reason"])
+	default:
+		t.Fatal("Expected a notifications/cancelled message after the caller cancelled")
+	}
+}
+
+func TestSearchTools(t *testing.T) {
+	t.Run("Unsupported without the search capability", func(t *testing.T) {
+		server := newMockMCPServer(t)
+		defer server.Close()
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		_, err := client.SearchTools(context.Background(), "hotels", nil)
+		assert.ErrorIs(t, err, transport.ErrToolSearchNotSupported)
+	})
+
+	t.Run("Sends the query when supported", func(t *testing.T) {
+		server := newMockMCPServer(t)
+		defer server.Close()
+		server.handlers["initialize"] = func(params json.RawMessage) (any, error) {
+			return initializeResult{
+				ProtocolVersion: "2025-11-25",
+				Capabilities:    serverCapabilities{Tools: map[string]any{"search": true, "toolsets": true}},
+				ServerInfo:      implementation{Name: "mock-server", Version: "1.0.0"},
+			}, nil
+		}
+		var query searchToolsRequestParams
+		server.handlers["tools/search"] = func(params json.RawMessage) (any, error) {
+			_ = json.Unmarshal(params, &query)
+			return listToolsResult{Tools: []mcpTool{{Name: "find-hotels", InputSchema: map[string]any{"type": "object"}}}}, nil
+		}
+		server.handlers["toolsets/list"] = func(params json.RawMessage) (any, error) {
+			return listToolsetsResult{Toolsets: []mcpToolset{{Name: "travel"}, {Name: "admin"}}}, nil
+		}
+
+		client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+		manifest, err := client.SearchTools(context.Background(), "hotels", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "hotels", query.Query)
+		assert.Contains(t, manifest.Tools, "find-hotels")
+
+		toolsets, err := client.ListToolsets(context.Background(), nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"travel", "admin"}, toolsets)
+	})
+}
+
+func TestListToolsets_Unsupported(t *testing.T) {
+	server := newMockMCPServer(t)
+	defer server.Close()
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	_, err := client.ListToolsets(context.Background(), nil)
+	assert.ErrorIs(t, err, transport.ErrToolsetListingNotSupported)
+}
+
+func TestInvokeToolResult_Metadata(t *testing.T) {
+	server := newMockMCPServer(t)
+	defer server.Close()
+
+	server.handlers["tools/call"] = func(params json.RawMessage) (any, error) {
+		return callToolResult{
+			Content:  []textContent{{Type: "text", Text: "done"}},
+			Warnings: []string{"deprecated"},
+			Meta:     map[string]any{"warnings": []any{"slow query"}},
+		}, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0")
+	result, err := client.InvokeToolResult(context.Background(), "my-tool", map[string]any{}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "done", result.Output)
+	assert.Equal(t, []string{"deprecated", "slow query"}, result.Warnings)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	assert.Contains(t, string(result.RawBody), `"text":"done"`)
+}
+
+func TestRequest_StatusErrorHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "slow down", http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	client, _ := New(ts.URL, ts.Client(), "test-client", "1.0.0")
+	_, err := client.ListTools(context.Background(), "", nil)
+
+	var statusErr *transport.StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.Equal(t, "5", statusErr.Header.Get("Retry-After"))
+}
+
+func TestListTools_MaxManifestSize(t *testing.T) {
+	server := newMockMCPServer(t)
+	defer server.Close()
+
+	server.handlers["tools/list"] = func(params json.RawMessage) (any, error) {
+		return listToolsResult{Tools: []mcpTool{{Name: "tool", Description: string(make([]byte, 256)), InputSchema: map[string]any{}}}}, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0", mcp.WithMaxManifestSize(128))
+	_, err := client.ListTools(context.Background(), "", nil)
+	assert.ErrorIs(t, err, transport.ErrManifestTooLarge)
+}
+
+func TestMethodOverrides(t *testing.T) {
+	server := newMockMCPServer(t)
+	defer server.Close()
+
+	server.handlers["tools/enumerate"] = func(params json.RawMessage) (any, error) {
+		return listToolsResult{Tools: []mcpTool{{Name: "tool", InputSchema: map[string]any{}}}}, nil
+	}
+
+	client, _ := New(server.URL, server.Client(), "test-client", "1.0.0", mcp.WithMethodOverrides(map[string]string{"tools/list": "tools/enumerate"}))
+	manifest, err := client.ListTools(context.Background(), "", nil)
+	require.NoError(t, err)
+	assert.Contains(t, manifest.Tools, "tool")
+}
+
+func TestSession_ServerAssignedID(t *testing.T) {
+	var sessionHeaders []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req jsonRPCRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		sessionHeaders = append(sessionHeaders, r.Header.Get("Mcp-Session-Id"))
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "session-1")
+			result = initializeResult{
+				ProtocolVersion: "2025-11-25",
+				Capabilities:    serverCapabilities{Tools: map[string]any{"listChanged": true}},
+				ServerInfo:      implementation{Name: "mock-server", Version: "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+			return
+		case "tools/list":
+			result = listToolsResult{Tools: []mcpTool{}}
+		}
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(jsonRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: resBytes})
+	}))
+	defer ts.Close()
+
+	client, _ := New(ts.URL, ts.Client(), "test-client", "1.0.0")
+	_, err := client.ListTools(context.Background(), "", nil)
+	require.NoError(t, err)
+
+	// The handshake carries no session; the notification and the listing do.
+	assert.Equal(t, []string{"", "session-1", "session-1"}, sessionHeaders)
+}
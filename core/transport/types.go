@@ -15,8 +15,17 @@
 package transport
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net/http"
 	"reflect"
+	"regexp"
+	"slices"
+	"sync"
+	"unicode/utf8"
 )
 
 // Schema for a tool parameter.
@@ -29,6 +38,31 @@ type ParameterSchema struct {
 	Items                *ParameterSchema `json:"items,omitempty"`
 	AdditionalProperties any              `json:"additionalProperties,omitempty"`
 	Default              any              `json:"default,omitempty"`
+	Enum                 []any            `json:"enum,omitempty"`
+	Minimum              *float64         `json:"minimum,omitempty"`
+	Maximum              *float64         `json:"maximum,omitempty"`
+	MinLength            *int             `json:"minLength,omitempty"`
+	MaxLength            *int             `json:"maxLength,omitempty"`
+	MinItems             *int             `json:"minItems,omitempty"`
+	MaxItems             *int             `json:"maxItems,omitempty"`
+	Pattern              string           `json:"pattern,omitempty"`
+}
+
+// patternCache holds the compiled regular expressions of parameter patterns,
+// keyed by pattern, so that each is compiled once.
+var patternCache sync.Map
+
+// compilePattern returns the compiled form of a parameter pattern.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
 }
 
 // ValidateType is a helper for manual type checking.
@@ -46,8 +80,14 @@ func (p *ParameterSchema) ValidateType(value any) error {
 			return fmt.Errorf("parameter '%s' expects a string, but got %T", p.Name, value)
 		}
 	case "integer":
-		switch value.(type) {
+		// Numbers decoded from JSON are float64 or json.Number, so whole
+		// values of those types are accepted as integers.
+		switch v := value.(type) {
 		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		case float32, float64, json.Number:
+			if _, ok := WholeNumber(v); !ok {
+				return fmt.Errorf("parameter '%s' expects an integer, but got %T with value %v", p.Name, value, value)
+			}
 		default:
 			return fmt.Errorf("parameter '%s' expects an integer, but got %T", p.Name, value)
 		}
@@ -61,6 +101,16 @@ func (p *ParameterSchema) ValidateType(value any) error {
 		if _, ok := value.(bool); !ok {
 			return fmt.Errorf("parameter '%s' expects a boolean, but got %T", p.Name, value)
 		}
+	case "bytes":
+		switch v := value.(type) {
+		case []byte:
+		case string:
+			if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+				return fmt.Errorf("parameter '%s' expects a base64-encoded string: %w", p.Name, err)
+			}
+		default:
+			return fmt.Errorf("parameter '%s' expects bytes or a base64-encoded string, but got %T", p.Name, value)
+		}
 	case "array":
 		v := reflect.ValueOf(value)
 		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
@@ -70,7 +120,10 @@ func (p *ParameterSchema) ValidateType(value any) error {
 			for i := range v.Len() {
 				item := v.Index(i).Interface()
 
-				if err := p.Items.ValidateType(item); err != nil {
+				// Validate each element, however deeply nested, against the
+				// item schema, naming it after its position when the schema
+				// has no name of its own.
+				if err := p.Items.element(fmt.Sprintf("%s[%d]", p.Name, i)).ValidateType(item); err != nil {
 					return fmt.Errorf("error in array '%s' at index %d: %w", p.Name, i, err)
 				}
 			}
@@ -101,7 +154,7 @@ func (p *ParameterSchema) ValidateType(value any) error {
 			for iter.Next() {
 				key := iter.Key().String()
 				val := iter.Value().Interface()
-				if err := ap.ValidateType(val); err != nil {
+				if err := ap.element(fmt.Sprintf("%s.%s", p.Name, key)).ValidateType(val); err != nil {
 					return fmt.Errorf("error in object '%s' for key '%s': %w", p.Name, key, err)
 				}
 			}
@@ -117,21 +170,168 @@ func (p *ParameterSchema) ValidateType(value any) error {
 	default:
 		return fmt.Errorf("unknown type '%s' in schema for parameter '%s'", p.Type, p.Name)
 	}
+
+	if len(p.Enum) > 0 && !slices.ContainsFunc(p.Enum, func(allowed any) bool { return enumValueEqual(allowed, value) }) {
+		return fmt.Errorf("parameter '%s' must be one of %v, but got %v", p.Name, p.Enum, value)
+	}
+	return p.validateBounds(value)
+}
+
+// validateBounds checks a value that has the parameter's type against the
+// range, length and size bounds of the schema, and strings against its
+// pattern. Unset bounds are not checked.
+func (p *ParameterSchema) validateBounds(value any) error {
+	switch p.Type {
+	case "integer", "float":
+		n, _ := toFloat64(value)
+		if p.Minimum != nil && n < *p.Minimum {
+			return fmt.Errorf("parameter '%s' must be at least %v, but got %v", p.Name, *p.Minimum, value)
+		}
+		if p.Maximum != nil && n > *p.Maximum {
+			return fmt.Errorf("parameter '%s' must be at most %v, but got %v", p.Name, *p.Maximum, value)
+		}
+	case "string":
+		// Lengths are counted in characters, as JSON Schema defines them.
+		n := utf8.RuneCountInString(value.(string))
+		if p.MinLength != nil && n < *p.MinLength {
+			return fmt.Errorf("parameter '%s' must be at least %d characters long, but got %d", p.Name, *p.MinLength, n)
+		}
+		if p.MaxLength != nil && n > *p.MaxLength {
+			return fmt.Errorf("parameter '%s' must be at most %d characters long, but got %d", p.Name, *p.MaxLength, n)
+		}
+		if p.Pattern != "" {
+			re, err := compilePattern(p.Pattern)
+			if err != nil {
+				return fmt.Errorf("invalid pattern in schema for parameter '%s': %w", p.Name, err)
+			}
+			if !re.MatchString(value.(string)) {
+				return fmt.Errorf("parameter '%s' does not match required pattern '%s'", p.Name, p.Pattern)
+			}
+		}
+	case "array":
+		n := reflect.ValueOf(value).Len()
+		if p.MinItems != nil && n < *p.MinItems {
+			return fmt.Errorf("parameter '%s' must have at least %d items, but got %d", p.Name, *p.MinItems, n)
+		}
+		if p.MaxItems != nil && n > *p.MaxItems {
+			return fmt.Errorf("parameter '%s' must have at most %d items, but got %d", p.Name, *p.MaxItems, n)
+		}
+	}
 	return nil
 }
 
+// enumValueEqual reports whether a value equals an allowed enum value.
+// Numbers are compared by value, since enum values decoded from JSON are
+// float64 while inputs may be any numeric type.
+func enumValueEqual(allowed any, value any) bool {
+	a, aIsNumber := toFloat64(allowed)
+	v, vIsNumber := toFloat64(value)
+	if aIsNumber || vIsNumber {
+		return aIsNumber && vIsNumber && a == v
+	}
+	return reflect.DeepEqual(allowed, value)
+}
+
+// WholeNumber converts a float or json.Number without a fractional part to an
+// int64. It reports false for fractional values, values out of the int64
+// range and non-numbers.
+func WholeNumber(value any) (int64, bool) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, true
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, false
+		}
+		return WholeNumber(f)
+	case float32:
+		return WholeNumber(float64(v))
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// toFloat64 converts a value of any numeric type, including json.Number, to a
+// float64.
+func toFloat64(value any) (float64, bool) {
+	if n, ok := value.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// validateDefault checks the parameter's default value against its schema.
+// Numbers decoded from JSON are float64, so a whole float64 is accepted as an
+// integer default.
+func (p *ParameterSchema) validateDefault() error {
+	value := p.Default
+	if f, ok := value.(float64); ok && p.Type == "integer" && f == math.Trunc(f) {
+		value = int64(f)
+	}
+	return p.ValidateType(value)
+}
+
+// element returns the schema of a nested element, such as an array item,
+// named after the element's path when the schema has no name of its own, so
+// that errors identify the offending element.
+func (p *ParameterSchema) element(path string) *ParameterSchema {
+	if p.Name != "" {
+		return p
+	}
+	named := *p
+	named.Name = path
+	return &named
+}
+
 // ValidateDefinition checks if the schema itself is well-formed.
 func (p *ParameterSchema) ValidateDefinition() error {
 	if p.Type == "" {
 		return fmt.Errorf("schema validation failed for '%s': type is missing", p.Name)
 	}
+	if p.Minimum != nil && p.Maximum != nil && *p.Minimum > *p.Maximum {
+		return fmt.Errorf("schema validation failed for '%s': minimum %v is greater than maximum %v", p.Name, *p.Minimum, *p.Maximum)
+	}
+	if p.MinLength != nil && p.MaxLength != nil && *p.MinLength > *p.MaxLength {
+		return fmt.Errorf("schema validation failed for '%s': minLength %d is greater than maxLength %d", p.Name, *p.MinLength, *p.MaxLength)
+	}
+	if p.MinItems != nil && p.MaxItems != nil && *p.MinItems > *p.MaxItems {
+		return fmt.Errorf("schema validation failed for '%s': minItems %d is greater than maxItems %d", p.Name, *p.MinItems, *p.MaxItems)
+	}
+	if p.Pattern != "" {
+		if _, err := compilePattern(p.Pattern); err != nil {
+			return fmt.Errorf("schema validation failed for '%s': invalid pattern: %w", p.Name, err)
+		}
+	}
+	if p.Default != nil {
+		if err := p.validateDefault(); err != nil {
+			return fmt.Errorf("schema validation failed for '%s': invalid default: %w", p.Name, err)
+		}
+	}
 
 	switch p.Type {
 	case "array":
 		if p.Items != nil {
 			// Arrays can now contain nested structures.
 			// Recursively validate the nested schema's definition.
-			if err := p.Items.ValidateDefinition(); err != nil {
+			if err := p.Items.element(p.Name + "[]").ValidateDefinition(); err != nil {
 				return err
 			}
 		}
@@ -145,7 +345,7 @@ func (p *ParameterSchema) ValidateDefinition() error {
 			if ap.Type == "object" || ap.Type == "array" {
 				return fmt.Errorf("invalid schema definition for object '%s': nested maps or arrays are not supported", p.Name)
 			}
-			if err := ap.ValidateDefinition(); err != nil {
+			if err := ap.element(p.Name + ".*").ValidateDefinition(); err != nil {
 				return err
 			}
 		default:
@@ -157,7 +357,7 @@ func (p *ParameterSchema) ValidateDefinition() error {
 			)
 		}
 
-	case "string", "integer", "float", "boolean":
+	case "string", "integer", "float", "boolean", "bytes":
 		// No type-specific rules for these.
 		break
 
@@ -173,6 +373,10 @@ type ToolSchema struct {
 	Description  string            `json:"description"`
 	Parameters   []ParameterSchema `json:"parameters"`
 	AuthRequired []string          `json:"authRequired,omitempty"`
+	Examples     []map[string]any  `json:"examples,omitempty"`
+	// OutputSchema describes the fields of the tool's structured result,
+	// when the server declares one.
+	OutputSchema []ParameterSchema `json:"outputSchema,omitempty"`
 }
 
 // Schema for the Toolbox manifest.
@@ -180,3 +384,158 @@ type ManifestSchema struct {
 	ServerVersion string                `json:"serverVersion"`
 	Tools         map[string]ToolSchema `json:"tools"`
 }
+
+// Resource describes a resource, such as a file or blob, exposed by a server.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents holds the contents of a resource, either as Text or, for
+// binary resources, as the decoded Blob.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     []byte `json:"blob,omitempty"`
+}
+
+// Prompt describes a prompt template exposed by a server.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument describes an argument accepted by a prompt template.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptResult is a prompt template rendered with its arguments.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage is a single message of a rendered prompt.
+type PromptMessage struct {
+	// Role is the speaker of the message, either "user" or "assistant".
+	Role    string        `json:"role"`
+	Content PromptContent `json:"content"`
+}
+
+// PromptContent is the content of a prompt message. Depending on Type, it
+// holds Text, binary Data such as an image or audio clip, or an embedded
+// Resource.
+type PromptContent struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key of a
+// tool invocation. The key is the same on every retry of a logical call, so
+// that servers can recognise and deduplicate repeated attempts.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// StatusError is returned by transports when the server answers a request
+// with an unexpected HTTP status.
+type StatusError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Body is the raw response body, which usually describes the failure.
+	Body string
+	// Header holds the response headers, such as a server request ID.
+	Header http.Header
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// ErrUnauthorized matches, with errors.Is, a StatusError for a request that
+// the server rejected as unauthenticated (401) or forbidden (403).
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Is reports whether target is ErrUnauthorized and the status is 401 or 403.
+func (e *StatusError) Is(target error) bool {
+	return target == ErrUnauthorized &&
+		(e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden)
+}
+
+// MCPError is a JSON-RPC error returned by an MCP server, such as an
+// invalid params error (-32602) for a tool call with bad arguments.
+type MCPError struct {
+	// Code is the JSON-RPC error code.
+	Code int
+	// Message is the short description of the error sent by the server.
+	Message string
+	// Data holds additional information about the error, if the server sent
+	// any.
+	Data json.RawMessage
+}
+
+func (e *MCPError) Error() string {
+	return fmt.Sprintf("MCP request failed with code %d: %s", e.Code, e.Message)
+}
+
+// VersionMismatchError is returned by an MCP handshake when the server
+// answers with a different protocol version than the one the client
+// requested, usually because it does not support the requested version.
+type VersionMismatchError struct {
+	// ClientVersion is the protocol version the client requested.
+	ClientVersion string
+	// ServerVersion is the protocol version the server offered instead.
+	ServerVersion string
+}
+
+func (e *VersionMismatchError) Error() string {
+	return fmt.Sprintf("MCP version mismatch: client (%s) != server (%s)", e.ClientVersion, e.ServerVersion)
+}
+
+// InvokeResult is the structured outcome of a tool invocation.
+type InvokeResult struct {
+	// Output is the processed tool output, identical to what InvokeTool returns.
+	Output any
+	// Warnings holds any non-fatal warnings the server attached to the response.
+	Warnings []string
+	// StatusCode is the HTTP status code of the response, if known.
+	StatusCode int
+	// Header holds the HTTP response headers, if known.
+	Header http.Header
+	// RawBody is the undecoded HTTP response body, if known.
+	RawBody []byte
+	// Content holds every content block of the result, including the
+	// non-text blocks that Output leaves out, if known.
+	Content []ContentBlock
+}
+
+// ContentBlock is a single item of a tool result's content. Depending on
+// Type, it holds Text, binary Data such as an image or audio clip, or an
+// embedded Resource.
+type ContentBlock struct {
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     []byte            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// StreamChunk is a piece of a tool result delivered over a streamed
+// invocation.
+type StreamChunk struct {
+	// Text is the text delta carried by the chunk. On the final chunk it
+	// holds the tool's complete output.
+	Text string
+	// Final reports whether this is the last chunk of a successful stream.
+	Final bool
+	// Err is set on the terminal chunk of a stream that failed.
+	Err error
+}
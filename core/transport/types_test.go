@@ -17,7 +17,9 @@
 package transport
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
 	"strings"
 	"testing"
 	"time"
@@ -122,7 +124,57 @@ func TestParameterSchemaInteger(t *testing.T) {
 			t.Fatal(err.Error())
 		}
 	})
+	t.Run("Test whole float64 param", func(t *testing.T) {
+		if err := schema.ValidateType(float64(42)); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+	t.Run("Test whole json.Number param", func(t *testing.T) {
+		if err := schema.ValidateType(json.Number("42")); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+	t.Run("Test fractional float64 param", func(t *testing.T) {
+		err := schema.ValidateType(2.5)
+		want := "parameter 'param_name' expects an integer, but got float64 with value 2.5"
+		if err == nil || err.Error() != want {
+			t.Fatalf("expected error %q, got %v", want, err)
+		}
+	})
+	t.Run("Test fractional json.Number param", func(t *testing.T) {
+		if err := schema.ValidateType(json.Number("2.5")); err == nil {
+			t.Fatal("expected an error for a fractional json.Number")
+		}
+	})
+
+}
 
+func TestWholeNumber(t *testing.T) {
+	testCases := []struct {
+		name   string
+		value  any
+		want   int64
+		wantOK bool
+	}{
+		{"whole float64", float64(42), 42, true},
+		{"negative float64", float64(-7), -7, true},
+		{"whole float32", float32(3), 3, true},
+		{"json.Number integer", json.Number("9007199254740993"), 9007199254740993, true},
+		{"json.Number exponent", json.Number("1e3"), 1000, true},
+		{"fractional float64", 2.5, 0, false},
+		{"fractional json.Number", json.Number("2.5"), 0, false},
+		{"out of range float64", 1e19, 0, false},
+		{"NaN", math.NaN(), 0, false},
+		{"not a number", "42", 0, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := WholeNumber(tc.value)
+			if ok != tc.wantOK || got != tc.want {
+				t.Errorf("WholeNumber(%v) = %d, %v; want %d, %v", tc.value, got, ok, tc.want, tc.wantOK)
+			}
+		})
+	}
 }
 
 // Tests ParameterSchema with type 'string'.
@@ -144,6 +196,156 @@ func TestParameterSchemaString(t *testing.T) {
 
 }
 
+// Tests ParameterSchema enum constraints.
+func TestParameterSchemaEnum(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  ParameterSchema
+		value   any
+		wantErr string
+	}{
+		{
+			name:   "string in enum",
+			schema: ParameterSchema{Name: "color", Type: "string", Enum: []any{"red", "green"}},
+			value:  "green",
+		},
+		{
+			name:    "string not in enum",
+			schema:  ParameterSchema{Name: "color", Type: "string", Enum: []any{"red", "green"}},
+			value:   "blue",
+			wantErr: "parameter 'color' must be one of [red green], but got blue",
+		},
+		{
+			name:   "integer in enum decoded from JSON",
+			schema: ParameterSchema{Name: "size", Type: "integer", Enum: []any{float64(1), float64(2)}},
+			value:  2,
+		},
+		{
+			name:   "integer of another width in enum",
+			schema: ParameterSchema{Name: "size", Type: "integer", Enum: []any{1, 2}},
+			value:  int64(1),
+		},
+		{
+			name:    "integer not in enum",
+			schema:  ParameterSchema{Name: "size", Type: "integer", Enum: []any{float64(1), float64(2)}},
+			value:   3,
+			wantErr: "parameter 'size' must be one of [1 2], but got 3",
+		},
+		{
+			name:    "string matching a number is rejected",
+			schema:  ParameterSchema{Name: "size", Type: "string", Enum: []any{"1", float64(2)}},
+			value:   "2",
+			wantErr: "must be one of",
+		},
+		{
+			name:   "nil optional value",
+			schema: ParameterSchema{Name: "color", Type: "string", Enum: []any{"red"}},
+			value:  nil,
+		},
+		{
+			name:    "type is checked before enum",
+			schema:  ParameterSchema{Name: "color", Type: "string", Enum: []any{"red"}},
+			value:   1,
+			wantErr: "parameter 'color' expects a string, but got int",
+		},
+		{
+			name: "array items",
+			schema: ParameterSchema{Name: "colors", Type: "array", Items: &ParameterSchema{
+				Name: "color", Type: "string", Enum: []any{"red", "green"},
+			}},
+			value:   []string{"red", "blue"},
+			wantErr: "error in array 'colors' at index 1: parameter 'color' must be one of [red green], but got blue",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.ValidateType(tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// Tests ParameterSchema range, length and size bounds.
+func TestParameterSchemaBounds(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	n := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		schema  ParameterSchema
+		value   any
+		wantErr string
+	}{
+		{"integer at minimum", ParameterSchema{Name: "age", Type: "integer", Minimum: f(18), Maximum: f(65)}, 18, ""},
+		{"integer at maximum", ParameterSchema{Name: "age", Type: "integer", Minimum: f(18), Maximum: f(65)}, int64(65), ""},
+		{"integer below minimum", ParameterSchema{Name: "age", Type: "integer", Minimum: f(18)}, 17, "parameter 'age' must be at least 18, but got 17"},
+		{"integer above maximum", ParameterSchema{Name: "age", Type: "integer", Maximum: f(65)}, uint8(66), "parameter 'age' must be at most 65, but got 66"},
+		{"float at minimum", ParameterSchema{Name: "ratio", Type: "float", Minimum: f(0.5)}, 0.5, ""},
+		{"float below minimum", ParameterSchema{Name: "ratio", Type: "float", Minimum: f(0.5)}, 0.49, "parameter 'ratio' must be at least 0.5, but got 0.49"},
+		{"float above maximum", ParameterSchema{Name: "ratio", Type: "float", Maximum: f(1)}, float32(1.5), "parameter 'ratio' must be at most 1, but got 1.5"},
+		{"string at min length", ParameterSchema{Name: "code", Type: "string", MinLength: n(2), MaxLength: n(3)}, "ab", ""},
+		{"string at max length in characters", ParameterSchema{Name: "code", Type: "string", MaxLength: n(3)}, "héé", ""},
+		{"string below min length", ParameterSchema{Name: "code", Type: "string", MinLength: n(2)}, "a", "parameter 'code' must be at least 2 characters long, but got 1"},
+		{"string above max length", ParameterSchema{Name: "code", Type: "string", MaxLength: n(3)}, "abcd", "parameter 'code' must be at most 3 characters long, but got 4"},
+		{"array at min items", ParameterSchema{Name: "ids", Type: "array", MinItems: n(1), MaxItems: n(2)}, []int{1}, ""},
+		{"array at max items", ParameterSchema{Name: "ids", Type: "array", MinItems: n(1), MaxItems: n(2)}, []int{1, 2}, ""},
+		{"array below min items", ParameterSchema{Name: "ids", Type: "array", MinItems: n(1)}, []int{}, "parameter 'ids' must have at least 1 items, but got 0"},
+		{"array above max items", ParameterSchema{Name: "ids", Type: "array", MaxItems: n(2)}, []int{1, 2, 3}, "parameter 'ids' must have at most 2 items, but got 3"},
+		{"unset bounds", ParameterSchema{Name: "age", Type: "integer"}, -1000, ""},
+		{"nil optional value", ParameterSchema{Name: "age", Type: "integer", Minimum: f(18)}, nil, ""},
+		{"array items bounds", ParameterSchema{Name: "ids", Type: "array", Items: &ParameterSchema{Name: "id", Type: "integer", Minimum: f(1)}}, []int{1, 0}, "error in array 'ids' at index 1: parameter 'id' must be at least 1, but got 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.ValidateType(tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// Tests ParameterSchema pattern constraints.
+func TestParameterSchemaPattern(t *testing.T) {
+	schema := ParameterSchema{Name: "order_id", Type: "string", Pattern: `^ORD-[0-9]{4}$`}
+
+	if err := schema.ValidateType("ORD-0042"); err != nil {
+		t.Errorf("Expected a matching value to pass, got %v", err)
+	}
+
+	err := schema.ValidateType("ORD-42")
+	want := "parameter 'order_id' does not match required pattern '^ORD-[0-9]{4}$'"
+	if err == nil || err.Error() != want {
+		t.Errorf("Expected error %q, got %v", want, err)
+	}
+
+	if err := schema.ValidateType(nil); err != nil {
+		t.Errorf("Expected a nil optional value to pass, got %v", err)
+	}
+
+	// The pattern is unanchored unless the schema anchors it, as in JSON Schema.
+	contains := ParameterSchema{Name: "email", Type: "string", Pattern: "@"}
+	if err := contains.ValidateType("user@example.com"); err != nil {
+		t.Errorf("Expected an unanchored pattern to match anywhere, got %v", err)
+	}
+}
+
 // Tests ParameterSchema with type 'boolean'.
 func TestParameterSchemaBoolean(t *testing.T) {
 
@@ -163,6 +365,46 @@ func TestParameterSchemaBoolean(t *testing.T) {
 
 }
 
+// Tests ParameterSchema with type 'bytes'.
+func TestParameterSchemaBytes(t *testing.T) {
+
+	schema := ParameterSchema{
+		Name:        "param_name",
+		Type:        "bytes",
+		Description: "bytes parameter",
+	}
+
+	t.Run("Test []byte param", func(t *testing.T) {
+		if err := schema.ValidateType([]byte{0x00, 0xff, 0x10}); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+	t.Run("Test base64 string param", func(t *testing.T) {
+		if err := schema.ValidateType("aGVsbG8gd29ybGQ="); err != nil {
+			t.Fatal(err.Error())
+		}
+	})
+	t.Run("Test invalid base64 string param", func(t *testing.T) {
+		err := schema.ValidateType("not base64!")
+		if err == nil {
+			t.Fatal("Expected an error for an invalid base64 string, but got nil")
+		}
+		if !strings.Contains(err.Error(), "expects a base64-encoded string") {
+			t.Errorf("Unexpected error message: %v", err)
+		}
+	})
+	t.Run("Test non-byte param", func(t *testing.T) {
+		err := schema.ValidateType(123)
+		if err == nil {
+			t.Fatal("Expected an error for a non-byte value, but got nil")
+		}
+		if !strings.Contains(err.Error(), "expects bytes or a base64-encoded string, but got int") {
+			t.Errorf("Unexpected error message: %v", err)
+		}
+	})
+
+}
+
 // Tests ParameterSchema with type 'float'.
 func TestParameterSchemaFloat(t *testing.T) {
 
@@ -588,6 +830,85 @@ func TestValidateTypeObject(t *testing.T) {
 	})
 }
 
+func TestValidateTypeNestedArrays(t *testing.T) {
+	rows := ParameterSchema{
+		Name: "rows",
+		Type: "array",
+		Items: &ParameterSchema{
+			Type:                 "object",
+			AdditionalProperties: &ParameterSchema{Type: "integer"},
+		},
+	}
+	grid := ParameterSchema{
+		Name: "grid",
+		Type: "array",
+		Items: &ParameterSchema{
+			Type: "array",
+			Items: &ParameterSchema{
+				Type:  "array",
+				Items: &ParameterSchema{Type: "integer"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		schema  ParameterSchema
+		value   any
+		wantErr string
+	}{
+		{
+			name:   "valid array of objects",
+			schema: rows,
+			value:  []map[string]any{{"a": 1}, {"b": 2, "c": int64(3)}},
+		},
+		{
+			name:    "element with a wrong nested field type",
+			schema:  rows,
+			value:   []map[string]any{{"a": 1}, {"b": "two"}},
+			wantErr: "error in array 'rows' at index 1: error in object 'rows[1]' for key 'b': parameter 'rows[1].b' expects an integer, but got string",
+		},
+		{
+			name:    "element that is not an object",
+			schema:  rows,
+			value:   []any{map[string]any{"a": 1}, "b"},
+			wantErr: "parameter 'rows[1]' expects a map, but got string",
+		},
+		{
+			name:   "valid deeply nested arrays",
+			schema: grid,
+			value:  [][][]int{{{1, 2}, {3}}, {}},
+		},
+		{
+			name:    "deeply nested element with a wrong type",
+			schema:  grid,
+			value:   []any{[]any{[]any{1}, []any{2, "three"}}},
+			wantErr: "error in array 'grid[0][1]' at index 1: parameter 'grid[0][1][1]' expects an integer, but got string",
+		},
+		{
+			name:    "nested element that is not an array",
+			schema:  grid,
+			value:   []any{[]any{1}},
+			wantErr: "parameter 'grid[0][0]' expects an array/slice, but got int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.ValidateType(tt.value)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
 func TestParameterSchema_ValidateDefinition(t *testing.T) {
 	t.Run("should succeed for simple valid types", func(t *testing.T) {
 		testCases := []struct {
@@ -609,6 +930,93 @@ func TestParameterSchema_ValidateDefinition(t *testing.T) {
 		}
 	})
 
+	t.Run("should fail for inverted bounds and malformed patterns", func(t *testing.T) {
+		minimum, maximum := 10.0, 1.0
+		minLength, maxLength := 5, 2
+		testCases := []struct {
+			name    string
+			schema  *ParameterSchema
+			wantErr string
+		}{
+			{"Range", &ParameterSchema{Name: "p_int", Type: "integer", Minimum: &minimum, Maximum: &maximum}, "minimum 10 is greater than maximum 1"},
+			{"Length", &ParameterSchema{Name: "p_string", Type: "string", MinLength: &minLength, MaxLength: &maxLength}, "minLength 5 is greater than maxLength 2"},
+			{"Items", &ParameterSchema{Name: "p_array", Type: "array", MinItems: &minLength, MaxItems: &maxLength}, "minItems 5 is greater than maxItems 2"},
+			{"Pattern", &ParameterSchema{Name: "p_string", Type: "string", Pattern: "[a-z"}, "schema validation failed for 'p_string': invalid pattern"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				err := tc.schema.ValidateDefinition()
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, but got: %v", tc.wantErr, err)
+				}
+			})
+		}
+	})
+
+	t.Run("should check defaults against the schema", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			schema  *ParameterSchema
+			wantErr string
+		}{
+			{"String default", &ParameterSchema{Name: "units", Type: "string", Default: "metric"}, ""},
+			{"Integer default decoded from JSON", &ParameterSchema{Name: "limit", Type: "integer", Default: float64(10)}, ""},
+			{"Array default", &ParameterSchema{Name: "tags", Type: "array", Items: &ParameterSchema{Type: "string"}, Default: []any{"a"}}, ""},
+			{"Wrong default type", &ParameterSchema{Name: "limit", Type: "integer", Default: "ten"}, "schema validation failed for 'limit': invalid default: parameter 'limit' expects an integer, but got string"},
+			{"Fractional integer default", &ParameterSchema{Name: "limit", Type: "integer", Default: 2.5}, "invalid default"},
+			{"Default outside enum", &ParameterSchema{Name: "units", Type: "string", Enum: []any{"metric", "imperial"}, Default: "kelvin"}, "invalid default: parameter 'units' must be one of"},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				err := tc.schema.ValidateDefinition()
+				if tc.wantErr == "" {
+					if err != nil {
+						t.Errorf("expected no error, but got: %v", err)
+					}
+					return
+				}
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, but got: %v", tc.wantErr, err)
+				}
+			})
+		}
+	})
+
+	t.Run("should fail for malformed nested item schemas", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			schema  *ParameterSchema
+			wantErr string
+		}{
+			{
+				"Item without a type",
+				&ParameterSchema{Name: "grid", Type: "array", Items: &ParameterSchema{Type: "array", Items: &ParameterSchema{}}},
+				"schema validation failed for 'grid[][]': type is missing",
+			},
+			{
+				"Object item with invalid additional properties",
+				&ParameterSchema{Name: "rows", Type: "array", Items: &ParameterSchema{Type: "object", AdditionalProperties: "integer"}},
+				"invalid schema for parameter 'rows[]'",
+			},
+			{
+				"Object item with an unknown value type",
+				&ParameterSchema{Name: "rows", Type: "array", Items: &ParameterSchema{Type: "object", AdditionalProperties: &ParameterSchema{Type: "decimal"}}},
+				"unknown schema type 'decimal' for parameter 'rows[].*'",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				err := tc.schema.ValidateDefinition()
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("expected error containing %q, but got: %v", tc.wantErr, err)
+				}
+			})
+		}
+	})
+
 	t.Run("should succeed for a valid array schema", func(t *testing.T) {
 		schema := &ParameterSchema{
 			Name:  "p_array",
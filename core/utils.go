@@ -15,11 +15,19 @@
 package core
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/googleapis/mcp-toolbox-sdk-go/core/transport"
 	"golang.org/x/oauth2"
 )
 
@@ -146,11 +154,68 @@ func (s *customTokenSource) Token() (*oauth2.Token, error) {
 	}, nil
 }
 
+// ContextTokenSource supplies tokens like an oauth2.TokenSource, but receives
+// the context of the call that needs the token, so that fetching it, for
+// example from an identity provider, can be cancelled or bounded by a
+// deadline. Use it with WithAuthTokenContextSource or
+// WithClientHeaderContextSource.
+type ContextTokenSource interface {
+	Token(ctx context.Context) (*oauth2.Token, error)
+}
+
+// contextTokenSource is implemented by header and auth sources whose value
+// depends on the context of the outgoing request, such as those added with
+// WithClientHeaderFunc or WithAuthTokenContextSource.
+type contextTokenSource interface {
+	oauth2.TokenSource
+	tokenContext(ctx context.Context) (*oauth2.Token, error)
+}
+
+// headerFuncSource adapts a context-aware header function to a TokenSource.
+type headerFuncSource func(ctx context.Context) (string, error)
+
+// Token resolves the header without a request context.
+func (f headerFuncSource) Token() (*oauth2.Token, error) {
+	return f.tokenContext(context.Background())
+}
+
+func (f headerFuncSource) tokenContext(ctx context.Context) (*oauth2.Token, error) {
+	value, err := f(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{AccessToken: value}, nil
+}
+
+// contextSourceAdapter adapts a ContextTokenSource to a TokenSource, so that
+// it can be stored alongside the other header and auth sources.
+type contextSourceAdapter struct {
+	src ContextTokenSource
+}
+
+// Token fetches a token without a request context.
+func (a contextSourceAdapter) Token() (*oauth2.Token, error) {
+	return a.src.Token(context.Background())
+}
+
+func (a contextSourceAdapter) tokenContext(ctx context.Context) (*oauth2.Token, error) {
+	return a.src.Token(ctx)
+}
+
+// resolveToken fetches a token from a header or auth source, passing the
+// request context to sources that accept it.
+func resolveToken(ctx context.Context, source oauth2.TokenSource) (*oauth2.Token, error) {
+	if cs, ok := source.(contextTokenSource); ok {
+		return cs.tokenContext(ctx)
+	}
+	return source.Token()
+}
+
 // Helper to resolve client-level headers
-func resolveClientHeaders(clientHeaderSources map[string]oauth2.TokenSource) (map[string]string, error) {
+func resolveClientHeaders(ctx context.Context, clientHeaderSources map[string]oauth2.TokenSource) (map[string]string, error) {
 	resolved := make(map[string]string)
 	for k, source := range clientHeaderSources {
-		token, err := source.Token()
+		token, err := resolveToken(ctx, source)
 		if err != nil {
 			return nil, fmt.Errorf("failed to resolve client header '%s': %w", k, err)
 		}
@@ -159,6 +224,16 @@ func resolveClientHeaders(clientHeaderSources map[string]oauth2.TokenSource) (ma
 	return resolved, nil
 }
 
+// redactHeaders returns a copy of the headers, safe to log, in which every
+// value is replaced by a placeholder.
+func redactHeaders(headers map[string]string) map[string]string {
+	redacted := make(map[string]string, len(headers))
+	for name := range headers {
+		redacted[name] = "REDACTED"
+	}
+	return redacted
+}
+
 // schemaToMap recursively converts a ParameterSchema to a map with its type and description.
 func schemaToMap(p *ParameterSchema) (map[string]any, error) {
 	var schema = make(map[string]any)
@@ -166,6 +241,10 @@ func schemaToMap(p *ParameterSchema) (map[string]any, error) {
 	if p.Type == "float" {
 		// Since there is no float type in JSON Schema Standard
 		schema["type"] = "number"
+	} else if p.Type == "bytes" {
+		// Binary data travels as a base64-encoded string
+		schema["type"] = "string"
+		schema["format"] = "byte"
 	} else {
 		schema["type"] = p.Type
 	}
@@ -178,6 +257,32 @@ func schemaToMap(p *ParameterSchema) (map[string]any, error) {
 		schema["default"] = p.Default
 	}
 
+	if len(p.Enum) > 0 {
+		schema["enum"] = p.Enum
+	}
+
+	if p.Minimum != nil {
+		schema["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		schema["maximum"] = *p.Maximum
+	}
+	if p.MinLength != nil {
+		schema["minLength"] = *p.MinLength
+	}
+	if p.MaxLength != nil {
+		schema["maxLength"] = *p.MaxLength
+	}
+	if p.MinItems != nil {
+		schema["minItems"] = *p.MinItems
+	}
+	if p.MaxItems != nil {
+		schema["maxItems"] = *p.MaxItems
+	}
+	if p.Pattern != "" {
+		schema["pattern"] = p.Pattern
+	}
+
 	// Handle array validation recursively
 	if p.Type == "array" && p.Items != nil {
 		itemSchema, err := schemaToMap(p.Items)
@@ -235,3 +340,485 @@ func checkSecureHeaders(url string, hasSensitiveData bool) {
 		log.Println("WARNING: This connection is using HTTP. To prevent credential exposure, please ensure all communication is sent over HTTPS.")
 	}
 }
+
+// suggestParameterName returns the name of the parameter that the given key
+// most likely refers to when they differ only in casing or word separators
+// (for example 'numRows' and 'num_rows'), or "" if there is no such parameter.
+func suggestParameterName(key string, params []ParameterSchema) string {
+	normalize := func(s string) string {
+		s = strings.ReplaceAll(s, "_", "")
+		s = strings.ReplaceAll(s, "-", "")
+		return strings.ToLower(s)
+	}
+
+	normalizedKey := normalize(key)
+	for _, p := range params {
+		if normalize(p.Name) == normalizedKey {
+			return p.Name
+		}
+	}
+	return ""
+}
+
+// isNilValue reports whether v is nil, either as an untyped nil or as a nil
+// map, slice, pointer or interface wrapped in an interface value.
+func isNilValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// canonicalizePayload normalizes a tool payload by encoding it to JSON and
+// decoding it back. Object keys are sorted by the encoder, Go-specific types
+// collapse to their JSON representation, and numbers are kept as json.Number
+// so that re-encoding reproduces them exactly.
+func canonicalizePayload(payload map[string]any) (map[string]any, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to canonicalize payload: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var canonical map[string]any
+	if err := decoder.Decode(&canonical); err != nil {
+		return nil, fmt.Errorf("failed to canonicalize payload: %w", err)
+	}
+	return canonical, nil
+}
+
+// decodeResultNumbers decodes a JSON tool result string, keeping numbers as
+// json.Number. Results that are not strings or not a single valid JSON value
+// are returned unchanged.
+func decodeResultNumbers(output any) any {
+	s, ok := output.(string)
+	if !ok {
+		return output
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(s))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return output
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return output
+	}
+	return decoded
+}
+
+// coerceIntegers converts the whole floats and json.Numbers that validation
+// accepts for integer parameters, including array items and map values, to
+// int64. The value is copied rather than modified.
+func coerceIntegers(value any, p *ParameterSchema) any {
+	if p == nil {
+		return value
+	}
+	switch p.Type {
+	case "integer":
+		switch value.(type) {
+		case float32, float64, json.Number:
+			if i, ok := transport.WholeNumber(value); ok {
+				return i
+			}
+		}
+	case "array":
+		if items, ok := value.([]any); ok && p.Items != nil {
+			coerced := make([]any, len(items))
+			for i, item := range items {
+				coerced[i] = coerceIntegers(item, p.Items)
+			}
+			return coerced
+		}
+	case "object":
+		values, ok := p.AdditionalProperties.(*ParameterSchema)
+		if m, isMap := value.(map[string]any); ok && isMap {
+			coerced := make(map[string]any, len(m))
+			for k, item := range m {
+				coerced[k] = coerceIntegers(item, values)
+			}
+			return coerced
+		}
+	}
+	return value
+}
+
+// compileGlob translates a glob pattern using '*', '?' and backslash escapes
+// into an anchored regular expression.
+func compileGlob(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid glob pattern: pattern cannot be empty")
+	}
+
+	runes := []rune(pattern)
+	var sb strings.Builder
+	sb.WriteString("(?s)^")
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '\\':
+			if i+1 == len(runes) {
+				return nil, fmt.Errorf("invalid glob pattern %q: trailing escape character", pattern)
+			}
+			i++
+			sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// validateOutput checks a tool result against the fields of the tool's
+// output schema. A string result is parsed as a JSON object first.
+func validateOutput(schema []ParameterSchema, output any) error {
+	var data []byte
+	if s, ok := output.(string); ok {
+		data = []byte(s)
+	} else {
+		var err error
+		if data, err = json.Marshal(output); err != nil {
+			return fmt.Errorf("result cannot be encoded as JSON: %w", err)
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var fields map[string]any
+	if err := dec.Decode(&fields); err != nil || fields == nil {
+		return fmt.Errorf("result is not a JSON object")
+	}
+
+	for _, param := range schema {
+		value, ok := fields[param.Name]
+		if !ok {
+			if param.Required {
+				return fmt.Errorf("missing required field '%s'", param.Name)
+			}
+			continue
+		}
+		if err := param.ValidateType(coerceJSONNumbers(&param, value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// coerceJSONNumbers converts the json.Number values within v into the Go
+// numbers that ParameterSchema.ValidateType expects, guided by the schema:
+// integers where the schema asks for one, float64 everywhere else.
+func coerceJSONNumbers(schema *ParameterSchema, v any) any {
+	switch val := v.(type) {
+	case json.Number:
+		if schema != nil && schema.Type == "integer" {
+			if n, err := val.Int64(); err == nil {
+				return n
+			}
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val
+	case []any:
+		var items *ParameterSchema
+		if schema != nil {
+			items = schema.Items
+		}
+		for i, item := range val {
+			val[i] = coerceJSONNumbers(items, item)
+		}
+		return val
+	case map[string]any:
+		var values *ParameterSchema
+		if schema != nil {
+			values, _ = schema.AdditionalProperties.(*ParameterSchema)
+		}
+		for k, item := range val {
+			val[k] = coerceJSONNumbers(values, item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// truncate shortens s to at most n runes for use in error messages, marking
+// the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "..."
+}
+
+// validateUTF8 reports an error if value, or any string nested in it as an
+// array element or object key or value, is not valid UTF-8.
+func validateUTF8(paramName string, value any) error {
+	if !isValidUTF8(reflect.ValueOf(value)) {
+		return fmt.Errorf("parameter '%s' contains invalid UTF-8", paramName)
+	}
+	return nil
+}
+
+// isValidUTF8 walks v and reports whether all strings within it are valid UTF-8.
+func isValidUTF8(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.ValidString(v.String())
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil() || isValidUTF8(v.Elem())
+	case reflect.Slice, reflect.Array:
+		// Raw bytes are not text and are sent base64-encoded.
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return true
+		}
+		for i := range v.Len() {
+			if !isValidUTF8(v.Index(i)) {
+				return false
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if !isValidUTF8(iter.Key()) || !isValidUTF8(iter.Value()) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validateHeaderName checks that a header name is a valid HTTP token as
+// defined by RFC 7230, section 3.2.6.
+func validateHeaderName(name string) error {
+	if name == "" {
+		return fmt.Errorf("invalid header name '%s'", name)
+	}
+	for i := 0; i < len(name); i++ {
+		if !isTokenChar(name[i]) {
+			return fmt.Errorf("invalid header name '%s'", name)
+		}
+	}
+	return nil
+}
+
+// isTokenChar reports whether c is an RFC 7230 tchar.
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
+// parseSemver parses a semantic version such as "1.2.3" or "v1.2.3-rc.1"
+// into its numeric core and pre-release suffix. Build metadata is ignored.
+func parseSemver(version string) ([3]int, string, error) {
+	var core [3]int
+	v := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	v, _, _ = strings.Cut(v, "+")
+	v, prerelease, _ := strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) != 3 {
+		return core, "", fmt.Errorf("invalid semantic version %q", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return core, "", fmt.Errorf("invalid semantic version %q", version)
+		}
+		core[i] = n
+	}
+	return core, prerelease, nil
+}
+
+// compareSemver compares two semantic versions and returns -1, 0, or +1 when
+// a is lower than, equal to, or greater than b. A pre-release version sorts
+// before the release it precedes; pre-release identifiers are otherwise
+// compared lexically.
+func compareSemver(a, b string) (int, error) {
+	aCore, aPre, err := parseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	bCore, bPre, err := parseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range aCore {
+		if aCore[i] != bCore[i] {
+			if aCore[i] < bCore[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	switch {
+	case aPre == bPre:
+		return 0, nil
+	case aPre == "":
+		return 1, nil
+	case bPre == "":
+		return -1, nil
+	default:
+		return strings.Compare(aPre, bPre), nil
+	}
+}
+
+// structFieldBinding is a parameter value bound by WithBindParamsFromStruct.
+// The binding is dropped for optional parameters when skipIfOptional is set.
+type structFieldBinding struct {
+	name           string
+	value          any
+	skipIfOptional bool
+}
+
+// validateStaticBinding checks a bound value against its parameter schema
+// when the tool is created. Bound functions are only validated once they are
+// resolved at invocation time.
+func validateStaticBinding(p ParameterSchema, value any) error {
+	if value != nil && reflect.TypeOf(value).Kind() == reflect.Func {
+		return nil
+	}
+	if err := p.ValidateType(value); err != nil {
+		return fmt.Errorf("bound parameter '%s' failed validation: %w", p.Name, err)
+	}
+	return nil
+}
+
+// unwrapStructBinding resolves a bound value for the given parameter. It
+// reports false when the value is a struct field binding that must not be
+// applied to the parameter.
+func unwrapStructBinding(val any, p ParameterSchema) (any, bool) {
+	f, ok := val.(structFieldBinding)
+	if !ok {
+		return val, true
+	}
+	if f.skipIfOptional && !p.Required {
+		return nil, false
+	}
+	return f.value, true
+}
+
+// structBindings returns one binding per parameter field of a struct, or of
+// the struct a pointer refers to.
+func structBindings(v any) ([]structFieldBinding, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("expected a struct, got a nil %T", v)
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %T", v)
+	}
+
+	var bindings []structFieldBinding
+	for name, field := range structFields(rv) {
+		bindings = append(bindings, structFieldBinding{
+			name:           name,
+			value:          structFieldValue(field),
+			skipIfOptional: field.IsZero() && !hasTagOption(field, "keepzero"),
+		})
+	}
+	return bindings, nil
+}
+
+// taggedField is an exported struct field value and its tags.
+type taggedField struct {
+	reflect.Value
+	tag reflect.StructTag
+}
+
+// hasTagOption reports whether the field's `toolbox` tag lists the option.
+func hasTagOption(f taggedField, option string) bool {
+	for opt := range strings.SplitSeq(f.tag.Get("toolbox"), ",") {
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
+
+// structFields yields the exported fields of a struct keyed by their
+// parameter name, following the naming rules of encoding/json. Embedded
+// structs without a name of their own are flattened into their parent.
+func structFields(rv reflect.Value) func(yield func(string, taggedField) bool) {
+	return func(yield func(string, taggedField) bool) {
+		rt := rv.Type()
+		for i := range rt.NumField() {
+			sf := rt.Field(i)
+			if !sf.IsExported() && !sf.Anonymous {
+				continue
+			}
+			jsonName, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+			if jsonName == "-" {
+				continue
+			}
+
+			fv := rv.Field(i)
+			if sf.Anonymous && jsonName == "" {
+				if fv.Kind() == reflect.Pointer {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct {
+					for name, field := range structFields(fv) {
+						if !yield(name, field) {
+							return
+						}
+					}
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+			}
+
+			name := jsonName
+			if name == "" {
+				name = sf.Name
+			}
+			if !yield(name, taggedField{Value: fv, tag: sf.Tag}) {
+				return
+			}
+		}
+	}
+}
+
+// structFieldValue converts a field to a bound parameter value, turning
+// nested structs into maps so that they can be sent as object parameters.
+func structFieldValue(field taggedField) any {
+	fv := field.Value
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil
+		}
+		fv = fv.Elem()
+	}
+	if fv.Kind() != reflect.Struct {
+		return fv.Interface()
+	}
+	obj := make(map[string]any)
+	for name, nested := range structFields(fv) {
+		obj[name] = structFieldValue(nested)
+	}
+	return obj
+}
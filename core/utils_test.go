@@ -18,6 +18,7 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"log"
 	"reflect"
@@ -151,7 +152,7 @@ func TestResolveClientHeaders(t *testing.T) {
 		}
 
 		// Execute function directly
-		headers, err := resolveClientHeaders(sources)
+		headers, err := resolveClientHeaders(context.Background(), sources)
 
 		// Verify
 		require.NoError(t, err)
@@ -163,7 +164,7 @@ func TestResolveClientHeaders(t *testing.T) {
 	t.Run("Success_Empty", func(t *testing.T) {
 		sources := make(map[string]oauth2.TokenSource)
 
-		headers, err := resolveClientHeaders(sources)
+		headers, err := resolveClientHeaders(context.Background(), sources)
 
 		require.NoError(t, err)
 		assert.Empty(t, headers)
@@ -178,7 +179,7 @@ func TestResolveClientHeaders(t *testing.T) {
 		}
 
 		// Execute
-		headers, err := resolveClientHeaders(sources)
+		headers, err := resolveClientHeaders(context.Background(), sources)
 
 		// Verify
 		require.Error(t, err)
@@ -212,6 +213,11 @@ func TestCustomTokenSource(t *testing.T) {
 	})
 }
 
+// ptr returns a pointer to v, for the optional fields of a schema.
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestSchemaToMap(t *testing.T) {
 	// Define test cases
 	testCases := []struct {
@@ -231,6 +237,69 @@ func TestSchemaToMap(t *testing.T) {
 				"description": "A simple string input.",
 			},
 		},
+		{
+			name: "Enum Parameter",
+			input: &ParameterSchema{
+				Type:        "string",
+				Description: "A color.",
+				Enum:        []any{"red", "green"},
+			},
+			expected: map[string]any{
+				"type":        "string",
+				"description": "A color.",
+				"enum":        []any{"red", "green"},
+			},
+		},
+		{
+			name: "Bounded Parameters",
+			input: &ParameterSchema{
+				Type:     "array",
+				MinItems: ptr(1),
+				MaxItems: ptr(5),
+				Items: &ParameterSchema{
+					Type:      "string",
+					MinLength: ptr(2),
+					MaxLength: ptr(8),
+					Pattern:   "^[a-z]+$",
+				},
+			},
+			expected: map[string]any{
+				"type":     "array",
+				"minItems": 1,
+				"maxItems": 5,
+				"items": map[string]any{
+					"type":      "string",
+					"minLength": 2,
+					"maxLength": 8,
+					"pattern":   "^[a-z]+$",
+				},
+			},
+		},
+		{
+			name: "Numeric Range Parameter",
+			input: &ParameterSchema{
+				Type:    "float",
+				Minimum: ptr(0.0),
+				Maximum: ptr(1.0),
+			},
+			expected: map[string]any{
+				"type":    "number",
+				"minimum": 0.0,
+				"maximum": 1.0,
+			},
+		},
+		{
+			name: "Bytes Parameter",
+			input: &ParameterSchema{
+				Type:        "bytes",
+				Description: "A file's contents.",
+			},
+			expected: map[string]any{
+				"type":        "string",
+				"format":      "byte",
+				"description": "A file's contents.",
+			},
+		},
 		{
 			name: "Array of Integers Parameter",
 			input: &ParameterSchema{
@@ -505,3 +574,66 @@ func TestCheckSecureHeaders(t *testing.T) {
 		assert.NotContains(t, output, "WARNING: This connection is using HTTP")
 	})
 }
+
+func TestCompileGlob(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		name    string
+		matches bool
+	}{
+		{"get-*", "get-user", true},
+		{"get-*", "get-", true},
+		{"get-*", "list-users", false},
+		{"get-?", "get-a", true},
+		{"get-?", "get-ab", false},
+		{"*.v1", "tool.v1", true},
+		{"*.v1", "toolxv1", false},
+		{`get-\*`, "get-*", true},
+		{`get-\*`, "get-user", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern+" "+tc.name, func(t *testing.T) {
+			re, err := compileGlob(tc.pattern)
+			require.NoError(t, err)
+			assert.Equal(t, tc.matches, re.MatchString(tc.name))
+		})
+	}
+
+	t.Run("Invalid patterns return an error", func(t *testing.T) {
+		_, err := compileGlob("")
+		assert.Error(t, err)
+		_, err = compileGlob(`get-\`)
+		assert.Error(t, err)
+	})
+}
+
+func TestCompareSemver(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a, b     string
+		expected int
+	}{
+		{"Equal versions", "1.2.3", "1.2.3", 0},
+		{"Leading v is ignored", "v1.2.3", "1.2.3", 0},
+		{"Lower patch", "1.2.3", "1.2.4", -1},
+		{"Higher minor", "1.10.0", "1.9.9", 1},
+		{"Lower major", "0.20.0", "1.0.0", -1},
+		{"Pre-release sorts before release", "1.0.0-rc.1", "1.0.0", -1},
+		{"Build metadata is ignored", "1.0.0+build.5", "1.0.0", 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := compareSemver(tc.a, tc.b)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, got)
+		})
+	}
+
+	t.Run("Non-semver version returns an error", func(t *testing.T) {
+		_, err := compareSemver("dev", "1.0.0")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "invalid semantic version \"dev\"")
+	})
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tbanthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/googleapis/mcp-toolbox-sdk-go/core"
+)
+
+// Executor runs a tool for a tool_use block returned by the Messages API. It
+// receives the block's raw input and returns the text to send back in the
+// matching tool_result block.
+type Executor func(ctx context.Context, input json.RawMessage) (string, error)
+
+// ToAnthropicTool converts a ToolboxTool into an anthropic.ToolParam that can
+// be passed in the Tools field of a Messages API request.
+//
+// Inputs:
+//
+//	tool: A pointer to the `core.ToolboxTool` to be converted.
+//
+// Returns:
+//
+//	An `anthropic.ToolParam` holding the tool's name, description and the
+//	JSON Schema of its unbound parameters, or an error if the tool is nil or
+//	its schema cannot be built.
+func ToAnthropicTool(tool *core.ToolboxTool) (anthropic.ToolParam, error) {
+	if tool == nil {
+		return anthropic.ToolParam{}, fmt.Errorf("error: ToAnthropicTool received a nil core.ToolboxTool pointer")
+	}
+
+	schema, err := tool.ParametersSchema()
+	if err != nil {
+		return anthropic.ToolParam{}, fmt.Errorf("error fetching input schema for tool '%s': %w", tool.Name(), err)
+	}
+
+	inputSchema := anthropic.ToolInputSchemaParam{Properties: schema["properties"]}
+	if required, ok := schema["required"].([]string); ok {
+		inputSchema.Required = required
+	}
+
+	toolParam := anthropic.ToolParam{
+		Name:        tool.Name(),
+		InputSchema: inputSchema,
+	}
+	if description := tool.Description(); description != "" {
+		toolParam.Description = anthropic.String(description)
+	}
+	return toolParam, nil
+}
+
+// ToAnthropicExecutor returns the Executor that runs a ToolboxTool when Claude
+// requests it in a tool_use block.
+//
+// Inputs:
+//
+//	tool: A pointer to the `core.ToolboxTool` to be executed.
+//
+// Returns:
+//
+//	An `Executor` that decodes the tool_use input, invokes the tool and
+//	returns its result as text, encoding structured results as JSON, or an
+//	error if the tool is nil.
+func ToAnthropicExecutor(tool *core.ToolboxTool) (Executor, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("error: ToAnthropicExecutor received a nil core.ToolboxTool pointer")
+	}
+
+	return func(ctx context.Context, input json.RawMessage) (string, error) {
+		// A tool without parameters may be called with an empty or null input.
+		if trimmed := bytes.TrimSpace(input); len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+			input = json.RawMessage("{}")
+		}
+
+		result, err := tool.InvokeJSON(ctx, input)
+		if err != nil {
+			return "", fmt.Errorf("error invoking core tool %s: %w", tool.Name(), err)
+		}
+		// Text results are passed through; structured results are sent to
+		// Claude as JSON.
+		if text, ok := result.(string); ok {
+			return text, nil
+		}
+		encoded, err := json.Marshal(result)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode result of tool %s: %w", tool.Name(), err)
+		}
+		return string(encoded), nil
+	}, nil
+}
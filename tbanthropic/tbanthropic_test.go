@@ -0,0 +1,184 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tbanthropic_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core"
+	"github.com/googleapis/mcp-toolbox-sdk-go/tbanthropic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockServer creates an MCP server offering a single 'search-hotels' tool
+// that echoes the location it is called with. Each call's arguments are sent
+// on the returned channel.
+func newMockServer(t *testing.T) (*httptest.Server, <-chan map[string]any) {
+	calls := make(chan map[string]any, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			ID     any             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []map[string]any{{
+				"name":        "search-hotels",
+				"description": "Search hotels by location.",
+				"inputSchema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"location":    map[string]any{"type": "string", "description": "City name."},
+						"max_results": map[string]any{"type": "integer", "description": "Result limit."},
+					},
+					"required": []string{"location"},
+				},
+			}}}
+		case "tools/call":
+			var params struct {
+				Arguments map[string]any `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			calls <- params.Arguments
+			text := "hotels in " + params.Arguments["location"].(string)
+			if params.Arguments["location"] == "Rome" {
+				text = `{"hotels": ["Hotel Artemide"], "count": 1}`
+			}
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": text}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": json.RawMessage(resBytes)})
+	}))
+	t.Cleanup(server.Close)
+	return server, calls
+}
+
+func loadTool(t *testing.T, server *httptest.Server, opts ...core.ToolOption) *core.ToolboxTool {
+	t.Helper()
+	client, err := core.NewToolboxClient(server.URL, core.WithHTTPClient(server.Client()), core.WithProtocol(core.MCPv20250618))
+	require.NoError(t, err)
+	tool, err := client.LoadTool("search-hotels", context.Background(), opts...)
+	require.NoError(t, err)
+	return tool
+}
+
+func TestToAnthropicTool(t *testing.T) {
+	server, _ := newMockServer(t)
+
+	t.Run("Maps name, description and input schema", func(t *testing.T) {
+		toolParam, err := tbanthropic.ToAnthropicTool(loadTool(t, server))
+		require.NoError(t, err)
+
+		raw, err := json.Marshal(toolParam)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{
+			"name": "search-hotels",
+			"description": "Search hotels by location.",
+			"input_schema": {
+				"type": "object",
+				"properties": {
+					"location": {"type": "string", "description": "City name."},
+					"max_results": {"type": "integer", "description": "Result limit."}
+				},
+				"required": ["location"]
+			}
+		}`, string(raw))
+	})
+
+	t.Run("Bound parameters are left out of the schema", func(t *testing.T) {
+		toolParam, err := tbanthropic.ToAnthropicTool(loadTool(t, server, core.WithBindParamString("location", "Paris")))
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]any{
+			"max_results": map[string]any{"type": "integer", "description": "Result limit."},
+		}, toolParam.InputSchema.Properties)
+		assert.Empty(t, toolParam.InputSchema.Required)
+	})
+
+	t.Run("Nil tool", func(t *testing.T) {
+		_, err := tbanthropic.ToAnthropicTool(nil)
+		assert.ErrorContains(t, err, "nil core.ToolboxTool pointer")
+
+		_, err = tbanthropic.ToAnthropicExecutor(nil)
+		assert.ErrorContains(t, err, "nil core.ToolboxTool pointer")
+	})
+}
+
+func TestToAnthropicExecutor(t *testing.T) {
+	server, calls := newMockServer(t)
+	execute, err := tbanthropic.ToAnthropicExecutor(loadTool(t, server))
+	require.NoError(t, err)
+
+	t.Run("Invokes the tool with the tool_use input", func(t *testing.T) {
+		result, err := execute(context.Background(), json.RawMessage(`{"location": "Paris", "max_results": 3}`))
+		require.NoError(t, err)
+		assert.Equal(t, "hotels in Paris", result)
+		assert.Equal(t, map[string]any{"location": "Paris", "max_results": float64(3)}, <-calls)
+	})
+
+	t.Run("Structured results are encoded as JSON", func(t *testing.T) {
+		client, err := core.NewToolboxClient(server.URL, core.WithHTTPClient(server.Client()), core.WithProtocol(core.MCPv20250618), core.WithNumberAsJSONNumber())
+		require.NoError(t, err)
+		tool, err := client.LoadTool("search-hotels", context.Background())
+		require.NoError(t, err)
+		execute, err := tbanthropic.ToAnthropicExecutor(tool)
+		require.NoError(t, err)
+
+		result, err := execute(context.Background(), json.RawMessage(`{"location": "Rome"}`))
+		require.NoError(t, err)
+		<-calls
+		assert.JSONEq(t, `{"hotels": ["Hotel Artemide"], "count": 1}`, result)
+	})
+
+	t.Run("Rejects input that is not an object", func(t *testing.T) {
+		_, err := execute(context.Background(), json.RawMessage(`["Paris"]`))
+		assert.ErrorContains(t, err, "expects a JSON object as input, but got a JSON array")
+	})
+
+	t.Run("Propagates invocation errors", func(t *testing.T) {
+		_, err := execute(context.Background(), json.RawMessage(`{}`))
+		assert.ErrorContains(t, err, "error invoking core tool search-hotels")
+	})
+}
@@ -0,0 +1,91 @@
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblangchain
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core"
+	"github.com/tmc/langchaingo/tools"
+)
+
+// langchainTool adapts a core.ToolboxTool to LangChainGo's tools.Tool
+// interface.
+type langchainTool struct {
+	tool *core.ToolboxTool
+}
+
+var _ tools.Tool = (*langchainTool)(nil)
+
+// ToLangchainTool converts a ToolboxTool into a LangChainGo tools.Tool.
+//
+// LangChainGo passes tool input as a single string, so the returned tool
+// expects a JSON object holding the tool's unbound parameters. Bound
+// parameters and auth token sources configured on the ToolboxTool are applied
+// as usual when it is called.
+//
+// Inputs:
+//
+//	tool: A pointer to the `core.ToolboxTool` to be converted.
+//
+// Returns:
+//
+//	A `tools.Tool` wrapping the ToolboxTool, or an error if the tool is nil.
+func ToLangchainTool(tool *core.ToolboxTool) (tools.Tool, error) {
+	if tool == nil {
+		return nil, fmt.Errorf("error: ToLangchainTool received a nil core.ToolboxTool pointer")
+	}
+	return &langchainTool{tool: tool}, nil
+}
+
+// Name returns the name of the tool.
+func (t *langchainTool) Name() string {
+	return t.tool.Name()
+}
+
+// Description returns the description of the tool, followed by the parameters
+// expected in the JSON input so that agents can format their calls.
+func (t *langchainTool) Description() string {
+	params := t.tool.DescribeParameters()
+	if params == "" {
+		return t.tool.Description()
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s The input must be a JSON object with the parameters: %s.", t.tool.Description(), params))
+}
+
+// Call decodes the JSON input, invokes the tool and returns its result as a
+// string. Text results are returned as is and structured results as JSON.
+func (t *langchainTool) Call(ctx context.Context, input string) (string, error) {
+	// A tool without parameters may be called with an empty input.
+	if strings.TrimSpace(input) == "" {
+		input = "{}"
+	}
+
+	result, err := t.tool.InvokeJSON(ctx, json.RawMessage(input))
+	if err != nil {
+		return "", fmt.Errorf("error invoking core tool %s: %w", t.tool.Name(), err)
+	}
+	if text, ok := result.(string); ok {
+		return text, nil
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode result of tool %s: %w", t.tool.Name(), err)
+	}
+	return string(encoded), nil
+}
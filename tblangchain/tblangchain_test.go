@@ -0,0 +1,184 @@
+//go:build unit
+
+// Copyright 2026 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tblangchain_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/googleapis/mcp-toolbox-sdk-go/core"
+	"github.com/googleapis/mcp-toolbox-sdk-go/tblangchain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolCall records the arguments and auth header of a tools/call request.
+type toolCall struct {
+	args  map[string]any
+	token string
+}
+
+// newMockServer creates an MCP server offering a single 'search-hotels' tool,
+// which requires the 'google' auth service and echoes the location it is
+// called with. Each call is sent on the returned channel.
+func newMockServer(t *testing.T) (*httptest.Server, <-chan toolCall) {
+	calls := make(chan toolCall, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req struct {
+			ID     any             `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+
+		var result any
+		switch req.Method {
+		case "initialize":
+			result = map[string]any{
+				"protocolVersion": "2025-06-18",
+				"capabilities":    map[string]any{"tools": map[string]any{}},
+				"serverInfo":      map[string]any{"name": "mock-server", "version": "1.0.0"},
+			}
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusOK)
+			return
+		case "tools/list":
+			result = map[string]any{"tools": []map[string]any{{
+				"name":        "search-hotels",
+				"description": "Search hotels by location.",
+				"inputSchema": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"location": map[string]any{"type": "string", "description": "City name."},
+					},
+					"required": []string{"location"},
+				},
+				"_meta": map[string]any{"toolbox/authInvoke": []string{"google"}},
+			}}}
+		case "tools/call":
+			var params struct {
+				Arguments map[string]any `json:"arguments"`
+			}
+			_ = json.Unmarshal(req.Params, &params)
+			calls <- toolCall{args: params.Arguments, token: r.Header.Get("google_token")}
+			location, _ := params.Arguments["location"].(string)
+			text := "hotels in " + location
+			if location == "Milan" {
+				text = `{"hotels": ["Hotel Milano"], "count": 1}`
+			}
+			result = map[string]any{"content": []map[string]any{{"type": "text", "text": text}}}
+		default:
+			http.Error(w, "method not found", http.StatusNotFound)
+			return
+		}
+
+		resBytes, _ := json.Marshal(result)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"jsonrpc": "2.0", "id": req.ID, "result": json.RawMessage(resBytes)})
+	}))
+	t.Cleanup(server.Close)
+	return server, calls
+}
+
+func loadTool(t *testing.T, server *httptest.Server, opts ...core.ToolOption) *core.ToolboxTool {
+	t.Helper()
+	client, err := core.NewToolboxClient(server.URL, core.WithHTTPClient(server.Client()), core.WithProtocol(core.MCPv20250618))
+	require.NoError(t, err)
+	opts = append(opts, core.WithAuthTokenString("google", "secret"))
+	tool, err := client.LoadTool("search-hotels", context.Background(), opts...)
+	require.NoError(t, err)
+	return tool
+}
+
+func TestToLangchainTool(t *testing.T) {
+	server, calls := newMockServer(t)
+
+	t.Run("Exposes the name and description", func(t *testing.T) {
+		tool, err := tblangchain.ToLangchainTool(loadTool(t, server))
+		require.NoError(t, err)
+
+		assert.Equal(t, "search-hotels", tool.Name())
+		assert.Equal(t, "Search hotels by location. The input must be a JSON object with the parameters: 'location' (type: string, description: City name.).", tool.Description())
+	})
+
+	t.Run("Call invokes the tool with the JSON input and auth token", func(t *testing.T) {
+		tool, err := tblangchain.ToLangchainTool(loadTool(t, server))
+		require.NoError(t, err)
+
+		result, err := tool.Call(context.Background(), `{"location": "Paris"}`)
+		require.NoError(t, err)
+		assert.Equal(t, "hotels in Paris", result)
+		assert.Equal(t, toolCall{args: map[string]any{"location": "Paris"}, token: "secret"}, <-calls)
+	})
+
+	t.Run("Bound parameters pass through", func(t *testing.T) {
+		tool, err := tblangchain.ToLangchainTool(loadTool(t, server, core.WithBindParamString("location", "Rome")))
+		require.NoError(t, err)
+		assert.Equal(t, "Search hotels by location.", tool.Description())
+
+		result, err := tool.Call(context.Background(), "")
+		require.NoError(t, err)
+		assert.Equal(t, "hotels in Rome", result)
+		assert.Equal(t, map[string]any{"location": "Rome"}, (<-calls).args)
+	})
+
+	t.Run("Structured results are encoded as JSON", func(t *testing.T) {
+		client, err := core.NewToolboxClient(server.URL, core.WithHTTPClient(server.Client()), core.WithProtocol(core.MCPv20250618), core.WithNumberAsJSONNumber())
+		require.NoError(t, err)
+		coreTool, err := client.LoadTool("search-hotels", context.Background(), core.WithAuthTokenString("google", "secret"))
+		require.NoError(t, err)
+		tool, err := tblangchain.ToLangchainTool(coreTool)
+		require.NoError(t, err)
+
+		result, err := tool.Call(context.Background(), `{"location": "Milan"}`)
+		require.NoError(t, err)
+		<-calls
+		assert.JSONEq(t, `{"hotels": ["Hotel Milano"], "count": 1}`, result)
+	})
+
+	t.Run("Malformed JSON input", func(t *testing.T) {
+		tool, err := tblangchain.ToLangchainTool(loadTool(t, server))
+		require.NoError(t, err)
+
+		_, err = tool.Call(context.Background(), `{"location": `)
+		assert.ErrorContains(t, err, "failed to parse JSON input for tool 'search-hotels'")
+		_, err = tool.Call(context.Background(), `"Paris"`)
+		assert.ErrorContains(t, err, "expects a JSON object as input, but got a JSON string")
+		assert.Empty(t, calls, "malformed input must not reach the server")
+	})
+
+	t.Run("Invocation errors are propagated", func(t *testing.T) {
+		tool, err := tblangchain.ToLangchainTool(loadTool(t, server))
+		require.NoError(t, err)
+
+		_, err = tool.Call(context.Background(), `{}`)
+		assert.ErrorContains(t, err, "error invoking core tool search-hotels")
+	})
+
+	t.Run("Nil tool", func(t *testing.T) {
+		_, err := tblangchain.ToLangchainTool(nil)
+		assert.ErrorContains(t, err, "nil core.ToolboxTool pointer")
+	})
+}